@@ -0,0 +1,594 @@
+package djoemo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// DefaultBackfillProgressTable is the table the progress of backfills is checkpointed in
+const DefaultBackfillProgressTable = "djoemo_backfills"
+
+// maxBackfillErrors is the number of errors kept in BackfillStats
+const maxBackfillErrors = 100
+
+// maxExpressionLength is the limit of DynamoDB for the length of condition and update expressions
+const maxExpressionLength = 4096
+
+// BackfillAction tells a backfill what to do with a scanned item
+type BackfillAction int
+
+const (
+	// BackfillSkip leaves the item unchanged
+	BackfillSkip BackfillAction = iota
+	// BackfillSave puts the item as changed by the BackfillFunc; items changed or deleted since they were scanned are skipped
+	BackfillSave
+	// BackfillDelete deletes the item; items changed since they were scanned are skipped
+	BackfillDelete
+)
+
+// BackfillFunc is called for every scanned item; to save an item, it changes item and returns BackfillSave.
+// Items of a page may be passed again after a backfill is resumed, so the func must be idempotent.
+type BackfillFunc func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (BackfillAction, error)
+
+// BackfillOf adapts a func changing models of type T to a BackfillFunc
+func BackfillOf[T any](fn func(ctx context.Context, item *T) (BackfillAction, error)) BackfillFunc {
+	return func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (BackfillAction, error) {
+		model := new(T)
+		if err := dynamo.UnmarshalItem(item, model); err != nil {
+			return BackfillSkip, err
+		}
+		action, err := fn(ctx, model)
+		if err != nil || action != BackfillSave {
+			return action, err
+		}
+		changed, err := dynamo.MarshalItem(model)
+		if err != nil {
+			return BackfillSkip, err
+		}
+		for name := range item {
+			delete(item, name)
+		}
+		for name, value := range changed {
+			item[name] = value
+		}
+		return action, nil
+	}
+}
+
+// BackfillSettings configure a backfill
+type BackfillSettings struct {
+	// ID names the backfill in the progress table; the progress of every segment is checkpointed after every page,
+	// and a backfill with the same ID resumes from the checkpoints. Progress is not checkpointed without ID.
+	ID string
+	// ProgressTable is the table checkpoints are saved in; it is created if it does not exist.
+	// Defaults to DefaultBackfillProgressTable
+	ProgressTable string
+	// Segments is the number of segments scanned in parallel; defaults to 1. A resumed backfill needs the same number.
+	Segments int
+	// PageSize limits the number of items scanned per request
+	PageSize int64
+	// RateLimit limits the capacity used on the table by the backfill; it replaces the limit of the table set by
+	// WithRateLimiter for the requests of the backfill
+	RateLimit RateLimit
+	// DryRun calls the BackfillFunc for all items without writing changes or checkpoints
+	DryRun bool
+	// MaxFailures stops the backfill with ErrBackfillFailures once more items failed; zero means unlimited
+	MaxFailures int64
+	// VersionAttribute names a number attribute that every write to the table increments, like the version of
+	// OptimisticLockSaveWithContext. Items are only written while their version is the scanned one, and saving
+	// increments it. Without it, items are only written while all scanned attributes are unchanged, and saves update
+	// the changed attributes only.
+	VersionAttribute string
+}
+
+// BackfillStats counts the items of a backfill; counts of resumed backfills include the items of former runs
+type BackfillStats struct {
+	Scanned int64
+	Updated int64
+	Deleted int64
+	// Skipped counts the items skipped by the BackfillFunc and the items changed or deleted since they were scanned
+	Skipped int64
+	Failed  int64
+	// Errors holds the first errors of failed items of this run, wrapped with the key of the item
+	Errors []error
+}
+
+// backfillProgress is the checkpoint of a segment of a backfill
+type backfillProgress struct {
+	ID               string `dynamo:",hash"`
+	Backfill         string
+	Segment          int
+	Segments         int
+	LastEvaluatedKey map[string]*dynamodb.AttributeValue `dynamo:",omitempty"`
+	Done             bool
+	Scanned          int64
+	Updated          int64
+	Deleted          int64
+	Skipped          int64
+	Failed           int64
+	UpdatedAt        time.Time
+}
+
+// backfill holds the state shared by the segments of a running backfill
+type backfill struct {
+	repository Repository
+	key        KeyInterface
+	fn         BackfillFunc
+	settings   BackfillSettings
+	schema     TableSchema
+	failed     atomic.Int64
+	mu         sync.Mutex
+	errors     []error
+}
+
+// Backfill scans the table of key in parallel segments and calls fn for every item; fn decides to save, delete or
+// skip the item. Failed items are counted and logged, and do not stop the backfill unless MaxFailures is exceeded.
+// Returns the counts of the backfill also if it fails.
+func (repository Repository) Backfill(ctx context.Context, key KeyInterface, fn BackfillFunc, settings BackfillSettings) (BackfillStats, error) {
+	if err := isValidTableName(key); err != nil {
+		return BackfillStats{}, err
+	}
+	if settings.Segments < 1 {
+		settings.Segments = 1
+	}
+	if settings.ProgressTable == "" {
+		settings.ProgressTable = DefaultBackfillProgressTable
+	}
+	if settings.RateLimit != (RateLimit{}) {
+		client := *repository.client
+		client.limiter = NewRateLimiter().WithTableLimit(key.TableName(), settings.RateLimit)
+		repository.client = &client
+		repository.dynamoClient = dynamo.NewFromIface(&client)
+	}
+
+	schema, err := repository.DescribeTable(ctx, key.TableName())
+	if err != nil {
+		return BackfillStats{}, err
+	}
+	b := &backfill{repository: repository, key: key, fn: fn, settings: settings, schema: schema}
+	progress, err := b.loadProgress(ctx)
+	if err != nil {
+		return BackfillStats{}, err
+	}
+	for _, segment := range progress {
+		b.failed.Add(segment.Failed)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errs := make([]error, settings.Segments)
+	var wg sync.WaitGroup
+	for segment := range progress {
+		wg.Add(1)
+		go func(segment int) {
+			defer wg.Done()
+			if errs[segment] = b.scanSegment(ctx, progress[segment]); errs[segment] != nil {
+				cancel()
+			}
+		}(segment)
+	}
+	wg.Wait()
+
+	stats := BackfillStats{Errors: b.errors}
+	for _, segment := range progress {
+		stats.Scanned += segment.Scanned
+		stats.Updated += segment.Updated
+		stats.Deleted += segment.Deleted
+		stats.Skipped += segment.Skipped
+		stats.Failed += segment.Failed
+	}
+	return stats, firstError(errs)
+}
+
+// firstError returns the first error that is not caused by the cancellation of the other segments
+func firstError(errs []error) error {
+	var canceled error
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil && canceled == nil {
+			canceled = err
+		}
+	}
+	return canceled
+}
+
+func (b *backfill) checkpointed() bool {
+	return b.settings.ID != "" && !b.settings.DryRun
+}
+
+func (b *backfill) progressKey(segment int) KeyInterface {
+	return Key().WithTableName(b.settings.ProgressTable).
+		WithHashKeyName("ID").
+		WithHashKey(fmt.Sprintf("%s#%d", b.settings.ID, segment))
+}
+
+// loadProgress returns the checkpoints of all segments; segments without checkpoint start from the beginning
+func (b *backfill) loadProgress(ctx context.Context) ([]*backfillProgress, error) {
+	progress := make([]*backfillProgress, b.settings.Segments)
+	for segment := range progress {
+		progress[segment] = &backfillProgress{
+			ID:       fmt.Sprintf("%s#%d", b.settings.ID, segment),
+			Backfill: b.settings.ID,
+			Segment:  segment,
+			Segments: b.settings.Segments,
+		}
+	}
+	if b.settings.ID == "" {
+		return progress, nil
+	}
+	if !b.settings.DryRun {
+		err := b.repository.EnsureTable(ctx, TableSchema{
+			Name:           b.settings.ProgressTable,
+			HashKey:        "ID",
+			AttributeTypes: map[string]string{"ID": dynamodb.ScalarAttributeTypeS},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for segment := range progress {
+		checkpoint := &backfillProgress{}
+		found, err := b.repository.GetItemWithContext(ctx, b.progressKey(segment), checkpoint)
+		var awsErr awserr.Error
+		if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+			return progress, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		if checkpoint.Segments != b.settings.Segments {
+			return nil, fmt.Errorf("djoemo: backfill %s was checkpointed with %d segments, not %d", b.settings.ID, checkpoint.Segments, b.settings.Segments)
+		}
+		progress[segment] = checkpoint
+	}
+	return progress, nil
+}
+
+// scanSegment scans a segment page by page from its checkpoint and checkpoints it after every page
+func (b *backfill) scanSegment(ctx context.Context, progress *backfillProgress) error {
+	for !progress.Done {
+		input := &dynamodb.ScanInput{
+			TableName:         aws.String(b.key.TableName()),
+			ExclusiveStartKey: progress.LastEvaluatedKey,
+		}
+		if b.settings.Segments > 1 {
+			input.Segment = aws.Int64(int64(progress.Segment))
+			input.TotalSegments = aws.Int64(int64(b.settings.Segments))
+		}
+		if b.settings.PageSize > 0 {
+			input.Limit = aws.Int64(b.settings.PageSize)
+		}
+
+		var output *dynamodb.ScanOutput
+		err := b.repository.retry(ctx, OpRead, b.key, func(ctx context.Context) (err error) {
+			output, err = b.repository.client.ScanWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range output.Items {
+			progress.Scanned++
+			b.process(ctx, item, progress)
+			if b.settings.MaxFailures > 0 && b.failed.Load() > b.settings.MaxFailures {
+				return fmt.Errorf("%w: %d items failed", ErrBackfillFailures, b.failed.Load())
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		progress.LastEvaluatedKey = output.LastEvaluatedKey
+		progress.Done = len(output.LastEvaluatedKey) == 0
+		progress.UpdatedAt = time.Now().UTC()
+		if b.checkpointed() {
+			if err := b.repository.SaveItemWithContext(ctx, b.progressKey(progress.Segment), progress); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// process applies the action of the backfill func to an item and counts it
+func (b *backfill) process(ctx context.Context, item map[string]*dynamodb.AttributeValue, progress *backfillProgress) {
+	key := b.itemKey(item)
+	scanned := expr.CopyItem(item)
+	action, err := b.fn(ctx, item)
+	if err == nil && !b.settings.DryRun && action != BackfillSkip {
+		var written bool
+		switch action {
+		case BackfillSave:
+			written, err = b.save(ctx, scanned, item, key)
+		case BackfillDelete:
+			written, err = b.delete(ctx, scanned, key)
+		}
+		if err == nil && !written {
+			action = BackfillSkip
+		}
+	}
+
+	if err != nil {
+		progress.Failed++
+		b.failed.Add(1)
+		b.fail(ctx, key, err)
+		return
+	}
+	switch action {
+	case BackfillSave:
+		progress.Updated++
+	case BackfillDelete:
+		progress.Deleted++
+	default:
+		progress.Skipped++
+	}
+}
+
+// save writes an item unless it was changed or deleted since it was scanned; returns false if it was. With a version
+// attribute the item is put, else the changed attributes are updated, so attributes added since the scan are kept
+func (b *backfill) save(ctx context.Context, scanned map[string]*dynamodb.AttributeValue, item map[string]*dynamodb.AttributeValue, key map[string]*dynamodb.AttributeValue) (bool, error) {
+	if !reflect.DeepEqual(b.itemKey(item), key) {
+		return false, errors.New("backfill func changed the key of the item")
+	}
+	if b.settings.VersionAttribute == "" {
+		return b.update(ctx, scanned, item, key)
+	}
+
+	version, err := nextVersion(scanned[b.settings.VersionAttribute])
+	if err != nil {
+		return false, err
+	}
+	item[b.settings.VersionAttribute] = version
+	condition, err := b.unchangedSince(scanned, item)
+	if err != nil {
+		return false, err
+	}
+	input := &dynamodb.PutItemInput{
+		TableName:                 aws.String(b.key.TableName()),
+		Item:                      item,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	}
+	err = b.repository.retry(ctx, OpUpdate, b.key, func(ctx context.Context) error {
+		_, err := b.repository.client.PutItemWithContext(ctx, input)
+		return err
+	})
+	return conditionalWritten(err)
+}
+
+// update sets the attributes of item that differ from the scanned ones and removes the attributes it dropped; an
+// unchanged item is only checked by the condition
+func (b *backfill) update(ctx context.Context, scanned map[string]*dynamodb.AttributeValue, item map[string]*dynamodb.AttributeValue, key map[string]*dynamodb.AttributeValue) (bool, error) {
+	condition, err := b.unchangedSince(scanned, item)
+	if err != nil {
+		return false, err
+	}
+
+	var set, remove []string
+	for i, name := range sortedNames(item) {
+		if b.isKey(name) || reflect.DeepEqual(item[name], scanned[name]) {
+			continue
+		}
+		condition.names[fmt.Sprintf("#s%d", i)] = aws.String(name)
+		if condition.values == nil {
+			condition.values = make(map[string]*dynamodb.AttributeValue)
+		}
+		condition.values[fmt.Sprintf(":s%d", i)] = item[name]
+		set = append(set, fmt.Sprintf("#s%d = :s%d", i, i))
+	}
+	for i, name := range sortedNames(scanned) {
+		if _, ok := item[name]; !ok {
+			condition.names[fmt.Sprintf("#r%d", i)] = aws.String(name)
+			remove = append(remove, fmt.Sprintf("#r%d", i))
+		}
+	}
+	var clauses []string
+	if len(set) > 0 {
+		clauses = append(clauses, "SET "+strings.Join(set, ", "))
+	}
+	if len(remove) > 0 {
+		clauses = append(clauses, "REMOVE "+strings.Join(remove, ", "))
+	}
+	update := strings.Join(clauses, " ")
+	if len(update) > maxExpressionLength {
+		return false, fmt.Errorf("%w: update of %d attributes, set VersionAttribute to put items instead", ErrBackfillExpressionTooLarge, len(set)+len(remove))
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(b.key.TableName()),
+		Key:                       key,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	}
+	if update != "" {
+		input.UpdateExpression = aws.String(update)
+	}
+	err = b.repository.retry(ctx, OpUpdate, b.key, func(ctx context.Context) error {
+		_, err := b.repository.client.UpdateItemWithContext(ctx, input)
+		return err
+	})
+	return conditionalWritten(err)
+}
+
+// delete deletes an item unless it was changed since it was scanned; returns false if it was
+func (b *backfill) delete(ctx context.Context, scanned map[string]*dynamodb.AttributeValue, key map[string]*dynamodb.AttributeValue) (bool, error) {
+	condition, err := b.unchangedSince(scanned, nil)
+	if err != nil {
+		return false, err
+	}
+	input := &dynamodb.DeleteItemInput{
+		TableName:                 aws.String(b.key.TableName()),
+		Key:                       key,
+		ConditionExpression:       condition.expression,
+		ExpressionAttributeNames:  condition.names,
+		ExpressionAttributeValues: condition.values,
+	}
+	err = b.repository.retry(ctx, OpDelete, b.key, func(ctx context.Context) error {
+		_, err := b.repository.client.DeleteItemWithContext(ctx, input)
+		return err
+	})
+	return conditionalWritten(err)
+}
+
+// conditionalWritten reports if a conditional write succeeded; a failed condition is no error
+func conditionalWritten(err error) (bool, error) {
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// backfillCondition is the condition expression of a write of a backfill
+type backfillCondition struct {
+	expression *string
+	names      map[string]*string
+	values     map[string]*dynamodb.AttributeValue
+}
+
+// unchangedSince returns a condition that holds while an item is in its scanned state: its version is the scanned one,
+// if the backfill has a version attribute, or else all scanned attributes are equal and the attributes item adds do
+// not exist. It fails if the condition exceeds the length DynamoDB allows.
+func (b *backfill) unchangedSince(scanned map[string]*dynamodb.AttributeValue, item map[string]*dynamodb.AttributeValue) (backfillCondition, error) {
+	condition := backfillCondition{
+		names:  map[string]*string{"#hash": aws.String(b.schema.HashKey)},
+		values: make(map[string]*dynamodb.AttributeValue),
+	}
+	clauses := []string{"attribute_exists(#hash)"}
+
+	if version := b.settings.VersionAttribute; version != "" {
+		condition.names["#version"] = aws.String(version)
+		if value, ok := scanned[version]; ok {
+			condition.values[":version"] = value
+			clauses = append(clauses, "#version = :version")
+		} else {
+			clauses = append(clauses, "attribute_not_exists(#version)")
+		}
+	} else {
+		for i, name := range sortedNames(scanned) {
+			if b.isKey(name) {
+				continue
+			}
+			condition.names[fmt.Sprintf("#a%d", i)] = aws.String(name)
+			condition.values[fmt.Sprintf(":a%d", i)] = scanned[name]
+			clauses = append(clauses, fmt.Sprintf("#a%d = :a%d", i, i))
+		}
+		for i, name := range sortedNames(item) {
+			if _, ok := scanned[name]; !ok {
+				condition.names[fmt.Sprintf("#n%d", i)] = aws.String(name)
+				clauses = append(clauses, fmt.Sprintf("attribute_not_exists(#n%d)", i))
+			}
+		}
+	}
+
+	condition.expression = aws.String(strings.Join(clauses, " AND "))
+	if len(*condition.expression) > maxExpressionLength {
+		return condition, fmt.Errorf("%w: condition on %d attributes, set VersionAttribute to condition on the version instead", ErrBackfillExpressionTooLarge, len(clauses))
+	}
+	if len(condition.values) == 0 {
+		condition.values = nil
+	}
+	return condition, nil
+}
+
+// isKey reports if name is a key attribute of the table
+func (b *backfill) isKey(name string) bool {
+	return name == b.schema.HashKey || name == b.schema.RangeKey
+}
+
+// sortedNames returns the attribute names of item in order, so expressions are deterministic
+func sortedNames(item map[string]*dynamodb.AttributeValue) []string {
+	names := make([]string, 0, len(item))
+	for name := range item {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nextVersion returns the version following the scanned version of an item; items without version get version 1
+func nextVersion(version *dynamodb.AttributeValue) (*dynamodb.AttributeValue, error) {
+	if version == nil {
+		return &dynamodb.AttributeValue{N: aws.String("1")}, nil
+	}
+	if version.N == nil {
+		return nil, fmt.Errorf("djoemo: version attribute is not a number")
+	}
+	n, err := expr.Number(*version.N)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.AttributeValue{N: aws.String(expr.FormatNumber(n.Add(n, big.NewFloat(1))))}, nil
+}
+
+// itemKey returns the primary key attributes of an item
+func (b *backfill) itemKey(item map[string]*dynamodb.AttributeValue) map[string]*dynamodb.AttributeValue {
+	key := map[string]*dynamodb.AttributeValue{b.schema.HashKey: item[b.schema.HashKey]}
+	if b.schema.RangeKey != "" {
+		key[b.schema.RangeKey] = item[b.schema.RangeKey]
+	}
+	return key
+}
+
+// fail logs the error of an item and keeps it for the stats
+func (b *backfill) fail(ctx context.Context, key map[string]*dynamodb.AttributeValue, err error) {
+	err = fmt.Errorf("item %s: %w", formatKey(key), err)
+	b.repository.log.WithContext(ctx).
+		WithField(TableName, b.key.TableName()).
+		WithField("Error", err.Error()).
+		Error("backfill of item failed")
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.errors) < maxBackfillErrors {
+		b.errors = append(b.errors, err)
+	}
+}
+
+// formatKey formats key attributes like UUID=abc,Time=5
+func formatKey(key map[string]*dynamodb.AttributeValue) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		value := key[name]
+		switch {
+		case value == nil:
+			parts[i] = name + "=<nil>"
+		case value.S != nil:
+			parts[i] = name + "=" + *value.S
+		case value.N != nil:
+			parts[i] = name + "=" + *value.N
+		default:
+			parts[i] = fmt.Sprintf("%s=%x", name, value.B)
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,275 @@
+package djoemo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backfill", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New().WithTable(UserTableName, "UUID", "")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	saveUsers := func(count int) {
+		for i := 0; i < count; i++ {
+			uuid := fmt.Sprintf("user-%02d", i)
+			Expect(repository.SaveItemWithContext(ctx, userKey(uuid), User{UUID: uuid, UserName: uuid})).To(Succeed())
+		}
+	}
+
+	user := func(uuid string) (User, bool) {
+		user := User{}
+		found, err := repository.GetItemWithContext(ctx, userKey(uuid), &user)
+		Expect(err).To(BeNil())
+		return user, found
+	}
+
+	It("should save, delete and skip items", func() {
+		saveUsers(6)
+		fn := djoemo.BackfillOf(func(ctx context.Context, user *User) (djoemo.BackfillAction, error) {
+			switch user.UUID {
+			case "user-00", "user-01":
+				return djoemo.BackfillDelete, nil
+			case "user-02":
+				return djoemo.BackfillSkip, nil
+			}
+			user.TraceID = "backfilled"
+			return djoemo.BackfillSave, nil
+		})
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{PageSize: 2})
+		Expect(err).To(BeNil())
+		Expect(stats).To(Equal(djoemo.BackfillStats{Scanned: 6, Updated: 3, Deleted: 2, Skipped: 1}))
+
+		_, found := user("user-00")
+		Expect(found).To(BeFalse())
+		saved, _ := user("user-05")
+		Expect(saved.TraceID).To(Equal("backfilled"))
+		Expect(saved.UserName).To(Equal("user-05"))
+		skipped, _ := user("user-02")
+		Expect(skipped.TraceID).To(BeEmpty())
+	})
+
+	It("should scan segments in parallel", func() {
+		saveUsers(20)
+		var mu sync.Mutex
+		seen := map[string]int{}
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[*item["UUID"].S]++
+			return djoemo.BackfillSkip, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{Segments: 4, PageSize: 3})
+		Expect(err).To(BeNil())
+		Expect(stats.Scanned).To(Equal(int64(20)))
+		Expect(stats.Skipped).To(Equal(int64(20)))
+		Expect(seen).To(HaveLen(20))
+		for _, count := range seen {
+			Expect(count).To(Equal(1))
+		}
+	})
+
+	It("should resume from the checkpoints of an interrupted backfill", func() {
+		saveUsers(10)
+		settings := djoemo.BackfillSettings{ID: "trace-ids", PageSize: 2}
+		calls := 0
+		runCtx, cancel := context.WithCancel(ctx)
+		fn := djoemo.BackfillOf(func(ctx context.Context, user *User) (djoemo.BackfillAction, error) {
+			calls++
+			if calls == 5 {
+				cancel()
+			}
+			user.TraceID = "backfilled"
+			return djoemo.BackfillSave, nil
+		})
+
+		_, err := repository.Backfill(runCtx, userKey(""), fn, settings)
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(db.Items(djoemo.DefaultBackfillProgressTable)).To(HaveLen(1))
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, settings)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(11), "the interrupted page is processed again")
+		Expect(stats.Scanned).To(Equal(int64(10)))
+		Expect(stats.Updated).To(Equal(int64(10)))
+
+		stats, err = repository.Backfill(ctx, userKey(""), fn, settings)
+		Expect(err).To(BeNil())
+		Expect(calls).To(Equal(11), "a finished backfill is not run again")
+		Expect(stats.Scanned).To(Equal(int64(10)))
+
+		_, err = repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{ID: "trace-ids", Segments: 2})
+		Expect(err).To(MatchError(ContainSubstring("was checkpointed with 1 segments, not 2")))
+	})
+
+	It("should not write in dry runs", func() {
+		saveUsers(3)
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			return djoemo.BackfillDelete, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{ID: "cleanup", DryRun: true})
+		Expect(err).To(BeNil())
+		Expect(stats.Deleted).To(Equal(int64(3)))
+		Expect(db.Items(UserTableName)).To(HaveLen(3))
+		Expect(db.Items(djoemo.DefaultBackfillProgressTable)).To(BeNil())
+	})
+
+	It("should count failed items and stop after too many failures", func() {
+		saveUsers(5)
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			if *item["UUID"].S < "user-03" {
+				return djoemo.BackfillSkip, errors.New("boom")
+			}
+			return djoemo.BackfillSkip, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{})
+		Expect(err).To(BeNil())
+		Expect(stats.Failed).To(Equal(int64(3)))
+		Expect(stats.Skipped).To(Equal(int64(2)))
+		Expect(stats.Errors).To(ContainElement(MatchError("item UUID=user-00: boom")))
+
+		_, err = repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{MaxFailures: 2})
+		Expect(err).To(MatchError(djoemo.ErrBackfillFailures))
+	})
+
+	It("should skip items deleted since they were scanned", func() {
+		saveUsers(1)
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			Expect(repository.DeleteItemWithContext(ctx, userKey("user-00"))).To(Succeed())
+			return djoemo.BackfillSave, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{})
+		Expect(err).To(BeNil())
+		Expect(stats.Skipped).To(Equal(int64(1)))
+		Expect(db.Items(UserTableName)).To(BeEmpty())
+	})
+
+	It("should skip items changed since they were scanned", func() {
+		saveUsers(2)
+		fn := djoemo.BackfillOf(func(ctx context.Context, user *User) (djoemo.BackfillAction, error) {
+			Expect(repository.SaveItemWithContext(ctx, userKey(user.UUID), User{UUID: user.UUID, UserName: "concurrent"})).To(Succeed())
+			if user.UUID == "user-01" {
+				return djoemo.BackfillDelete, nil
+			}
+			user.TraceID = "backfilled"
+			return djoemo.BackfillSave, nil
+		})
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{})
+		Expect(err).To(BeNil())
+		Expect(stats).To(Equal(djoemo.BackfillStats{Scanned: 2, Skipped: 2}))
+		saved, _ := user("user-00")
+		Expect(saved).To(Equal(User{UUID: "user-00", UserName: "concurrent"}))
+		deleted, found := user("user-01")
+		Expect(found).To(BeTrue())
+		Expect(deleted).To(Equal(User{UUID: "user-01", UserName: "concurrent"}))
+	})
+
+	It("should keep attributes added since the scan and skip items whose added attributes were written", func() {
+		saveUsers(2)
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			uuid := *item["UUID"].S
+			Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey(uuid), map[string]any{"TraceID": "concurrent"})).To(Succeed())
+			if uuid == "user-00" {
+				item["UserName"] = &dynamodb.AttributeValue{S: aws.String("backfilled")}
+			} else {
+				item["TraceID"] = &dynamodb.AttributeValue{S: aws.String("backfilled")}
+			}
+			return djoemo.BackfillSave, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{})
+		Expect(err).To(BeNil())
+		Expect(stats).To(Equal(djoemo.BackfillStats{Scanned: 2, Updated: 1, Skipped: 1}))
+		saved, _ := user("user-00")
+		Expect(saved).To(Equal(User{UUID: "user-00", UserName: "backfilled", TraceID: "concurrent"}))
+		skipped, _ := user("user-01")
+		Expect(skipped).To(Equal(User{UUID: "user-01", UserName: "user-01", TraceID: "concurrent"}))
+	})
+
+	It("should fail items whose condition exceeds the expression length", func() {
+		item := map[string]any{"UUID": "wide"}
+		for i := 0; i < 300; i++ {
+			item[fmt.Sprintf("A%03d", i)] = i
+		}
+		Expect(repository.SaveItemWithContext(ctx, userKey("wide"), item)).To(Succeed())
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			return djoemo.BackfillDelete, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{})
+		Expect(err).To(BeNil())
+		Expect(stats.Failed).To(Equal(int64(1)))
+		Expect(stats.Errors).To(ContainElement(MatchError(djoemo.ErrBackfillExpressionTooLarge)))
+		Expect(db.Items(UserTableName)).To(HaveLen(1))
+	})
+
+	It("should only write items of the scanned version and increment it", func() {
+		for i, version := range []int{0, 3} {
+			item := map[string]any{"UUID": fmt.Sprintf("user-%02d", i), "Version": version}
+			Expect(repository.SaveItemWithContext(ctx, userKey(item["UUID"].(string)), item)).To(Succeed())
+		}
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			if *item["UUID"].S == "user-01" {
+				Expect(repository.SaveItemWithContext(ctx, userKey("user-01"), map[string]any{"UUID": "user-01", "Version": 4})).To(Succeed())
+			}
+			item["UserName"] = &dynamodb.AttributeValue{S: aws.String("backfilled")}
+			return djoemo.BackfillSave, nil
+		}
+
+		stats, err := repository.Backfill(ctx, userKey(""), fn, djoemo.BackfillSettings{VersionAttribute: "Version"})
+		Expect(err).To(BeNil())
+		Expect(stats).To(Equal(djoemo.BackfillStats{Scanned: 2, Updated: 1, Skipped: 1}))
+
+		saved := map[string]any{}
+		_, err = repository.GetItemWithContext(ctx, userKey("user-00"), &saved)
+		Expect(err).To(BeNil())
+		Expect(saved).To(Equal(map[string]any{"UUID": "user-00", "Version": 1.0, "UserName": "backfilled"}))
+		changed := map[string]any{}
+		_, err = repository.GetItemWithContext(ctx, userKey("user-01"), &changed)
+		Expect(err).To(BeNil())
+		Expect(changed).To(Equal(map[string]any{"UUID": "user-01", "Version": 4.0}))
+	})
+
+	It("should limit the rate of the backfill", func() {
+		saveUsers(2)
+		fn := func(ctx context.Context, item map[string]*dynamodb.AttributeValue) (djoemo.BackfillAction, error) {
+			return djoemo.BackfillSave, nil
+		}
+
+		settings := djoemo.BackfillSettings{RateLimit: djoemo.RateLimit{ReadUnits: 100, WriteUnits: 100}}
+		stats, err := repository.Backfill(ctx, userKey(""), fn, settings)
+		Expect(err).To(BeNil())
+		Expect(stats.Updated).To(Equal(int64(2)))
+	})
+})
@@ -0,0 +1,151 @@
+package djoemo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Capacity is a number of read and write capacity units
+type Capacity struct {
+	ReadUnits  float64
+	WriteUnits float64
+}
+
+// Add returns the sum of both capacities
+func (c Capacity) Add(other Capacity) Capacity {
+	return Capacity{
+		ReadUnits:  c.ReadUnits + other.ReadUnits,
+		WriteUnits: c.WriteUnits + other.WriteUnits,
+	}
+}
+
+// ConsumedCapacity sums up the capacity units consumed per table and global secondary index by the operations
+// executed with the context returned by WithConsumedCapacity; units of local secondary indexes count for their table
+type ConsumedCapacity struct {
+	sync.Mutex
+	parent   *ConsumedCapacity
+	capacity map[capacityKey]Capacity
+}
+
+type capacityKey struct {
+	table string
+	index string
+}
+
+type consumedCapacityContextKey int
+
+const consumedCapacityCtxKey consumedCapacityContextKey = iota
+
+// WithConsumedCapacity returns a context that collects the capacity consumed by operations executed with it;
+// capacity collected by a nested context also counts for the outer one
+func WithConsumedCapacity(ctx context.Context) (context.Context, *ConsumedCapacity) {
+	consumed := &ConsumedCapacity{
+		parent:   ConsumedCapacityFromContext(ctx),
+		capacity: make(map[capacityKey]Capacity),
+	}
+	return context.WithValue(ctx, consumedCapacityCtxKey, consumed), consumed
+}
+
+// ConsumedCapacityFromContext returns the consumed capacity collected by ctx, nil if ctx does not collect any
+func ConsumedCapacityFromContext(ctx context.Context) *ConsumedCapacity {
+	consumed, ok := ctx.Value(consumedCapacityCtxKey).(*ConsumedCapacity)
+	if !ok {
+		return nil
+	}
+	return consumed
+}
+
+// Table returns the capacity consumed by a table, without its global secondary indexes
+func (c *ConsumedCapacity) Table(tableName string) Capacity {
+	return c.get(capacityKey{table: tableName})
+}
+
+// Index returns the capacity consumed by a global secondary index of a table
+func (c *ConsumedCapacity) Index(tableName string, indexName string) Capacity {
+	return c.get(capacityKey{table: tableName, index: indexName})
+}
+
+// Total returns the capacity consumed by all tables and indexes
+func (c *ConsumedCapacity) Total() Capacity {
+	c.Lock()
+	defer c.Unlock()
+
+	var total Capacity
+	for _, capacity := range c.capacity {
+		total = total.Add(capacity)
+	}
+	return total
+}
+
+func (c *ConsumedCapacity) get(key capacityKey) Capacity {
+	c.Lock()
+	defer c.Unlock()
+	return c.capacity[key]
+}
+
+func (c *ConsumedCapacity) add(key capacityKey, capacity Capacity) {
+	for consumed := c; consumed != nil; consumed = consumed.parent {
+		consumed.Lock()
+		consumed.capacity[key] = consumed.capacity[key].Add(capacity)
+		consumed.Unlock()
+	}
+}
+
+// capacityUsage is the capacity of a request per table and index
+type capacityUsage map[capacityKey]Capacity
+
+// consumedUsage splits the consumed capacity returned by DynamoDB into tables and indexes; DynamoDB mostly reports
+// units without telling reads and writes apart, those are attributed by the kind of request
+func consumedUsage(consumed []*dynamodb.ConsumedCapacity, write bool) capacityUsage {
+	usage := make(capacityUsage)
+	for _, cc := range consumed {
+		if cc == nil {
+			continue
+		}
+		tableKey := capacityKey{table: aws.StringValue(cc.TableName)}
+
+		if cc.Table == nil && len(cc.GlobalSecondaryIndexes) == 0 && len(cc.LocalSecondaryIndexes) == 0 {
+			usage[tableKey] = usage[tableKey].Add(capacityUnits(cc.ReadCapacityUnits, cc.WriteCapacityUnits, cc.CapacityUnits, write))
+			continue
+		}
+
+		if cc.Table != nil {
+			usage[tableKey] = usage[tableKey].Add(capacityOf(cc.Table, write))
+		}
+		for _, index := range cc.LocalSecondaryIndexes {
+			usage[tableKey] = usage[tableKey].Add(capacityOf(index, write))
+		}
+		for indexName, index := range cc.GlobalSecondaryIndexes {
+			indexKey := capacityKey{table: tableKey.table, index: indexName}
+			usage[indexKey] = usage[indexKey].Add(capacityOf(index, write))
+		}
+	}
+	return usage
+}
+
+func capacityOf(capacity *dynamodb.Capacity, write bool) Capacity {
+	if capacity == nil {
+		return Capacity{}
+	}
+	return capacityUnits(capacity.ReadCapacityUnits, capacity.WriteCapacityUnits, capacity.CapacityUnits, write)
+}
+
+func capacityUnits(read, written, total *float64, write bool) Capacity {
+	if read != nil || written != nil {
+		return Capacity{ReadUnits: aws.Float64Value(read), WriteUnits: aws.Float64Value(written)}
+	}
+	if write {
+		return Capacity{WriteUnits: aws.Float64Value(total)}
+	}
+	return Capacity{ReadUnits: aws.Float64Value(total)}
+}
+
+func consumedCapacities(cc *dynamodb.ConsumedCapacity) []*dynamodb.ConsumedCapacity {
+	if cc == nil {
+		return nil
+	}
+	return []*dynamodb.ConsumedCapacity{cc}
+}
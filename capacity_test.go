@@ -0,0 +1,123 @@
+package djoemo_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsumedCapacity", func() {
+	const (
+		UserTableName = "UserTable"
+		IndexName     = "email-index"
+	)
+
+	var (
+		dAPIMock        *mock.MockDynamoDBAPI
+		repository      djoemo.RepositoryInterface
+		metricsMock     *mock.MockMetricsInterface
+		capacityMetrics *mock.MockCapacityMetricsInterface
+		key             djoemo.KeyInterface
+	)
+
+	putOutput := &dynamodb.PutItemOutput{
+		ConsumedCapacity: &dynamodb.ConsumedCapacity{
+			TableName:     aws.String(UserTableName),
+			CapacityUnits: aws.Float64(2),
+			Table:         &dynamodb.Capacity{CapacityUnits: aws.Float64(1)},
+			GlobalSecondaryIndexes: map[string]*dynamodb.Capacity{
+				IndexName: {CapacityUnits: aws.Float64(1)},
+			},
+		},
+	}
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		metricsMock = mock.NewMockMetricsInterface(mockCtrl)
+		capacityMetrics = mock.NewMockCapacityMetricsInterface(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+		repository.WithMetrics(struct {
+			djoemo.MetricsInterface
+			djoemo.CapacityMetricsInterface
+		}{metricsMock, capacityMetrics})
+
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+
+		metricsMock.EXPECT().Record(gomock.Any(), gomock.Any(), key, gomock.Any(), gomock.Any()).AnyTimes()
+	})
+
+	It("should request the consumed capacity and collect it per table and index", func() {
+		dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ aws.Context, input *dynamodb.PutItemInput, _ ...interface{}) (*dynamodb.PutItemOutput, error) {
+				Expect(aws.StringValue(input.ReturnConsumedCapacity)).To(Equal(dynamodb.ReturnConsumedCapacityIndexes))
+				return putOutput, nil
+			})
+		capacityMetrics.EXPECT().RecordConsumedCapacity(gomock.Any(), UserTableName, "", djoemo.Capacity{WriteUnits: 1})
+		capacityMetrics.EXPECT().RecordConsumedCapacity(gomock.Any(), UserTableName, IndexName, djoemo.Capacity{WriteUnits: 1})
+
+		ctx, consumed := djoemo.WithConsumedCapacity(context.Background())
+		Expect(repository.SaveItemWithContext(ctx, key, &User{UUID: "uuid"})).To(BeNil())
+
+		Expect(consumed.Table(UserTableName)).To(Equal(djoemo.Capacity{WriteUnits: 1}))
+		Expect(consumed.Index(UserTableName, IndexName)).To(Equal(djoemo.Capacity{WriteUnits: 1}))
+		Expect(consumed.Total()).To(Equal(djoemo.Capacity{WriteUnits: 2}))
+	})
+
+	It("should attribute units to reads and writes as reported", func() {
+		dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+			ConsumedCapacity: &dynamodb.ConsumedCapacity{
+				TableName:     aws.String(UserTableName),
+				CapacityUnits: aws.Float64(0.5),
+			},
+		}, nil)
+		capacityMetrics.EXPECT().RecordConsumedCapacity(gomock.Any(), UserTableName, "", djoemo.Capacity{ReadUnits: 0.5})
+
+		ctx, consumed := djoemo.WithConsumedCapacity(context.Background())
+		_, err := repository.GetItemWithContext(ctx, key, &User{})
+		Expect(err).To(BeNil())
+
+		Expect(consumed.Table(UserTableName)).To(Equal(djoemo.Capacity{ReadUnits: 0.5}))
+	})
+
+	It("should count capacity of nested contexts for the outer one", func() {
+		dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any()).Return(putOutput, nil).Times(2)
+		capacityMetrics.EXPECT().RecordConsumedCapacity(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(4)
+
+		ctx, outer := djoemo.WithConsumedCapacity(context.Background())
+		Expect(repository.SaveItemWithContext(ctx, key, &User{UUID: "uuid"})).To(BeNil())
+		nested, inner := djoemo.WithConsumedCapacity(ctx)
+		Expect(repository.SaveItemWithContext(nested, key, &User{UUID: "uuid"})).To(BeNil())
+
+		Expect(inner.Total()).To(Equal(djoemo.Capacity{WriteUnits: 2}))
+		Expect(outer.Total()).To(Equal(djoemo.Capacity{WriteUnits: 4}))
+	})
+
+	It("should debit the rate limiter with the consumed capacity", func() {
+		repository.WithRateLimiter(djoemo.NewRateLimiter().WithTableLimit(UserTableName, djoemo.RateLimit{WriteUnits: 5}))
+		dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{
+			ConsumedCapacity: &dynamodb.ConsumedCapacity{
+				TableName:     aws.String(UserTableName),
+				CapacityUnits: aws.Float64(5),
+			},
+		}, nil).Times(1)
+		capacityMetrics.EXPECT().RecordConsumedCapacity(gomock.Any(), UserTableName, "", djoemo.Capacity{WriteUnits: 5})
+
+		Expect(repository.SaveItemWithContext(context.Background(), key, &User{UUID: "uuid"})).To(BeNil())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		Expect(repository.SaveItemWithContext(ctx, key, &User{UUID: "uuid"})).To(Equal(context.DeadlineExceeded))
+	})
+})
@@ -0,0 +1,532 @@
+package djoemo
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed operations are executed
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen operations fail fast with ErrCircuitOpen
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen a limited number of probe operations is executed to test for recovery
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitBreakerSettings configures when a circuit breaker trips and how it recovers
+type CircuitBreakerSettings struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	FailureThreshold int
+	// LatencyThreshold counts operations slower than the threshold as failures; zero disables the latency check
+	LatencyThreshold time.Duration
+	// OpenTimeout is the time the circuit stays open before probe operations are let through
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probes allowed, and of successful probes needed to close the circuit;
+	// probes that are canceled or exceed their deadline count neither as success nor as failure
+	HalfOpenProbes int
+	// IsFailure decides if an error counts as failure; by default retryable errors and exceeded deadlines do
+	IsFailure func(err error) bool
+}
+
+// DefaultCircuitBreakerSettings opens the circuit after five consecutive failures and probes for recovery after ten seconds
+func DefaultCircuitBreakerSettings() CircuitBreakerSettings {
+	return CircuitBreakerSettings{
+		FailureThreshold: 5,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenProbes:   1,
+		IsFailure:        isCircuitFailure,
+	}
+}
+
+func isCircuitFailure(err error) bool {
+	return IsRetryableError(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// circuitBreaker tracks the state of a single table or index
+type circuitBreaker struct {
+	sync.Mutex
+	name     string
+	settings CircuitBreakerSettings
+
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+	// generation is incremented on every state change; outcomes of operations allowed in an earlier generation are ignored
+	generation uint64
+}
+
+// allow returns ErrCircuitOpen if the operation must fail fast, or else the generation the operation is allowed in
+func (b *circuitBreaker) allow() (generation uint64, changed bool, err error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state == CircuitOpen {
+		if time.Since(b.openedAt) < b.settings.OpenTimeout {
+			return 0, false, ErrCircuitOpen
+		}
+		b.setState(CircuitHalfOpen)
+		b.probes = 0
+		b.successes = 0
+		changed = true
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.probes >= max(b.settings.HalfOpenProbes, 1) {
+			return 0, changed, ErrCircuitOpen
+		}
+		b.probes++
+	}
+	return b.generation, changed, nil
+}
+
+// done records the outcome of an operation allowed in generation and returns if the state changed; an aborted probe
+// only releases its slot, so the circuit stays half-open
+func (b *circuitBreaker) done(generation uint64, failure bool, aborted bool) (changed bool) {
+	b.Lock()
+	defer b.Unlock()
+
+	if generation != b.generation {
+		return false
+	}
+
+	switch b.state {
+	case CircuitClosed:
+		if !failure {
+			b.failures = 0
+			return false
+		}
+		b.failures++
+		if b.failures < max(b.settings.FailureThreshold, 1) {
+			return false
+		}
+	case CircuitHalfOpen:
+		b.probes--
+		if aborted {
+			return false
+		}
+		if !failure {
+			b.successes++
+			if b.successes < max(b.settings.HalfOpenProbes, 1) {
+				return false
+			}
+			b.setState(CircuitClosed)
+			b.failures = 0
+			return true
+		}
+	default:
+		return false
+	}
+
+	b.setState(CircuitOpen)
+	b.openedAt = time.Now()
+	return true
+}
+
+func (b *circuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.generation++
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.Lock()
+	defer b.Unlock()
+	return b.state
+}
+
+// CircuitMetricsInterface can be implemented by a metrics publisher to track circuit breaker state changes
+type CircuitMetricsInterface interface {
+	RecordCircuitState(ctx context.Context, circuit string, state CircuitState)
+}
+
+// circuitBreakers holds a circuit breaker per table and per index, shared by a repository and its indexes
+type circuitBreakers struct {
+	sync.Mutex
+	settings CircuitBreakerSettings
+	breakers map[string]*circuitBreaker
+	log      LogInterface
+	metrics  *Metrics
+}
+
+func (c *circuitBreakers) breaker(name string) *circuitBreaker {
+	c.Lock()
+	defer c.Unlock()
+
+	b, ok := c.breakers[name]
+	if !ok {
+		b = &circuitBreaker{name: name, settings: c.settings, state: CircuitClosed}
+		c.breakers[name] = b
+	}
+	return b
+}
+
+// call runs fn guarded by the circuit breaker of name
+func (c *circuitBreakers) call(ctx context.Context, name string, fn func() error) error {
+	b := c.breaker(name)
+
+	generation, changed, err := b.allow()
+	if changed {
+		c.report(ctx, b)
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = fn()
+
+	failure := err != nil && c.settings.IsFailure(err)
+	if c.settings.LatencyThreshold > 0 && time.Since(start) > c.settings.LatencyThreshold {
+		failure = true
+	}
+	aborted := ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	if b.done(generation, failure, aborted) {
+		c.report(ctx, b)
+	}
+	return err
+}
+
+func (c *circuitBreakers) report(ctx context.Context, b *circuitBreaker) {
+	state := b.currentState()
+
+	c.Lock()
+	log, metrics := c.log, c.metrics
+	c.Unlock()
+
+	log.WithContext(ctx).WithField(circuitField, b.name).Warn("circuit breaker " + string(state))
+	metrics.RecordCircuitState(ctx, b.name, state)
+}
+
+func (c *circuitBreakers) withLog(log LogInterface) {
+	c.Lock()
+	defer c.Unlock()
+	c.log = log
+}
+
+const circuitField = "Circuit"
+
+// CircuitBreakerRepository decorates a repository with a circuit breaker per table and per index
+type CircuitBreakerRepository struct {
+	repository RepositoryInterface
+	breakers   *circuitBreakers
+}
+
+// NewCircuitBreakerRepository factory method for a repository failing fast with ErrCircuitOpen
+// while a table or index keeps failing; settings without IsFailure count retryable errors and exceeded deadlines as failures
+func NewCircuitBreakerRepository(repository RepositoryInterface, settings CircuitBreakerSettings) RepositoryInterface {
+	if settings.IsFailure == nil {
+		settings.IsFailure = isCircuitFailure
+	}
+
+	return &CircuitBreakerRepository{
+		repository: repository,
+		breakers: &circuitBreakers{
+			settings: settings,
+			breakers: make(map[string]*circuitBreaker),
+			log:      NewNopLog(),
+			metrics:  New(),
+		},
+	}
+}
+
+// WithLog enables logging of the repository and of circuit breaker state changes
+func (r *CircuitBreakerRepository) WithLog(log LogInterface) {
+	r.repository.WithLog(log)
+	r.breakers.withLog(log)
+}
+
+// WithMetrics enables metrics of the repository and of circuit breaker state changes
+func (r *CircuitBreakerRepository) WithMetrics(metricsInterface MetricsInterface) {
+	r.repository.WithMetrics(metricsInterface)
+	r.breakers.metrics.Add(metricsInterface)
+}
+
+// WithRetryPolicy sets the retry policy of the repository; an operation counts as failed once all its attempts failed
+func (r *CircuitBreakerRepository) WithRetryPolicy(policy RetryPolicy) {
+	r.repository.WithRetryPolicy(policy)
+}
+
+// WithSlogHandler enables logging of the repository and of circuit breaker state changes to handler
+func (r *CircuitBreakerRepository) WithSlogHandler(handler slog.Handler) {
+	r.WithLog(NewSlogLog(slog.New(handler)))
+}
+
+// WithLogRedactor sets the function redacting keys and attribute values of requests logged by the repository
+func (r *CircuitBreakerRepository) WithLogRedactor(redactor Redactor) {
+	r.repository.WithLogRedactor(redactor)
+}
+
+// WithSlowLog sets the slow log of the repository
+func (r *CircuitBreakerRepository) WithSlowLog(slowLog *SlowLog) {
+	r.repository.WithSlowLog(slowLog)
+}
+
+// WithTracerProvider sets the tracer provider of the repository
+func (r *CircuitBreakerRepository) WithTracerProvider(provider trace.TracerProvider) {
+	r.repository.WithTracerProvider(provider)
+}
+
+// WithRateLimiter sets the rate limiter of the repository; time spent waiting for capacity counts towards LatencyThreshold
+func (r *CircuitBreakerRepository) WithRateLimiter(limiter *RateLimiter) {
+	r.repository.WithRateLimiter(limiter)
+}
+
+// WithOutboxTable sets the name of the outbox table of the repository
+func (r *CircuitBreakerRepository) WithOutboxTable(tableName string) {
+	r.repository.WithOutboxTable(tableName)
+}
+
+// WithPrometheusMetrics enables prometheus metrics of the repository and of circuit breaker state changes
+func (r *CircuitBreakerRepository) WithPrometheusMetrics(registry *prometheus.Registry) RepositoryInterface {
+	r.repository.WithPrometheusMetrics(registry)
+	if prommetrics, err := NewPrometheusMetrics(registry); err == nil {
+		r.breakers.metrics.Add(prommetrics)
+	}
+	return r
+}
+
+// CircuitState returns the state of the circuit breaker of a table
+func (r *CircuitBreakerRepository) CircuitState(tableName string) CircuitState {
+	return r.breakers.breaker(tableName).currentState()
+}
+
+// GetItemWithContext see Repository.GetItemWithContext
+func (r *CircuitBreakerRepository) GetItemWithContext(ctx context.Context, key KeyInterface, item any) (found bool, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		found, err = r.repository.GetItemWithContext(ctx, key, item)
+		return err
+	})
+	return found, err
+}
+
+// SaveItemWithContext see Repository.SaveItemWithContext
+func (r *CircuitBreakerRepository) SaveItemWithContext(ctx context.Context, key KeyInterface, item any) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.SaveItemWithContext(ctx, key, item)
+	})
+}
+
+// UpdateWithContext see Repository.UpdateWithContext
+func (r *CircuitBreakerRepository) UpdateWithContext(ctx context.Context, expression UpdateExpression, key KeyInterface, values map[string]any) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.UpdateWithContext(ctx, expression, key, values)
+	})
+}
+
+// UpdateWithUpdateExpressions see Repository.UpdateWithUpdateExpressions
+func (r *CircuitBreakerRepository) UpdateWithUpdateExpressions(ctx context.Context, key KeyInterface, updateExpressions UpdateExpressions) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.UpdateWithUpdateExpressions(ctx, key, updateExpressions)
+	})
+}
+
+// UpdateWithUpdateExpressionsAndReturnValue see Repository.UpdateWithUpdateExpressionsAndReturnValue
+func (r *CircuitBreakerRepository) UpdateWithUpdateExpressionsAndReturnValue(ctx context.Context, key KeyInterface, item any, updateExpressions UpdateExpressions) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.UpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions)
+	})
+}
+
+// ConditionalUpdateWithUpdateExpressionsAndReturnValue see Repository.ConditionalUpdateWithUpdateExpressionsAndReturnValue
+func (r *CircuitBreakerRepository) ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx context.Context, key KeyInterface, item any, updateExpressions UpdateExpressions, conditionExpression string, conditionArgs ...any) (conditionMet bool, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		conditionMet, err = r.repository.ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions, conditionExpression, conditionArgs...)
+		return err
+	})
+	return conditionMet, err
+}
+
+// DeleteItemWithContext see Repository.DeleteItemWithContext
+func (r *CircuitBreakerRepository) DeleteItemWithContext(ctx context.Context, key KeyInterface) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.DeleteItemWithContext(ctx, key)
+	})
+}
+
+// SaveItemsWithContext see Repository.SaveItemsWithContext
+func (r *CircuitBreakerRepository) SaveItemsWithContext(ctx context.Context, key KeyInterface, items any) error {
+	return r.breakers.call(ctx, key.TableName(), func() error {
+		return r.repository.SaveItemsWithContext(ctx, key, items)
+	})
+}
+
+// DeleteItemsWithContext see Repository.DeleteItemsWithContext
+func (r *CircuitBreakerRepository) DeleteItemsWithContext(ctx context.Context, keys []KeyInterface) error {
+	if len(keys) == 0 {
+		return r.repository.DeleteItemsWithContext(ctx, keys)
+	}
+	return r.breakers.call(ctx, keys[0].TableName(), func() error {
+		return r.repository.DeleteItemsWithContext(ctx, keys)
+	})
+}
+
+// GetItemsWithContext see Repository.GetItemsWithContext
+func (r *CircuitBreakerRepository) GetItemsWithContext(ctx context.Context, key KeyInterface, out any) (found bool, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		found, err = r.repository.GetItemsWithContext(ctx, key, out)
+		return err
+	})
+	return found, err
+}
+
+// QueryWithContext see Repository.QueryWithContext
+func (r *CircuitBreakerRepository) QueryWithContext(ctx context.Context, query QueryInterface, item any) error {
+	return r.breakers.call(ctx, query.TableName(), func() error {
+		return r.repository.QueryWithContext(ctx, query, item)
+	})
+}
+
+// CreateTable see Repository.CreateTable; managing tables does not pass the circuit breaker
+func (r *CircuitBreakerRepository) CreateTable(ctx context.Context, model any) error {
+	return r.repository.CreateTable(ctx, model)
+}
+
+// DescribeTable see Repository.DescribeTable
+func (r *CircuitBreakerRepository) DescribeTable(ctx context.Context, tableName string) (TableSchema, error) {
+	return r.repository.DescribeTable(ctx, tableName)
+}
+
+// EnsureTable see Repository.EnsureTable
+func (r *CircuitBreakerRepository) EnsureTable(ctx context.Context, model any) error {
+	return r.repository.EnsureTable(ctx, model)
+}
+
+// Backfill see Repository.Backfill; backfills do not pass the circuit breaker, failed items are counted by the backfill
+func (r *CircuitBreakerRepository) Backfill(ctx context.Context, key KeyInterface, fn BackfillFunc, settings BackfillSettings) (BackfillStats, error) {
+	return r.repository.Backfill(ctx, key, fn, settings)
+}
+
+// GIndex returns the index repository decorated with a circuit breaker per table and index
+func (r *CircuitBreakerRepository) GIndex(name string) GlobalIndexInterface {
+	return &CircuitBreakerGlobalIndex{
+		name:     name,
+		index:    r.repository.GIndex(name),
+		breakers: r.breakers,
+	}
+}
+
+// OptimisticLockSaveWithContext see Repository.OptimisticLockSaveWithContext
+func (r *CircuitBreakerRepository) OptimisticLockSaveWithContext(ctx context.Context, key KeyInterface, item any) (saved bool, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		saved, err = r.repository.OptimisticLockSaveWithContext(ctx, key, item)
+		return err
+	})
+	return saved, err
+}
+
+// ScanIteratorWithContext see Repository.ScanIteratorWithContext; only creating the iterator is guarded
+func (r *CircuitBreakerRepository) ScanIteratorWithContext(ctx context.Context, key KeyInterface, searchLimit int64) (iterator IteratorInterface, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		iterator, err = r.repository.ScanIteratorWithContext(ctx, key, searchLimit)
+		return err
+	})
+	return iterator, err
+}
+
+// ConditionalUpdateWithContext see Repository.ConditionalUpdateWithContext
+func (r *CircuitBreakerRepository) ConditionalUpdateWithContext(ctx context.Context, key KeyInterface, item any, expression string, expressionArgs ...any) (conditionMet bool, err error) {
+	err = r.breakers.call(ctx, key.TableName(), func() error {
+		conditionMet, err = r.repository.ConditionalUpdateWithContext(ctx, key, item, expression, expressionArgs...)
+		return err
+	})
+	return conditionMet, err
+}
+
+// BatchGetItemsWithContext see Repository.BatchGetItemsWithContext
+func (r *CircuitBreakerRepository) BatchGetItemsWithContext(ctx context.Context, keys []KeyInterface, out any) (found bool, err error) {
+	if len(keys) == 0 {
+		return r.repository.BatchGetItemsWithContext(ctx, keys, out)
+	}
+	err = r.breakers.call(ctx, keys[0].TableName(), func() error {
+		found, err = r.repository.BatchGetItemsWithContext(ctx, keys, out)
+		return err
+	})
+	return found, err
+}
+
+// CircuitBreakerGlobalIndex decorates an index repository with a circuit breaker per table and index
+type CircuitBreakerGlobalIndex struct {
+	name     string
+	index    GlobalIndexInterface
+	breakers *circuitBreakers
+}
+
+func (gi *CircuitBreakerGlobalIndex) circuit(key KeyInterface) string {
+	return key.TableName() + "/" + gi.name
+}
+
+// WithLog enables logging of the index repository
+func (gi *CircuitBreakerGlobalIndex) WithLog(log LogInterface) {
+	gi.index.WithLog(log)
+}
+
+// WithMetrics enables metrics of the index repository
+func (gi *CircuitBreakerGlobalIndex) WithMetrics(metricsInterface MetricsInterface) {
+	gi.index.WithMetrics(metricsInterface)
+}
+
+// WithRetryPolicy sets the retry policy of the index repository
+func (gi *CircuitBreakerGlobalIndex) WithRetryPolicy(policy RetryPolicy) {
+	gi.index.WithRetryPolicy(policy)
+}
+
+// WithSlogHandler enables logging of the index repository to handler
+func (gi *CircuitBreakerGlobalIndex) WithSlogHandler(handler slog.Handler) {
+	gi.index.WithSlogHandler(handler)
+}
+
+// WithTracerProvider sets the tracer provider of the index repository
+func (gi *CircuitBreakerGlobalIndex) WithTracerProvider(provider trace.TracerProvider) {
+	gi.index.WithTracerProvider(provider)
+}
+
+// WithPrometheusMetrics enables prometheus metrics of the index repository
+func (gi *CircuitBreakerGlobalIndex) WithPrometheusMetrics(registry *prometheus.Registry) GlobalIndexInterface {
+	gi.index.WithPrometheusMetrics(registry)
+	return gi
+}
+
+// GetItemWithContext see GlobalIndex.GetItemWithContext
+func (gi *CircuitBreakerGlobalIndex) GetItemWithContext(ctx context.Context, key KeyInterface, item interface{}) (found bool, err error) {
+	err = gi.breakers.call(ctx, gi.circuit(key), func() error {
+		found, err = gi.index.GetItemWithContext(ctx, key, item)
+		return err
+	})
+	return found, err
+}
+
+// GetItemsWithContext see GlobalIndex.GetItemsWithContext
+func (gi *CircuitBreakerGlobalIndex) GetItemsWithContext(ctx context.Context, key KeyInterface, items interface{}) (found bool, err error) {
+	err = gi.breakers.call(ctx, gi.circuit(key), func() error {
+		found, err = gi.index.GetItemsWithContext(ctx, key, items)
+		return err
+	})
+	return found, err
+}
+
+// GetItemsWithRangeWithContext see GlobalIndex.GetItemsWithRangeWithContext
+func (gi *CircuitBreakerGlobalIndex) GetItemsWithRangeWithContext(ctx context.Context, key KeyInterface, items interface{}) (found bool, err error) {
+	err = gi.breakers.call(ctx, gi.circuit(key), func() error {
+		found, err = gi.index.GetItemsWithRangeWithContext(ctx, key, items)
+		return err
+	})
+	return found, err
+}
+
+// QueryWithContext see GlobalIndex.QueryWithContext
+func (gi *CircuitBreakerGlobalIndex) QueryWithContext(ctx context.Context, query QueryInterface, item interface{}) error {
+	return gi.breakers.call(ctx, gi.circuit(query), func() error {
+		return gi.index.QueryWithContext(ctx, query, item)
+	})
+}
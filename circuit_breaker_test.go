@@ -0,0 +1,196 @@
+package djoemo_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+	const (
+		UserTableName    = "UserTable"
+		ProfileTableName = "ProfileTable"
+	)
+
+	var (
+		repositoryMock *mock.MockRepositoryInterface
+		indexMock      *mock.MockGlobalIndexInterface
+		logMock        *mock.MockLogInterface
+		repository     djoemo.RepositoryInterface
+		userKey        djoemo.KeyInterface
+		profileKey     djoemo.KeyInterface
+		throttled      error
+	)
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		repositoryMock = mock.NewMockRepositoryInterface(mockCtrl)
+		indexMock = mock.NewMockGlobalIndexInterface(mockCtrl)
+		logMock = mock.NewMockLogInterface(mockCtrl)
+
+		repository = djoemo.NewCircuitBreakerRepository(repositoryMock, djoemo.CircuitBreakerSettings{
+			FailureThreshold: 2,
+			OpenTimeout:      20 * time.Millisecond,
+			HalfOpenProbes:   1,
+		})
+		repositoryMock.EXPECT().WithLog(logMock)
+		repository.WithLog(logMock)
+
+		logMock.EXPECT().WithContext(gomock.Any()).Return(logMock).AnyTimes()
+		logMock.EXPECT().WithField("Circuit", gomock.Any()).Return(logMock).AnyTimes()
+
+		userKey = djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+		profileKey = djoemo.Key().WithTableName(ProfileTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+		throttled = awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throughput exceeded", nil)
+	})
+
+	It("should open after consecutive failures and fail fast", func() {
+		repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, throttled).Times(2)
+		logMock.EXPECT().Warn("circuit breaker open").Times(1)
+
+		for i := 0; i < 2; i++ {
+			_, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+			Expect(err).To(Equal(throttled))
+		}
+
+		found, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).To(Equal(djoemo.ErrCircuitOpen))
+		Expect(found).To(BeFalse())
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitOpen))
+	})
+
+	It("should keep a circuit per table", func() {
+		repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, throttled).Times(2)
+		repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), profileKey, gomock.Any()).Return(true, nil).Times(1)
+		logMock.EXPECT().Warn("circuit breaker open").Times(1)
+
+		for i := 0; i < 2; i++ {
+			_, _ = repository.GetItemWithContext(context.Background(), userKey, &User{})
+		}
+
+		found, err := repository.GetItemWithContext(context.Background(), profileKey, &Profile{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+	})
+
+	It("should not count errors that are no failures", func() {
+		invalid := errors.New("invalid")
+		repositoryMock.EXPECT().SaveItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(invalid).Times(3)
+
+		for i := 0; i < 3; i++ {
+			Expect(repository.SaveItemWithContext(context.Background(), userKey, &User{})).To(Equal(invalid))
+		}
+	})
+
+	It("should close again after a successful probe", func() {
+		gomock.InOrder(
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, throttled).Times(2),
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(true, nil).Times(2),
+		)
+		logMock.EXPECT().Warn("circuit breaker open").Times(1)
+		logMock.EXPECT().Warn("circuit breaker half_open").Times(1)
+		logMock.EXPECT().Warn("circuit breaker closed").Times(1)
+
+		for i := 0; i < 2; i++ {
+			_, _ = repository.GetItemWithContext(context.Background(), userKey, &User{})
+		}
+		time.Sleep(30 * time.Millisecond)
+
+		for i := 0; i < 2; i++ {
+			found, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+		}
+	})
+
+	It("should stay half-open after a canceled probe", func() {
+		gomock.InOrder(
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, throttled).Times(2),
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, context.Canceled).Times(1),
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, context.DeadlineExceeded).Times(1),
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(true, nil).Times(1),
+		)
+		logMock.EXPECT().Warn("circuit breaker open").Times(1)
+		logMock.EXPECT().Warn("circuit breaker half_open").Times(1)
+		logMock.EXPECT().Warn("circuit breaker closed").Times(1)
+
+		for i := 0; i < 2; i++ {
+			_, _ = repository.GetItemWithContext(context.Background(), userKey, &User{})
+		}
+		time.Sleep(30 * time.Millisecond)
+
+		_, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).To(Equal(context.Canceled))
+		_, err = repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).To(Equal(context.DeadlineExceeded))
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitHalfOpen))
+
+		found, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitClosed))
+	})
+
+	It("should ignore outcomes of operations allowed before the circuit opened", func() {
+		blocking := func(found bool, err error) (chan struct{}, chan error) {
+			started, release, done := make(chan struct{}), make(chan struct{}), make(chan error)
+			repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).DoAndReturn(
+				func(context.Context, djoemo.KeyInterface, any) (bool, error) {
+					close(started)
+					<-release
+					return found, err
+				}).Times(1)
+			go func() {
+				_, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+				done <- err
+			}()
+			<-started
+			return release, done
+		}
+		logMock.EXPECT().Warn("circuit breaker open").Times(2)
+		logMock.EXPECT().Warn("circuit breaker half_open").Times(1)
+
+		releaseStale, staleDone := blocking(true, nil)
+		repositoryMock.EXPECT().GetItemWithContext(gomock.Any(), userKey, gomock.Any()).Return(false, throttled).Times(2)
+		for i := 0; i < 2; i++ {
+			_, _ = repository.GetItemWithContext(context.Background(), userKey, &User{})
+		}
+		time.Sleep(30 * time.Millisecond)
+		releaseProbe, probeDone := blocking(false, throttled)
+
+		close(releaseStale)
+		Expect(<-staleDone).To(BeNil())
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitHalfOpen))
+		_, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).To(Equal(djoemo.ErrCircuitOpen))
+
+		close(releaseProbe)
+		Expect(<-probeDone).To(Equal(throttled))
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitOpen))
+	})
+
+	It("should keep a circuit per index", func() {
+		repositoryMock.EXPECT().GIndex("email-index").Return(indexMock)
+		indexMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(throttled).Times(2)
+		logMock.EXPECT().Warn("circuit breaker open").Times(1)
+
+		index := repository.GIndex("email-index")
+		query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("email")
+		for i := 0; i < 2; i++ {
+			Expect(index.QueryWithContext(context.Background(), query, &[]User{})).To(Equal(throttled))
+		}
+
+		Expect(index.QueryWithContext(context.Background(), query, &[]User{})).To(Equal(djoemo.ErrCircuitOpen))
+		Expect(repository.(*djoemo.CircuitBreakerRepository).CircuitState(UserTableName)).To(Equal(djoemo.CircuitClosed))
+	})
+})
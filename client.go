@@ -0,0 +1,328 @@
+package djoemo
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// apiClient wraps the dynamodb client of a repository to apply repository settings to every request
+type apiClient struct {
+	dynamodbiface.DynamoDBAPI
+	limiter *RateLimiter
+	metrics *Metrics
+	log     LogInterface
+	redact  Redactor
+	slowLog *SlowLog
+}
+
+func newAPIClient(dynamoClient dynamodbiface.DynamoDBAPI, metrics *Metrics) *apiClient {
+	return &apiClient{DynamoDBAPI: dynamoClient, metrics: metrics, log: NewNopLog()}
+}
+
+// transactUnits is the number of capacity units a transaction consumes per item of up to 1 KB or 4 KB
+const transactUnits = 2
+
+// returnConsumedCapacity is requested from DynamoDB to report the consumed capacity per table and index
+var returnConsumedCapacity = aws.String(dynamodb.ReturnConsumedCapacityIndexes)
+
+// wait blocks until the rate limiter grants the estimated units of a request; items are assumed to fit into one unit.
+// If waiting for a table or index fails, the units already granted for the others are refunded
+func (c *apiClient) wait(ctx context.Context, estimate capacityUsage) error {
+	if c.limiter == nil {
+		return nil
+	}
+	granted := make(capacityUsage, len(estimate))
+	for key, capacity := range estimate {
+		if err := c.limiter.Wait(ctx, key.table, key.index, capacity.ReadUnits, capacity.WriteUnits); err != nil {
+			for key, capacity := range granted {
+				c.limiter.Consume(key.table, key.index, -capacity.ReadUnits, -capacity.WriteUnits)
+			}
+			return err
+		}
+		granted[key] = capacity
+	}
+	return nil
+}
+
+// send traces and logs a request to DynamoDB, waits for the rate limiter before and reports the consumed capacity after it;
+// call returns the output and the capacity consumed by the request
+func send[O any](c *apiClient, ctx context.Context, name string, input any, estimate capacityUsage, write bool, call func(ctx context.Context) (O, []*dynamodb.ConsumedCapacity, error)) (O, error) {
+	ctx, span := startCallSpan(ctx, name, estimate)
+	defer span.End()
+
+	if err := c.wait(ctx, estimate); err != nil {
+		recordSpanError(span, err)
+		var output O
+		return output, err
+	}
+
+	start := time.Now()
+	output, consumed, err := call(ctx)
+	c.logRequest(ctx, name, input, time.Since(start), err)
+	if err != nil {
+		recordSpanError(span, err)
+		return output, maskRetryError(ctx, err)
+	}
+
+	total := c.consumed(ctx, estimate, consumed, write)
+	span.SetAttributes(attrConsumedRead.Float64(total.ReadUnits), attrConsumedWrite.Float64(total.WriteUnits))
+	return output, nil
+}
+
+// consumed reports the capacity consumed by a request to metrics and ctx, and settles the difference
+// to the estimate with the rate limiter; it returns the total capacity consumed
+func (c *apiClient) consumed(ctx context.Context, estimate capacityUsage, consumed []*dynamodb.ConsumedCapacity, write bool) Capacity {
+	var total Capacity
+	if len(consumed) == 0 {
+		return total
+	}
+
+	usage := consumedUsage(consumed, write)
+	collected := ConsumedCapacityFromContext(ctx)
+	for key, capacity := range usage {
+		total = total.Add(capacity)
+		if c.metrics != nil {
+			c.metrics.RecordConsumedCapacity(ctx, key.table, key.index, capacity)
+		}
+		if collected != nil {
+			collected.add(key, capacity)
+		}
+	}
+
+	if c.limiter == nil {
+		return total
+	}
+	for key, capacity := range estimate {
+		usage[key] = Capacity{
+			ReadUnits:  usage[key].ReadUnits - capacity.ReadUnits,
+			WriteUnits: usage[key].WriteUnits - capacity.WriteUnits,
+		}
+	}
+	for key, capacity := range usage {
+		c.limiter.Consume(key.table, key.index, capacity.ReadUnits, capacity.WriteUnits)
+	}
+	return total
+}
+
+// readUnits returns the read units of an item, eventually consistent reads cost half a unit
+func readUnits(consistentRead *bool) float64 {
+	if aws.BoolValue(consistentRead) {
+		return 1
+	}
+	return 0.5
+}
+
+func singleUsage(tableName *string, indexName *string, capacity Capacity) capacityUsage {
+	return capacityUsage{
+		capacityKey{table: aws.StringValue(tableName), index: aws.StringValue(indexName)}: capacity,
+	}
+}
+
+func transactWriteTableName(item *dynamodb.TransactWriteItem) string {
+	switch {
+	case item.Put != nil:
+		return aws.StringValue(item.Put.TableName)
+	case item.Update != nil:
+		return aws.StringValue(item.Update.TableName)
+	case item.Delete != nil:
+		return aws.StringValue(item.Delete.TableName)
+	case item.ConditionCheck != nil:
+		return aws.StringValue(item.ConditionCheck.TableName)
+	}
+	return ""
+}
+
+// GetItemWithContext calls the wrapped client
+func (c *apiClient) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	estimate := singleUsage(input.TableName, nil, Capacity{ReadUnits: readUnits(input.ConsistentRead)})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "GetItem", input, estimate, false, func(ctx context.Context) (*dynamodb.GetItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.GetItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if err == nil {
+			payloadSizeFromContext(ctx).add(output.Item)
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// PutItemWithContext calls the wrapped client
+func (c *apiClient) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	estimate := singleUsage(input.TableName, nil, Capacity{WriteUnits: 1})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "PutItem", input, estimate, true, func(ctx context.Context) (*dynamodb.PutItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.PutItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if err == nil {
+			payloadSizeFromContext(ctx).add(input.Item)
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// UpdateItemWithContext calls the wrapped client
+func (c *apiClient) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	estimate := singleUsage(input.TableName, nil, Capacity{WriteUnits: 1})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "UpdateItem", input, estimate, true, func(ctx context.Context) (*dynamodb.UpdateItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.UpdateItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// DeleteItemWithContext calls the wrapped client
+func (c *apiClient) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	estimate := singleUsage(input.TableName, nil, Capacity{WriteUnits: 1})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "DeleteItem", input, estimate, true, func(ctx context.Context) (*dynamodb.DeleteItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.DeleteItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// QueryWithContext calls the wrapped client
+func (c *apiClient) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	estimate := singleUsage(input.TableName, input.IndexName, Capacity{ReadUnits: readUnits(input.ConsistentRead)})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "Query", input, estimate, false, func(ctx context.Context) (*dynamodb.QueryOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.QueryWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if err == nil {
+			payloadSizeFromContext(ctx).add(output.Items...)
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// ScanWithContext calls the wrapped client
+func (c *apiClient) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	estimate := singleUsage(input.TableName, input.IndexName, Capacity{ReadUnits: readUnits(input.ConsistentRead)})
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "Scan", input, estimate, false, func(ctx context.Context) (*dynamodb.ScanOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.ScanWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if err == nil {
+			payloadSizeFromContext(ctx).add(output.Items...)
+		}
+		return output, consumedCapacities(output.ConsumedCapacity), err
+	})
+}
+
+// BatchGetItemWithContext calls the wrapped client
+func (c *apiClient) BatchGetItemWithContext(ctx aws.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	estimate := make(capacityUsage)
+	for tableName, keys := range input.RequestItems {
+		estimate[capacityKey{table: tableName}] = Capacity{ReadUnits: float64(len(keys.Keys)) * readUnits(keys.ConsistentRead)}
+	}
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "BatchGetItem", input, estimate, false, func(ctx context.Context) (*dynamodb.BatchGetItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.BatchGetItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if size := payloadSizeFromContext(ctx); size != nil && err == nil {
+			for _, items := range output.Responses {
+				size.add(items...)
+			}
+		}
+		return output, output.ConsumedCapacity, err
+	})
+}
+
+// BatchWriteItemWithContext calls the wrapped client
+func (c *apiClient) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	estimate := make(capacityUsage)
+	for tableName, requests := range input.RequestItems {
+		estimate[capacityKey{table: tableName}] = Capacity{WriteUnits: float64(len(requests))}
+	}
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "BatchWriteItem", input, estimate, true, func(ctx context.Context) (*dynamodb.BatchWriteItemOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.BatchWriteItemWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		if size := payloadSizeFromContext(ctx); size != nil && err == nil {
+			// unprocessed items are counted once a later request processes them
+			for tableName, requests := range input.RequestItems {
+				size.add(processedItems(requests, output.UnprocessedItems[tableName])...)
+			}
+		}
+		return output, output.ConsumedCapacity, err
+	})
+}
+
+// TransactGetItemsWithContext calls the wrapped client
+func (c *apiClient) TransactGetItemsWithContext(ctx aws.Context, input *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	estimate := make(capacityUsage)
+	for _, item := range input.TransactItems {
+		if item.Get == nil {
+			continue
+		}
+		key := capacityKey{table: aws.StringValue(item.Get.TableName)}
+		estimate[key] = estimate[key].Add(Capacity{ReadUnits: transactUnits})
+	}
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "TransactGetItems", input, estimate, false, func(ctx context.Context) (*dynamodb.TransactGetItemsOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.TransactGetItemsWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		return output, output.ConsumedCapacity, err
+	})
+}
+
+// TransactWriteItemsWithContext calls the wrapped client
+func (c *apiClient) TransactWriteItemsWithContext(ctx aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	estimate := make(capacityUsage)
+	for _, item := range input.TransactItems {
+		key := capacityKey{table: transactWriteTableName(item)}
+		estimate[key] = estimate[key].Add(Capacity{WriteUnits: transactUnits})
+	}
+	if input.ReturnConsumedCapacity == nil {
+		input.ReturnConsumedCapacity = returnConsumedCapacity
+	}
+	return send(c, ctx, "TransactWriteItems", input, estimate, true, func(ctx context.Context) (*dynamodb.TransactWriteItemsOutput, []*dynamodb.ConsumedCapacity, error) {
+		output, err := c.DynamoDBAPI.TransactWriteItemsWithContext(ctx, input, withoutClientRetries(ctx, opts)...)
+		if output == nil {
+			return output, nil, err
+		}
+		return output, output.ConsumedCapacity, err
+	})
+}
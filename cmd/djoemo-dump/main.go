@@ -0,0 +1,192 @@
+// Command djoemo-dump exports tables and query results to JSON Lines and imports them again, see package dump:
+//
+//	djoemo-dump export -table UserTable -format dynamodb -out users.jsonl
+//	djoemo-dump export -table PostTable -hash-key user-1 -range-key 2024 -range-op GE
+//	djoemo-dump import -endpoint http://localhost:8000 -rename-key ID=UUID users.jsonl
+//
+// The AWS region and credentials are taken from the environment and the shared config, like the AWS CLI.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/dump"
+	"github.com/adjoeio/djoemo/internal/cli"
+)
+
+const usage = `usage: djoemo-dump <command> [flags]
+
+commands:
+  export   write the items of a table or a query to JSON Lines
+  import   write items of JSON Lines files to a table
+
+run djoemo-dump <command> -h for the flags of a command
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "djoemo-dump:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	switch args[0] {
+	case "export":
+		return runExport(ctx, args[1:], stdout)
+	case "import":
+		return runImport(ctx, args[1:], stdin, stdout)
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
+
+func runExport(ctx context.Context, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	var (
+		conn                   cli.Connection
+		keys                   cli.KeyFlags
+		options                dump.ExportOptions
+		tableName, out, format string
+	)
+	conn.Register(flags)
+	keys.Register(flags, true)
+	flags.StringVar(&tableName, "table", "", "name of the exported table (required)")
+	flags.StringVar(&format, "format", string(dump.JSON), "format of the items: json or dynamodb")
+	flags.StringVar(&out, "out", "-", "file the items are written to; - writes to stdout")
+	flags.StringVar(&options.IndexName, "index", "", "scan or query an index instead of the table")
+	flags.Int64Var(&options.Limit, "limit", 0, "maximum number of exported items; 0 exports all")
+	flags.Int64Var(&options.PageSize, "page-size", 0, "maximum number of items read per request")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if tableName == "" {
+		return errors.New("export: -table is required")
+	}
+	options.Format = dump.Format(format)
+
+	client, err := conn.Client()
+	if err != nil {
+		return err
+	}
+	w, closeOut, err := cli.Create(out, stdout)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if keys.HashKey == "" {
+		count, err = dump.Export(ctx, client, tableName, w, options)
+	} else {
+		count, err = exportQuery(ctx, client, tableName, keys, w, options)
+	}
+	if closeErr := closeOut(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d items of %s\n", count, tableName)
+	return nil
+}
+
+// exportQuery exports the items matching the key flags; key values are converted to the types of the keys
+func exportQuery(ctx context.Context, client dynamodbiface.DynamoDBAPI, tableName string, keys cli.KeyFlags, w io.Writer, options dump.ExportOptions) (int, error) {
+	schema, err := djoemo.NewRepository(client).DescribeTable(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	query, err := keys.Query(schema, options.IndexName, 0)
+	if err != nil {
+		return 0, err
+	}
+	return dump.ExportQuery(ctx, client, query, w, options)
+}
+
+func runImport(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	var (
+		conn              cli.Connection
+		options           dump.ImportOptions
+		tableName, format string
+		renames           renameFlag
+	)
+	conn.Register(flags)
+	flags.StringVar(&tableName, "table", "", "name of the table the items are written to; defaults to the file name without extension")
+	flags.StringVar(&format, "format", string(dump.JSON), "format of the items: json or dynamodb")
+	flags.IntVar(&options.ChunkSize, "chunk-size", dump.MaxChunkSize, "number of items written per batch")
+	flags.Var(&renames, "rename-key", "renames an attribute like FROM=TO; repeatable")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	options.Format = dump.Format(format)
+	options.KeyMapping = renames
+
+	client, err := conn.Client()
+	if err != nil {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, file := range files {
+		table := tableName
+		if table == "" && file != "-" {
+			table = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		}
+		if table == "" {
+			return errors.New("import: -table is required to import from stdin")
+		}
+
+		r, closeIn, err := cli.Open(file, stdin)
+		if err != nil {
+			return err
+		}
+		count, err := dump.Import(ctx, client, table, r, options)
+		closeIn()
+		if err != nil {
+			return fmt.Errorf("import %s: %w", file, err)
+		}
+		fmt.Fprintf(stdout, "imported %d items of %s into %s\n", count, file, table)
+	}
+	return nil
+}
+
+// renameFlag collects FROM=TO attribute renames
+type renameFlag map[string]string
+
+func (r *renameFlag) String() string {
+	renames := make([]string, 0, len(*r))
+	for from, to := range *r {
+		renames = append(renames, from+"="+to)
+	}
+	return strings.Join(renames, ",")
+}
+
+func (r *renameFlag) Set(value string) error {
+	from, to, ok := strings.Cut(value, "=")
+	if !ok || from == "" || to == "" {
+		return fmt.Errorf("expected FROM=TO, got %q", value)
+	}
+	if *r == nil {
+		*r = renameFlag{}
+	}
+	(*r)[from] = to
+	return nil
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/dump"
+	"github.com/adjoeio/djoemo/internal/cli"
+)
+
+func runGet(ctx context.Context, c *command, args []string) error {
+	var keys cli.KeyFlags
+	keys.Register(c.flags, false)
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := keys.Key(schema)
+	if err != nil {
+		return err
+	}
+
+	item, err := get(ctx, repository, key)
+	if err != nil {
+		return err
+	}
+	p := c.printer(schema)
+	if err := p.add(item); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// get returns an item or ErrNoItemFound
+func get(ctx context.Context, repository djoemo.RepositoryInterface, key djoemo.KeyInterface) (map[string]*dynamodb.AttributeValue, error) {
+	item := map[string]*dynamodb.AttributeValue{}
+	found, err := repository.GetItemWithContext(ctx, key, &item)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, djoemo.ErrNoItemFound
+	}
+	return item, nil
+}
+
+func runQuery(ctx context.Context, c *command, args []string) error {
+	var (
+		keys      cli.KeyFlags
+		indexName string
+		limit     int64
+	)
+	keys.Register(c.flags, true)
+	c.flags.StringVar(&indexName, "index", "", "query an index instead of the table")
+	c.flags.Int64Var(&limit, "limit", 0, "maximum number of items; 0 prints all")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	items, err := query(ctx, repository, schema, keys, indexName, limit)
+	if err != nil {
+		return err
+	}
+	p := c.printer(schema)
+	for _, item := range items {
+		if err := p.add(item); err != nil {
+			return err
+		}
+	}
+	return p.flush()
+}
+
+// query returns the items of a query on a table or index
+func query(ctx context.Context, repository djoemo.RepositoryInterface, schema djoemo.TableSchema, keys cli.KeyFlags, indexName string, limit int64) ([]map[string]*dynamodb.AttributeValue, error) {
+	q, err := keys.Query(schema, indexName, limit)
+	if err != nil {
+		return nil, err
+	}
+	items := []map[string]*dynamodb.AttributeValue{}
+	if indexName != "" {
+		err = repository.GIndex(indexName).QueryWithContext(ctx, q, &items)
+	} else {
+		err = repository.QueryWithContext(ctx, q, &items)
+	}
+	return items, err
+}
+
+func runScan(ctx context.Context, c *command, args []string) error {
+	var limit, pageSize int64
+	c.flags.Int64Var(&limit, "limit", 0, "maximum number of items; 0 prints all")
+	c.flags.Int64Var(&pageSize, "page-size", 100, "maximum number of items read per request")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	p := c.printer(schema)
+	err = scan(ctx, repository, c.tableName, pageSize, limit, p.add)
+	if err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// scan passes the items of a table to fn until limit items are passed or the table is exhausted
+func scan(ctx context.Context, repository djoemo.RepositoryInterface, tableName string, pageSize int64, limit int64, fn func(item map[string]*dynamodb.AttributeValue) error) error {
+	iterator, err := repository.ScanIteratorWithContext(ctx, djoemo.Key().WithTableName(tableName), pageSize)
+	if err != nil {
+		return err
+	}
+	count := int64(0)
+	for item := (map[string]*dynamodb.AttributeValue{}); (limit == 0 || count < limit) && iterator.NextItem(&item); item = nil {
+		count++
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if failed, ok := iterator.(interface{ Err() error }); ok {
+		return failed.Err()
+	}
+	return nil
+}
+
+func runCount(ctx context.Context, c *command, args []string) error {
+	var (
+		keys      cli.KeyFlags
+		indexName string
+	)
+	keys.Register(c.flags, true)
+	c.flags.StringVar(&indexName, "index", "", "count the items of an index instead of the table")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	client, err := c.client(c.conn)
+	if err != nil {
+		return err
+	}
+	schema, err := djoemo.NewRepository(client).DescribeTable(ctx, c.tableName)
+	if err != nil {
+		return err
+	}
+
+	total, err := count(ctx, client, schema, keys, indexName)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, total)
+	return err
+}
+
+// count returns the number of items of a query or, without hash key, of a scan; DynamoDB returns the count only, so
+// no items are read
+func count(ctx context.Context, client dynamodbiface.DynamoDBAPI, schema djoemo.TableSchema, keys cli.KeyFlags, indexName string) (int64, error) {
+	if keys.HashKey == "" {
+		input := &dynamodb.ScanInput{TableName: aws.String(schema.Name), Select: aws.String(dynamodb.SelectCount)}
+		if indexName != "" {
+			input.IndexName = aws.String(indexName)
+		}
+		total := int64(0)
+		for {
+			output, err := client.ScanWithContext(ctx, input)
+			if err != nil {
+				return 0, err
+			}
+			total += aws.Int64Value(output.Count)
+			if len(output.LastEvaluatedKey) == 0 {
+				return total, nil
+			}
+			input.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}
+
+	q, err := keys.Query(schema, indexName, 0)
+	if err != nil {
+		return 0, err
+	}
+	query := dynamo.NewFromIface(client).Table(schema.Name).Get(*q.HashKeyName(), q.HashKey())
+	if q.RangeKeyName() != nil && q.RangeKey() != nil {
+		query = query.Range(*q.RangeKeyName(), dynamo.Operator(q.RangeOp()), djoemo.RangeValues(q)...)
+	}
+	if indexName != "" {
+		query = query.Index(indexName)
+	}
+	return query.CountWithContext(ctx)
+}
+
+func runPut(ctx context.Context, c *command, args []string) error {
+	var (
+		itemFlag, format    string
+		ifNotExists, dryRun bool
+	)
+	c.flags.StringVar(&itemFlag, "item", "-", "the item as JSON; - reads it from stdin")
+	c.flags.StringVar(&format, "format", string(dump.JSON), "format of the item: json or dynamodb")
+	c.flags.BoolVar(&ifNotExists, "if-not-exists", false, "fail instead of replacing an existing item")
+	c.flags.BoolVar(&dryRun, "dry-run", false, "print the item without writing it")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	line := []byte(itemFlag)
+	if itemFlag == "-" {
+		if line, err = io.ReadAll(c.stdin); err != nil {
+			return err
+		}
+		line = bytes.TrimSpace(line)
+	}
+	item, err := dump.Format(format).Decode(line)
+	if err != nil {
+		return fmt.Errorf("put: item: %w", err)
+	}
+	key, err := itemKey(schema, item)
+	if err != nil {
+		return err
+	}
+
+	if !dryRun {
+		if ifNotExists {
+			var saved bool
+			saved, err = repository.ConditionalUpdateWithContext(ctx, key, item, "attribute_not_exists($)", schema.HashKey)
+			if err == nil && !saved {
+				err = fmt.Errorf("%w: item exists", errConditionFailed)
+			}
+		} else {
+			err = repository.SaveItemWithContext(ctx, key, item)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return c.print(schema, item, dryRun, "not written")
+}
+
+// itemKey returns the key of an item of a table
+func itemKey(schema djoemo.TableSchema, item map[string]*dynamodb.AttributeValue) (djoemo.KeyInterface, error) {
+	if item[schema.HashKey] == nil {
+		return nil, fmt.Errorf("%w: item has no hash key %s", djoemo.ErrInvalidHashKeyValue, schema.HashKey)
+	}
+	key := djoemo.Key().WithTableName(schema.Name).WithHashKeyName(schema.HashKey).WithHashKey(item[schema.HashKey])
+	if schema.RangeKey == "" {
+		return key, nil
+	}
+	if item[schema.RangeKey] == nil {
+		return nil, fmt.Errorf("%w: item has no range key %s", djoemo.ErrInvalidRangeKeyValue, schema.RangeKey)
+	}
+	return key.WithRangeKeyName(schema.RangeKey).WithRangeKey(item[schema.RangeKey]), nil
+}
+
+func runUpdate(ctx context.Context, c *command, args []string) error {
+	var (
+		keys                    cli.KeyFlags
+		set, add, setIfNotExist string
+		upsert, dryRun          bool
+	)
+	keys.Register(c.flags, false)
+	c.flags.StringVar(&set, "set", "", `attributes to set as JSON object, like {"Name":"alice"}`)
+	c.flags.StringVar(&add, "add", "", `numbers to add to attributes as JSON object, like {"Count":1}`)
+	c.flags.StringVar(&setIfNotExist, "set-if-not-exists", "", "attributes to set if they are not set as JSON object")
+	c.flags.BoolVar(&upsert, "upsert", false, "create the item if it does not exist")
+	c.flags.BoolVar(&dryRun, "dry-run", false, "print the current item without updating it")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := keys.Key(schema)
+	if err != nil {
+		return err
+	}
+
+	expressions := djoemo.UpdateExpressions{}
+	for expression, values := range map[djoemo.UpdateExpression]string{djoemo.Set: set, djoemo.Add: add, djoemo.SetIfNotExists: setIfNotExist} {
+		if values == "" {
+			continue
+		}
+		item, err := dump.JSON.Decode([]byte(values))
+		if err != nil {
+			return fmt.Errorf("update: %s: %w", expression, err)
+		}
+		expressions[expression] = make(map[string]any, len(item))
+		for name, value := range item {
+			expressions[expression][name] = value
+		}
+	}
+	if len(expressions) == 0 {
+		return fmt.Errorf("update: one of -set, -add or -set-if-not-exists is required")
+	}
+
+	item := map[string]*dynamodb.AttributeValue{}
+	switch {
+	case dryRun:
+		item, err = get(ctx, repository, key)
+		if errors.Is(err, djoemo.ErrNoItemFound) && upsert {
+			item, err = map[string]*dynamodb.AttributeValue{}, nil
+		}
+	case upsert:
+		err = repository.UpdateWithUpdateExpressionsAndReturnValue(ctx, key, &item, expressions)
+	default:
+		var updated bool
+		updated, err = repository.ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx, key, &item, expressions,
+			"attribute_exists($)", schema.HashKey)
+		if err == nil && !updated {
+			err = djoemo.ErrNoItemFound
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return c.print(schema, item, dryRun, "not updated")
+}
+
+func runDelete(ctx context.Context, c *command, args []string) error {
+	var (
+		keys   cli.KeyFlags
+		dryRun bool
+	)
+	keys.Register(c.flags, false)
+	c.flags.BoolVar(&dryRun, "dry-run", false, "print the item without deleting it")
+	if err := c.parse(args); err != nil {
+		return err
+	}
+	repository, schema, err := c.open(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := keys.Key(schema)
+	if err != nil {
+		return err
+	}
+
+	item, err := get(ctx, repository, key)
+	if err != nil {
+		return err
+	}
+	if !dryRun {
+		if err := repository.DeleteItemWithContext(ctx, key); err != nil {
+			return err
+		}
+	}
+	return c.print(schema, item, dryRun, "not deleted")
+}
+
+// print prints the item of a write; dry runs report that the item was not written on stderr
+func (c *command) print(schema djoemo.TableSchema, item map[string]*dynamodb.AttributeValue, dryRun bool, skipped string) error {
+	if dryRun {
+		fmt.Fprintf(c.stderr, "dry run: item %s\n", skipped)
+	}
+	p := c.printer(schema)
+	if err := p.add(item); err != nil {
+		return err
+	}
+	return p.flush()
+}
@@ -0,0 +1,142 @@
+// Command djoemo runs ad-hoc requests against DynamoDB or DynamoDB Local through a djoemo repository, so keys are
+// validated and requests are built exactly like in services using djoemo:
+//
+//	djoemo get -table UserTable -hash-key 123
+//	djoemo query -table PostTable -hash-key 123 -range-key 2024 -range-op GE -output table
+//	djoemo count -table PostTable -hash-key 123 -range-key 2024 -range-op BETWEEN -range-key-to 2025
+//	djoemo update -endpoint http://localhost:8000 -table UserTable -hash-key 123 -set '{"UserName":"alice"}'
+//
+// Key values are converted to the types of the keys of the table. Items are printed as JSON Lines, see package dump,
+// or as table. Writes can be checked with -dry-run, which prints the item without writing it.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/internal/cli"
+)
+
+const usage = `usage: djoemo <command> [flags]
+
+commands:
+  get      print an item
+  query    print the items with a hash key, optionally compared by range key
+  scan     print the items of a table or index
+  count    print the number of items of a query or a scan
+  put      write an item
+  update   update attributes of an item
+  delete   delete an item and print it
+
+run djoemo <command> -h for the flags of a command
+`
+
+// errConditionFailed is returned by writes whose condition failed
+var errConditionFailed = errors.New("condition failed")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	e := env{
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+		client: func(conn cli.Connection) (dynamodbiface.DynamoDBAPI, error) { return conn.Client() },
+	}
+	if err := run(ctx, os.Args[1:], e); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "djoemo:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// env is the environment of a command
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	// client returns the DynamoDB client of the connection flags
+	client func(conn cli.Connection) (dynamodbiface.DynamoDBAPI, error)
+}
+
+func run(ctx context.Context, args []string, e env) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+	commands := map[string]func(ctx context.Context, c *command, args []string) error{
+		"get":    runGet,
+		"query":  runQuery,
+		"scan":   runScan,
+		"count":  runCount,
+		"put":    runPut,
+		"update": runUpdate,
+		"delete": runDelete,
+	}
+	runCommand, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+	return runCommand(ctx, newCommand(args[0], e), args[1:])
+}
+
+// command holds the flags all commands share
+type command struct {
+	env
+	flags     *flag.FlagSet
+	conn      cli.Connection
+	tableName string
+	output    string
+}
+
+func newCommand(name string, e env) *command {
+	c := &command{env: e, flags: flag.NewFlagSet(name, flag.ContinueOnError)}
+	c.flags.SetOutput(e.stderr)
+	c.conn.Register(c.flags)
+	c.flags.StringVar(&c.tableName, "table", "", "name of the table (required)")
+	c.flags.StringVar(&c.output, "output", outputJSON, "format of printed items: json, dynamodb or table")
+	return c
+}
+
+// parse parses the flags and checks the shared ones
+func (c *command) parse(args []string) error {
+	if err := c.flags.Parse(args); err != nil {
+		return err
+	}
+	if c.flags.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %v", c.flags.Name(), c.flags.Args())
+	}
+	if c.tableName == "" {
+		return fmt.Errorf("%s: -table is required", c.flags.Name())
+	}
+	_, err := newPrinter(c.output, io.Discard, djoemo.TableSchema{})
+	return err
+}
+
+// open returns a repository and the schema of the table
+func (c *command) open(ctx context.Context) (djoemo.RepositoryInterface, djoemo.TableSchema, error) {
+	client, err := c.client(c.conn)
+	if err != nil {
+		return nil, djoemo.TableSchema{}, err
+	}
+	repository := djoemo.NewRepository(client)
+	schema, err := repository.DescribeTable(ctx, c.tableName)
+	if err != nil {
+		return nil, djoemo.TableSchema{}, err
+	}
+	return repository, schema, nil
+}
+
+// printer returns the printer of the output flag
+func (c *command) printer(schema djoemo.TableSchema) *printer {
+	p, _ := newPrinter(c.output, c.stdout, schema)
+	return p
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/internal/cli"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Post model with hash and range key
+type Post struct {
+	UserUUID string `dynamo:",hash"`
+	Created  int    `dynamo:",range"`
+	Title    string
+	Likes    int
+}
+
+func (Post) TableSchema() djoemo.TableSchema {
+	return djoemo.TableSchema{Name: "PostTable"}
+}
+
+var _ = Describe("djoemo", func() {
+	var (
+		db             *fake.DB
+		ctx            context.Context
+		stdout, stderr *bytes.Buffer
+		stdin          string
+	)
+
+	BeforeEach(func() {
+		db = fake.New()
+		ctx = context.Background()
+		stdout, stderr, stdin = &bytes.Buffer{}, &bytes.Buffer{}, ""
+		repository := djoemo.NewRepository(db)
+		Expect(repository.CreateTable(ctx, Post{})).To(Succeed())
+		for created, title := range []string{"first", "second", "third"} {
+			key := djoemo.Key().WithTableName("PostTable").
+				WithHashKeyName("UserUUID").WithHashKey("a").
+				WithRangeKeyName("Created").WithRangeKey(created + 1)
+			Expect(repository.SaveItemWithContext(ctx, key, Post{UserUUID: "a", Created: created + 1, Title: title})).To(Succeed())
+		}
+	})
+
+	djoemoCmd := func(args ...string) error {
+		stdout.Reset()
+		stderr.Reset()
+		return run(ctx, args, env{
+			stdin:  strings.NewReader(stdin),
+			stdout: stdout,
+			stderr: stderr,
+			client: func(cli.Connection) (dynamodbiface.DynamoDBAPI, error) { return db, nil },
+		})
+	}
+
+	It("should get items by typed keys", func() {
+		Expect(djoemoCmd("get", "-table", "PostTable", "-hash-key", "a", "-range-key", "2")).To(Succeed())
+		Expect(stdout.String()).To(Equal(`{"Created":2,"Likes":0,"Title":"second","UserUUID":"a"}` + "\n"))
+
+		Expect(djoemoCmd("get", "-table", "PostTable", "-hash-key", "a", "-range-key", "9")).To(MatchError(djoemo.ErrNoItemFound))
+		Expect(djoemoCmd("get", "-table", "PostTable", "-hash-key", "a", "-range-key", "x")).To(MatchError(ContainSubstring(`range key Created: "x" is not a number`)))
+		Expect(djoemoCmd("get", "-table", "PostTable", "-hash-key", "a")).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+	})
+
+	It("should query with range operators and print tables", func() {
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-range-key", "2", "-range-op", "GE", "-descending", "-output", "table")).To(Succeed())
+		Expect(stdout.String()).To(Equal("" +
+			"UserUUID  Created  Likes  Title\n" +
+			"a         3        0      third\n" +
+			"a         2        0      second\n"))
+
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-limit", "1", "-output", "dynamodb")).To(Succeed())
+		Expect(stdout.String()).To(Equal(`{"Created":{"N":"1"},"Likes":{"N":"0"},"Title":{"S":"first"},"UserUUID":{"S":"a"}}` + "\n"))
+	})
+
+	It("should scan and count items", func() {
+		Expect(djoemoCmd("scan", "-table", "PostTable", "-limit", "2")).To(Succeed())
+		Expect(strings.Count(stdout.String(), "\n")).To(Equal(2))
+
+		Expect(djoemoCmd("count", "-table", "PostTable")).To(Succeed())
+		Expect(stdout.String()).To(Equal("3\n"))
+		Expect(djoemoCmd("count", "-table", "PostTable", "-hash-key", "a", "-range-key", "2", "-range-op", "LT")).To(Succeed())
+		Expect(stdout.String()).To(Equal("1\n"))
+		Expect(djoemoCmd("count", "-table", "PostTable", "-hash-key", "a", "-range-key", "2", "-range-op", "BETWEEN", "-range-key-to", "3")).To(Succeed())
+		Expect(stdout.String()).To(Equal("2\n"))
+	})
+
+	It("should query between bounds and reject unknown range operators", func() {
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-range-op", "BETWEEN", "-range-key-to", "2")).To(Succeed())
+		Expect(strings.Count(stdout.String(), "\n")).To(Equal(2))
+
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-range-op", "BETWEEN")).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-range-key-to", "2")).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+		Expect(djoemoCmd("query", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-range-op", "NE")).To(MatchError(ContainSubstring(`unknown range operator "NE"`)))
+	})
+
+	It("should put items", func() {
+		stdin = `{"UserUUID":"b","Created":1,"Title":"new"}`
+		Expect(djoemoCmd("put", "-table", "PostTable", "-dry-run")).To(Succeed())
+		Expect(stderr.String()).To(Equal("dry run: item not written\n"))
+		Expect(db.Items("PostTable")).To(HaveLen(3))
+
+		Expect(djoemoCmd("put", "-table", "PostTable", "-if-not-exists")).To(Succeed())
+		Expect(djoemoCmd("get", "-table", "PostTable", "-hash-key", "b", "-range-key", "1")).To(Succeed())
+		Expect(stdout.String()).To(Equal(`{"Created":1,"Title":"new","UserUUID":"b"}` + "\n"))
+
+		Expect(djoemoCmd("put", "-table", "PostTable", "-if-not-exists")).To(MatchError(errConditionFailed))
+		Expect(djoemoCmd("put", "-table", "PostTable", "-item", `{"Title":"no key"}`)).To(MatchError(djoemo.ErrInvalidHashKeyValue))
+	})
+
+	It("should update existing items", func() {
+		Expect(djoemoCmd("update", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-set", `{"Title":"edited"}`, "-add", `{"Likes":2}`)).To(Succeed())
+		Expect(stdout.String()).To(Equal(`{"Created":1,"Likes":2,"Title":"edited","UserUUID":"a"}` + "\n"))
+
+		Expect(djoemoCmd("update", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-add", `{"Likes":1}`, "-dry-run")).To(Succeed())
+		Expect(stdout.String()).To(ContainSubstring(`"Likes":2`))
+		Expect(stderr.String()).To(Equal("dry run: item not updated\n"))
+
+		Expect(djoemoCmd("update", "-table", "PostTable", "-hash-key", "b", "-range-key", "1", "-set", `{"Title":"new"}`)).To(MatchError(djoemo.ErrNoItemFound))
+		Expect(djoemoCmd("update", "-table", "PostTable", "-hash-key", "b", "-range-key", "1", "-set", `{"Title":"new"}`, "-upsert")).To(Succeed())
+		Expect(db.Items("PostTable")).To(HaveLen(4))
+	})
+
+	It("should delete items and print them", func() {
+		Expect(djoemoCmd("delete", "-table", "PostTable", "-hash-key", "a", "-range-key", "1", "-dry-run")).To(Succeed())
+		Expect(db.Items("PostTable")).To(HaveLen(3))
+
+		Expect(djoemoCmd("delete", "-table", "PostTable", "-hash-key", "a", "-range-key", "1")).To(Succeed())
+		Expect(stdout.String()).To(ContainSubstring(`"Title":"first"`))
+		Expect(db.Items("PostTable")).To(HaveLen(2))
+		Expect(djoemoCmd("delete", "-table", "PostTable", "-hash-key", "a", "-range-key", "1")).To(MatchError(djoemo.ErrNoItemFound))
+	})
+
+	It("should reject invalid commands and flags", func() {
+		Expect(djoemoCmd("drop", "-table", "PostTable")).To(MatchError(ContainSubstring(`unknown command "drop"`)))
+		Expect(djoemoCmd("get", "-hash-key", "a")).To(MatchError("get: -table is required"))
+		Expect(djoemoCmd("scan", "-table", "PostTable", "-output", "xml")).To(MatchError(ContainSubstring(`unknown output "xml"`)))
+		Expect(djoemoCmd("get", "-table", "MissingTable", "-hash-key", "a")).To(MatchError(ContainSubstring("ResourceNotFoundException")))
+	})
+})
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/dump"
+)
+
+// Formats of printed items
+const (
+	outputJSON     = "json"
+	outputDynamoDB = "dynamodb"
+	outputTable    = "table"
+)
+
+// printer prints items; JSON Lines are printed right away, tables once all items are added
+type printer struct {
+	out    io.Writer
+	format dump.Format
+	schema djoemo.TableSchema
+	table  bool
+	items  []map[string]*dynamodb.AttributeValue
+}
+
+func newPrinter(output string, out io.Writer, schema djoemo.TableSchema) (*printer, error) {
+	switch output {
+	case outputJSON:
+		return &printer{out: out, format: dump.JSON, schema: schema}, nil
+	case outputDynamoDB:
+		return &printer{out: out, format: dump.DynamoDBJSON, schema: schema}, nil
+	case outputTable:
+		return &printer{out: out, schema: schema, table: true}, nil
+	}
+	return nil, fmt.Errorf("unknown output %q, expected %s, %s or %s", output, outputJSON, outputDynamoDB, outputTable)
+}
+
+// add prints an item
+func (p *printer) add(item map[string]*dynamodb.AttributeValue) error {
+	if p.table {
+		p.items = append(p.items, item)
+		return nil
+	}
+	line, err := p.format.Encode(item)
+	if err != nil {
+		return err
+	}
+	_, err = p.out.Write(line)
+	return err
+}
+
+// flush prints the table of the added items
+func (p *printer) flush() error {
+	if !p.table {
+		return nil
+	}
+	columns := p.columns()
+	w := tabwriter.NewWriter(p.out, 0, 4, 2, ' ', 0)
+	for i, column := range columns {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, column)
+	}
+	fmt.Fprintln(w)
+	for _, item := range p.items {
+		for i, column := range columns {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, cell(item[column]))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+// columns returns the key attributes followed by the other attributes of all items in alphabetical order
+func (p *printer) columns() []string {
+	keys := []string{p.schema.HashKey}
+	if p.schema.RangeKey != "" {
+		keys = append(keys, p.schema.RangeKey)
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		seen[key] = true
+	}
+	var others []string
+	for _, item := range p.items {
+		for name := range item {
+			if !seen[name] {
+				seen[name] = true
+				others = append(others, name)
+			}
+		}
+	}
+	sort.Strings(others)
+	return append(keys, others...)
+}
+
+// cell formats a value for a table; strings are printed unquoted, missing values as -
+func cell(value *dynamodb.AttributeValue) string {
+	switch {
+	case value == nil:
+		return "-"
+	case value.S != nil:
+		return *value.S
+	case value.N != nil:
+		return *value.N
+	}
+	line, err := dump.JSON.Encode(map[string]*dynamodb.AttributeValue{"v": value})
+	if err != nil {
+		return "?"
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(line, &wrapped); err != nil {
+		return "?"
+	}
+	return string(wrapped["v"])
+}
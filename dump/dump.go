@@ -0,0 +1,225 @@
+// Package dump exports tables and query results to JSON Lines and imports them again, to seed local environments
+// and to snapshot test data:
+//
+//	count, err := dump.Export(ctx, client, "UserTable", file, dump.ExportOptions{Format: dump.DynamoDBJSON})
+//	count, err = dump.Import(ctx, localClient, "UserTable", file, dump.ImportOptions{Format: dump.DynamoDBJSON})
+//
+// Every line holds one item, in plain JSON or in DynamoDB JSON, see Format.
+package dump
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// MaxChunkSize is the maximum number of items DynamoDB writes in one batch
+const MaxChunkSize = 25
+
+// maxLineSize is the size of the longest line Import reads; items are at most 400 KB, but grow when encoded
+const maxLineSize = 4 << 20
+
+// ExportOptions configure exports
+type ExportOptions struct {
+	// Format of the items; defaults to JSON
+	Format Format
+	// IndexName scans or queries a global or local index instead of the table
+	IndexName string
+	// Limit is the maximum number of exported items; zero exports all
+	Limit int64
+	// PageSize limits the number of items read per request
+	PageSize int64
+}
+
+// ImportOptions configure imports
+type ImportOptions struct {
+	// Format of the items; defaults to JSON
+	Format Format
+	// ChunkSize is the number of items written per batch; defaults to and is limited by MaxChunkSize
+	ChunkSize int
+	// KeyMapping renames attributes of the imported items from the keys to the values of the map, like the keys of
+	// items imported into a table with other key names
+	KeyMapping map[string]string
+}
+
+// iter is implemented by the scan and query iterators of dynamo
+type iter interface {
+	NextWithContext(ctx context.Context, out any) bool
+	Err() error
+}
+
+// Export writes all items of a table as JSON Lines to w and returns the number of written items
+func Export(ctx context.Context, client dynamodbiface.DynamoDBAPI, tableName string, w io.Writer, options ExportOptions) (int, error) {
+	scan := dynamo.NewFromIface(client).Table(tableName).Scan()
+	if options.IndexName != "" {
+		scan = scan.Index(options.IndexName)
+	}
+	if options.Limit > 0 {
+		scan = scan.Limit(options.Limit)
+	}
+	if options.PageSize > 0 {
+		scan = scan.SearchLimit(options.PageSize)
+	}
+	return export(ctx, scan.Iter(), w, options)
+}
+
+// ExportQuery writes the items matching query as JSON Lines to w and returns the number of written items; the
+// limit of query is ignored, see ExportOptions.Limit
+func ExportQuery(ctx context.Context, client dynamodbiface.DynamoDBAPI, query djoemo.QueryInterface, w io.Writer, options ExportOptions) (int, error) {
+	if query.TableName() == "" {
+		return 0, djoemo.ErrInvalidTableName
+	}
+	if query.HashKeyName() == nil || *query.HashKeyName() == "" {
+		return 0, djoemo.ErrInvalidHashKeyName
+	}
+
+	q := dynamo.NewFromIface(client).Table(query.TableName()).Get(*query.HashKeyName(), query.HashKey())
+	if query.RangeKeyName() != nil && query.RangeKey() != nil {
+		rangeOp := query.RangeOp()
+		if rangeOp == "" {
+			rangeOp = djoemo.Equal
+		}
+		q = q.Range(*query.RangeKeyName(), dynamo.Operator(rangeOp), djoemo.RangeValues(query)...)
+	}
+	if query.Descending() {
+		q = q.Order(dynamo.Descending)
+	}
+	if options.IndexName != "" {
+		q = q.Index(options.IndexName)
+	}
+	if options.Limit > 0 {
+		q = q.Limit(options.Limit)
+	}
+	if options.PageSize > 0 {
+		q = q.SearchLimit(options.PageSize)
+	}
+	return export(ctx, q.Iter(), w, options)
+}
+
+func export(ctx context.Context, items iter, w io.Writer, options ExportOptions) (int, error) {
+	format := options.Format
+	if format == "" {
+		format = JSON
+	}
+	if err := format.valid(); err != nil {
+		return 0, err
+	}
+
+	out := bufio.NewWriter(w)
+	count := 0
+	for item := map[string]*dynamodb.AttributeValue{}; items.NextWithContext(ctx, &item); item = nil {
+		line, err := format.Encode(item)
+		if err != nil {
+			return count, err
+		}
+		if _, err := out.Write(line); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := items.Err(); err != nil {
+		return count, err
+	}
+	return count, out.Flush()
+}
+
+// Import writes the items of JSON Lines read from r to the table tableName in batches and returns the number of
+// written items; existing items with the same key are replaced. Empty lines are skipped.
+func Import(ctx context.Context, client dynamodbiface.DynamoDBAPI, tableName string, r io.Reader, options ImportOptions) (int, error) {
+	format := options.Format
+	if format == "" {
+		format = JSON
+	}
+	if err := format.valid(); err != nil {
+		return 0, err
+	}
+	chunkSize := options.ChunkSize
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		chunkSize = MaxChunkSize
+	}
+
+	repository := djoemo.NewRepository(client)
+	schema, err := repository.DescribeTable(ctx, tableName)
+	if err != nil {
+		return 0, err
+	}
+	// the key only names table and keys, the values are taken from the items
+	key := djoemo.Key().WithTableName(tableName).WithHashKeyName(schema.HashKey).WithHashKey("")
+	if schema.RangeKey != "" {
+		key = key.WithRangeKeyName(schema.RangeKey)
+	}
+
+	count := 0
+	chunk := make([]map[string]*dynamodb.AttributeValue, 0, chunkSize)
+	write := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := repository.SaveItemsWithContext(ctx, key, chunk); err != nil {
+			return err
+		}
+		count += len(chunk)
+		chunk = chunk[:0]
+		return nil
+	}
+
+	lines := bufio.NewScanner(r)
+	lines.Buffer(make([]byte, 0, 64<<10), maxLineSize)
+	for number := 1; lines.Scan(); number++ {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		item, err := format.Decode(lines.Bytes())
+		if err != nil {
+			return count, fmt.Errorf("dump: line %d: %w", number, err)
+		}
+		item = remap(item, options.KeyMapping)
+		if err := hasKey(item, schema); err != nil {
+			return count, fmt.Errorf("dump: line %d: %w", number, err)
+		}
+
+		chunk = append(chunk, item)
+		if len(chunk) == chunkSize {
+			if err := write(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := lines.Err(); err != nil {
+		return count, err
+	}
+	return count, write()
+}
+
+// remap renames the attributes of item by mapping
+func remap(item map[string]*dynamodb.AttributeValue, mapping map[string]string) map[string]*dynamodb.AttributeValue {
+	if len(mapping) == 0 {
+		return item
+	}
+	remapped := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, value := range item {
+		if to, ok := mapping[name]; ok {
+			name = to
+		}
+		remapped[name] = value
+	}
+	return remapped
+}
+
+// hasKey checks that item has the key attributes of schema
+func hasKey(item map[string]*dynamodb.AttributeValue, schema djoemo.TableSchema) error {
+	if item[schema.HashKey] == nil {
+		return fmt.Errorf("%w: item has no hash key %s", djoemo.ErrInvalidHashKeyValue, schema.HashKey)
+	}
+	if schema.RangeKey != "" && item[schema.RangeKey] == nil {
+		return fmt.Errorf("%w: item has no range key %s", djoemo.ErrInvalidRangeKeyValue, schema.RangeKey)
+	}
+	return nil
+}
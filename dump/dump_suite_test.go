@@ -0,0 +1,13 @@
+package dump_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestDump(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dump Suite")
+}
@@ -0,0 +1,193 @@
+package dump_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/dump"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	UUID     string
+	UserName string
+	Age      int
+	Tags     []string `dynamo:",set"`
+}
+
+// Post model with hash and range key
+type Post struct {
+	UserUUID string
+	Created  int
+	Title    string
+}
+
+// batchCounter counts batch writes
+type batchCounter struct {
+	*fake.DB
+	batches []int
+}
+
+func (c *batchCounter) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	for _, requests := range input.RequestItems {
+		c.batches = append(c.batches, len(requests))
+	}
+	return c.DB.BatchWriteItemWithContext(ctx, input, opts...)
+}
+
+var _ = Describe("Dump", func() {
+	const (
+		UserTableName = "UserTable"
+		PostTableName = "PostTable"
+	)
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New().
+			WithTable(UserTableName, "UUID", "").
+			WithTable(PostTableName, "UserUUID", "Created")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	savePosts := func(userUUID string, count int) {
+		for i := 1; i <= count; i++ {
+			key := djoemo.Key().WithTableName(PostTableName).
+				WithHashKeyName("UserUUID").WithHashKey(userUUID).
+				WithRangeKeyName("Created").WithRangeKey(i)
+			Expect(repository.SaveItemWithContext(ctx, key, Post{UserUUID: userUUID, Created: i, Title: fmt.Sprint("post ", i)})).To(Succeed())
+		}
+	}
+
+	It("should export items as plain JSON", func() {
+		Expect(repository.SaveItemWithContext(ctx, userKey("a"), User{UUID: "a", UserName: "alice", Age: 42, Tags: []string{"admin"}})).To(Succeed())
+
+		out := &bytes.Buffer{}
+		count, err := dump.Export(ctx, db, UserTableName, out, dump.ExportOptions{})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(1))
+		Expect(out.String()).To(Equal(`{"Age":42,"Tags":["admin"],"UUID":"a","UserName":"alice"}` + "\n"))
+	})
+
+	It("should export and import items in DynamoDB JSON unchanged", func() {
+		item := map[string]*dynamodb.AttributeValue{
+			"UUID":    {S: aws.String("a")},
+			"Age":     {N: aws.String("42")},
+			"Avatar":  {B: []byte{1, 2}},
+			"Active":  {BOOL: aws.Bool(true)},
+			"Deleted": {NULL: aws.Bool(true)},
+			"Tags":    {SS: aws.StringSlice([]string{"admin", "beta"})},
+			"Scores":  {NS: aws.StringSlice([]string{"1", "2.5"})},
+			"Meta":    {M: map[string]*dynamodb.AttributeValue{"Empty": {M: map[string]*dynamodb.AttributeValue{}}}},
+			"History": {L: []*dynamodb.AttributeValue{{S: aws.String("x")}, {L: []*dynamodb.AttributeValue{}}}},
+		}
+		_, err := db.PutItem(&dynamodb.PutItemInput{TableName: aws.String(UserTableName), Item: item})
+		Expect(err).To(BeNil())
+
+		out := &bytes.Buffer{}
+		_, err = dump.Export(ctx, db, UserTableName, out, dump.ExportOptions{Format: dump.DynamoDBJSON})
+		Expect(err).To(BeNil())
+		Expect(out.String()).To(ContainSubstring(`"Meta":{"M":{"Empty":{"M":{}}}}`))
+		Expect(out.String()).To(ContainSubstring(`"Avatar":{"B":"AQI="}`))
+
+		local := fake.New().WithTable(UserTableName, "UUID", "")
+		count, err := dump.Import(ctx, local, UserTableName, out, dump.ImportOptions{Format: dump.DynamoDBJSON})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(1))
+		Expect(local.Items(UserTableName)).To(Equal(db.Items(UserTableName)))
+	})
+
+	It("should export query results", func() {
+		savePosts("a", 5)
+		savePosts("b", 2)
+
+		query := djoemo.Query().WithTableName(PostTableName).
+			WithHashKeyName("UserUUID").WithHashKey("a").
+			WithRangeKeyName("Created").WithRangeKey(2).WithRangeOp(djoemo.Greater).
+			WithDescending()
+		out := &bytes.Buffer{}
+		count, err := dump.ExportQuery(ctx, db, query, out, dump.ExportOptions{Limit: 2})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(2))
+		Expect(out.String()).To(Equal("" +
+			`{"Created":5,"Title":"post 5","UserUUID":"a"}` + "\n" +
+			`{"Created":4,"Title":"post 4","UserUUID":"a"}` + "\n"))
+	})
+
+	It("should export query results between bounds", func() {
+		savePosts("a", 5)
+
+		query := djoemo.Query().WithTableName(PostTableName).
+			WithHashKeyName("UserUUID").WithHashKey("a").
+			WithRangeKeyName("Created").WithRangeKey([]int{2, 4}).WithRangeOp(djoemo.Between)
+		out := &bytes.Buffer{}
+		count, err := dump.ExportQuery(ctx, db, query, out, dump.ExportOptions{})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(3))
+		Expect(strings.Split(strings.TrimSpace(out.String()), "\n")).To(Equal([]string{
+			`{"Created":2,"Title":"post 2","UserUUID":"a"}`,
+			`{"Created":3,"Title":"post 3","UserUUID":"a"}`,
+			`{"Created":4,"Title":"post 4","UserUUID":"a"}`,
+		}))
+	})
+
+	It("should import items in chunks with remapped keys and another table name", func() {
+		lines := &strings.Builder{}
+		for i := 0; i < 30; i++ {
+			fmt.Fprintf(lines, `{"ID":"user-%02d","UserName":"user %d","Tags":["a","b"]}`+"\n\n", i, i)
+		}
+		client := &batchCounter{DB: fake.New().WithTable("LocalUserTable", "UUID", "")}
+
+		count, err := dump.Import(ctx, client, "LocalUserTable", strings.NewReader(lines.String()), dump.ImportOptions{
+			ChunkSize:  10,
+			KeyMapping: map[string]string{"ID": "UUID"},
+		})
+		Expect(err).To(BeNil())
+		Expect(count).To(Equal(30))
+		Expect(client.batches).To(Equal([]int{10, 10, 10}))
+
+		user := map[string]*dynamodb.AttributeValue{}
+		found, err := djoemo.NewRepository(client).GetItemWithContext(ctx,
+			djoemo.Key().WithTableName("LocalUserTable").WithHashKeyName("UUID").WithHashKey("user-07"), &user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(user["UserName"].S).To(Equal(aws.String("user 7")))
+		Expect(user["Tags"].L).To(HaveLen(2), "plain JSON imports sets as lists")
+	})
+
+	It("should fail imports of invalid lines with their line number", func() {
+		_, err := dump.Import(ctx, db, UserTableName, strings.NewReader(`{"UUID":"a"}`+"\n"+`{"UserName":"b"}`), dump.ImportOptions{})
+		Expect(err).To(MatchError(djoemo.ErrInvalidHashKeyValue))
+		Expect(err.Error()).To(ContainSubstring("line 2: "))
+		Expect(db.Items(UserTableName)).To(HaveLen(0), "the items of an incomplete chunk are not written")
+
+		_, err = dump.Import(ctx, db, PostTableName, strings.NewReader(`{"UserUUID":"a"}`), dump.ImportOptions{})
+		Expect(err).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+
+		_, err = dump.Import(ctx, db, UserTableName, strings.NewReader(`{"UUID":{"S":"a","N":"1"}}`), dump.ImportOptions{Format: dump.DynamoDBJSON})
+		Expect(err).To(MatchError(ContainSubstring("line 1: attribute value needs exactly one type, got [N S]")))
+
+		_, err = dump.Export(ctx, db, UserTableName, &bytes.Buffer{}, dump.ExportOptions{Format: "csv"})
+		Expect(err).To(MatchError(ContainSubstring(`unknown format "csv"`)))
+	})
+})
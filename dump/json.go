@@ -0,0 +1,262 @@
+package dump
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Format of dumped items
+type Format string
+
+const (
+	// JSON writes items as plain JSON objects like {"UUID":"a","Count":1}; it is easy to edit, but types DynamoDB
+	// distinguishes are lost: sets are imported as lists and binary values as base64 strings
+	JSON Format = "json"
+	// DynamoDBJSON writes items as typed attribute values like {"UUID":{"S":"a"},"Count":{"N":"1"}}, the format of
+	// the DynamoDB API and the AWS CLI; items are imported unchanged
+	DynamoDBJSON Format = "dynamodb"
+)
+
+func (f Format) valid() error {
+	if f != JSON && f != DynamoDBJSON {
+		return fmt.Errorf("dump: unknown format %q, expected %q or %q", f, JSON, DynamoDBJSON)
+	}
+	return nil
+}
+
+// Encode returns an item as a JSON line in format
+func (f Format) Encode(item map[string]*dynamodb.AttributeValue) ([]byte, error) {
+	if err := f.valid(); err != nil {
+		return nil, err
+	}
+	var out any
+	if f == JSON {
+		out = plainItem(item)
+	} else {
+		out = typedItem(item)
+	}
+	line, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// Decode returns the item of a JSON line in format
+func (f Format) Decode(line []byte) (map[string]*dynamodb.AttributeValue, error) {
+	if err := f.valid(); err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+	if f == DynamoDBJSON {
+		item := map[string]typedValue{}
+		if err := decoder.Decode(&item); err != nil {
+			return nil, err
+		}
+		return typedToItem(item), nil
+	}
+
+	item := map[string]any{}
+	if err := decoder.Decode(&item); err != nil {
+		return nil, err
+	}
+	values := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, value := range item {
+		values[name] = plainToValue(value)
+	}
+	return values, nil
+}
+
+// plainItem converts an item to plain JSON values
+func plainItem(item map[string]*dynamodb.AttributeValue) map[string]any {
+	out := make(map[string]any, len(item))
+	for name, value := range item {
+		out[name] = plainValue(value)
+	}
+	return out
+}
+
+func plainValue(value *dynamodb.AttributeValue) any {
+	switch {
+	case value == nil || value.NULL != nil:
+		return nil
+	case value.S != nil:
+		return *value.S
+	case value.N != nil:
+		return json.Number(*value.N)
+	case value.B != nil:
+		return value.B
+	case value.BOOL != nil:
+		return *value.BOOL
+	case value.SS != nil:
+		return aws.StringValueSlice(value.SS)
+	case value.NS != nil:
+		numbers := make([]json.Number, len(value.NS))
+		for i, n := range value.NS {
+			numbers[i] = json.Number(aws.StringValue(n))
+		}
+		return numbers
+	case value.BS != nil:
+		return value.BS
+	case value.M != nil:
+		return plainItem(value.M)
+	}
+	list := make([]any, len(value.L))
+	for i, element := range value.L {
+		list[i] = plainValue(element)
+	}
+	return list
+}
+
+// plainToValue converts a plain JSON value decoded with UseNumber to an attribute value
+func plainToValue(value any) *dynamodb.AttributeValue {
+	switch value := value.(type) {
+	case string:
+		return &dynamodb.AttributeValue{S: aws.String(value)}
+	case json.Number:
+		return &dynamodb.AttributeValue{N: aws.String(value.String())}
+	case bool:
+		return &dynamodb.AttributeValue{BOOL: aws.Bool(value)}
+	case map[string]any:
+		m := make(map[string]*dynamodb.AttributeValue, len(value))
+		for name, element := range value {
+			m[name] = plainToValue(element)
+		}
+		return &dynamodb.AttributeValue{M: m}
+	case []any:
+		list := make([]*dynamodb.AttributeValue, len(value))
+		for i, element := range value {
+			list[i] = plainToValue(element)
+		}
+		return &dynamodb.AttributeValue{L: list}
+	}
+	return &dynamodb.AttributeValue{NULL: aws.Bool(true)}
+}
+
+// typedValue is an attribute value in DynamoDB JSON; unlike dynamodb.AttributeValue it omits unset types
+type typedValue struct {
+	S    *string               `json:"S,omitempty"`
+	N    *string               `json:"N,omitempty"`
+	B    []byte                `json:"B,omitempty"`
+	BOOL *bool                 `json:"BOOL,omitempty"`
+	NULL *bool                 `json:"NULL,omitempty"`
+	SS   []string              `json:"SS,omitempty"`
+	NS   []string              `json:"NS,omitempty"`
+	BS   [][]byte              `json:"BS,omitempty"`
+	M    map[string]typedValue `json:"M,omitempty"`
+	L    []typedValue          `json:"L,omitempty"`
+	// empty marks empty maps and lists to encode, which omitempty would drop
+	empty string
+}
+
+func (v typedValue) MarshalJSON() ([]byte, error) {
+	switch v.empty {
+	case "M":
+		return []byte(`{"M":{}}`), nil
+	case "L":
+		return []byte(`{"L":[]}`), nil
+	}
+	type plain typedValue
+	return json.Marshal(plain(v))
+}
+
+func (v *typedValue) UnmarshalJSON(data []byte) error {
+	type plain typedValue
+	if err := json.Unmarshal(data, (*plain)(v)); err != nil {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) != 1 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("attribute value needs exactly one type, got %v", names)
+	}
+	return nil
+}
+
+func typedItem(item map[string]*dynamodb.AttributeValue) map[string]typedValue {
+	out := make(map[string]typedValue, len(item))
+	for name, value := range item {
+		out[name] = toTyped(value)
+	}
+	return out
+}
+
+func toTyped(value *dynamodb.AttributeValue) typedValue {
+	if value == nil {
+		return typedValue{NULL: aws.Bool(true)}
+	}
+	typed := typedValue{
+		S:    value.S,
+		N:    value.N,
+		B:    value.B,
+		BOOL: value.BOOL,
+		NULL: value.NULL,
+		SS:   aws.StringValueSlice(value.SS),
+		NS:   aws.StringValueSlice(value.NS),
+		BS:   value.BS,
+	}
+	switch {
+	case value.M != nil && len(value.M) == 0:
+		typed.empty = "M"
+	case value.M != nil:
+		typed.M = typedItem(value.M)
+	case value.L != nil && len(value.L) == 0:
+		typed.empty = "L"
+	case value.L != nil:
+		typed.L = make([]typedValue, len(value.L))
+		for i, element := range value.L {
+			typed.L[i] = toTyped(element)
+		}
+	}
+	return typed
+}
+
+func typedToItem(item map[string]typedValue) map[string]*dynamodb.AttributeValue {
+	values := make(map[string]*dynamodb.AttributeValue, len(item))
+	for name, value := range item {
+		values[name] = fromTyped(value)
+	}
+	return values
+}
+
+func fromTyped(typed typedValue) *dynamodb.AttributeValue {
+	value := &dynamodb.AttributeValue{
+		S:    typed.S,
+		N:    typed.N,
+		B:    typed.B,
+		BOOL: typed.BOOL,
+		NULL: typed.NULL,
+		SS:   aws.StringSlice(typed.SS),
+		NS:   aws.StringSlice(typed.NS),
+		BS:   typed.BS,
+	}
+	if typed.SS == nil {
+		value.SS = nil
+	}
+	if typed.NS == nil {
+		value.NS = nil
+	}
+	switch {
+	case typed.M != nil:
+		value.M = typedToItem(typed.M)
+	case typed.L != nil:
+		value.L = make([]*dynamodb.AttributeValue, len(typed.L))
+		for i, element := range typed.L {
+			value.L[i] = fromTyped(element)
+		}
+	}
+	return value
+}
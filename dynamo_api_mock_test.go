@@ -0,0 +1,161 @@
+package djoemo_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DynamoMock", func() {
+	const (
+		UserTableName = "UserTable"
+	)
+
+	var (
+		dAPIMock    *mock.MockDynamoDBAPI
+		dMock       mock.DynamoMock
+		repository  djoemo.RepositoryInterface
+		metricsMock *mock.MockMetricsInterface
+	)
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		metricsMock = mock.NewMockMetricsInterface(mockCtrl)
+		dMock = mock.NewDynamoMock(dAPIMock)
+		repository = djoemo.NewRepository(dAPIMock)
+		repository.WithMetrics(metricsMock)
+		repository.WithLog(mock.NewMockLogInterface(mockCtrl))
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey(uuid)
+	}
+
+	Describe("Update", func() {
+		It("should match update actions regardless of placeholders and order", func() {
+			dMock.Should().Update(
+				dMock.WithMatch(
+					mock.InputExpect().
+						TableEq(UserTableName).
+						KeyEq("UUID", "uuid").
+						UpdateEq("SET UserName = ?, TraceID = ?", "name", "trace").
+						SetEq("TraceID", "trace"),
+				),
+			).Exec()
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpUpdate, gomock.Any(), gomock.Any(), true)
+
+			updates := map[string]interface{}{
+				"TraceID":  "trace",
+				"UserName": "name",
+			}
+			Expect(repository.UpdateWithContext(context.Background(), djoemo.Set, userKey("uuid"), updates)).To(Succeed())
+		})
+
+		It("should match added values of reserved attribute names", func() {
+			dMock.Should().Update(
+				dMock.WithMatch(mock.InputExpect().AddEq("Count", 1)),
+			).Exec()
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpUpdate, gomock.Any(), gomock.Any(), true)
+
+			updates := map[string]interface{}{"Count": 1}
+			Expect(repository.UpdateWithContext(context.Background(), djoemo.Add, userKey("uuid"), updates)).To(Succeed())
+		})
+
+		It("should not match other updates", func() {
+			input := &dynamodb.UpdateItemInput{
+				TableName:                aws.String(UserTableName),
+				UpdateExpression:         aws.String("SET #n = :name REMOVE Token ADD Visits :one"),
+				ConditionExpression:      aws.String("attribute_exists(UUID) AND Version = :one"),
+				ExpressionAttributeNames: map[string]*string{"#n": aws.String("UserName")},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":name": {S: aws.String("name")},
+					":one":  {N: aws.String("1")},
+				},
+				ReturnValues: aws.String(dynamodb.ReturnValueAllNew),
+			}
+
+			Expect(mock.InputExpect().
+				UpdateEq("ADD Visits ? SET UserName = ? REMOVE Token", 1, "name").
+				RemoveEq("Token").
+				ConditionEq("(Version = ?) AND attribute_exists(UUID)", 1).
+				ReturnValuesEq(dynamodb.ReturnValueAllNew).
+				Matches(input)).To(BeTrue())
+			Expect(mock.InputExpect().SetEq("UserName", "other").Matches(input)).To(BeFalse())
+			Expect(mock.InputExpect().UpdateEq("SET UserName = ?", "name").Matches(input)).To(BeFalse())
+			Expect(mock.InputExpect().ConditionEq("Version = ?", 1).Matches(input)).To(BeFalse())
+			Expect(mock.InputExpect().ReturnValuesEq(dynamodb.ReturnValueNone).Matches(input)).To(BeFalse())
+		})
+	})
+
+	Describe("ConditionalUpdate", func() {
+		It("should match the condition of the put", func() {
+			dMock.Should().Save(
+				dMock.WithMatch(
+					mock.InputExpect().
+						FieldEq("UserName", "name").
+						ConditionEq("UserName = ?", "old"),
+				),
+			).Exec()
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpUpdate, gomock.Any(), gomock.Any(), true)
+
+			updated, err := repository.ConditionalUpdateWithContext(context.Background(), userKey("uuid"),
+				map[string]interface{}{"UserName": "name"}, "UserName = ?", "old")
+			Expect(err).To(BeNil())
+			Expect(updated).To(BeTrue())
+		})
+	})
+
+	Describe("TransactWrite", func() {
+		It("should match the items of the transaction in order", func() {
+			ctx, uow := djoemo.NewUnitOfWork(context.Background())
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid1"), &User{UUID: "uuid1", UserName: "name"})).To(Succeed())
+			Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid2"), map[string]interface{}{"UserName": "name"})).To(Succeed())
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid3"))).To(Succeed())
+
+			dMock.Should().TransactWrite(
+				dMock.WithMatch(
+					mock.InputExpect().TransactItemsEq(
+						mock.InputExpect().FieldEq("UserName", "name"),
+						mock.InputExpect().KeyEq("UUID", "uuid2").SetEq("UserName", "name"),
+						mock.InputExpect().TableEq(UserTableName).KeyEq("UUID", "uuid3"),
+					),
+				),
+			).Exec()
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpCommit, gomock.Any(), gomock.Any(), true).Times(3)
+
+			Expect(uow.Commit(ctx)).To(Succeed())
+		})
+	})
+
+	Describe("ScanAll", func() {
+		It("should match the filter of the scan", func() {
+			dMock.Should().ScanAll(
+				dMock.WithTable(UserTableName),
+				dMock.WithFilter("Status = ? AND Visits > ?", "active", 2),
+				dMock.WithScanAllOutput([]map[string]interface{}{{"UUID": "uuid"}}),
+			).Exec()
+
+			output, err := dAPIMock.ScanWithContext(context.Background(), &dynamodb.ScanInput{
+				TableName:                aws.String(UserTableName),
+				FilterExpression:         aws.String("Visits > :a AND #b = :b"),
+				ExpressionAttributeNames: map[string]*string{"#b": aws.String("Status")},
+				ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+					":a": {N: aws.String("2")},
+					":b": {S: aws.String("active")},
+				},
+			})
+			Expect(err).To(BeNil())
+			Expect(output.Items).To(HaveLen(1))
+		})
+	})
+})
@@ -3,19 +3,24 @@ package djoemo
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"reflect"
 	"time"
 
 	"github.com/guregu/dynamo"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // GlobalIndex models a global secondary index used in a query
 type GlobalIndex struct {
 	name         string
 	dynamoClient *dynamo.DB
+	client       *apiClient
 	log          LogInterface
 	metrics      *Metrics
+	retryPolicy  *RetryPolicy
+	tracer       trace.Tracer
 }
 
 // WithLog enables logging; it accepts LogInterface as logger
@@ -23,14 +28,33 @@ func (gi *GlobalIndex) WithLog(log LogInterface) {
 	gi.log = log
 }
 
+// WithSlogHandler enables logging to handler
+func (gi *GlobalIndex) WithSlogHandler(handler slog.Handler) {
+	gi.WithLog(NewSlogLog(slog.New(handler)))
+}
+
 // WithMetrics enables metrics; it accepts MetricsInterface as metrics publisher
 func (gi *GlobalIndex) WithMetrics(metricsInterface MetricsInterface) {
 	gi.metrics.Add(metricsInterface)
 }
 
-// WithPrometheusMetrics enables prometheus metrics
+// WithRetryPolicy sets the policy used to retry failed operations; it can be overridden per operation with WithOperationRetryPolicy
+func (gi *GlobalIndex) WithRetryPolicy(policy RetryPolicy) {
+	gi.retryPolicy = &policy
+}
+
+// WithTracerProvider sets the provider of the tracer creating a span per operation
+func (gi *GlobalIndex) WithTracerProvider(provider trace.TracerProvider) {
+	gi.tracer = provider.Tracer(instrumentationName)
+}
+
+// WithPrometheusMetrics enables prometheus metrics; an error registering them at registry is logged
 func (gi *GlobalIndex) WithPrometheusMetrics(registry *prometheus.Registry) GlobalIndexInterface {
-	prommetrics := NewPrometheusMetrics(registry)
+	prommetrics, err := NewPrometheusMetrics(registry)
+	if err != nil {
+		gi.log.WithField("Error", err.Error()).Error("prometheus metrics not registered")
+		return gi
+	}
 	gi.metrics.Add(prommetrics)
 	return gi
 }
@@ -38,35 +62,51 @@ func (gi *GlobalIndex) WithPrometheusMetrics(registry *prometheus.Registry) Glob
 // GetItemWithContext item; it needs a key interface that is used to get the table name, hash key, and the range key if it exists; output will be contained in item; context is optional param, which used to enable log with context
 func (gi GlobalIndex) GetItemWithContext(ctx context.Context, key KeyInterface, item any) (bool, error) {
 	var err error
+	ctx, span := gi.startSpan(ctx, "GetItem", key)
+	defer span.end(&err)
 	defer gi.recordMetrics(ctx, OpRead, key, &err)()
 
-	if err = isValidKey(key); err != nil {
+	if err = isValidIndexKey(key, gi.name); err != nil {
 		return false, err
 	}
 
-	err = buildTableKeyCondition(gi.table(key.TableName()), key).Index(gi.name).OneWithContext(ctx, item)
+	err = gi.retry(ctx, OpRead, key, func(ctx context.Context) error {
+		return buildTableKeyCondition(gi.table(key.TableName()), key).Index(gi.name).OneWithContext(ctx, item)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			gi.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(ErrNoItemFound.Error())
+			span.setItemCount(0)
 			return false, nil
 		}
 
 		return false, err
 	}
 
+	span.setItemCount(1)
 	return true, nil
 }
 
 // GetItemsWithContext queries multiple items by key (hash key) and returns it in the slice of items items
 func (gi GlobalIndex) GetItemsWithContext(ctx context.Context, key KeyInterface, items any) (bool, error) {
 	var err error
+	ctx, span := gi.startSpan(ctx, "GetItems", key)
+	defer span.end(&err)
 	defer gi.recordMetrics(ctx, OpRead, key, &err)()
+	ctx, recordItems := gi.recordItems(ctx, OpRead, key, items, &err)
+	defer recordItems()
 
-	if err = isValidKey(key); err != nil {
+	if err = isValidIndexKey(key, gi.name); err != nil {
 		return false, err
 	}
 
-	err = gi.table(key.TableName()).Get(*key.HashKeyName(), key.HashKey()).Index(gi.name).AllWithContext(ctx, items)
+	span.countItems(items)
+	n := sliceLen(items)
+	err = gi.retry(ctx, OpRead, key, func(ctx context.Context) error {
+		truncateSlice(items, n)
+		payloadSizeFromContext(ctx).reset()
+		return gi.table(key.TableName()).Get(*key.HashKeyName(), key.HashKey()).Index(gi.name).AllWithContext(ctx, items)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			gi.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(ErrNoItemFound.Error())
@@ -93,13 +133,23 @@ func (gi GlobalIndex) GetItemsWithContext(ctx context.Context, key KeyInterface,
 // GetItemsWithRangeWithContext queries multiple items by key (hash key) and returns it in the slice of items respecting the range key
 func (gi GlobalIndex) GetItemsWithRangeWithContext(ctx context.Context, key KeyInterface, items any) (bool, error) {
 	var err error
+	ctx, span := gi.startSpan(ctx, "GetItemsWithRange", key)
+	defer span.end(&err)
 	defer gi.recordMetrics(ctx, OpRead, key, &err)()
+	ctx, recordItems := gi.recordItems(ctx, OpRead, key, items, &err)
+	defer recordItems()
 
-	if err = isValidKey(key); err != nil {
+	if err = isValidIndexKey(key, gi.name); err != nil {
 		return false, err
 	}
 
-	err = buildTableKeyCondition(gi.table(key.TableName()), key).Index(gi.name).AllWithContext(ctx, items)
+	span.countItems(items)
+	n := sliceLen(items)
+	err = gi.retry(ctx, OpRead, key, func(ctx context.Context) error {
+		truncateSlice(items, n)
+		payloadSizeFromContext(ctx).reset()
+		return buildTableKeyCondition(gi.table(key.TableName()), key).Index(gi.name).AllWithContext(ctx, items)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			gi.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(ErrNoItemFound.Error())
@@ -128,15 +178,20 @@ func (gi GlobalIndex) table(tableName string) dynamo.Table {
 }
 
 // QueryWithContext by query; it accepts a query interface that is used to get the table name, hash key and range key with its operator if it exists;
-// context which used to enable log with context, the output will be given in items
+// context which used to enable log with context, the output will be given in items, a pointer to a slice or
+// the Polymorphic of a TypeRegistry decoding items by their discriminator
 // returns error in case of error
 func (gi GlobalIndex) QueryWithContext(ctx context.Context, query QueryInterface, item any) (err error) {
+	ctx, span := gi.startSpan(ctx, "Query", query)
+	defer span.end(&err)
 	defer gi.recordMetrics(ctx, OpRead, query, &err)()
+	ctx, recordItems := gi.recordItems(ctx, OpRead, query, item, &err)
+	defer recordItems()
 
-	if !IsPointerOFSlice(item) {
-		return ErrInvalidPointerSliceType
+	if err = validateOutput(item); err != nil {
+		return err
 	}
-	if err = isValidKey(query); err != nil {
+	if err = isValidIndexKey(query, gi.name); err != nil {
 		return err
 	}
 
@@ -144,7 +199,7 @@ func (gi GlobalIndex) QueryWithContext(ctx context.Context, query QueryInterface
 
 	// by range
 	if query.RangeKeyName() != nil && query.RangeKey() != nil {
-		q = q.Range(*query.RangeKeyName(), dynamo.Operator(query.RangeOp()), query.RangeKey())
+		q = q.Range(*query.RangeKeyName(), dynamo.Operator(query.RangeOp()), RangeValues(query)...)
 	}
 
 	if limit := valueFromPtr(query.Limit()); limit > 0 {
@@ -155,7 +210,13 @@ func (gi GlobalIndex) QueryWithContext(ctx context.Context, query QueryInterface
 		q = q.Order(dynamo.Descending)
 	}
 
-	err = q.AllWithContext(ctx, item)
+	span.countItems(item)
+	n := sliceLen(item)
+	err = gi.retry(ctx, OpRead, query, func(ctx context.Context) error {
+		truncateSlice(item, n)
+		payloadSizeFromContext(ctx).reset()
+		return queryAll(ctx, q, item)
+	})
 	if err != nil {
 		return err
 	}
@@ -163,9 +224,22 @@ func (gi GlobalIndex) QueryWithContext(ctx context.Context, query QueryInterface
 	return nil
 }
 
+func (gi GlobalIndex) retry(ctx context.Context, op string, key KeyInterface, fn func(ctx context.Context) error) error {
+	return retry(withIndexName(ctx, gi.name), gi.retryPolicy, gi.log, gi.metrics, op, key, fn)
+}
+
+func (gi GlobalIndex) startSpan(ctx context.Context, operation string, key KeyInterface) (context.Context, *operationSpan) {
+	return startOperationSpan(ctx, gi.tracer, gi.client, operation, gi.name, key)
+}
+
 func (gi GlobalIndex) recordMetrics(ctx context.Context, op string, key KeyInterface, err *error) func() {
+	ctx = withIndexName(ctx, gi.name)
 	start := time.Now()
 	return func() {
 		gi.metrics.Record(ctx, op, key, time.Since(start), isOpSuccess(err))
 	}
 }
+
+func (gi GlobalIndex) recordItems(ctx context.Context, op string, key KeyInterface, items any, err *error) (context.Context, func()) {
+	return recordItems(withIndexName(ctx, gi.name), gi.metrics, op, key, items, err)
+}
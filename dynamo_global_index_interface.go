@@ -2,8 +2,10 @@ package djoemo
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:generate mockgen -source=dynamo_global_index_interface.go -destination=./mock/dynamo_global_index_interface.go -package=mock .
@@ -12,9 +14,17 @@ type GlobalIndexInterface interface {
 	// WithLog enables logging; it accepts LogInterface as logger
 	WithLog(log LogInterface)
 
+	// WithSlogHandler enables logging to handler
+	WithSlogHandler(handler slog.Handler)
+
 	// WithMetrics enables metrics; it accepts MetricsInterface as metrics publisher
 	WithMetrics(metricsInterface MetricsInterface)
 
+	// WithRetryPolicy sets the policy used to retry failed operations; it can be overridden per operation with WithOperationRetryPolicy
+	WithRetryPolicy(policy RetryPolicy)
+	// WithTracerProvider sets the provider of the tracer creating a span per operation
+	WithTracerProvider(provider trace.TracerProvider)
+
 	// WithPrometheusMetrics enables prometheus metrics
 	WithPrometheusMetrics(registry *prometheus.Registry) GlobalIndexInterface
 
@@ -32,7 +42,8 @@ type GlobalIndexInterface interface {
 	GetItemsWithRangeWithContext(ctx context.Context, key KeyInterface, items interface{}) (bool, error)
 
 	// QueryWithContext by query; it accepts a query interface that is used to get the table name, hash key and range key with its operator if it exists;
-	// context which used to enable log with context, the output will be given in items
+	// context which used to enable log with context, the output will be given in items, a pointer to a slice or
+	// the Polymorphic of a TypeRegistry decoding items by their discriminator
 	// returns error in case of error
 	QueryWithContext(ctx context.Context, query QueryInterface, item interface{}) error
 }
@@ -3,12 +3,14 @@ package djoemo
 import (
 	"context"
 	"errors"
+	"log/slog"
 	"reflect"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/dynamodb"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
 	"github.com/guregu/dynamo"
@@ -17,22 +19,42 @@ import (
 // Repository facade for github.com/guregu/djoemo
 type Repository struct {
 	dynamoClient *dynamo.DB
+	client       *apiClient
 	log          LogInterface
 	metrics      *Metrics
+	retryPolicy  *RetryPolicy
+	tracer       trace.Tracer
+	outboxTable  string
 }
 
 // NewRepository factory method for djoemo repository
 func NewRepository(dynamoClient dynamodbiface.DynamoDBAPI) RepositoryInterface {
+	metrics := &Metrics{}
+	client := newAPIClient(dynamoClient, metrics)
 	return &Repository{
-		dynamoClient: dynamo.NewFromIface(dynamoClient),
+		dynamoClient: dynamo.NewFromIface(client),
+		client:       client,
 		log:          NewNopLog(),
-		metrics:      &Metrics{},
+		metrics:      metrics,
+		tracer:       defaultTracer(),
 	}
 }
 
-// WithLog enables logging; it accepts LogInterface as logger
+// WithLog enables logging; it accepts LogInterface as logger. Every request to DynamoDB is logged at debug level
+// if log implements DebugLogInterface
 func (repository *Repository) WithLog(log LogInterface) {
 	repository.log = log
+	repository.client.log = log
+}
+
+// WithSlogHandler enables logging to handler
+func (repository *Repository) WithSlogHandler(handler slog.Handler) {
+	repository.WithLog(NewSlogLog(slog.New(handler)))
+}
+
+// WithLogRedactor sets the function redacting keys and attribute values of logged requests; they are logged as they are by default
+func (repository *Repository) WithLogRedactor(redactor Redactor) {
+	repository.client.redact = redactor
 }
 
 // WithMetrics enables metrics; it accepts MetricsInterface as metrics publisher
@@ -40,9 +62,42 @@ func (repository *Repository) WithMetrics(metricsInterface MetricsInterface) {
 	repository.metrics.Add(metricsInterface)
 }
 
-// WithPrometheusMetrics enables prometheus metrics
+// WithRetryPolicy sets the policy used to retry failed operations; it can be overridden per operation with WithOperationRetryPolicy.
+// Once a policy is set, it replaces the retries of the underlying client for throttling and server errors
+func (repository *Repository) WithRetryPolicy(policy RetryPolicy) {
+	repository.retryPolicy = &policy
+}
+
+// WithRateLimiter makes every request to DynamoDB wait until the limits of its table or index allow it;
+// it also applies to the index repositories created with GIndex
+func (repository *Repository) WithRateLimiter(limiter *RateLimiter) {
+	repository.client.limiter = limiter
+}
+
+// WithSlowLog keeps requests to DynamoDB that exceed the threshold of slowLog or fail, as well as operations exceeding
+// the threshold without a slow request, in it and logs them as warning; it also applies to the index repositories
+// created with GIndex
+func (repository *Repository) WithSlowLog(slowLog *SlowLog) {
+	repository.client.slowLog = slowLog
+}
+
+// WithTracerProvider sets the provider of the tracer creating a span per operation; defaults to the global provider
+func (repository *Repository) WithTracerProvider(provider trace.TracerProvider) {
+	repository.tracer = provider.Tracer(instrumentationName)
+}
+
+// WithOutboxTable sets the name of the table domain events are written to; DefaultOutboxTable by default
+func (repository *Repository) WithOutboxTable(tableName string) {
+	repository.outboxTable = tableName
+}
+
+// WithPrometheusMetrics enables prometheus metrics; an error registering them at registry is logged
 func (repository *Repository) WithPrometheusMetrics(registry *prometheus.Registry) RepositoryInterface {
-	prommetrics := NewPrometheusMetrics(registry)
+	prommetrics, err := NewPrometheusMetrics(registry)
+	if err != nil {
+		repository.log.WithField("Error", err.Error()).Error("prometheus metrics not registered")
+		return repository
+	}
 	repository.metrics.Add(prommetrics)
 	return repository
 }
@@ -51,37 +106,68 @@ func (repository *Repository) WithPrometheusMetrics(registry *prometheus.Registr
 // context which used to enable log with context; the output will be given in item
 // returns true if item is found, returns false and nil if no item found, returns false and an error in case of error
 func (repository Repository) GetItemWithContext(ctx context.Context, key KeyInterface, item interface{}) (bool, error) {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.getItem(repository, key, item, func() (bool, error) {
+			return repository.getItem(ctx, key, item)
+		})
+	}
+
+	return repository.getItem(ctx, key, item)
+}
+
+func (repository Repository) getItem(ctx context.Context, key KeyInterface, item interface{}) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "GetItem", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpRead, key, &err)()
 
 	if err = isValidKey(key); err != nil {
 		return false, err
 	}
 
-	err = buildTableKeyCondition(repository.table(key.TableName()), key).OneWithContext(ctx, item)
+	err = repository.retry(ctx, OpRead, key, func(ctx context.Context) error {
+		return buildTableKeyCondition(repository.table(key.TableName()), key).OneWithContext(ctx, item)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			repository.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(ErrNoItemFound.Error())
+			span.setItemCount(0)
 			return false, nil
 		}
 
 		return false, err
 	}
 
+	span.setItemCount(1)
 	return true, nil
 }
 
 // SaveItemWithContext it accepts a key interface, that is used to get the table name; item is the item to be saved; context which used to enable log with context
 // returns error in case of error
 func (repository Repository) SaveItemWithContext(ctx context.Context, key KeyInterface, item interface{}) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerSave(ctx, repository, key, item)
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "SaveItem", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpCommit, key, &err)()
 
 	if err = isValidKey(key); err != nil {
 		return err
 	}
 
-	err = repository.table(key.TableName()).Put(item).RunWithContext(ctx)
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpCommit, key, 1, func(tx *dynamo.WriteTx) {
+			tx.Put(repository.table(key.TableName()).Put(item))
+		})
+		return err
+	}
+
+	err = repository.retry(ctx, OpCommit, key, func(ctx context.Context) error {
+		return repository.table(key.TableName()).Put(item).RunWithContext(ctx)
+	})
 	if err != nil {
 		return err
 	}
@@ -93,7 +179,13 @@ func (repository Repository) SaveItemWithContext(ctx context.Context, key KeyInt
 // values contains the values that should be used in the update; context which used to enable log with context
 // returns error in case of error
 func (repository Repository) UpdateWithContext(ctx context.Context, expression UpdateExpression, key KeyInterface, values map[string]interface{}) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerUpdate(ctx, repository, key, UpdateExpressions{expression: values})
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "Update", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpUpdate, key, &err)()
 
 	if err = isValidKey(key); err != nil {
@@ -130,7 +222,14 @@ func (repository Repository) UpdateWithContext(ctx context.Context, expression U
 		}
 	}
 
-	err = update.RunWithContext(ctx)
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpUpdate, key, 1, func(tx *dynamo.WriteTx) {
+			tx.Update(update)
+		})
+		return err
+	}
+
+	err = repository.retry(ctx, OpUpdate, key, update.RunWithContext)
 	if err != nil {
 		return err
 	}
@@ -192,7 +291,13 @@ func (repository Repository) UpdateWithUpdateExpressions(
 	key KeyInterface,
 	updateExpressions UpdateExpressions,
 ) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerUpdate(ctx, repository, key, updateExpressions)
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "UpdateWithUpdateExpressions", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpUpdate, key, &err)()
 
 	update, err := repository.prepareUpdateWithUpdateExpressions(ctx, key, updateExpressions)
@@ -200,7 +305,14 @@ func (repository Repository) UpdateWithUpdateExpressions(
 		return err
 	}
 
-	err = update.RunWithContext(ctx)
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpUpdate, key, 1, func(tx *dynamo.WriteTx) {
+			tx.Update(update)
+		})
+		return err
+	}
+
+	err = repository.retry(ctx, OpUpdate, key, update.RunWithContext)
 	if err != nil {
 		return err
 	}
@@ -218,14 +330,22 @@ func (repository Repository) UpdateWithUpdateExpressionsAndReturnValue(
 	updateExpressions UpdateExpressions,
 ) error {
 	var err error
+	ctx, span := repository.startSpan(ctx, "UpdateWithUpdateExpressionsAndReturnValue", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpUpdate, key, &err)()
 
+	if err = hasDomainEvents(ctx); err != nil {
+		return err
+	}
+
 	update, err := repository.prepareUpdateWithUpdateExpressions(ctx, key, updateExpressions)
 	if err != nil {
 		return err
 	}
 
-	err = update.ValueWithContext(ctx, item)
+	err = repository.retry(ctx, OpUpdate, key, func(ctx context.Context) error {
+		return update.ValueWithContext(ctx, item)
+	})
 	if err != nil {
 		return err
 	}
@@ -245,8 +365,14 @@ func (repository Repository) ConditionalUpdateWithUpdateExpressionsAndReturnValu
 	conditionArgs ...interface{},
 ) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "ConditionalUpdateWithUpdateExpressionsAndReturnValue", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpUpdate, key, &err)()
 
+	if err = hasDomainEvents(ctx); err != nil {
+		return false, err
+	}
+
 	update, err := repository.prepareUpdateWithUpdateExpressions(ctx, key, updateExpressions)
 	if err != nil {
 		return false, err
@@ -254,7 +380,9 @@ func (repository Repository) ConditionalUpdateWithUpdateExpressionsAndReturnValu
 
 	update = update.If(conditionExpression, conditionArgs...)
 
-	err = update.ValueWithContext(ctx, item)
+	err = repository.retry(ctx, OpUpdate, key, func(ctx context.Context) error {
+		return update.ValueWithContext(ctx, item)
+	})
 	if err != nil {
 		if awsError, ok := err.(awserr.Error); ok && awsError.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
 			repository.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(dynamodb.ErrCodeConditionalCheckFailedException)
@@ -270,21 +398,25 @@ func (repository Repository) ConditionalUpdateWithUpdateExpressionsAndReturnValu
 // DeleteItemWithContext item by its key; it accepts key of item to be deleted; context which used to enable log with context
 // returns error in case of error
 func (repository Repository) DeleteItemWithContext(ctx context.Context, key KeyInterface) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerDelete(ctx, repository, key)
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "DeleteItem", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpDelete, key, &err)()
 
 	if err = isValidKey(key); err != nil {
 		return err
 	}
-	// by hash
-	delete := repository.table(key.TableName()).Delete(*key.HashKeyName(), key.HashKey())
-
-	// by range
-	if key.RangeKeyName() != nil && key.RangeKey() != nil {
-		delete = delete.Range(*key.RangeKeyName(), key.RangeKey())
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpDelete, key, 1, func(tx *dynamo.WriteTx) {
+			tx.Delete(deleteByKey(repository.table(key.TableName()), key))
+		})
+		return err
 	}
-
-	err = delete.RunWithContext(ctx)
+	err = repository.retry(ctx, OpDelete, key, deleteByKey(repository.table(key.TableName()), key).RunWithContext)
 	if err != nil {
 		return err
 	}
@@ -295,10 +427,18 @@ func (repository Repository) DeleteItemWithContext(ctx context.Context, key KeyI
 // SaveItemsWithContext batch save a slice of items by key; it accepts key of item to be saved; item to be saved; context which used to enable log with context
 // returns error in case of error
 func (repository Repository) SaveItemsWithContext(ctx context.Context, key KeyInterface, items interface{}) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerSaves(ctx, repository, key, items)
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "SaveItems", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpCommit, key, &err)()
+	ctx, recordItems := repository.recordItems(ctx, OpCommit, key, items, &err)
+	defer recordItems()
 
-	if err = isValidKey(key); err != nil {
+	if err = isValidTemplateKey(key); err != nil {
 		return err
 	}
 
@@ -313,8 +453,21 @@ func (repository Repository) SaveItemsWithContext(ctx context.Context, key KeyIn
 	if err != nil {
 		return err
 	}
+	span.setItemCount(len(itemSlice))
+
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpCommit, key, len(itemSlice), func(tx *dynamo.WriteTx) {
+			for _, item := range itemSlice {
+				tx.Put(repository.table(key.TableName()).Put(item))
+			}
+		})
+		return err
+	}
 
-	_, err = batch.Write().Put(itemSlice...).RunWithContext(ctx)
+	err = repository.retry(ctx, OpCommit, key, func(ctx context.Context) error {
+		_, err := batch.Write().Put(itemSlice...).RunWithContext(ctx)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -325,12 +478,19 @@ func (repository Repository) SaveItemsWithContext(ctx context.Context, key KeyIn
 // DeleteItemsWithContext deletes items matching the keys; it accepts array of keys to be deleted; context which used to enable log with context
 // returns error in case of error
 func (repository Repository) DeleteItemsWithContext(ctx context.Context, keys []KeyInterface) error {
+	if uow := UnitOfWorkFromContext(ctx); uow != nil {
+		return uow.registerDeletes(ctx, repository, keys)
+	}
+
 	var err error
+	ctx, span := repository.startSpan(ctx, "DeleteItems", keys...)
+	defer span.end(&err)
 	defer repository.recordMultipleMetrics(ctx, OpDelete, keys, &err)()
 
 	if len(keys) == 0 {
 		return nil
 	}
+	span.setItemCount(len(keys))
 	for i := 0; i < len(keys); i++ {
 		if err = isValidKey(keys[i]); err != nil {
 			return err
@@ -344,12 +504,24 @@ func (repository Repository) DeleteItemsWithContext(ctx context.Context, keys []
 		batch = repository.table(keys[0].TableName()).Batch(*keys[0].HashKeyName(), *keys[0].RangeKeyName())
 	}
 
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		err = repository.transactWithEvents(ctx, OpDelete, keys[0], len(keys), func(tx *dynamo.WriteTx) {
+			for _, key := range keys {
+				tx.Delete(deleteByKey(repository.table(key.TableName()), key))
+			}
+		})
+		return err
+	}
+
 	dynamoKeys := make([]dynamo.Keyed, len(keys))
 	for i := 0; i < len(keys); i++ {
 		dynamoKeys[i] = dynamo.Keyed(keys[i])
 	}
 
-	_, err = batch.Write().Delete(dynamoKeys...).RunWithContext(ctx)
+	err = repository.retry(ctx, OpDelete, keys[0], func(ctx context.Context) error {
+		_, err := batch.Write().Delete(dynamoKeys...).RunWithContext(ctx)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -362,13 +534,23 @@ func (repository Repository) DeleteItemsWithContext(ctx context.Context, keys []
 // returns true if items are found, returns false and nil if no items found, returns false and error in case of error
 func (repository Repository) GetItemsWithContext(ctx context.Context, key KeyInterface, items interface{}) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "GetItems", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpRead, key, &err)()
+	ctx, recordItems := repository.recordItems(ctx, OpRead, key, items, &err)
+	defer recordItems()
 
-	if err = isValidKey(key); err != nil {
+	if err = isValidPartialKey(key); err != nil {
 		return false, err
 	}
 
-	err = repository.table(key.TableName()).Get(*key.HashKeyName(), key.HashKey()).AllWithContext(ctx, items)
+	span.countItems(items)
+	n := sliceLen(items)
+	err = repository.retry(ctx, OpRead, key, func(ctx context.Context) error {
+		truncateSlice(items, n)
+		payloadSizeFromContext(ctx).reset()
+		return repository.table(key.TableName()).Get(*key.HashKeyName(), key.HashKey()).AllWithContext(ctx, items)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			repository.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(ErrNoItemFound.Error())
@@ -393,15 +575,20 @@ func (repository Repository) GetItemsWithContext(ctx context.Context, key KeyInt
 }
 
 // QueryWithContext by query; it accepts a query interface that is used to get the table name, hash key and range key with its operator if it exists;
-// context which used to enable log with context, the output will be given in items
+// context which used to enable log with context, the output will be given in items, a pointer to a slice or
+// the Polymorphic of a TypeRegistry decoding items by their discriminator
 // returns error in case of error
 func (repository Repository) QueryWithContext(ctx context.Context, query QueryInterface, item interface{}) (err error) {
+	ctx, span := repository.startSpan(ctx, "Query", query)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpRead, query, &err)()
+	ctx, recordItems := repository.recordItems(ctx, OpRead, query, item, &err)
+	defer recordItems()
 
-	if !IsPointerOFSlice(item) {
-		return ErrInvalidPointerSliceType
+	if err = validateOutput(item); err != nil {
+		return err
 	}
-	if err = isValidKey(query); err != nil {
+	if err = isValidPartialKey(query); err != nil {
 		return err
 	}
 
@@ -409,7 +596,7 @@ func (repository Repository) QueryWithContext(ctx context.Context, query QueryIn
 
 	// by range
 	if query.RangeKeyName() != nil && query.RangeKey() != nil {
-		q = q.Range(*query.RangeKeyName(), dynamo.Operator(query.RangeOp()), query.RangeKey())
+		q = q.Range(*query.RangeKeyName(), dynamo.Operator(query.RangeOp()), RangeValues(query)...)
 	}
 
 	if limit := valueFromPtr(query.Limit()); limit > 0 {
@@ -420,7 +607,13 @@ func (repository Repository) QueryWithContext(ctx context.Context, query QueryIn
 		q = q.Order(dynamo.Descending)
 	}
 
-	err = q.AllWithContext(ctx, item)
+	span.countItems(item)
+	n := sliceLen(item)
+	err = repository.retry(ctx, OpRead, query, func(ctx context.Context) error {
+		truncateSlice(item, n)
+		payloadSizeFromContext(ctx).reset()
+		return queryAll(ctx, q, item)
+	})
 	if err != nil {
 		return err
 	}
@@ -431,8 +624,14 @@ func (repository Repository) QueryWithContext(ctx context.Context, query QueryIn
 // OptimisticLockSaveWithContext saves an item if the version attribute on the server matches the version of the object
 func (repository Repository) OptimisticLockSaveWithContext(ctx context.Context, key KeyInterface, item interface{}) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "OptimisticLockSave", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpCommit, key, &err)()
 
+	if err = hasDomainEvents(ctx); err != nil {
+		return false, err
+	}
+
 	model, isDjoemoModel := item.(ModelInterface)
 	if !isDjoemoModel {
 		return false, errors.New("Items to use with OptimisticLock must implement the ModelInterface")
@@ -445,7 +644,7 @@ func (repository Repository) OptimisticLockSaveWithContext(ctx context.Context,
 
 	update := repository.table(key.TableName()).Put(item).If("attribute_not_exists(Version) OR Version = ?", currentVersion)
 
-	err = update.Run()
+	err = repository.retry(ctx, OpCommit, key, update.RunWithContext)
 	if err != nil {
 		if awserr, ok := err.(awserr.Error); ok && awserr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
 			repository.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(dynamodb.ErrCodeConditionalCheckFailedException)
@@ -460,11 +659,17 @@ func (repository Repository) OptimisticLockSaveWithContext(ctx context.Context,
 // ConditionalUpdateWithContext updates an item when the condition is met, otherwise the update will be rejected
 func (repository Repository) ConditionalUpdateWithContext(ctx context.Context, key KeyInterface, item interface{}, expression string, expressionArgs ...interface{}) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "ConditionalUpdate", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpUpdate, key, &err)()
 
+	if err = hasDomainEvents(ctx); err != nil {
+		return false, err
+	}
+
 	update := repository.table(key.TableName()).Put(item).If(expression, expressionArgs...)
 
-	err = update.Run()
+	err = repository.retry(ctx, OpUpdate, key, update.RunWithContext)
 	if err != nil {
 		if awserr, ok := err.(awserr.Error); ok && awserr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
 			repository.log.WithContext(ctx).WithField(TableName, key.TableName()).Info(dynamodb.ErrCodeConditionalCheckFailedException)
@@ -482,7 +687,10 @@ func (repository *Repository) GIndex(name string) GlobalIndexInterface {
 		name:         name,
 		log:          repository.log,
 		dynamoClient: repository.dynamoClient,
+		client:       repository.client,
 		metrics:      repository.metrics,
+		retryPolicy:  repository.retryPolicy,
+		tracer:       repository.tracer,
 	}
 }
 
@@ -493,6 +701,8 @@ func (repository *Repository) table(tableName string) dynamo.Table {
 // ScanIteratorWithContext returns an instance of an Iterator that provides methods for scanning tables
 func (repository *Repository) ScanIteratorWithContext(ctx context.Context, key KeyInterface, searchLimit int64) (IteratorInterface, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "ScanIterator", key)
+	defer span.end(&err)
 	defer repository.recordMetrics(ctx, OpRead, key, &err)()
 
 	if err = isValidTableName(key); err != nil {
@@ -502,12 +712,16 @@ func (repository *Repository) ScanIteratorWithContext(ctx context.Context, key K
 	scan := repository.table(key.TableName()).Scan()
 	pagingIterator := scan.Iter()
 
+	ctx, size := withPayloadSize(ctx)
 	itr := &Iterator{
 		scan:        scan,
 		tableName:   key.TableName(),
 		searchLimit: searchLimit,
 		iterator:    pagingIterator,
 		ctx:         ctx,
+		recordItems: func(count int) {
+			repository.metrics.RecordItems(ctx, OpRead, key, count, size.total())
+		},
 	}
 	itr.scan.SearchLimit(searchLimit)
 
@@ -519,6 +733,8 @@ func (repository *Repository) ScanIteratorWithContext(ctx context.Context, key K
 // Returns (true, nil) if at least one item is found, (false, nil) if none found, or (false, err) on error.
 func (repository Repository) BatchGetItemsWithContext(ctx context.Context, keys []KeyInterface, out interface{}) (bool, error) {
 	var err error
+	ctx, span := repository.startSpan(ctx, "BatchGetItems", keys...)
+	defer span.end(&err)
 	defer repository.recordMultipleMetrics(ctx, OpRead, keys, &err)()
 
 	if len(keys) == 0 {
@@ -550,7 +766,15 @@ func (repository Repository) BatchGetItemsWithContext(ctx context.Context, keys
 	}
 
 	// Execute batch get
-	err = batch.Get(dKeys...).AllWithContext(ctx, out)
+	ctx, recordItems := repository.recordItems(ctx, OpRead, keys[0], out, &err)
+	defer recordItems()
+	span.countItems(out)
+	n := sliceLen(out)
+	err = repository.retry(ctx, OpRead, keys[0], func(ctx context.Context) error {
+		truncateSlice(out, n)
+		payloadSizeFromContext(ctx).reset()
+		return batch.Get(dKeys...).AllWithContext(ctx, out)
+	})
 	if err != nil {
 		if errors.Is(err, dynamo.ErrNotFound) {
 			repository.log.WithContext(ctx).WithField(TableName, tableName).Info(ErrNoItemFound.Error())
@@ -574,6 +798,14 @@ func (repository Repository) BatchGetItemsWithContext(ctx context.Context, keys
 	return true, nil
 }
 
+func (repository Repository) retry(ctx context.Context, op string, key KeyInterface, fn func(ctx context.Context) error) error {
+	return retry(ctx, repository.retryPolicy, repository.log, repository.metrics, op, key, fn)
+}
+
+func (repository Repository) startSpan(ctx context.Context, operation string, keys ...KeyInterface) (context.Context, *operationSpan) {
+	return startOperationSpan(ctx, repository.tracer, repository.client, operation, "", keys...)
+}
+
 func (repository Repository) recordMetrics(ctx context.Context, op string, key KeyInterface, err *error) func() {
 	start := time.Now()
 	return func() {
@@ -581,6 +813,10 @@ func (repository Repository) recordMetrics(ctx context.Context, op string, key K
 	}
 }
 
+func (repository Repository) recordItems(ctx context.Context, op string, key KeyInterface, items any, err *error) (context.Context, func()) {
+	return recordItems(ctx, repository.metrics, op, key, items, err)
+}
+
 func (repository Repository) recordMultipleMetrics(ctx context.Context, op string, keys []KeyInterface, err *error) func() {
 	start := time.Now()
 	return func() {
@@ -2,8 +2,10 @@ package djoemo
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RepositoryInterface provides an interface to enable mocking the AWS dynamodb repository
@@ -11,12 +13,32 @@ import (
 //
 //go:generate mockgen -source=dynamo_repository_interface.go -destination=./mock/dynamo_repository_interface.go -package=mock .
 type RepositoryInterface interface {
-	// WithLog enables logging; it accepts LogInterface as logger
+	// WithLog enables logging; it accepts LogInterface as logger. Every request to DynamoDB is logged at debug level
+	// if log implements DebugLogInterface
 	WithLog(log LogInterface)
 
+	// WithSlogHandler enables logging to handler
+	WithSlogHandler(handler slog.Handler)
+
+	// WithLogRedactor sets the function redacting keys and attribute values of logged requests
+	WithLogRedactor(redactor Redactor)
+
 	// WithMetrics enables metrics; it accepts MetricsInterface as metrics publisher
 	WithMetrics(metricsInterface MetricsInterface)
 
+	// WithRetryPolicy sets the policy used to retry failed operations; it can be overridden per operation with WithOperationRetryPolicy
+	WithRetryPolicy(policy RetryPolicy)
+	// WithTracerProvider sets the provider of the tracer creating a span per operation
+	WithTracerProvider(provider trace.TracerProvider)
+	// WithRateLimiter makes every request to DynamoDB wait until the limits of its table or index allow it
+	WithRateLimiter(limiter *RateLimiter)
+
+	// WithSlowLog keeps requests to DynamoDB and operations that exceed the threshold of slowLog, and failed requests, in it
+	WithSlowLog(slowLog *SlowLog)
+
+	// WithOutboxTable sets the name of the table domain events are written to; DefaultOutboxTable by default
+	WithOutboxTable(tableName string)
+
 	// WithPrometheusMetrics enables prometheus metrics
 	WithPrometheusMetrics(registry *prometheus.Registry) RepositoryInterface
 
@@ -65,7 +87,8 @@ type RepositoryInterface interface {
 	GetItemsWithContext(ctx context.Context, key KeyInterface, out any) (bool, error)
 
 	// QueryWithContext by query; it accepts a query interface that is used to get the table name, hash key and range key with its operator if it exists;
-	// context which used to enable log with context, the output will be given in items
+	// context which used to enable log with context, the output will be given in items, a pointer to a slice or
+	// the Polymorphic of a TypeRegistry decoding items by their discriminator
 	// returns error in case of error
 	QueryWithContext(ctx context.Context, query QueryInterface, item any) error
 
@@ -85,4 +108,19 @@ type RepositoryInterface interface {
 	// and fills out (pointer to a slice) with any found items.
 	// returns true if at least one item is found, returns false and nil if no items found, returns false and error in case of error
 	BatchGetItemsWithContext(ctx context.Context, keys []KeyInterface, out any) (bool, error)
+
+	// CreateTable creates the table of model and waits until it is active; model is a TableSchema or a struct with
+	// key and index tags, see SchemaOf
+	CreateTable(ctx context.Context, model any) error
+
+	// DescribeTable returns the schema of an existing table
+	DescribeTable(ctx context.Context, tableName string) (TableSchema, error)
+
+	// EnsureTable creates the table of model if it does not exist; an existing table is not changed,
+	// returns ErrTableSchemaMismatch if it differs from the schema of model
+	EnsureTable(ctx context.Context, model any) error
+
+	// Backfill scans the table of key in parallel segments and calls fn for every item to save, delete or skip it;
+	// progress is checkpointed per segment if settings.ID is set, so an interrupted backfill resumes
+	Backfill(ctx context.Context, key KeyInterface, fn BackfillFunc, settings BackfillSettings) (BackfillStats, error)
 }
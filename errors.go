@@ -22,3 +22,42 @@ var ErrInvalidPointerSliceType = errors.New("invalid type expected pointer of sl
 
 // ErrInvalidBatchRequest batch request should be for same table
 var ErrInvalidBatchRequest = errors.New("batch request with multiple tables")
+
+// ErrInvalidPointerType should be pointer error
+var ErrInvalidPointerType = errors.New("invalid type expected pointer")
+
+// ErrUnitOfWorkConflict pending writes in a unit of work can not be combined
+var ErrUnitOfWorkConflict = errors.New("conflicting writes in unit of work")
+
+// ErrCircuitOpen operation rejected because the circuit breaker of the table or index is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ErrTableSchemaMismatch existing table differs from the schema of its model
+var ErrTableSchemaMismatch = errors.New("table differs from schema")
+
+// ErrBackfillFailures backfill stopped because more items failed than allowed
+var ErrBackfillFailures = errors.New("too many failed items in backfill")
+
+// ErrBackfillExpressionTooLarge condition or update of a backfilled item exceeds the expression length of DynamoDB
+var ErrBackfillExpressionTooLarge = errors.New("backfill expression too large")
+
+// ErrInvalidDomainEvent domain event can not be written to the outbox
+var ErrInvalidDomainEvent = errors.New("invalid domain event")
+
+// ErrDomainEventsNotSupported write can not be combined with domain events in a transaction
+var ErrDomainEventsNotSupported = errors.New("write does not support domain events")
+
+// ErrOutboxTransactionTooLarge writes and their domain events exceed MaxTransactionItems
+var ErrOutboxTransactionTooLarge = errors.New("too many writes and domain events for a transaction")
+
+// ErrUnknownItemType discriminator of an item is missing or not registered with the type registry
+var ErrUnknownItemType = errors.New("unknown item type")
+
+// ErrInvalidRangeKeyName range key name is invalid error
+var ErrInvalidRangeKeyName = errors.New("invalid range key name")
+
+// ErrInvalidRangeKeyValue range key value is invalid error
+var ErrInvalidRangeKeyValue = errors.New("invalid range key value")
+
+// ErrInvalidIndexName index is not part of the registered table schema
+var ErrInvalidIndexName = errors.New("invalid index name")
@@ -0,0 +1,350 @@
+package fake
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// Limits of the number of items per request
+const (
+	maxBatchGetItems     = 100
+	maxBatchWriteItems   = 25
+	maxTransactItems     = 100
+	cancellationNone     = "None"
+	cancellationFailed   = "ConditionalCheckFailed"
+	cancellationMessage  = "Transaction cancelled, please refer cancellation reasons for specific reasons"
+	duplicateKeysMessage = "Provided list of item keys contains duplicates"
+)
+
+// capacities returns the consumed capacity of the tables of a request in the order of their names
+func capacities(consumed map[string]*capacity, mode *string) []*dynamodb.ConsumedCapacity {
+	names := make([]string, 0, len(consumed))
+	for name := range consumed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []*dynamodb.ConsumedCapacity
+	for _, name := range names {
+		if cc := consumed[name].consumed(mode); cc != nil {
+			result = append(result, cc)
+		}
+	}
+	return result
+}
+
+func capacityOf(consumed map[string]*capacity, tableName string) *capacity {
+	if consumed[tableName] == nil {
+		consumed[tableName] = newCapacity(tableName)
+	}
+	return consumed[tableName]
+}
+
+// BatchGetItem gets items of one or more tables
+func (db *DB) BatchGetItem(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	return db.BatchGetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchGetItemWithContext gets items of one or more tables; all keys are processed
+func (db *DB) BatchGetItemWithContext(ctx aws.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(input.RequestItems) == 0 {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Member must have length greater than or equal to 1")
+	}
+	total := 0
+	for _, keys := range input.RequestItems {
+		if keys != nil {
+			total += len(keys.Keys)
+		}
+	}
+	if total > maxBatchGetItems {
+		return nil, validationError("Too many items requested for the BatchGetItem call")
+	}
+
+	output := &dynamodb.BatchGetItemOutput{
+		Responses:       make(map[string][]map[string]*dynamodb.AttributeValue),
+		UnprocessedKeys: make(map[string]*dynamodb.KeysAndAttributes),
+	}
+	consumed := make(map[string]*capacity)
+	for tableName, keys := range input.RequestItems {
+		t, err := db.table(aws.String(tableName))
+		if err != nil {
+			return nil, err
+		}
+		if keys == nil || len(keys.Keys) == 0 {
+			return nil, validationError("1 validation error detected: Value at 'requestItems.%s.member.keys' failed to satisfy constraint: Member must have length greater than or equal to 1", tableName)
+		}
+		scope := expr.NewScope(keys.ExpressionAttributeNames, nil)
+		paths, err := parseProjection(scope, keys.ProjectionExpression, keys.AttributesToGet)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkUnused(scope); err != nil {
+			return nil, err
+		}
+
+		seen := make(map[string]bool)
+		items := []map[string]*dynamodb.AttributeValue{}
+		for _, key := range keys.Keys {
+			encoded, err := t.checkKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if seen[encoded] {
+				return nil, validationError(duplicateKeysMessage)
+			}
+			seen[encoded] = true
+
+			item := t.items[encoded]
+			if item != nil {
+				items = append(items, project(item, paths))
+			}
+			capacityOf(consumed, tableName).read(t.primary(), expr.Size(item), aws.BoolValue(keys.ConsistentRead), false)
+		}
+		output.Responses[tableName] = items
+	}
+	output.ConsumedCapacity = capacities(consumed, input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// BatchWriteItem puts and deletes items of one or more tables
+func (db *DB) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	return db.BatchWriteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchWriteItemWithContext puts and deletes items of one or more tables; all requests are processed
+func (db *DB) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	total := 0
+	for _, requests := range input.RequestItems {
+		total += len(requests)
+	}
+	if total == 0 || total > maxBatchWriteItems {
+		return nil, validationError("1 validation error detected: Value at 'requestItems' failed to satisfy constraint: Map value must satisfy constraint: [Member must have length less than or equal to 25, Member must have length greater than or equal to 1]")
+	}
+
+	var writes []*write
+	for tableName, requests := range input.RequestItems {
+		t, err := db.table(aws.String(tableName))
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, writeRequest := range requests {
+			var w *write
+			switch {
+			case writeRequest == nil || (writeRequest.PutRequest == nil) == (writeRequest.DeleteRequest == nil):
+				return nil, validationError("Supplied AttributeValue has more than one datatypes set, must contain exactly one of the supported datatypes")
+			case writeRequest.PutRequest != nil:
+				w, err = t.preparePut(writeRequest.PutRequest.Item, conditional{}, expr.NewScope(nil, nil))
+			default:
+				w, err = t.prepareDelete(writeRequest.DeleteRequest.Key, conditional{}, expr.NewScope(nil, nil))
+			}
+			if err != nil {
+				return nil, err
+			}
+			if seen[w.key] {
+				return nil, validationError(duplicateKeysMessage)
+			}
+			seen[w.key] = true
+			writes = append(writes, w)
+		}
+	}
+
+	consumed := make(map[string]*capacity)
+	for _, w := range writes {
+		w.apply()
+		capacityOf(consumed, w.table.name).write(w.table, w.old, w.updated, false)
+	}
+	return &dynamodb.BatchWriteItemOutput{
+		UnprocessedItems: make(map[string][]*dynamodb.WriteRequest),
+		ConsumedCapacity: capacities(consumed, input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// TransactGetItems gets items of one or more tables in one transaction
+func (db *DB) TransactGetItems(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+	return db.TransactGetItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactGetItemsWithContext gets items of one or more tables in one transaction; responses are in the order of
+// the requested items
+func (db *DB) TransactGetItemsWithContext(ctx aws.Context, input *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > maxTransactItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d, Member must have length greater than or equal to 1", maxTransactItems)
+	}
+
+	output := &dynamodb.TransactGetItemsOutput{}
+	consumed := make(map[string]*capacity)
+	seen := make(map[string]bool)
+	for _, transactItem := range input.TransactItems {
+		if transactItem == nil || transactItem.Get == nil {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.member.get' failed to satisfy constraint: Member must not be null")
+		}
+		get := transactItem.Get
+		t, err := db.table(get.TableName)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := t.checkKey(get.Key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[t.name+"/"+encoded] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		seen[t.name+"/"+encoded] = true
+
+		scope := expr.NewScope(get.ExpressionAttributeNames, nil)
+		paths, err := parseProjection(scope, get.ProjectionExpression, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkUnused(scope); err != nil {
+			return nil, err
+		}
+
+		response := &dynamodb.ItemResponse{}
+		if item := t.items[encoded]; item != nil {
+			response.Item = project(item, paths)
+		}
+		output.Responses = append(output.Responses, response)
+		capacityOf(consumed, t.name).read(t.primary(), expr.Size(t.items[encoded]), true, true)
+	}
+	output.ConsumedCapacity = capacities(consumed, input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// TransactWriteItems checks conditions and puts, updates and deletes items of one or more tables in one transaction
+func (db *DB) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return db.TransactWriteItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactWriteItemsWithContext checks conditions and puts, updates and deletes items of one or more tables in one
+// transaction; if a condition fails, nothing is written and the error lists a cancellation reason per item
+func (db *DB) TransactWriteItemsWithContext(ctx aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if len(input.TransactItems) == 0 || len(input.TransactItems) > maxTransactItems {
+		return nil, validationError("1 validation error detected: Value at 'transactItems' failed to satisfy constraint: Member must have length less than or equal to %d, Member must have length greater than or equal to 1", maxTransactItems)
+	}
+
+	var writes []*write
+	reasons := make([]string, len(input.TransactItems))
+	failed := false
+	seen := make(map[string]bool)
+	for i, transactItem := range input.TransactItems {
+		w, err := db.prepareTransactWrite(transactItem)
+		switch {
+		case isConditionalCheckFailed(err):
+			reasons[i], failed = cancellationFailed, true
+		case err != nil:
+			return nil, err
+		default:
+			reasons[i] = cancellationNone
+		}
+		if seen[w.table.name+"/"+w.key] {
+			return nil, validationError("Transaction request cannot include multiple operations on one item")
+		}
+		seen[w.table.name+"/"+w.key] = true
+		writes = append(writes, w)
+	}
+	if failed {
+		return nil, awserr.New(dynamodb.ErrCodeTransactionCanceledException, cancellationMessage+" ["+strings.Join(reasons, ", ")+"]", nil)
+	}
+
+	consumed := make(map[string]*capacity)
+	for _, w := range writes {
+		w.apply()
+		if w.check {
+			capacityOf(consumed, w.table.name).read(w.table.primary(), expr.Size(w.old), true, true)
+			continue
+		}
+		capacityOf(consumed, w.table.name).write(w.table, w.old, w.updated, true)
+	}
+	return &dynamodb.TransactWriteItemsOutput{ConsumedCapacity: capacities(consumed, input.ReturnConsumedCapacity)}, nil
+}
+
+// prepareTransactWrite validates one action of a transaction and evaluates its condition; the caller holds the lock
+func (db *DB) prepareTransactWrite(transactItem *dynamodb.TransactWriteItem) (*write, error) {
+	if transactItem == nil {
+		return nil, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+	}
+	actions := 0
+	for _, set := range []bool{transactItem.ConditionCheck != nil, transactItem.Put != nil, transactItem.Update != nil, transactItem.Delete != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return nil, validationError("TransactItems can only contain one of Check, Put, Update or Delete")
+	}
+
+	switch {
+	case transactItem.ConditionCheck != nil:
+		check := transactItem.ConditionCheck
+		t, err := db.table(check.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if check.ConditionExpression == nil {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.member.conditionCheck.conditionExpression' failed to satisfy constraint: Member must not be null")
+		}
+		scope := expr.NewScope(check.ExpressionAttributeNames, check.ExpressionAttributeValues)
+		return t.prepareConditionCheck(check.Key, conditional{expression: check.ConditionExpression}, scope)
+	case transactItem.Put != nil:
+		put := transactItem.Put
+		t, err := db.table(put.TableName)
+		if err != nil {
+			return nil, err
+		}
+		scope := expr.NewScope(put.ExpressionAttributeNames, put.ExpressionAttributeValues)
+		return t.preparePut(put.Item, conditional{expression: put.ConditionExpression}, scope)
+	case transactItem.Update != nil:
+		update := transactItem.Update
+		t, err := db.table(update.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if update.UpdateExpression == nil {
+			return nil, validationError("1 validation error detected: Value null at 'transactItems.member.update.updateExpression' failed to satisfy constraint: Member must not be null")
+		}
+		scope := expr.NewScope(update.ExpressionAttributeNames, update.ExpressionAttributeValues)
+		return t.prepareUpdate(update.Key, update.UpdateExpression, nil, conditional{expression: update.ConditionExpression}, scope)
+	default:
+		del := transactItem.Delete
+		t, err := db.table(del.TableName)
+		if err != nil {
+			return nil, err
+		}
+		scope := expr.NewScope(del.ExpressionAttributeNames, del.ExpressionAttributeValues)
+		return t.prepareDelete(del.Key, conditional{expression: del.ConditionExpression}, scope)
+	}
+}
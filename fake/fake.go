@@ -0,0 +1,381 @@
+// Package fake implements dynamodbiface.DynamoDBAPI in memory, so repositories can be tested against real
+// behaviour instead of expected requests:
+//
+//	db := fake.New().WithTable("users", "UUID", "")
+//	repository := djoemo.NewRepository(db)
+//
+// The fake keeps tables, secondary indexes and items, evaluates condition, update, key condition, filter and
+// projection expressions as well as their legacy parameters and fails like DynamoDB with awserr errors.
+// Requests it does not implement, like backups, panic. Streams returns the streams of tables with streams enabled,
+// which record writes like DynamoDB Streams.
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// ErrCodeValidationException is the error code of invalid requests
+const ErrCodeValidationException = "ValidationException"
+
+// DB is an in memory DynamoDB; it is safe for concurrent use
+type DB struct {
+	// DynamoDBAPI is nil, calls of methods the fake does not implement panic
+	dynamodbiface.DynamoDBAPI
+
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// New creates an empty DB
+func New() *DB {
+	return &DB{tables: make(map[string]*table)}
+}
+
+// WithTable adds a table with a hash key and an optional range key; key attributes may be of any scalar type.
+// It panics if the table exists
+func (db *DB) WithTable(tableName string, hashKey string, rangeKey string) *DB {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, ok := db.tables[tableName]; ok {
+		panic(fmt.Sprintf("fake: table %s already exists", tableName))
+	}
+	db.tables[tableName] = newTable(tableName, keySchema{hash: hashKey, rng: rangeKey})
+	return db
+}
+
+// WithGlobalIndex adds a global secondary index projecting all attributes to a table added before
+func (db *DB) WithGlobalIndex(tableName string, indexName string, hashKey string, rangeKey string) *DB {
+	return db.withIndex(tableName, &index{name: indexName, key: keySchema{hash: hashKey, rng: rangeKey}})
+}
+
+// WithLocalIndex adds a local secondary index projecting all attributes to a table added before
+func (db *DB) WithLocalIndex(tableName string, indexName string, rangeKey string) *DB {
+	return db.withIndex(tableName, &index{name: indexName, local: true, key: keySchema{rng: rangeKey}})
+}
+
+func (db *DB) withIndex(tableName string, idx *index) *DB {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[tableName]
+	if !ok {
+		panic(fmt.Sprintf("fake: table %s does not exist", tableName))
+	}
+	if idx.local {
+		idx.key.hash = t.key.hash
+	}
+	if err := t.addIndex(idx); err != nil {
+		panic("fake: " + err.Error())
+	}
+	return db
+}
+
+// Items returns a copy of the items of a table ordered by their primary key, nil if the table does not exist
+func (db *DB) Items(tableName string) []map[string]*dynamodb.AttributeValue {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[tableName]
+	if !ok {
+		return nil
+	}
+	items := make([]map[string]*dynamodb.AttributeValue, 0, len(t.items))
+	for _, item := range t.entries(t.primary()) {
+		items = append(items, expr.CopyItem(item))
+	}
+	return items
+}
+
+// CreateTable creates a table
+func (db *DB) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	return db.CreateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateTableWithContext creates a table; it is active right away
+func (db *DB) CreateTableWithContext(ctx aws.Context, input *dynamodb.CreateTableInput, opts ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tableName := aws.StringValue(input.TableName)
+	if tableName == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+	if _, ok := db.tables[tableName]; ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceInUseException, "Table already exists: "+tableName, nil)
+	}
+
+	attributes := make(map[string]string)
+	for _, definition := range input.AttributeDefinitions {
+		attributes[aws.StringValue(definition.AttributeName)] = aws.StringValue(definition.AttributeType)
+	}
+	key, err := keySchemaOf(input.KeySchema, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTable(tableName, key)
+	t.attributes = attributes
+	t.billingMode = aws.StringValue(input.BillingMode)
+	t.throughput = input.ProvisionedThroughput
+	if input.StreamSpecification != nil && aws.BoolValue(input.StreamSpecification.StreamEnabled) {
+		if aws.StringValue(input.StreamSpecification.StreamViewType) == "" {
+			return nil, validationError("One or more parameter values were invalid: StreamViewType is required if streams are enabled")
+		}
+		t.enableStream(input.StreamSpecification)
+	}
+	for _, gsi := range input.GlobalSecondaryIndexes {
+		idx := &index{name: aws.StringValue(gsi.IndexName), projection: gsi.Projection, throughput: gsi.ProvisionedThroughput}
+		if idx.key, err = keySchemaOf(gsi.KeySchema, attributes); err != nil {
+			return nil, err
+		}
+		if err := t.addIndex(idx); err != nil {
+			return nil, validationError("%s", err)
+		}
+	}
+	for _, lsi := range input.LocalSecondaryIndexes {
+		idx := &index{name: aws.StringValue(lsi.IndexName), local: true, projection: lsi.Projection}
+		if idx.key, err = keySchemaOf(lsi.KeySchema, attributes); err != nil {
+			return nil, err
+		}
+		if idx.key.hash != key.hash || idx.key.rng == "" || key.rng == "" {
+			return nil, validationError("Table KeySchema and LocalSecondaryIndex %s KeySchema must have the same hash key and a range key", idx.name)
+		}
+		if err := t.addIndex(idx); err != nil {
+			return nil, validationError("%s", err)
+		}
+	}
+	for name := range attributes {
+		if !t.isKeyAttribute(name) {
+			return nil, validationError("One or more parameter values were invalid: Some AttributeDefinitions are not used. AttributeDefinitions: %s", name)
+		}
+	}
+
+	db.tables[tableName] = t
+	return &dynamodb.CreateTableOutput{TableDescription: t.describe()}, nil
+}
+
+// keySchemaOf validates a key schema; all key attributes must be defined as S, N or B
+func keySchemaOf(elements []*dynamodb.KeySchemaElement, attributes map[string]string) (keySchema, error) {
+	var key keySchema
+	for i, element := range elements {
+		name := aws.StringValue(element.AttributeName)
+		switch attributes[name] {
+		case dynamodb.ScalarAttributeTypeS, dynamodb.ScalarAttributeTypeN, dynamodb.ScalarAttributeTypeB:
+		default:
+			return key, validationError("One or more parameter values were invalid: Some index key attributes are not defined in AttributeDefinitions. Keys: [%s]", name)
+		}
+		switch keyType := aws.StringValue(element.KeyType); {
+		case i == 0 && keyType == dynamodb.KeyTypeHash:
+			key.hash = name
+		case i == 1 && keyType == dynamodb.KeyTypeRange:
+			key.rng = name
+		default:
+			return key, validationError("Invalid KeySchema: the first element must be a HASH key and the optional second a RANGE key")
+		}
+	}
+	if key.hash == "" {
+		return key, validationError("Invalid KeySchema: Some index key attribute have no definition")
+	}
+	return key, nil
+}
+
+// DescribeTable describes a table
+func (db *DB) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return db.DescribeTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeTableWithContext describes a table
+func (db *DB) DescribeTableWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	return &dynamodb.DescribeTableOutput{Table: t.describe()}, nil
+}
+
+// UpdateTimeToLive enables or disables the time to live of a table
+func (db *DB) UpdateTimeToLive(input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return db.UpdateTimeToLiveWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTimeToLiveWithContext enables or disables the time to live of a table; expired items are not deleted
+func (db *DB) UpdateTimeToLiveWithContext(ctx aws.Context, input *dynamodb.UpdateTimeToLiveInput, opts ...request.Option) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	specification := input.TimeToLiveSpecification
+	if specification == nil || aws.StringValue(specification.AttributeName) == "" || specification.Enabled == nil {
+		return nil, validationError("1 validation error detected: TimeToLiveSpecification must have an AttributeName and Enabled")
+	}
+	if aws.BoolValue(specification.Enabled) && aws.BoolValue(t.ttlSpecification().Enabled) {
+		return nil, validationError("TimeToLive is already enabled")
+	}
+	if !aws.BoolValue(specification.Enabled) && !aws.BoolValue(t.ttlSpecification().Enabled) {
+		return nil, validationError("TimeToLive is already disabled")
+	}
+	t.ttl = specification
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: specification}, nil
+}
+
+// DescribeTimeToLive describes the time to live of a table
+func (db *DB) DescribeTimeToLive(input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return db.DescribeTimeToLiveWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeTimeToLiveWithContext describes the time to live of a table
+func (db *DB) DescribeTimeToLiveWithContext(ctx aws.Context, input *dynamodb.DescribeTimeToLiveInput, opts ...request.Option) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	description := &dynamodb.TimeToLiveDescription{TimeToLiveStatus: aws.String(dynamodb.TimeToLiveStatusDisabled)}
+	if aws.BoolValue(t.ttlSpecification().Enabled) {
+		description.TimeToLiveStatus = aws.String(dynamodb.TimeToLiveStatusEnabled)
+		description.AttributeName = t.ttl.AttributeName
+	}
+	return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: description}, nil
+}
+
+// DeleteTable deletes a table and its items
+func (db *DB) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	return db.DeleteTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteTableWithContext deletes a table and its items
+func (db *DB) DeleteTableWithContext(ctx aws.Context, input *dynamodb.DeleteTableInput, opts ...request.Option) (*dynamodb.DeleteTableOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	delete(db.tables, t.name)
+	description := t.describe()
+	description.TableStatus = aws.String(dynamodb.TableStatusDeleting)
+	return &dynamodb.DeleteTableOutput{TableDescription: description}, nil
+}
+
+// ListTables lists the names of the tables in alphabetical order
+func (db *DB) ListTables(input *dynamodb.ListTablesInput) (*dynamodb.ListTablesOutput, error) {
+	return db.ListTablesWithContext(aws.BackgroundContext(), input)
+}
+
+// ListTablesWithContext lists the names of the tables in alphabetical order
+func (db *DB) ListTablesWithContext(ctx aws.Context, input *dynamodb.ListTablesInput, opts ...request.Option) (*dynamodb.ListTablesOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var names []string
+	for name := range db.tables {
+		if name > aws.StringValue(input.ExclusiveStartTableName) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	output := &dynamodb.ListTablesOutput{TableNames: aws.StringSlice(names)}
+	if limit := int(aws.Int64Value(input.Limit)); limit > 0 && len(names) > limit {
+		output.TableNames = output.TableNames[:limit]
+		output.LastEvaluatedTableName = aws.String(names[limit-1])
+	}
+	return output, nil
+}
+
+// WaitUntilTableExists returns once the table exists
+func (db *DB) WaitUntilTableExists(input *dynamodb.DescribeTableInput) error {
+	return db.WaitUntilTableExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableExistsWithContext returns right away; tables are active once they are created
+func (db *DB) WaitUntilTableExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	_, err := db.DescribeTableWithContext(ctx, input)
+	return err
+}
+
+// WaitUntilTableNotExists returns once the table does not exist
+func (db *DB) WaitUntilTableNotExists(input *dynamodb.DescribeTableInput) error {
+	return db.WaitUntilTableNotExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableNotExistsWithContext returns right away; tables are gone once they are deleted
+func (db *DB) WaitUntilTableNotExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	_, err := db.DescribeTableWithContext(ctx, input)
+	if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return awserr.New(request.WaiterResourceNotReadyErrorCode, "table still exists", nil)
+}
+
+// table returns the table with the name; the caller holds the lock
+func (db *DB) table(tableName *string) (*table, error) {
+	name := aws.StringValue(tableName)
+	if name == "" {
+		return nil, validationError("1 validation error detected: Value null at 'tableName' failed to satisfy constraint: Member must not be null")
+	}
+	t, ok := db.tables[name]
+	if !ok {
+		return nil, awserr.New(dynamodb.ErrCodeResourceNotFoundException, "Requested resource not found: Table: "+name+" not found", nil)
+	}
+	return t, nil
+}
+
+func checkContext(ctx aws.Context) error {
+	if ctx == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return awserr.New(request.CanceledErrorCode, "request context canceled", err)
+	}
+	return nil
+}
+
+func validationError(format string, args ...any) error {
+	return awserr.New(ErrCodeValidationException, fmt.Sprintf(format, args...), nil)
+}
+
+func conditionalCheckFailed() error {
+	return awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "The conditional request failed", nil)
+}
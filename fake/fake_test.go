@@ -0,0 +1,379 @@
+package fake_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	djoemo.Model
+	UUID     string
+	UserName string
+	Email    string
+}
+
+// Profile model with hash and range key
+type Profile struct {
+	UUID  string
+	Email string
+	Score int
+}
+
+var _ = Describe("DB", func() {
+	const (
+		UserTableName    = "UserTable"
+		ProfileTableName = "ProfileTable"
+		EmailIndexName   = "EmailIndex"
+	)
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New().
+			WithTable(UserTableName, "UUID", "").
+			WithGlobalIndex(UserTableName, EmailIndexName, "Email", "").
+			WithTable(ProfileTableName, "UUID", "Email")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	saveProfiles := func(uuid string, emails ...string) {
+		for i, email := range emails {
+			key := djoemo.Key().WithTableName(ProfileTableName).
+				WithHashKeyName("UUID").WithHashKey(uuid).
+				WithRangeKeyName("Email").WithRangeKey(email)
+			Expect(repository.SaveItemWithContext(ctx, key, &Profile{UUID: uuid, Email: email, Score: i})).To(Succeed())
+		}
+	}
+
+	Describe("items", func() {
+		It("should save, get, update and delete an item", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "name"})).To(Succeed())
+
+			user := &User{}
+			found, err := repository.GetItemWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(user.UserName).To(Equal("name"))
+
+			err = repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]any{"UserName": "updated"})
+			Expect(err).To(BeNil())
+			found, err = repository.GetItemWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(user.UserName).To(Equal("updated"))
+
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid"))).To(Succeed())
+			found, err = repository.GetItemWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeFalse())
+			Expect(db.Items(UserTableName)).To(BeEmpty())
+		})
+
+		It("should increment numbers and return the updated item", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid"})).To(Succeed())
+
+			user := &User{}
+			err := repository.UpdateWithUpdateExpressionsAndReturnValue(ctx, userKey("uuid"), user, djoemo.UpdateExpressions{
+				djoemo.Add: {"Version": 2},
+			})
+			Expect(err).To(BeNil())
+			Expect(user.Version).To(Equal(uint(2)))
+		})
+
+		It("should reject stale writes with optimistic locking", func() {
+			user := &User{UUID: "uuid", UserName: "name"}
+			saved, err := repository.OptimisticLockSaveWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+			Expect(saved).To(BeTrue())
+
+			stale := &User{UUID: "uuid", UserName: "stale"}
+			saved, err = repository.OptimisticLockSaveWithContext(ctx, userKey("uuid"), stale)
+			Expect(err).To(BeNil())
+			Expect(saved).To(BeFalse())
+
+			saved, err = repository.OptimisticLockSaveWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+			Expect(saved).To(BeTrue())
+			Expect(db.Items(UserTableName)[0]["Version"].N).To(Equal(aws.String("2")))
+		})
+
+		It("should fail like DynamoDB for invalid keys and unknown tables", func() {
+			err := repository.SaveItemWithContext(ctx, userKey("uuid"), &Profile{Email: "email"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(awserr.Error).Code()).To(Equal(fake.ErrCodeValidationException))
+
+			_, err = repository.GetItemWithContext(ctx, djoemo.Key().WithTableName("Unknown").WithHashKeyName("UUID").WithHashKey("uuid"), &User{})
+			Expect(err.(awserr.Error).Code()).To(Equal(dynamodb.ErrCodeResourceNotFoundException))
+		})
+	})
+
+	Describe("queries", func() {
+		BeforeEach(func() {
+			saveProfiles("uuid", "c@mail", "a@mail", "b@mail")
+			saveProfiles("other", "d@mail")
+		})
+
+		It("should query a partition ordered by range key", func() {
+			profiles := []Profile{}
+			query := djoemo.Query().WithTableName(ProfileTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+			Expect(repository.QueryWithContext(ctx, query, &profiles)).To(Succeed())
+			Expect(profiles).To(HaveLen(3))
+			Expect([]string{profiles[0].Email, profiles[1].Email, profiles[2].Email}).To(Equal([]string{"a@mail", "b@mail", "c@mail"}))
+		})
+
+		It("should query by range key in descending order with a limit", func() {
+			profiles := []Profile{}
+			query := djoemo.Query().WithTableName(ProfileTableName).WithHashKeyName("UUID").WithHashKey("uuid").
+				WithRangeKeyName("Email").WithRangeKey("a@mail").WithRangeOp(djoemo.Greater).
+				WithDescending().WithLimit(1)
+			Expect(repository.QueryWithContext(ctx, query, &profiles)).To(Succeed())
+			Expect(profiles).To(HaveLen(1))
+			Expect(profiles[0].Email).To(Equal("c@mail"))
+		})
+
+		It("should page through results", func() {
+			var pages int
+			var emails []string
+			var start map[string]*dynamodb.AttributeValue
+			for {
+				output, err := db.Query(&dynamodb.QueryInput{
+					TableName:                 aws.String(ProfileTableName),
+					KeyConditionExpression:    aws.String("#uuid = :uuid"),
+					FilterExpression:          aws.String("Score > :score"),
+					ExpressionAttributeNames:  map[string]*string{"#uuid": aws.String("UUID")},
+					ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":uuid": {S: aws.String("uuid")}, ":score": {N: aws.String("0")}},
+					Limit:                     aws.Int64(1),
+					ExclusiveStartKey:         start,
+				})
+				Expect(err).To(BeNil())
+				pages++
+				for _, item := range output.Items {
+					emails = append(emails, *item["Email"].S)
+				}
+				if start = output.LastEvaluatedKey; start == nil {
+					break
+				}
+			}
+			Expect(pages).To(Equal(3))
+			Expect(emails).To(Equal([]string{"a@mail", "b@mail"}))
+		})
+
+		It("should query global secondary indexes", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("1"), &User{UUID: "1", Email: "mail"})).To(Succeed())
+			Expect(repository.SaveItemWithContext(ctx, userKey("2"), &User{UUID: "2", Email: "mail"})).To(Succeed())
+			Expect(repository.SaveItemWithContext(ctx, userKey("3"), &User{UUID: "3", Email: "other"})).To(Succeed())
+
+			users := []User{}
+			key := djoemo.Key().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("mail")
+			found, err := repository.GIndex(EmailIndexName).GetItemsWithContext(ctx, key, &users)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(users).To(HaveLen(2))
+		})
+
+		It("should scan all items with the iterator", func() {
+			iterator, err := repository.ScanIteratorWithContext(ctx, djoemo.Key().WithTableName(ProfileTableName), 2)
+			Expect(err).To(BeNil())
+			count := 0
+			profile := &Profile{}
+			for iterator.NextItem(profile) {
+				count++
+			}
+			Expect(count).To(Equal(4))
+		})
+	})
+
+	Describe("batches and transactions", func() {
+		It("should save and get items in batches", func() {
+			users := []User{{UUID: "1"}, {UUID: "2"}, {UUID: "3"}}
+			Expect(repository.SaveItemsWithContext(ctx, userKey(""), users)).To(Succeed())
+
+			loaded := []User{}
+			found, err := repository.BatchGetItemsWithContext(ctx, []djoemo.KeyInterface{userKey("1"), userKey("3"), userKey("4")}, &loaded)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(loaded).To(HaveLen(2))
+		})
+
+		It("should commit a unit of work in one transaction", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("1"), &User{UUID: "1"})).To(Succeed())
+
+			uowCtx, uow := djoemo.NewUnitOfWork(ctx)
+			Expect(repository.SaveItemWithContext(uowCtx, userKey("2"), &User{UUID: "2"})).To(Succeed())
+			Expect(repository.DeleteItemWithContext(uowCtx, userKey("1"))).To(Succeed())
+			Expect(db.Items(UserTableName)).To(HaveLen(1))
+
+			Expect(uow.Commit(ctx)).To(Succeed())
+			items := db.Items(UserTableName)
+			Expect(items).To(HaveLen(1))
+			Expect(items[0]["UUID"].S).To(Equal(aws.String("2")))
+		})
+
+		It("should cancel transactions with failed conditions without writing", func() {
+			_, err := db.TransactWriteItems(&dynamodb.TransactWriteItemsInput{TransactItems: []*dynamodb.TransactWriteItem{
+				{Put: &dynamodb.Put{
+					TableName: aws.String(UserTableName),
+					Item:      map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("1")}},
+				}},
+				{ConditionCheck: &dynamodb.ConditionCheck{
+					TableName:           aws.String(UserTableName),
+					Key:                 map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("2")}},
+					ConditionExpression: aws.String("attribute_exists(UUID)"),
+				}},
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(err.(awserr.Error).Code()).To(Equal(dynamodb.ErrCodeTransactionCanceledException))
+			Expect(err.(awserr.Error).Message()).To(HaveSuffix("[None, ConditionalCheckFailed]"))
+			Expect(db.Items(UserTableName)).To(BeEmpty())
+		})
+	})
+
+	Describe("tables", func() {
+		It("should create and describe tables with indexes", func() {
+			_, err := db.CreateTable(&dynamodb.CreateTableInput{
+				TableName: aws.String("Events"),
+				AttributeDefinitions: []*dynamodb.AttributeDefinition{
+					{AttributeName: aws.String("ID"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeS)},
+					{AttributeName: aws.String("Time"), AttributeType: aws.String(dynamodb.ScalarAttributeTypeN)},
+				},
+				KeySchema: []*dynamodb.KeySchemaElement{
+					{AttributeName: aws.String("ID"), KeyType: aws.String(dynamodb.KeyTypeHash)},
+				},
+				GlobalSecondaryIndexes: []*dynamodb.GlobalSecondaryIndex{{
+					IndexName:  aws.String("TimeIndex"),
+					KeySchema:  []*dynamodb.KeySchemaElement{{AttributeName: aws.String("Time"), KeyType: aws.String(dynamodb.KeyTypeHash)}},
+					Projection: &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeKeysOnly)},
+				}},
+			})
+			Expect(err).To(BeNil())
+
+			_, err = db.PutItem(&dynamodb.PutItemInput{
+				TableName: aws.String("Events"),
+				Item:      map[string]*dynamodb.AttributeValue{"ID": {S: aws.String("id")}, "Time": {S: aws.String("now")}},
+			})
+			Expect(err).To(MatchError(ContainSubstring("Type mismatch for key Time")))
+
+			output, err := db.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String("Events")})
+			Expect(err).To(BeNil())
+			Expect(output.Table.TableStatus).To(Equal(aws.String(dynamodb.TableStatusActive)))
+			Expect(output.Table.GlobalSecondaryIndexes).To(HaveLen(1))
+		})
+
+		It("should enable time to live once", func() {
+			input := &dynamodb.UpdateTimeToLiveInput{
+				TableName: aws.String(UserTableName),
+				TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+					AttributeName: aws.String("ExpiresAt"),
+					Enabled:       aws.Bool(true),
+				},
+			}
+			_, err := db.UpdateTimeToLive(input)
+			Expect(err).To(BeNil())
+			_, err = db.UpdateTimeToLive(input)
+			Expect(err).To(MatchError(ContainSubstring("TimeToLive is already enabled")))
+
+			output, err := db.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String(UserTableName)})
+			Expect(err).To(BeNil())
+			Expect(output.TimeToLiveDescription.TimeToLiveStatus).To(Equal(aws.String(dynamodb.TimeToLiveStatusEnabled)))
+			Expect(output.TimeToLiveDescription.AttributeName).To(Equal(aws.String("ExpiresAt")))
+		})
+	})
+
+	Describe("streams", func() {
+		var streams *fake.Streams
+
+		BeforeEach(func() {
+			db.WithStream(UserTableName, dynamodb.StreamViewTypeNewAndOldImages)
+			streams = db.Streams()
+		})
+
+		shardRecords := func(shardID *string) ([]*dynamodbstreams.Record, *string) {
+			arn := describeStream(db, UserTableName)
+			iterator, err := streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+				StreamArn: arn, ShardId: shardID, ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
+			})
+			Expect(err).To(BeNil())
+			output, err := streams.GetRecords(&dynamodbstreams.GetRecordsInput{ShardIterator: iterator.ShardIterator})
+			Expect(err).To(BeNil())
+			return output.Records, output.NextShardIterator
+		}
+
+		It("should record inserts, modifications and removals with images", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "name"})).To(Succeed())
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "name"})).To(Succeed())
+			Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]any{"UserName": "updated"})).To(Succeed())
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid"))).To(Succeed())
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid"))).To(Succeed())
+
+			description, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: describeStream(db, UserTableName)})
+			Expect(err).To(BeNil())
+			Expect(description.StreamDescription.Shards).To(HaveLen(1))
+			records, next := shardRecords(description.StreamDescription.Shards[0].ShardId)
+			Expect(next).NotTo(BeNil())
+			Expect(records).To(HaveLen(3))
+
+			Expect(records[0].EventName).To(Equal(aws.String(dynamodbstreams.OperationTypeInsert)))
+			Expect(records[0].Dynamodb.Keys).To(Equal(map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("uuid")}}))
+			Expect(records[0].Dynamodb.OldImage).To(BeNil())
+			Expect(records[1].EventName).To(Equal(aws.String(dynamodbstreams.OperationTypeModify)))
+			Expect(records[1].Dynamodb.OldImage["UserName"].S).To(Equal(aws.String("name")))
+			Expect(records[1].Dynamodb.NewImage["UserName"].S).To(Equal(aws.String("updated")))
+			Expect(records[2].EventName).To(Equal(aws.String(dynamodbstreams.OperationTypeRemove)))
+			Expect(records[2].Dynamodb.NewImage).To(BeNil())
+			Expect(*records[0].Dynamodb.SequenceNumber < *records[2].Dynamodb.SequenceNumber).To(BeTrue())
+		})
+
+		It("should close split shards and open their children", func() {
+			Expect(repository.SaveItemWithContext(ctx, userKey("a"), &User{UUID: "a"})).To(Succeed())
+			db.SplitStreamShards(UserTableName, 2)
+			Expect(repository.SaveItemWithContext(ctx, userKey("b"), &User{UUID: "b"})).To(Succeed())
+
+			description, err := streams.DescribeStream(&dynamodbstreams.DescribeStreamInput{StreamArn: describeStream(db, UserTableName)})
+			Expect(err).To(BeNil())
+			shards := description.StreamDescription.Shards
+			Expect(shards).To(HaveLen(3))
+			Expect(shards[0].SequenceNumberRange.EndingSequenceNumber).NotTo(BeNil())
+			Expect(shards[1].ParentShardId).To(Equal(shards[0].ShardId))
+			Expect(shards[2].ParentShardId).To(Equal(shards[0].ShardId))
+
+			records, next := shardRecords(shards[0].ShardId)
+			Expect(records).To(HaveLen(1))
+			Expect(next).To(BeNil())
+			children, _ := shardRecords(shards[1].ShardId)
+			others, _ := shardRecords(shards[2].ShardId)
+			Expect(append(children, others...)).To(HaveLen(1))
+
+			_, err = streams.GetShardIterator(&dynamodbstreams.GetShardIteratorInput{
+				StreamArn: describeStream(db, UserTableName), ShardId: aws.String("unknown"), ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeLatest),
+			})
+			Expect(err.(awserr.Error).Code()).To(Equal(dynamodbstreams.ErrCodeResourceNotFoundException))
+		})
+	})
+})
+
+// describeStream returns the ARN of the stream of a table
+func describeStream(db *fake.DB, tableName string) *string {
+	output, err := db.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	Expect(err).To(BeNil())
+	return output.Table.LatestStreamArn
+}
@@ -0,0 +1,418 @@
+package fake
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// maxItemSize is the maximum size of an item
+const maxItemSize = 400 * 1024
+
+// write is a validated change of one item that is applied once all writes of a request are validated
+type write struct {
+	table   *table
+	key     string
+	old     expr.Item
+	updated expr.Item
+	paths   []expr.Path
+	deleted bool
+	check   bool
+}
+
+func (w *write) apply() {
+	switch {
+	case w.check:
+	case w.deleted:
+		delete(w.table.items, w.key)
+		w.record(nil)
+	default:
+		w.table.items[w.key] = w.updated
+		w.record(w.updated)
+	}
+}
+
+// record adds the change to the stream of the table if it is enabled
+func (w *write) record(updated expr.Item) {
+	if w.table.changes != nil {
+		w.table.changes.add(w.table.key, w.old, updated)
+	}
+}
+
+// isConditionalCheckFailed reports if err is the failure of a condition
+func isConditionalCheckFailed(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	return ok && awsErr.Code() == dynamodb.ErrCodeConditionalCheckFailedException
+}
+
+// conditional is the condition of a write as expression or legacy Expected parameter
+type conditional struct {
+	expression *string
+	expected   map[string]*dynamodb.ExpectedAttributeValue
+	operator   *string
+}
+
+func (c conditional) parse(scope *expr.Scope) (expr.Condition, error) {
+	return parseCondition(scope, "ConditionExpression", c.expression, "Expected", len(c.expected) > 0, func() (expr.Condition, error) {
+		return expr.FromExpected(c.expected, c.operator)
+	})
+}
+
+// parseCondition parses a condition given as expression or as legacy parameter, nil if neither is given
+func parseCondition(scope *expr.Scope, name string, expression *string, legacyName string, hasLegacy bool, legacy func() (expr.Condition, error)) (expr.Condition, error) {
+	switch {
+	case expression != nil && hasLegacy:
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {%s} Expression parameters: {%s}", legacyName, name)
+	case expression != nil:
+		condition, err := scope.Condition(*expression)
+		if err != nil {
+			return nil, validationError("Invalid %s: %s", name, err)
+		}
+		return condition, nil
+	case hasLegacy:
+		condition, err := legacy()
+		if err != nil {
+			return nil, validationError("%s", err)
+		}
+		return condition, nil
+	}
+	return nil, nil
+}
+
+// parseProjection parses a projection given as expression or as legacy AttributesToGet, nil for all attributes
+func parseProjection(scope *expr.Scope, expression *string, attributesToGet []*string) ([]expr.Path, error) {
+	switch {
+	case expression != nil && len(attributesToGet) > 0:
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {AttributesToGet} Expression parameters: {ProjectionExpression}")
+	case expression != nil:
+		paths, err := scope.Projection(*expression)
+		if err != nil {
+			return nil, validationError("Invalid ProjectionExpression: %s", err)
+		}
+		return paths, nil
+	}
+	var paths []expr.Path
+	for _, name := range attributesToGet {
+		paths = append(paths, expr.Attribute(aws.StringValue(name)))
+	}
+	return paths, nil
+}
+
+func checkUnused(scope *expr.Scope) error {
+	if err := scope.Unused(); err != nil {
+		return validationError("%s", err)
+	}
+	return nil
+}
+
+// project applies a projection to a copy of item
+func project(item expr.Item, paths []expr.Path) map[string]*dynamodb.AttributeValue {
+	if paths == nil {
+		return expr.CopyItem(item)
+	}
+	return expr.Project(item, paths)
+}
+
+func evaluate(condition expr.Condition, item expr.Item) error {
+	if condition != nil && !condition.Eval(item) {
+		return conditionalCheckFailed()
+	}
+	return nil
+}
+
+func checkSize(item expr.Item) error {
+	if expr.Size(item) > maxItemSize {
+		return validationError("Item size has exceeded the maximum allowed size")
+	}
+	return nil
+}
+
+func (t *table) preparePut(item expr.Item, condition conditional, scope *expr.Scope) (*write, error) {
+	key, err := t.checkItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(item); err != nil {
+		return nil, err
+	}
+	parsed, err := condition.parse(scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+	w := &write{table: t, key: key, old: t.items[key], updated: expr.CopyItem(item)}
+	return w, evaluate(parsed, w.old)
+}
+
+func (t *table) prepareDelete(key expr.Item, condition conditional, scope *expr.Scope) (*write, error) {
+	encoded, err := t.checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := condition.parse(scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+	w := &write{table: t, key: encoded, old: t.items[encoded], deleted: true}
+	return w, evaluate(parsed, w.old)
+}
+
+func (t *table) prepareConditionCheck(key expr.Item, condition conditional, scope *expr.Scope) (*write, error) {
+	w, err := t.prepareDelete(key, condition, scope)
+	if w != nil {
+		w.deleted, w.check = false, true
+	}
+	return w, err
+}
+
+func (t *table) prepareUpdate(key expr.Item, expression *string, attributeUpdates map[string]*dynamodb.AttributeValueUpdate, condition conditional, scope *expr.Scope) (*write, error) {
+	encoded, err := t.checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var update *expr.Update
+	switch {
+	case expression != nil && len(attributeUpdates) > 0:
+		return nil, validationError("Can not use both expression and non-expression parameters in the same request: Non-expression parameters: {AttributeUpdates} Expression parameters: {UpdateExpression}")
+	case expression != nil:
+		if update, err = scope.Update(*expression); err != nil {
+			return nil, validationError("Invalid UpdateExpression: %s", err)
+		}
+	default:
+		update = fromAttributeUpdates(attributeUpdates)
+	}
+	for _, path := range update.Paths() {
+		if name := path[0].Name; name == t.key.hash || name == t.key.rng {
+			return nil, validationError("One or more parameter values were invalid: Cannot update attribute %s. This attribute is part of the key", name)
+		}
+	}
+
+	parsed, err := condition.parse(scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+
+	w := &write{table: t, key: encoded, old: t.items[encoded], paths: update.Paths()}
+	if err := evaluate(parsed, w.old); err != nil {
+		return w, err
+	}
+
+	item := w.old
+	if item == nil {
+		item = key
+	}
+	if w.updated, err = update.Apply(item); err != nil {
+		return nil, validationError("%s", err)
+	}
+	if _, err := t.checkItem(w.updated); err != nil {
+		return nil, err
+	}
+	return w, checkSize(w.updated)
+}
+
+// fromAttributeUpdates converts the legacy AttributeUpdates parameter
+func fromAttributeUpdates(updates map[string]*dynamodb.AttributeValueUpdate) *expr.Update {
+	update := &expr.Update{}
+	for name, attributeUpdate := range updates {
+		path := expr.Attribute(name)
+		switch action := aws.StringValue(attributeUpdate.Action); {
+		case action == dynamodb.AttributeActionAdd:
+			update.Add = append(update.Add, expr.ValueAction{Path: path, Value: attributeUpdate.Value})
+		case action == dynamodb.AttributeActionDelete && attributeUpdate.Value != nil:
+			update.Delete = append(update.Delete, expr.ValueAction{Path: path, Value: attributeUpdate.Value})
+		case action == dynamodb.AttributeActionDelete:
+			update.Remove = append(update.Remove, path)
+		default:
+			update.Set = append(update.Set, expr.SetAction{Path: path, Value: expr.OperandValue{Operand: expr.ValueOperand{Value: attributeUpdate.Value}}})
+		}
+	}
+	return update
+}
+
+func checkReturnValues(returnValues *string, allowed ...string) error {
+	if returnValues == nil {
+		return nil
+	}
+	for _, value := range append(allowed, dynamodb.ReturnValueNone) {
+		if *returnValues == value {
+			return nil
+		}
+	}
+	return validationError("ReturnValues can only be %v", append(allowed, dynamodb.ReturnValueNone))
+}
+
+// GetItem gets an item
+func (db *DB) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return db.GetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// GetItemWithContext gets an item
+func (db *DB) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := t.checkKey(input.Key)
+	if err != nil {
+		return nil, err
+	}
+	scope := expr.NewScope(input.ExpressionAttributeNames, nil)
+	paths, err := parseProjection(scope, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+
+	output := &dynamodb.GetItemOutput{}
+	item := t.items[encoded]
+	if item != nil {
+		output.Item = project(item, paths)
+	}
+	consumed := newCapacity(t.name)
+	consumed.read(t.primary(), expr.Size(item), aws.BoolValue(input.ConsistentRead), false)
+	output.ConsumedCapacity = consumed.consumed(input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// PutItem creates or replaces an item
+func (db *DB) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return db.PutItemWithContext(aws.BackgroundContext(), input)
+}
+
+// PutItemWithContext creates or replaces an item
+func (db *DB) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	scope := expr.NewScope(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	w, err := t.preparePut(input.Item, conditional{input.ConditionExpression, input.Expected, input.ConditionalOperator}, scope)
+	if err != nil {
+		return nil, err
+	}
+	w.apply()
+
+	output := &dynamodb.PutItemOutput{}
+	if aws.StringValue(input.ReturnValues) == dynamodb.ReturnValueAllOld && w.old != nil {
+		output.Attributes = expr.CopyItem(w.old)
+	}
+	consumed := newCapacity(t.name)
+	consumed.write(t, w.old, w.updated, false)
+	output.ConsumedCapacity = consumed.consumed(input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// UpdateItem updates an item or creates it if it does not exist
+func (db *DB) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return db.UpdateItemWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateItemWithContext updates an item or creates it if it does not exist
+func (db *DB) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	err = checkReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld, dynamodb.ReturnValueUpdatedOld, dynamodb.ReturnValueAllNew, dynamodb.ReturnValueUpdatedNew)
+	if err != nil {
+		return nil, err
+	}
+	scope := expr.NewScope(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	w, err := t.prepareUpdate(input.Key, input.UpdateExpression, input.AttributeUpdates, conditional{input.ConditionExpression, input.Expected, input.ConditionalOperator}, scope)
+	if err != nil {
+		return nil, err
+	}
+	w.apply()
+
+	output := &dynamodb.UpdateItemOutput{}
+	var attributes map[string]*dynamodb.AttributeValue
+	switch aws.StringValue(input.ReturnValues) {
+	case dynamodb.ReturnValueAllOld:
+		attributes = expr.CopyItem(w.old)
+	case dynamodb.ReturnValueUpdatedOld:
+		attributes = expr.Project(w.old, w.paths)
+	case dynamodb.ReturnValueAllNew:
+		attributes = expr.CopyItem(w.updated)
+	case dynamodb.ReturnValueUpdatedNew:
+		attributes = expr.Project(w.updated, w.paths)
+	}
+	if len(attributes) > 0 {
+		output.Attributes = attributes
+	}
+	consumed := newCapacity(t.name)
+	consumed.write(t, w.old, w.updated, false)
+	output.ConsumedCapacity = consumed.consumed(input.ReturnConsumedCapacity)
+	return output, nil
+}
+
+// DeleteItem deletes an item
+func (db *DB) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return db.DeleteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteItemWithContext deletes an item
+func (db *DB) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReturnValues(input.ReturnValues, dynamodb.ReturnValueAllOld); err != nil {
+		return nil, err
+	}
+	scope := expr.NewScope(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	w, err := t.prepareDelete(input.Key, conditional{input.ConditionExpression, input.Expected, input.ConditionalOperator}, scope)
+	if err != nil {
+		return nil, err
+	}
+	w.apply()
+
+	output := &dynamodb.DeleteItemOutput{}
+	if aws.StringValue(input.ReturnValues) == dynamodb.ReturnValueAllOld && w.old != nil {
+		output.Attributes = expr.CopyItem(w.old)
+	}
+	consumed := newCapacity(t.name)
+	consumed.write(t, w.old, nil, false)
+	output.ConsumedCapacity = consumed.consumed(input.ReturnConsumedCapacity)
+	return output, nil
+}
@@ -0,0 +1,330 @@
+package fake
+
+import (
+	"hash/fnv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// maxPageSize is the maximum size of the items a query or scan evaluates per request
+const maxPageSize = 1024 * 1024
+
+// reading are the parameters shared by queries and scans
+type reading struct {
+	idx        *index
+	filter     expr.Condition
+	paths      []expr.Path
+	count      bool
+	start      map[string]*dynamodb.AttributeValue
+	limit      int64
+	forward    bool
+	consistent bool
+}
+
+// page is the result of a query or scan request
+type page struct {
+	items   []map[string]*dynamodb.AttributeValue
+	count   int64
+	scanned int64
+	size    int
+	last    map[string]*dynamodb.AttributeValue
+}
+
+// prepareReading validates the parameters shared by queries and scans
+func (t *table) prepareReading(indexName *string, selection *string, limit *int64, start map[string]*dynamodb.AttributeValue, consistent *bool) (*reading, error) {
+	idx, err := t.index(indexName)
+	if err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(consistent) && idx.name != "" && !idx.local {
+		return nil, validationError("Consistent reads are not supported on global secondary indexes")
+	}
+	if limit != nil && *limit < 1 {
+		return nil, validationError("1 validation error detected: Value '%d' at 'limit' failed to satisfy constraint: Member must have value greater than or equal to 1", *limit)
+	}
+	if err := t.checkStartKey(start, idx); err != nil {
+		return nil, err
+	}
+	r := &reading{
+		idx:        idx,
+		start:      start,
+		limit:      aws.Int64Value(limit),
+		forward:    true,
+		consistent: aws.BoolValue(consistent),
+		count:      aws.StringValue(selection) == dynamodb.SelectCount,
+	}
+
+	switch aws.StringValue(selection) {
+	case dynamodb.SelectAllAttributes:
+		if idx.name != "" && !idx.local && aws.StringValue(idx.projection.ProjectionType) != dynamodb.ProjectionTypeAll {
+			return nil, validationError("One or more parameter values were invalid: Select type ALL_ATTRIBUTES is not supported for global secondary index %s because its projection type is not ALL", idx.name)
+		}
+	case dynamodb.SelectAllProjectedAttributes:
+		if idx.name == "" {
+			return nil, validationError("One or more parameter values were invalid: Select type ALL_PROJECTED_ATTRIBUTES is supported only for index queries")
+		}
+	case "", dynamodb.SelectCount, dynamodb.SelectSpecificAttributes:
+	default:
+		return nil, validationError("1 validation error detected: Value '%s' at 'select' failed to satisfy constraint", *selection)
+	}
+	return r, nil
+}
+
+// project sets the projection and validates it against the selection
+func (r *reading) project(paths []expr.Path, selection *string) error {
+	switch s := aws.StringValue(selection); {
+	case paths != nil && s != "" && s != dynamodb.SelectSpecificAttributes:
+		return validationError("Cannot specify the AttributesToGet or ProjectionExpression when choosing to get %s", s)
+	case paths == nil && s == dynamodb.SelectSpecificAttributes:
+		return validationError("SPECIFIC_ATTRIBUTES requires AttributesToGet or ProjectionExpression")
+	}
+	r.paths = paths
+	return nil
+}
+
+// view returns the attributes of item a read of the index sees; local indexes fetch missing attributes from the table
+func (r *reading) view(t *table, item expr.Item) expr.Item {
+	if r.idx.local {
+		return item
+	}
+	return t.project(item, r.idx)
+}
+
+// read evaluates entries after the exclusive start key up to the limit or 1 MB of data
+func (t *table) read(r *reading, entries []expr.Item) page {
+	if !r.forward {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	order := t.order(r.idx)
+	if r.start != nil {
+		position := len(entries)
+		for i, entry := range entries {
+			result := compareItems(entry, r.start, order)
+			if r.forward && result > 0 || !r.forward && result < 0 {
+				position = i
+				break
+			}
+		}
+		entries = entries[position:]
+	}
+
+	var p page
+	for i, entry := range entries {
+		view := r.view(t, entry)
+		p.scanned++
+		p.size += expr.Size(view)
+		if r.filter == nil || r.filter.Eval(view) {
+			p.count++
+			if !r.count {
+				p.items = append(p.items, project(view, r.paths))
+			}
+		}
+		if (r.limit > 0 && p.scanned >= r.limit || p.size >= maxPageSize) && i < len(entries)-1 {
+			p.last = t.lastKey(entry, r.idx)
+			break
+		}
+	}
+	if p.items == nil && !r.count {
+		p.items = []map[string]*dynamodb.AttributeValue{}
+	}
+	return p
+}
+
+// checkKeyCondition validates that a key condition has an equality condition on the hash key of the index and at
+// most one condition on its range key
+func checkKeyCondition(condition expr.Condition, idx *index) error {
+	var hash, rng bool
+	for _, conjunct := range expr.Conjuncts(condition) {
+		var path expr.Path
+		var onRange bool
+		switch c := conjunct.(type) {
+		case expr.Comparison:
+			operand, ok := c.Left.(expr.PathOperand)
+			_, isValue := c.Right.(expr.ValueOperand)
+			if !ok || !isValue || c.Operator == "<>" {
+				return validationError("Invalid operator used in KeyConditionExpression: %s", c.Operator)
+			}
+			path, onRange = operand.Path, c.Operator != "="
+		case expr.Between:
+			operand, ok := c.Operand.(expr.PathOperand)
+			if !ok {
+				return validationError("Query key condition not supported")
+			}
+			path, onRange = operand.Path, true
+		case expr.Function:
+			if c.Name != expr.FunctionBeginsWith {
+				return validationError("Invalid operator used in KeyConditionExpression: %s", c.Name)
+			}
+			path, onRange = c.Path, true
+		default:
+			return validationError("Query key condition not supported")
+		}
+
+		if len(path) != 1 || path[0].IsIndex {
+			return validationError("Query key condition not supported")
+		}
+		switch name := path[0].Name; {
+		case name == idx.key.hash && !onRange && !hash:
+			hash = true
+		case name == idx.key.rng && !rng:
+			rng = true
+		default:
+			return validationError("Query key condition not supported")
+		}
+	}
+	if !hash {
+		return validationError("Query condition missed key schema element: %s", idx.key.hash)
+	}
+	return nil
+}
+
+// Query reads the items of a partition of a table or index
+func (db *DB) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return db.QueryWithContext(aws.BackgroundContext(), input)
+}
+
+// QueryWithContext reads the items of a partition of a table or index
+func (db *DB) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	r, err := t.prepareReading(input.IndexName, input.Select, input.Limit, input.ExclusiveStartKey, input.ConsistentRead)
+	if err != nil {
+		return nil, err
+	}
+	r.forward = input.ScanIndexForward == nil || *input.ScanIndexForward
+
+	scope := expr.NewScope(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	keyCondition, err := parseCondition(scope, "KeyConditionExpression", input.KeyConditionExpression, "KeyConditions", len(input.KeyConditions) > 0, func() (expr.Condition, error) {
+		return expr.FromConditions(input.KeyConditions, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if keyCondition == nil {
+		return nil, validationError("Either the KeyConditions or KeyConditionExpression parameter must be specified in the request.")
+	}
+	if err := checkKeyCondition(keyCondition, r.idx); err != nil {
+		return nil, err
+	}
+	r.filter, err = parseCondition(scope, "FilterExpression", input.FilterExpression, "QueryFilter", len(input.QueryFilter) > 0, func() (expr.Condition, error) {
+		return expr.FromConditions(input.QueryFilter, input.ConditionalOperator)
+	})
+	if err != nil {
+		return nil, err
+	}
+	paths, err := parseProjection(scope, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.project(paths, input.Select); err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+
+	var entries []expr.Item
+	for _, entry := range t.entries(r.idx) {
+		if keyCondition.Eval(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	p := t.read(r, entries)
+
+	consumed := newCapacity(t.name)
+	consumed.read(r.idx, p.size, r.consistent, false)
+	return &dynamodb.QueryOutput{
+		Items:            p.items,
+		Count:            aws.Int64(p.count),
+		ScannedCount:     aws.Int64(p.scanned),
+		LastEvaluatedKey: p.last,
+		ConsumedCapacity: consumed.consumed(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// Scan reads the items of a table or index
+func (db *DB) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return db.ScanWithContext(aws.BackgroundContext(), input)
+}
+
+// ScanWithContext reads the items of a table or index, or of a segment of them; items are in the order of their key
+func (db *DB) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+	r, err := t.prepareReading(input.IndexName, input.Select, input.Limit, input.ExclusiveStartKey, input.ConsistentRead)
+	if err != nil {
+		return nil, err
+	}
+	if (input.Segment == nil) != (input.TotalSegments == nil) {
+		return nil, validationError("The TotalSegments parameter is required but was not present in the request when Segment parameter is present")
+	}
+	segment, total := aws.Int64Value(input.Segment), aws.Int64Value(input.TotalSegments)
+	if input.TotalSegments != nil && (total < 1 || total > 1000000 || segment < 0 || segment >= total) {
+		return nil, validationError("The Segment parameter is zero-based and must be less than parameter TotalSegments: Segment: %d is not less than TotalSegments: %d", segment, total)
+	}
+
+	scope := expr.NewScope(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	r.filter, err = parseCondition(scope, "FilterExpression", input.FilterExpression, "ScanFilter", len(input.ScanFilter) > 0, func() (expr.Condition, error) {
+		return expr.FromConditions(input.ScanFilter, input.ConditionalOperator)
+	})
+	if err != nil {
+		return nil, err
+	}
+	paths, err := parseProjection(scope, input.ProjectionExpression, input.AttributesToGet)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.project(paths, input.Select); err != nil {
+		return nil, err
+	}
+	if err := checkUnused(scope); err != nil {
+		return nil, err
+	}
+
+	var entries []expr.Item
+	for _, entry := range t.entries(r.idx) {
+		if total <= 1 || segmentOf(entry[r.idx.key.hash], total) == segment {
+			entries = append(entries, entry)
+		}
+	}
+	p := t.read(r, entries)
+
+	consumed := newCapacity(t.name)
+	consumed.read(r.idx, p.size, r.consistent, false)
+	return &dynamodb.ScanOutput{
+		Items:            p.items,
+		Count:            aws.Int64(p.count),
+		ScannedCount:     aws.Int64(p.scanned),
+		LastEvaluatedKey: p.last,
+		ConsumedCapacity: consumed.consumed(input.ReturnConsumedCapacity),
+	}, nil
+}
+
+// segmentOf assigns a hash key to one of total segments; all items of a partition are in the same segment
+func segmentOf(hash *dynamodb.AttributeValue, total int64) int64 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(expr.Format(hash)))
+	return int64(h.Sum32()) % total
+}
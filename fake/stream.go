@@ -0,0 +1,391 @@
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// maxRecords is the maximum and default number of records returned by GetRecords
+const maxRecords = 1000
+
+// changeStream holds the change records of a table with streams enabled
+type changeStream struct {
+	arn      string
+	label    string
+	viewType string
+	created  time.Time
+	shards   []*streamShard
+	sequence int64
+}
+
+// streamShard holds records in the order of their sequence numbers; writes to closed shards go to their children
+type streamShard struct {
+	id       string
+	parent   string
+	start    string
+	end      string
+	records  []*dynamodbstreams.Record
+	closed   bool
+	sequence int64
+}
+
+func newChangeStream(tableArn string, viewType string, created time.Time) *changeStream {
+	label := created.UTC().Format("2006-01-02T15:04:05.000")
+	s := &changeStream{arn: tableArn + "/stream/" + label, label: label, viewType: viewType, created: created}
+	s.addShard("")
+	return s
+}
+
+func (s *changeStream) addShard(parent string) {
+	s.shards = append(s.shards, &streamShard{
+		id:     fmt.Sprintf("shardId-%020d-%08d", s.created.UnixMilli(), len(s.shards)),
+		parent: parent,
+		start:  sequenceNumber(s.sequence + 1),
+	})
+}
+
+// open returns the shards records are written to
+func (s *changeStream) open() []*streamShard {
+	var open []*streamShard
+	for _, shard := range s.shards {
+		if !shard.closed {
+			open = append(open, shard)
+		}
+	}
+	return open
+}
+
+func (s *changeStream) shard(id string) *streamShard {
+	for _, shard := range s.shards {
+		if shard.id == id {
+			return shard
+		}
+	}
+	return nil
+}
+
+// split closes the open shards and opens count shards, whose parents are the closed shards
+func (s *changeStream) split(count int) {
+	open := s.open()
+	for _, shard := range open {
+		shard.closed = true
+		shard.end = sequenceNumber(s.sequence)
+	}
+	for i := 0; i < count; i++ {
+		s.addShard(open[i%len(open)].id)
+	}
+}
+
+// sequenceNumber formats sequence numbers with the minimum length of DynamoDB, so they sort like numbers
+func sequenceNumber(n int64) string {
+	return fmt.Sprintf("%021d", n)
+}
+
+// add records a change of an item; writes that do not change the item are not recorded, like in DynamoDB
+func (s *changeStream) add(key keySchema, old expr.Item, updated expr.Item) {
+	record := &dynamodbstreams.Record{
+		AwsRegion:    aws.String("fake"),
+		EventSource:  aws.String("aws:dynamodb"),
+		EventVersion: aws.String("1.1"),
+	}
+	item := updated
+	switch {
+	case old == nil && updated == nil:
+		return
+	case old == nil:
+		record.EventName = aws.String(dynamodbstreams.OperationTypeInsert)
+	case updated == nil:
+		record.EventName = aws.String(dynamodbstreams.OperationTypeRemove)
+		item = old
+	case expr.Equal(&dynamodb.AttributeValue{M: old}, &dynamodb.AttributeValue{M: updated}):
+		return
+	default:
+		record.EventName = aws.String(dynamodbstreams.OperationTypeModify)
+	}
+
+	s.sequence++
+	keys := expr.Item{}
+	for _, name := range key.names() {
+		keys[name] = expr.Copy(item[name])
+	}
+	change := &dynamodbstreams.StreamRecord{
+		ApproximateCreationDateTime: aws.Time(time.Now().Truncate(time.Second)),
+		Keys:                        keys,
+		SequenceNumber:              aws.String(sequenceNumber(s.sequence)),
+		StreamViewType:              aws.String(s.viewType),
+	}
+	size := expr.Size(keys)
+	if updated != nil && (s.viewType == dynamodb.StreamViewTypeNewImage || s.viewType == dynamodb.StreamViewTypeNewAndOldImages) {
+		change.NewImage = expr.CopyItem(updated)
+		size += expr.Size(updated)
+	}
+	if old != nil && (s.viewType == dynamodb.StreamViewTypeOldImage || s.viewType == dynamodb.StreamViewTypeNewAndOldImages) {
+		change.OldImage = expr.CopyItem(old)
+		size += expr.Size(old)
+	}
+	change.SizeBytes = aws.Int64(int64(size))
+	record.Dynamodb = change
+	record.EventID = aws.String(fmt.Sprintf("%032x", s.sequence))
+
+	// records of an item go to the same shard, so they are read in order
+	open := s.open()
+	hash := fnv.New32a()
+	hash.Write([]byte(key.encode(keys)))
+	shard := open[int(hash.Sum32())%len(open)]
+	shard.records = append(shard.records, record)
+}
+
+// WithStream enables the stream of a table added before with one shard; viewType is one of the
+// dynamodb.StreamViewType values. It panics if the table does not exist
+func (db *DB) WithStream(tableName string, viewType string) *DB {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[tableName]
+	if !ok {
+		panic(fmt.Sprintf("fake: table %s does not exist", tableName))
+	}
+	t.enableStream(&dynamodb.StreamSpecification{StreamEnabled: aws.Bool(true), StreamViewType: aws.String(viewType)})
+	return db
+}
+
+// SplitStreamShards closes the open shards of the stream of a table and opens shards new ones, like DynamoDB does when
+// partitions split; the closed shards are the parents of the new shards. It panics if the table has no stream
+func (db *DB) SplitStreamShards(tableName string, shards int) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[tableName]
+	if !ok || t.changes == nil {
+		panic(fmt.Sprintf("fake: table %s has no stream", tableName))
+	}
+	t.changes.split(shards)
+}
+
+// Streams implements dynamodbstreamsiface.DynamoDBStreamsAPI for the streams of the tables of a DB; the shard
+// iterators of the fake do not expire
+type Streams struct {
+	// DynamoDBStreamsAPI is nil, calls of methods the fake does not implement panic
+	dynamodbstreamsiface.DynamoDBStreamsAPI
+
+	db *DB
+}
+
+// Streams returns the streams of the tables of db
+func (db *DB) Streams() *Streams {
+	return &Streams{db: db}
+}
+
+func (s *Streams) stream(arn *string) (*table, error) {
+	for _, t := range s.db.tables {
+		if t.changes != nil && t.changes.arn == aws.StringValue(arn) {
+			return t, nil
+		}
+	}
+	return nil, awserr.New(dynamodbstreams.ErrCodeResourceNotFoundException, "Requested resource not found: Stream: "+aws.StringValue(arn)+" not found", nil)
+}
+
+// ListStreams lists the streams of all tables or of the table TableName
+func (s *Streams) ListStreams(input *dynamodbstreams.ListStreamsInput) (*dynamodbstreams.ListStreamsOutput, error) {
+	return s.ListStreamsWithContext(aws.BackgroundContext(), input)
+}
+
+// ListStreamsWithContext lists the streams of all tables or of the table TableName
+func (s *Streams) ListStreamsWithContext(ctx aws.Context, input *dynamodbstreams.ListStreamsInput, opts ...request.Option) (*dynamodbstreams.ListStreamsOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	var streams []*dynamodbstreams.Stream
+	for _, t := range s.db.tables {
+		if t.changes == nil || (input.TableName != nil && aws.StringValue(input.TableName) != t.name) {
+			continue
+		}
+		streams = append(streams, &dynamodbstreams.Stream{
+			StreamArn:   aws.String(t.changes.arn),
+			StreamLabel: aws.String(t.changes.label),
+			TableName:   aws.String(t.name),
+		})
+	}
+	sort.Slice(streams, func(i, j int) bool { return *streams[i].StreamArn < *streams[j].StreamArn })
+
+	start := 0
+	if input.ExclusiveStartStreamArn != nil {
+		start = sort.Search(len(streams), func(i int) bool { return *streams[i].StreamArn > *input.ExclusiveStartStreamArn })
+	}
+	streams = streams[start:]
+	output := &dynamodbstreams.ListStreamsOutput{Streams: streams}
+	if limit := int(aws.Int64Value(input.Limit)); limit > 0 && len(streams) > limit {
+		output.Streams = streams[:limit]
+		output.LastEvaluatedStreamArn = streams[limit-1].StreamArn
+	}
+	return output, nil
+}
+
+// DescribeStream describes a stream and its shards
+func (s *Streams) DescribeStream(input *dynamodbstreams.DescribeStreamInput) (*dynamodbstreams.DescribeStreamOutput, error) {
+	return s.DescribeStreamWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeStreamWithContext describes a stream and its shards
+func (s *Streams) DescribeStreamWithContext(ctx aws.Context, input *dynamodbstreams.DescribeStreamInput, opts ...request.Option) (*dynamodbstreams.DescribeStreamOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	t, err := s.stream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	description := &dynamodbstreams.StreamDescription{
+		CreationRequestDateTime: aws.Time(t.changes.created),
+		KeySchema:               t.key.elements(),
+		StreamArn:               aws.String(t.changes.arn),
+		StreamLabel:             aws.String(t.changes.label),
+		StreamStatus:            aws.String(dynamodbstreams.StreamStatusEnabled),
+		StreamViewType:          aws.String(t.changes.viewType),
+		TableName:               aws.String(t.name),
+	}
+
+	shards := t.changes.shards
+	if input.ExclusiveStartShardId != nil {
+		for i, shard := range shards {
+			if shard.id == aws.StringValue(input.ExclusiveStartShardId) {
+				shards = shards[i+1:]
+				break
+			}
+		}
+	}
+	limit := int(aws.Int64Value(input.Limit))
+	if limit <= 0 {
+		limit = 100
+	}
+	if len(shards) > limit {
+		shards = shards[:limit]
+		description.LastEvaluatedShardId = aws.String(shards[limit-1].id)
+	}
+	for _, shard := range shards {
+		described := &dynamodbstreams.Shard{
+			ShardId:             aws.String(shard.id),
+			SequenceNumberRange: &dynamodbstreams.SequenceNumberRange{StartingSequenceNumber: aws.String(shard.start)},
+		}
+		if shard.parent != "" {
+			described.ParentShardId = aws.String(shard.parent)
+		}
+		if shard.closed {
+			described.SequenceNumberRange.EndingSequenceNumber = aws.String(shard.end)
+		}
+		description.Shards = append(description.Shards, described)
+	}
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: description}, nil
+}
+
+// GetShardIterator returns an iterator for a position in a shard
+func (s *Streams) GetShardIterator(input *dynamodbstreams.GetShardIteratorInput) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	return s.GetShardIteratorWithContext(aws.BackgroundContext(), input)
+}
+
+// GetShardIteratorWithContext returns an iterator for a position in a shard
+func (s *Streams) GetShardIteratorWithContext(ctx aws.Context, input *dynamodbstreams.GetShardIteratorInput, opts ...request.Option) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	t, err := s.stream(input.StreamArn)
+	if err != nil {
+		return nil, err
+	}
+	shard := t.changes.shard(aws.StringValue(input.ShardId))
+	if shard == nil {
+		return nil, awserr.New(dynamodbstreams.ErrCodeResourceNotFoundException, "Requested resource not found: Shard does not exist", nil)
+	}
+
+	sequence := aws.StringValue(input.SequenceNumber)
+	position := 0
+	switch iteratorType := aws.StringValue(input.ShardIteratorType); iteratorType {
+	case dynamodbstreams.ShardIteratorTypeTrimHorizon:
+	case dynamodbstreams.ShardIteratorTypeLatest:
+		position = len(shard.records)
+	case dynamodbstreams.ShardIteratorTypeAtSequenceNumber, dynamodbstreams.ShardIteratorTypeAfterSequenceNumber:
+		if sequence == "" {
+			return nil, validationError("SequenceNumber is required for shard iterator type %s", iteratorType)
+		}
+		position = sort.Search(len(shard.records), func(i int) bool {
+			current := *shard.records[i].Dynamodb.SequenceNumber
+			if iteratorType == dynamodbstreams.ShardIteratorTypeAtSequenceNumber {
+				return current >= sequence
+			}
+			return current > sequence
+		})
+	default:
+		return nil, validationError("Invalid ShardIteratorType: %s", iteratorType)
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(shardIterator(t.changes.arn, shard.id, position))}, nil
+}
+
+func shardIterator(arn string, shardID string, position int) string {
+	return strings.Join([]string{arn, shardID, strconv.Itoa(position)}, "|")
+}
+
+// GetRecords returns the records of a shard from the position of an iterator; NextShardIterator is nil once all
+// records of a closed shard are read
+func (s *Streams) GetRecords(input *dynamodbstreams.GetRecordsInput) (*dynamodbstreams.GetRecordsOutput, error) {
+	return s.GetRecordsWithContext(aws.BackgroundContext(), input)
+}
+
+// GetRecordsWithContext returns the records of a shard from the position of an iterator; NextShardIterator is nil
+// once all records of a closed shard are read
+func (s *Streams) GetRecordsWithContext(ctx aws.Context, input *dynamodbstreams.GetRecordsInput, opts ...request.Option) (*dynamodbstreams.GetRecordsOutput, error) {
+	if err := checkContext(ctx); err != nil {
+		return nil, err
+	}
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	parts := strings.Split(aws.StringValue(input.ShardIterator), "|")
+	position, err := 0, error(nil)
+	if len(parts) == 3 {
+		position, err = strconv.Atoi(parts[2])
+	}
+	if len(parts) != 3 || err != nil {
+		return nil, validationError("Invalid ShardIterator")
+	}
+	t, err := s.stream(aws.String(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	shard := t.changes.shard(parts[1])
+	if shard == nil {
+		return nil, awserr.New(dynamodbstreams.ErrCodeResourceNotFoundException, "Requested resource not found: Shard does not exist", nil)
+	}
+
+	limit := int(aws.Int64Value(input.Limit))
+	if limit <= 0 || limit > maxRecords {
+		limit = maxRecords
+	}
+	end := min(position+limit, len(shard.records))
+	output := &dynamodbstreams.GetRecordsOutput{Records: shard.records[position:end:end]}
+	if !shard.closed || end < len(shard.records) {
+		output.NextShardIterator = aws.String(shardIterator(t.changes.arn, shard.id, end))
+	}
+	return output, nil
+}
@@ -0,0 +1,505 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// keySchema is the hash key and optional range key of a table or index
+type keySchema struct {
+	hash string
+	rng  string
+}
+
+func (k keySchema) names() []string {
+	if k.rng == "" {
+		return []string{k.hash}
+	}
+	return []string{k.hash, k.rng}
+}
+
+func (k keySchema) elements() []*dynamodb.KeySchemaElement {
+	elements := []*dynamodb.KeySchemaElement{{AttributeName: aws.String(k.hash), KeyType: aws.String(dynamodb.KeyTypeHash)}}
+	if k.rng != "" {
+		elements = append(elements, &dynamodb.KeySchemaElement{AttributeName: aws.String(k.rng), KeyType: aws.String(dynamodb.KeyTypeRange)})
+	}
+	return elements
+}
+
+// encode returns a string identifying the key of item
+func (k keySchema) encode(item expr.Item) string {
+	encoded := expr.Format(item[k.hash])
+	if k.rng != "" {
+		encoded += "|" + expr.Format(item[k.rng])
+	}
+	return encoded
+}
+
+// index is a secondary index, or the primary key of a table if name is empty
+type index struct {
+	name       string
+	key        keySchema
+	local      bool
+	projection *dynamodb.Projection
+	throughput *dynamodb.ProvisionedThroughput
+}
+
+type table struct {
+	name        string
+	key         keySchema
+	attributes  map[string]string
+	indexes     []*index
+	items       map[string]expr.Item
+	created     time.Time
+	billingMode string
+	throughput  *dynamodb.ProvisionedThroughput
+	stream      *dynamodb.StreamSpecification
+	changes     *changeStream
+	ttl         *dynamodb.TimeToLiveSpecification
+}
+
+func newTable(name string, key keySchema) *table {
+	return &table{
+		name:       name,
+		key:        key,
+		attributes: make(map[string]string),
+		items:      make(map[string]expr.Item),
+		created:    time.Now(),
+	}
+}
+
+func (t *table) arn() string {
+	return "arn:aws:dynamodb:fake:000000000000:table/" + t.name
+}
+
+// enableStream enables the stream of the table, so writes are recorded
+func (t *table) enableStream(spec *dynamodb.StreamSpecification) {
+	t.stream = spec
+	t.changes = newChangeStream(t.arn(), aws.StringValue(spec.StreamViewType), t.created)
+}
+
+func (t *table) addIndex(idx *index) error {
+	if idx.name == "" {
+		return fmt.Errorf("index name must not be empty")
+	}
+	if _, err := t.index(aws.String(idx.name)); err == nil {
+		return fmt.Errorf("duplicate index name: %s", idx.name)
+	}
+	if idx.projection == nil {
+		idx.projection = &dynamodb.Projection{ProjectionType: aws.String(dynamodb.ProjectionTypeAll)}
+	}
+	t.indexes = append(t.indexes, idx)
+	return nil
+}
+
+// primary returns the primary key as index
+func (t *table) primary() *index {
+	return &index{key: t.key}
+}
+
+// index returns the index with the name or the primary key if name is nil
+func (t *table) index(name *string) (*index, error) {
+	if name == nil {
+		return t.primary(), nil
+	}
+	for _, idx := range t.indexes {
+		if idx.name == *name {
+			return idx, nil
+		}
+	}
+	return nil, validationError("The table does not have the specified index: %s", *name)
+}
+
+func (t *table) isKeyAttribute(name string) bool {
+	if name == t.key.hash || name == t.key.rng {
+		return true
+	}
+	for _, idx := range t.indexes {
+		if name == idx.key.hash || name == idx.key.rng {
+			return true
+		}
+	}
+	return false
+}
+
+// checkKey validates that key consists of the primary key attributes only and returns the encoded key
+func (t *table) checkKey(key expr.Item) (string, error) {
+	if len(key) != len(t.key.names()) {
+		return "", validationError("The provided key element does not match the schema")
+	}
+	for _, name := range t.key.names() {
+		if err := t.checkKeyValue(name, key[name], "The provided key element does not match the schema"); err != nil {
+			return "", err
+		}
+	}
+	return t.key.encode(key), nil
+}
+
+// checkItem validates the attribute values and keys of an item and returns the encoded key
+func (t *table) checkItem(item expr.Item) (string, error) {
+	for name, value := range item {
+		if err := checkValue(value); err != nil {
+			return "", err
+		}
+		if t.isKeyAttribute(name) {
+			if err := t.checkKeyValue(name, value, "One or more parameter values were invalid: Type mismatch for key "+name); err != nil {
+				return "", err
+			}
+		}
+	}
+	for _, name := range t.key.names() {
+		if item[name] == nil {
+			return "", validationError("One or more parameter values were invalid: Missing the key %s in the item", name)
+		}
+	}
+	return t.key.encode(item), nil
+}
+
+// checkKeyValue validates the type of a key attribute; attributes of tables added by WithTable take any scalar type
+func (t *table) checkKeyValue(name string, value *dynamodb.AttributeValue, mismatch string) error {
+	if value == nil {
+		return validationError("%s", mismatch)
+	}
+	valueType := expr.TypeOf(value)
+	switch expected := t.attributes[name]; {
+	case expected != "" && valueType != expected:
+		return validationError("%s", mismatch)
+	case valueType != expr.TypeString && valueType != expr.TypeNumber && valueType != expr.TypeBinary:
+		return validationError("%s", mismatch)
+	case valueType == expr.TypeString && *value.S == "", valueType == expr.TypeBinary && len(value.B) == 0:
+		return validationError("One or more parameter values are not valid. The AttributeValue for a key attribute cannot contain an empty %s value. Key: %s", map[string]string{expr.TypeString: "string", expr.TypeBinary: "binary"}[valueType], name)
+	}
+	return nil
+}
+
+// checkValue validates that a value has exactly one type and that sets are neither empty nor contain duplicates
+func checkValue(value *dynamodb.AttributeValue) error {
+	switch expr.TypeOf(value) {
+	case "":
+		return validationError("Supplied AttributeValue is empty, must contain exactly one of the supported datatypes")
+	case expr.TypeStringSet, expr.TypeNumberSet, expr.TypeBinarySet:
+		elements := expr.SetElements(value)
+		if len(elements) == 0 {
+			return validationError("One or more parameter values were invalid: An %s set may not be empty", expr.TypeOf(value))
+		}
+		for i, element := range elements {
+			for _, other := range elements[:i] {
+				if expr.Equal(element, other) {
+					return validationError("One or more parameter values were invalid: Input collection %s contains duplicates", expr.Format(value))
+				}
+			}
+		}
+	case expr.TypeList:
+		for _, element := range value.L {
+			if err := checkValue(element); err != nil {
+				return err
+			}
+		}
+	case expr.TypeMap:
+		for _, element := range value.M {
+			if err := checkValue(element); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// order returns the attributes that order the items of an index: its key followed by the primary key
+func (t *table) order(idx *index) []string {
+	order := idx.key.names()
+	for _, name := range t.key.names() {
+		if name != idx.key.hash && name != idx.key.rng {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+// entries returns the items of an index, the items with all of its key attributes, ordered by the index key
+func (t *table) entries(idx *index) []expr.Item {
+	var entries []expr.Item
+	for _, item := range t.items {
+		if isIndexed(item, idx) {
+			entries = append(entries, item)
+		}
+	}
+	order := t.order(idx)
+	sort.Slice(entries, func(i, j int) bool {
+		return compareItems(entries[i], entries[j], order) < 0
+	})
+	return entries
+}
+
+func isIndexed(item expr.Item, idx *index) bool {
+	for _, name := range idx.key.names() {
+		if item[name] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// compareItems compares items by the attributes in order; values of different types are ordered by type
+func compareItems(a, b expr.Item, order []string) int {
+	for _, name := range order {
+		result, ok := expr.Compare(a[name], b[name])
+		if !ok {
+			if typeA, typeB := expr.TypeOf(a[name]), expr.TypeOf(b[name]); typeA != typeB {
+				if typeA < typeB {
+					return -1
+				}
+				return 1
+			}
+		}
+		if result != 0 {
+			return result
+		}
+	}
+	return 0
+}
+
+// lastKey returns the key of an item that continues a query or scan of an index
+func (t *table) lastKey(item expr.Item, idx *index) map[string]*dynamodb.AttributeValue {
+	key := make(map[string]*dynamodb.AttributeValue)
+	for _, name := range t.order(idx) {
+		key[name] = expr.Copy(item[name])
+	}
+	return key
+}
+
+// checkStartKey validates the exclusive start key of a query or scan of an index
+func (t *table) checkStartKey(start map[string]*dynamodb.AttributeValue, idx *index) error {
+	if start == nil {
+		return nil
+	}
+	order := t.order(idx)
+	if len(start) != len(order) {
+		return validationError("The provided starting key is invalid: The provided key element does not match the schema")
+	}
+	for _, name := range order {
+		if err := t.checkKeyValue(name, start[name], "The provided starting key is invalid: The provided key element does not match the schema"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// project returns the attributes of item projected into an index
+func (t *table) project(item expr.Item, idx *index) expr.Item {
+	if idx.projection == nil || aws.StringValue(idx.projection.ProjectionType) == dynamodb.ProjectionTypeAll {
+		return item
+	}
+	var paths []expr.Path
+	for _, name := range t.order(idx) {
+		paths = append(paths, expr.Attribute(name))
+	}
+	if aws.StringValue(idx.projection.ProjectionType) == dynamodb.ProjectionTypeInclude {
+		for _, name := range idx.projection.NonKeyAttributes {
+			paths = append(paths, expr.Attribute(aws.StringValue(name)))
+		}
+	}
+	return expr.Project(item, paths)
+}
+
+// size returns the sum of the sizes of the items of the table
+func (t *table) size(idx *index) int64 {
+	var size int64
+	for _, item := range t.items {
+		if isIndexed(item, idx) {
+			size += int64(expr.Size(t.project(item, idx)))
+		}
+	}
+	return size
+}
+
+func (t *table) describe() *dynamodb.TableDescription {
+	description := &dynamodb.TableDescription{
+		TableName:        aws.String(t.name),
+		TableArn:         aws.String(t.arn()),
+		TableStatus:      aws.String(dynamodb.TableStatusActive),
+		CreationDateTime: aws.Time(t.created),
+		KeySchema:        t.key.elements(),
+		ItemCount:        aws.Int64(int64(len(t.items))),
+		TableSizeBytes:   aws.Int64(t.size(t.primary())),
+	}
+	names := make([]string, 0, len(t.attributes))
+	for name := range t.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		description.AttributeDefinitions = append(description.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: aws.String(t.attributes[name]),
+		})
+	}
+	if t.billingMode != "" {
+		description.BillingModeSummary = &dynamodb.BillingModeSummary{BillingMode: aws.String(t.billingMode)}
+	}
+	if t.throughput != nil {
+		description.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+			ReadCapacityUnits:  t.throughput.ReadCapacityUnits,
+			WriteCapacityUnits: t.throughput.WriteCapacityUnits,
+		}
+	}
+
+	if t.changes != nil {
+		description.StreamSpecification = t.stream
+		description.LatestStreamLabel = aws.String(t.changes.label)
+		description.LatestStreamArn = aws.String(t.changes.arn)
+	}
+
+	for _, idx := range t.indexes {
+		count := int64(0)
+		for _, item := range t.items {
+			if isIndexed(item, idx) {
+				count++
+			}
+		}
+		if idx.local {
+			description.LocalSecondaryIndexes = append(description.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndexDescription{
+				IndexName:      aws.String(idx.name),
+				KeySchema:      idx.key.elements(),
+				Projection:     idx.projection,
+				ItemCount:      aws.Int64(count),
+				IndexSizeBytes: aws.Int64(t.size(idx)),
+			})
+			continue
+		}
+		gsi := &dynamodb.GlobalSecondaryIndexDescription{
+			IndexName:      aws.String(idx.name),
+			IndexStatus:    aws.String(dynamodb.IndexStatusActive),
+			KeySchema:      idx.key.elements(),
+			Projection:     idx.projection,
+			ItemCount:      aws.Int64(count),
+			IndexSizeBytes: aws.Int64(t.size(idx)),
+		}
+		if idx.throughput != nil {
+			gsi.ProvisionedThroughput = &dynamodb.ProvisionedThroughputDescription{
+				ReadCapacityUnits:  idx.throughput.ReadCapacityUnits,
+				WriteCapacityUnits: idx.throughput.WriteCapacityUnits,
+			}
+		}
+		description.GlobalSecondaryIndexes = append(description.GlobalSecondaryIndexes, gsi)
+	}
+	return description
+}
+
+// capacity collects the units consumed by a request per table and index
+type capacity struct {
+	tableName string
+	table     float64
+	global    map[string]float64
+	local     map[string]float64
+}
+
+func newCapacity(tableName string) *capacity {
+	return &capacity{tableName: tableName, global: make(map[string]float64), local: make(map[string]float64)}
+}
+
+// read adds the units of reading size bytes: half a unit per 4 KB for eventually consistent reads
+func (c *capacity) read(idx *index, size int, consistent bool, transactional bool) {
+	units := math.Ceil(float64(size) / 4096)
+	if units == 0 {
+		units = 1
+	}
+	if !consistent {
+		units /= 2
+	}
+	if transactional {
+		units *= 2
+	}
+	c.add(idx, units)
+}
+
+// write adds the units of writing the larger of the old and new item to the table and the indexes of either
+func (c *capacity) write(t *table, old expr.Item, updated expr.Item, transactional bool) {
+	units := func(items ...expr.Item) float64 {
+		size := 0
+		for _, item := range items {
+			if item != nil {
+				size = max(size, expr.Size(item))
+			}
+		}
+		result := max(math.Ceil(float64(size)/1024), 1)
+		if transactional {
+			result *= 2
+		}
+		return result
+	}
+	c.add(t.primary(), units(old, updated))
+	for _, idx := range t.indexes {
+		var items []expr.Item
+		for _, item := range []expr.Item{old, updated} {
+			if item != nil && isIndexed(item, idx) {
+				items = append(items, t.project(item, idx))
+			}
+		}
+		if len(items) > 0 {
+			c.add(idx, units(items...))
+		}
+	}
+}
+
+func (c *capacity) add(idx *index, units float64) {
+	switch {
+	case idx.name == "":
+		c.table += units
+	case idx.local:
+		c.local[idx.name] += units
+	default:
+		c.global[idx.name] += units
+	}
+}
+
+// consumed returns the consumed capacity in the detail requested by mode, nil for NONE
+func (c *capacity) consumed(mode *string) *dynamodb.ConsumedCapacity {
+	total := c.table
+	for _, units := range c.global {
+		total += units
+	}
+	for _, units := range c.local {
+		total += units
+	}
+
+	switch aws.StringValue(mode) {
+	case dynamodb.ReturnConsumedCapacityTotal:
+		return &dynamodb.ConsumedCapacity{TableName: aws.String(c.tableName), CapacityUnits: aws.Float64(total)}
+	case dynamodb.ReturnConsumedCapacityIndexes:
+		consumed := &dynamodb.ConsumedCapacity{
+			TableName:     aws.String(c.tableName),
+			CapacityUnits: aws.Float64(total),
+			Table:         &dynamodb.Capacity{CapacityUnits: aws.Float64(c.table)},
+		}
+		for name, units := range c.global {
+			if consumed.GlobalSecondaryIndexes == nil {
+				consumed.GlobalSecondaryIndexes = make(map[string]*dynamodb.Capacity)
+			}
+			consumed.GlobalSecondaryIndexes[name] = &dynamodb.Capacity{CapacityUnits: aws.Float64(units)}
+		}
+		for name, units := range c.local {
+			if consumed.LocalSecondaryIndexes == nil {
+				consumed.LocalSecondaryIndexes = make(map[string]*dynamodb.Capacity)
+			}
+			consumed.LocalSecondaryIndexes[name] = &dynamodb.Capacity{CapacityUnits: aws.Float64(units)}
+		}
+		return consumed
+	}
+	return nil
+}
+
+// ttlSpecification returns the time to live of the table; it is disabled by default
+func (t *table) ttlSpecification() *dynamodb.TimeToLiveSpecification {
+	if t.ttl == nil {
+		return &dynamodb.TimeToLiveSpecification{Enabled: aws.Bool(false)}
+	}
+	return t.ttl
+}
@@ -1,6 +1,8 @@
 package djoemo
 
 import (
+	"reflect"
+
 	"github.com/guregu/dynamo"
 )
 
@@ -18,13 +20,31 @@ func buildTableKeyCondition(table dynamo.Table, key KeyInterface) *dynamo.Query
 
 	// by range
 	if key.RangeKeyName() != nil && key.RangeKey() != nil {
-		strVal, ok := key.RangeKey().(string)
-		if ok && strVal == "" {
-			return q
-		}
-
 		q = q.Range(*key.RangeKeyName(), dynamo.Equal, key.RangeKey())
 	}
 
 	return q
 }
+
+func deleteByKey(table dynamo.Table, key KeyInterface) *dynamo.Delete {
+	// by hash
+	delete := table.Delete(*key.HashKeyName(), key.HashKey())
+
+	// by range
+	if key.RangeKeyName() != nil && key.RangeKey() != nil {
+		delete = delete.Range(*key.RangeKeyName(), key.RangeKey())
+	}
+
+	return delete
+}
+
+// RangeValues returns the values the range key of query is compared with; Between takes its lower and upper bound as
+// slice or array of two values, like []string{"2024-01", "2024-03"}
+func RangeValues(query QueryInterface) []any {
+	value := reflect.ValueOf(query.RangeKey())
+	if query.RangeOp() == Between && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) &&
+		value.Len() == 2 && value.Type().Elem().Kind() != reflect.Uint8 {
+		return []any{value.Index(0).Interface(), value.Index(1).Interface()}
+	}
+	return []any{query.RangeKey()}
+}
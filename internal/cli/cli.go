@@ -0,0 +1,226 @@
+// Package cli holds the flags and helpers shared by the commands in cmd
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// Connection holds the flags of the DynamoDB client; region and credentials are taken from the environment and the
+// shared config, like the AWS CLI
+type Connection struct {
+	Region   string
+	Endpoint string
+}
+
+// Register adds the flags -region and -endpoint to flags
+func (c *Connection) Register(flags *flag.FlagSet) {
+	flags.StringVar(&c.Region, "region", "", "AWS region; defaults to the region of the environment")
+	flags.StringVar(&c.Endpoint, "endpoint", "", "DynamoDB endpoint, like http://localhost:8000 for DynamoDB Local")
+}
+
+// Client returns a DynamoDB client for the flags
+func (c *Connection) Client() (dynamodbiface.DynamoDBAPI, error) {
+	config := aws.NewConfig()
+	if c.Region != "" {
+		config = config.WithRegion(c.Region)
+	}
+	if c.Endpoint != "" {
+		config = config.WithEndpoint(c.Endpoint)
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{Config: *config, SharedConfigState: session.SharedConfigEnable})
+	if err != nil {
+		return nil, err
+	}
+	return dynamodb.New(sess), nil
+}
+
+// KeyFlags holds the flags of the key of an item or a query
+type KeyFlags struct {
+	HashKey  string
+	RangeKey string
+	// RangeKeyTo is the upper bound of the range key of Between; RangeKey is the lower bound
+	RangeKeyTo string
+	RangeOp    string
+	Descending bool
+}
+
+// rangeOperators are the operators of key conditions on the range key
+var rangeOperators = map[djoemo.Operator]bool{
+	djoemo.Equal:          true,
+	djoemo.Less:           true,
+	djoemo.LessOrEqual:    true,
+	djoemo.Greater:        true,
+	djoemo.GreaterOrEqual: true,
+	djoemo.BeginsWith:     true,
+	djoemo.Between:        true,
+}
+
+// Register adds the flags -hash-key and -range-key to flags; queries add -range-op, -range-key-to and -descending
+// as well
+func (k *KeyFlags) Register(flags *flag.FlagSet, query bool) {
+	flags.StringVar(&k.HashKey, "hash-key", "", "value of the hash key")
+	flags.StringVar(&k.RangeKey, "range-key", "", "value of the range key")
+	if query {
+		k.RangeOp = string(djoemo.Equal)
+		flags.Func("range-op", "comparison of the range key: EQ, LT, LE, GT, GE, BEGINS_WITH or BETWEEN (default EQ)", func(value string) error {
+			if !rangeOperators[djoemo.Operator(value)] {
+				return fmt.Errorf("unknown range operator %q", value)
+			}
+			k.RangeOp = value
+			return nil
+		})
+		flags.StringVar(&k.RangeKeyTo, "range-key-to", "", "upper bound of the range key of -range-op BETWEEN")
+		flags.BoolVar(&k.Descending, "descending", false, "query items in descending order of their range key")
+	}
+}
+
+// Key returns the key of an item of a table; the values of the flags are converted to the types of the keys
+func (k *KeyFlags) Key(schema djoemo.TableSchema) (djoemo.KeyInterface, error) {
+	if k.HashKey == "" {
+		return nil, fmt.Errorf("%w: -hash-key is required", djoemo.ErrInvalidHashKeyValue)
+	}
+	hashKey, err := KeyValue(k.HashKey, schema.AttributeTypes[schema.HashKey])
+	if err != nil {
+		return nil, fmt.Errorf("hash key %s: %w", schema.HashKey, err)
+	}
+	key := djoemo.Key().WithTableName(schema.Name).WithHashKeyName(schema.HashKey).WithHashKey(hashKey)
+	if schema.RangeKey == "" {
+		if k.RangeKey != "" {
+			return nil, fmt.Errorf("%w: %s has no range key", djoemo.ErrInvalidRangeKeyName, schema.Name)
+		}
+		return key, nil
+	}
+	if k.RangeKey == "" {
+		return nil, fmt.Errorf("%w: -range-key is required for %s", djoemo.ErrInvalidRangeKeyValue, schema.Name)
+	}
+	rangeKey, err := KeyValue(k.RangeKey, schema.AttributeTypes[schema.RangeKey])
+	if err != nil {
+		return nil, fmt.Errorf("range key %s: %w", schema.RangeKey, err)
+	}
+	return key.WithRangeKeyName(schema.RangeKey).WithRangeKey(rangeKey), nil
+}
+
+// Query returns a query on a table or, if indexName is set, on one of its indexes; the range key is optional and a
+// limit of zero queries all items
+func (k *KeyFlags) Query(schema djoemo.TableSchema, indexName string, limit int64) (djoemo.QueryInterface, error) {
+	hashKeyName, rangeKeyName := schema.HashKey, schema.RangeKey
+	if indexName != "" {
+		index, ok := FindIndex(schema, indexName)
+		if !ok {
+			return nil, fmt.Errorf("%w: table %s has no index %s", djoemo.ErrInvalidIndexName, schema.Name, indexName)
+		}
+		hashKeyName, rangeKeyName = index.HashKey, index.RangeKey
+	}
+	if k.HashKey == "" {
+		return nil, fmt.Errorf("%w: -hash-key is required", djoemo.ErrInvalidHashKeyValue)
+	}
+
+	hashKey, err := KeyValue(k.HashKey, schema.AttributeTypes[hashKeyName])
+	if err != nil {
+		return nil, fmt.Errorf("hash key %s: %w", hashKeyName, err)
+	}
+	query := djoemo.Query().WithTableName(schema.Name).WithHashKeyName(hashKeyName).WithHashKey(hashKey)
+	if k.RangeKey != "" {
+		if rangeKeyName == "" {
+			return nil, fmt.Errorf("%w: %s has no range key", djoemo.ErrInvalidRangeKeyName, schema.Name)
+		}
+		rangeKey, err := KeyValue(k.RangeKey, schema.AttributeTypes[rangeKeyName])
+		if err != nil {
+			return nil, fmt.Errorf("range key %s: %w", rangeKeyName, err)
+		}
+		rangeOp := djoemo.Operator(k.RangeOp)
+		if rangeOp == "" {
+			rangeOp = djoemo.Equal
+		}
+		if rangeOp == djoemo.Between {
+			if k.RangeKeyTo == "" {
+				return nil, fmt.Errorf("%w: -range-key-to is required for BETWEEN", djoemo.ErrInvalidRangeKeyValue)
+			}
+			to, err := KeyValue(k.RangeKeyTo, schema.AttributeTypes[rangeKeyName])
+			if err != nil {
+				return nil, fmt.Errorf("range key %s: %w", rangeKeyName, err)
+			}
+			rangeKey = []any{rangeKey, to}
+		}
+		query = query.WithRangeKeyName(rangeKeyName).WithRangeKey(rangeKey).WithRangeOp(rangeOp)
+	}
+	if k.RangeKeyTo != "" && (k.RangeKey == "" || djoemo.Operator(k.RangeOp) != djoemo.Between) {
+		return nil, fmt.Errorf("%w: -range-key-to needs -range-key and -range-op BETWEEN", djoemo.ErrInvalidRangeKeyValue)
+	}
+	if k.Descending {
+		query = query.WithDescending()
+	}
+	if limit > 0 {
+		query = query.WithLimit(limit)
+	}
+	return query, nil
+}
+
+// FindIndex returns the global or local index name of schema
+func FindIndex(schema djoemo.TableSchema, name string) (djoemo.IndexSchema, bool) {
+	for _, indexes := range [][]djoemo.IndexSchema{schema.GlobalIndexes, schema.LocalIndexes} {
+		for _, index := range indexes {
+			if index.Name == name {
+				return index, true
+			}
+		}
+	}
+	return djoemo.IndexSchema{}, false
+}
+
+// KeyValue converts a flag to the type of a key attribute
+func KeyValue(value string, attributeType string) (any, error) {
+	switch attributeType {
+	case dynamodb.ScalarAttributeTypeN:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return number(value), nil
+	case dynamodb.ScalarAttributeTypeB:
+		return []byte(value), nil
+	}
+	return value, nil
+}
+
+// number keeps the digits of numbers, which float64 would round
+type number string
+
+// MarshalDynamo marshals the number as N
+func (n number) MarshalDynamo() (*dynamodb.AttributeValue, error) {
+	return &dynamodb.AttributeValue{N: aws.String(string(n))}, nil
+}
+
+// Create opens the file name for writing; - is stdout
+func Create(name string, stdout io.Writer) (io.Writer, func() error, error) {
+	if name == "-" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
+
+// Open opens the file name for reading; - is stdin
+func Open(name string, stdin io.Reader) (io.Reader, func() error, error) {
+	if name == "-" {
+		return stdin, func() error { return nil }, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, file.Close, nil
+}
@@ -0,0 +1,466 @@
+package expr
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Condition is a parsed condition, filter or key condition expression
+type Condition interface {
+	// Eval evaluates the condition against item
+	Eval(item Item) bool
+	// String formats the condition with resolved placeholders
+	String() string
+}
+
+// Operand is an operand of a condition: a path, a value or the size of a path
+type Operand interface {
+	// Resolve returns the value of the operand for item, nil if it does not exist
+	Resolve(item Item) *dynamodb.AttributeValue
+	String() string
+}
+
+// PathOperand is the value of the attribute at a path
+type PathOperand struct {
+	Path Path
+}
+
+// Resolve returns the value at the path
+func (o PathOperand) Resolve(item Item) *dynamodb.AttributeValue {
+	return o.Path.Get(item)
+}
+
+func (o PathOperand) String() string {
+	return o.Path.String()
+}
+
+// ValueOperand is an expression attribute value
+type ValueOperand struct {
+	Value *dynamodb.AttributeValue
+}
+
+// Resolve returns the value
+func (o ValueOperand) Resolve(item Item) *dynamodb.AttributeValue {
+	return o.Value
+}
+
+func (o ValueOperand) String() string {
+	return Format(o.Value)
+}
+
+// SizeOperand is the size of the attribute at a path
+type SizeOperand struct {
+	Path Path
+}
+
+// Resolve returns the length of strings and binaries or the number of elements of sets, lists and maps
+func (o SizeOperand) Resolve(item Item) *dynamodb.AttributeValue {
+	value := o.Path.Get(item)
+	size := -1
+	switch TypeOf(value) {
+	case TypeString:
+		size = len(*value.S)
+	case TypeBinary:
+		size = len(value.B)
+	case TypeStringSet, TypeNumberSet, TypeBinarySet:
+		size = len(SetElements(value))
+	case TypeList:
+		size = len(value.L)
+	case TypeMap:
+		size = len(value.M)
+	}
+	if size < 0 {
+		return nil
+	}
+	return &dynamodb.AttributeValue{N: aws.String(strconv.Itoa(size))}
+}
+
+func (o SizeOperand) String() string {
+	return "size(" + o.Path.String() + ")"
+}
+
+// Comparison compares two operands with =, <>, <, <=, > or >=
+type Comparison struct {
+	Operator string
+	Left     Operand
+	Right    Operand
+}
+
+// Eval compares the operands; missing attributes and values of different types are only unequal
+func (c Comparison) Eval(item Item) bool {
+	left, right := c.Left.Resolve(item), c.Right.Resolve(item)
+	switch c.Operator {
+	case "=":
+		return left != nil && right != nil && Equal(left, right)
+	case "<>":
+		return left == nil || right == nil || !Equal(left, right)
+	}
+
+	result, ok := Compare(left, right)
+	if !ok {
+		return false
+	}
+	switch c.Operator {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return false
+}
+
+func (c Comparison) String() string {
+	return c.Left.String() + " " + c.Operator + " " + c.Right.String()
+}
+
+// Between checks if an operand is between two operands, inclusively
+type Between struct {
+	Operand Operand
+	Low     Operand
+	High    Operand
+}
+
+// Eval checks the bounds
+func (c Between) Eval(item Item) bool {
+	value := c.Operand.Resolve(item)
+	low, okLow := Compare(value, c.Low.Resolve(item))
+	high, okHigh := Compare(value, c.High.Resolve(item))
+	return okLow && okHigh && low >= 0 && high <= 0
+}
+
+func (c Between) String() string {
+	return c.Operand.String() + " BETWEEN " + c.Low.String() + " AND " + c.High.String()
+}
+
+// In checks if an operand equals one of a list of operands
+type In struct {
+	Operand Operand
+	List    []Operand
+}
+
+// Eval compares the operand with the list
+func (c In) Eval(item Item) bool {
+	value := c.Operand.Resolve(item)
+	if value == nil {
+		return false
+	}
+	for _, operand := range c.List {
+		if Equal(value, operand.Resolve(item)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c In) String() string {
+	list := make([]string, len(c.List))
+	for i, operand := range c.List {
+		list[i] = operand.String()
+	}
+	return c.Operand.String() + " IN (" + strings.Join(list, ", ") + ")"
+}
+
+// Functions of conditions
+const (
+	FunctionAttributeExists    = "attribute_exists"
+	FunctionAttributeNotExists = "attribute_not_exists"
+	FunctionAttributeType      = "attribute_type"
+	FunctionBeginsWith         = "begins_with"
+	FunctionContains           = "contains"
+)
+
+// Function is a function of a condition on a path and an optional operand
+type Function struct {
+	Name    string
+	Path    Path
+	Operand Operand
+}
+
+// Eval evaluates the function
+func (c Function) Eval(item Item) bool {
+	value := c.Path.Get(item)
+	var operand *dynamodb.AttributeValue
+	if c.Operand != nil {
+		operand = c.Operand.Resolve(item)
+	}
+
+	switch c.Name {
+	case FunctionAttributeExists:
+		return value != nil
+	case FunctionAttributeNotExists:
+		return value == nil
+	case FunctionAttributeType:
+		return value != nil && operand != nil && operand.S != nil && TypeOf(value) == *operand.S
+	case FunctionBeginsWith:
+		switch {
+		case value == nil || operand == nil:
+			return false
+		case value.S != nil && operand.S != nil:
+			return strings.HasPrefix(*value.S, *operand.S)
+		case value.B != nil && operand.B != nil:
+			return bytes.HasPrefix(value.B, operand.B)
+		}
+	case FunctionContains:
+		switch TypeOf(value) {
+		case TypeString:
+			return operand != nil && operand.S != nil && strings.Contains(*value.S, *operand.S)
+		case TypeBinary:
+			return operand != nil && operand.B != nil && bytes.Contains(value.B, operand.B)
+		case TypeStringSet, TypeNumberSet, TypeBinarySet:
+			return containsEqual(SetElements(value), operand)
+		case TypeList:
+			return containsEqual(value.L, operand)
+		}
+	}
+	return false
+}
+
+func (c Function) String() string {
+	if c.Operand == nil {
+		return c.Name + "(" + c.Path.String() + ")"
+	}
+	return c.Name + "(" + c.Path.String() + ", " + c.Operand.String() + ")"
+}
+
+// And is the conjunction of two conditions
+type And struct {
+	Left  Condition
+	Right Condition
+}
+
+// Eval evaluates both conditions
+func (c And) Eval(item Item) bool {
+	return c.Left.Eval(item) && c.Right.Eval(item)
+}
+
+func (c And) String() string {
+	return group(c.Left, c) + " AND " + group(c.Right, c)
+}
+
+// Or is the disjunction of two conditions
+type Or struct {
+	Left  Condition
+	Right Condition
+}
+
+// Eval evaluates either condition
+func (c Or) Eval(item Item) bool {
+	return c.Left.Eval(item) || c.Right.Eval(item)
+}
+
+func (c Or) String() string {
+	return group(c.Left, c) + " OR " + group(c.Right, c)
+}
+
+// Not negates a condition
+type Not struct {
+	Condition Condition
+}
+
+// Eval negates the condition
+func (c Not) Eval(item Item) bool {
+	return !c.Condition.Eval(item)
+}
+
+func (c Not) String() string {
+	return "NOT " + group(c.Condition, c)
+}
+
+// group wraps condition in parentheses if it is a conjunction or disjunction of a different kind than parent
+func group(condition Condition, parent Condition) string {
+	_, isAnd := condition.(And)
+	_, isOr := condition.(Or)
+	_, parentAnd := parent.(And)
+	_, parentOr := parent.(Or)
+	if isAnd && !parentAnd || isOr && !parentOr {
+		return "(" + condition.String() + ")"
+	}
+	return condition.String()
+}
+
+// Conjuncts returns the conditions of a conjunction, or condition itself
+func Conjuncts(condition Condition) []Condition {
+	if and, ok := condition.(And); ok {
+		return append(Conjuncts(and.Left), Conjuncts(and.Right)...)
+	}
+	return []Condition{condition}
+}
+
+// Condition parses a condition, filter or key condition expression
+func (s *Scope) Condition(expression string) (Condition, error) {
+	p, err := s.parser(expression)
+	if err != nil {
+		return nil, err
+	}
+	condition, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+	return condition, nil
+}
+
+func (p *parser) or() (Condition, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Condition, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (Condition, error) {
+	if p.accept("NOT") {
+		condition, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Condition: condition}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (Condition, error) {
+	if p.accept("(") {
+		condition, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return condition, p.expect(")")
+	}
+
+	t := p.peek()
+	if t.kind == tokenIdentifier && p.peekAt(1).is("(") && !strings.EqualFold(t.text, "size") {
+		return p.function()
+	}
+
+	operand, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := p.next(); {
+	case t.is("=") || t.is("<>") || t.is("<") || t.is("<=") || t.is(">") || t.is(">="):
+		right, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return Comparison{Operator: t.text, Left: operand, Right: right}, nil
+	case t.is("BETWEEN"):
+		low, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return Between{Operand: operand, Low: low, High: high}, nil
+	case t.is("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var list []Operand
+		for {
+			element, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, element)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return In{Operand: operand, List: list}, p.expect(")")
+	default:
+		p.pos--
+		return nil, p.unexpected()
+	}
+}
+
+func (p *parser) function() (Condition, error) {
+	name := strings.ToLower(p.next().text)
+	p.next()
+
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	function := Function{Name: name, Path: path}
+
+	switch name {
+	case FunctionAttributeExists, FunctionAttributeNotExists:
+	case FunctionAttributeType, FunctionBeginsWith, FunctionContains:
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		if function.Operand, err = p.operand(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid function name; function: %s", name)
+	}
+	return function, p.expect(")")
+}
+
+func (p *parser) operand() (Operand, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenValue:
+		p.next()
+		value, err := p.scope.value(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return ValueOperand{Value: value}, nil
+	case t.kind == tokenIdentifier && strings.EqualFold(t.text, "size") && p.peekAt(1).is("("):
+		p.next()
+		p.next()
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		return SizeOperand{Path: path}, p.expect(")")
+	}
+
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return PathOperand{Path: path}, nil
+}
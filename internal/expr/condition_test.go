@@ -0,0 +1,101 @@
+package expr_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Condition", func() {
+	item := expr.Item{
+		"UUID":   {S: aws.String("uuid")},
+		"Amount": {N: aws.String("10")},
+		"Tags":   {SS: aws.StringSlice([]string{"a", "b"})},
+		"Status": {S: aws.String("active")},
+		"Meta":   {M: map[string]*dynamodb.AttributeValue{"Name": {S: aws.String("name")}}},
+		"List":   {L: []*dynamodb.AttributeValue{{N: aws.String("1")}, {S: aws.String("two")}}},
+	}
+	names := map[string]*string{"#status": aws.String("Status"), "#name": aws.String("Name")}
+	values := map[string]*dynamodb.AttributeValue{
+		":count":  {N: aws.String("10.0")},
+		":low":    {N: aws.String("5")},
+		":high":   {N: aws.String("20")},
+		":active": {S: aws.String("active")},
+		":tag":    {S: aws.String("a")},
+		":prefix": {S: aws.String("na")},
+		":type":   {S: aws.String("SS")},
+	}
+
+	DescribeTable("should evaluate expressions against an item",
+		func(expression string, expected bool) {
+			condition, err := expr.NewScope(names, values).Condition(expression)
+			Expect(err).To(BeNil())
+			Expect(condition.Eval(item)).To(Equal(expected))
+		},
+		Entry("numbers by value", "Amount = :count", true),
+		Entry("missing attributes are not equal", "Missing = :count", false),
+		Entry("missing attributes are unequal", "Missing <> :count", true),
+		Entry("between", "Amount BETWEEN :low AND :high", true),
+		Entry("in", "#status IN (:tag, :active)", true),
+		Entry("nested paths", "begins_with(Meta.#name, :prefix)", true),
+		Entry("list elements", "List[1] = :tag OR List[0] < :low", true),
+		Entry("set contains", "contains(Tags, :tag)", true),
+		Entry("attribute type", "attribute_type(Tags, :type)", true),
+		Entry("size", "size(Tags) < :low", true),
+		Entry("precedence of AND over OR", "attribute_exists(Missing) AND Amount = :low OR #status = :active", true),
+		Entry("parentheses", "attribute_exists(Missing) AND (Amount = :low OR #status = :active)", false),
+		Entry("not", "NOT attribute_not_exists(UUID)", true),
+	)
+
+	It("should format conditions with resolved placeholders", func() {
+		condition, err := expr.NewScope(names, values).Condition("(#status = :active AND Amount > :low) OR NOT contains(Tags, :tag)")
+		Expect(err).To(BeNil())
+		Expect(condition.String()).To(Equal(`(Status = "active" AND Amount > 5) OR NOT contains(Tags, "a")`))
+	})
+
+	It("should sort the conjuncts of normalized conditions", func() {
+		condition, err := expr.NewScope(names, values).Condition("Amount > :low AND (#status = :active OR Amount = :low) AND attribute_exists(UUID)")
+		Expect(err).To(BeNil())
+		Expect(expr.Normalize(condition).String()).To(Equal(`Amount > 5 AND (Status = "active" OR Amount = 5) AND attribute_exists(UUID)`))
+	})
+
+	It("should fail for reserved words and undefined placeholders", func() {
+		_, err := expr.NewScope(nil, values).Condition("Status = :active")
+		Expect(err).To(MatchError(ContainSubstring("reserved keyword")))
+
+		_, err = expr.NewScope(names, nil).Condition("#status = :missing")
+		Expect(err).To(MatchError(ContainSubstring(":missing")))
+	})
+
+	It("should accept reserved words as names if allowed", func() {
+		condition, err := expr.NewScope(nil, values).AllowReserved().Condition("Status = :active")
+		Expect(err).To(BeNil())
+		Expect(condition.String()).To(Equal(`Status = "active"`))
+
+		_, err = expr.NewScope(nil, values).AllowReserved().Condition("And = :active")
+		Expect(err).To(MatchError(ContainSubstring("reserved keyword")))
+	})
+
+	It("should report unused placeholders", func() {
+		scope := expr.NewScope(names, values)
+		_, err := scope.Condition("#status = :active")
+		Expect(err).To(BeNil())
+		Expect(scope.Unused()).To(MatchError(ContainSubstring("#name, :count")))
+	})
+
+	It("should convert legacy conditions", func() {
+		condition, err := expr.FromConditions(map[string]*dynamodb.Condition{
+			"UUID": {ComparisonOperator: aws.String(dynamodb.ComparisonOperatorEq), AttributeValueList: []*dynamodb.AttributeValue{{S: aws.String("uuid")}}},
+			"Amount": {ComparisonOperator: aws.String(dynamodb.ComparisonOperatorBetween), AttributeValueList: []*dynamodb.AttributeValue{
+				{N: aws.String("1")}, {N: aws.String("9")},
+			}},
+		}, nil)
+		Expect(err).To(BeNil())
+		Expect(condition.String()).To(Equal(`Amount BETWEEN 1 AND 9 AND UUID = "uuid"`))
+		Expect(condition.Eval(item)).To(BeFalse())
+	})
+})
@@ -0,0 +1,150 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// FromConditions converts the legacy KeyConditions, QueryFilter or ScanFilter parameter of a request into a
+// condition; conditions are joined by operator, AND by default, in the order of their attribute names
+func FromConditions(conditions map[string]*dynamodb.Condition, operator *string) (Condition, error) {
+	names := make([]string, 0, len(conditions))
+	for name := range conditions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result Condition
+	for _, name := range names {
+		condition := conditions[name]
+		if condition == nil {
+			return nil, fmt.Errorf("condition for attribute %s is empty", name)
+		}
+		converted, err := fromCondition(Attribute(name), aws.StringValue(condition.ComparisonOperator), condition.AttributeValueList)
+		if err != nil {
+			return nil, err
+		}
+		result = join(result, converted, operator)
+	}
+	return result, nil
+}
+
+// FromExpected converts the legacy Expected parameter of a request into a condition
+func FromExpected(expected map[string]*dynamodb.ExpectedAttributeValue, operator *string) (Condition, error) {
+	names := make([]string, 0, len(expected))
+	for name := range expected {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result Condition
+	for _, name := range names {
+		value := expected[name]
+		if value == nil {
+			return nil, fmt.Errorf("expected value for attribute %s is empty", name)
+		}
+
+		var converted Condition
+		var err error
+		switch {
+		case value.ComparisonOperator != nil:
+			list := value.AttributeValueList
+			if value.Value != nil {
+				list = []*dynamodb.AttributeValue{value.Value}
+			}
+			converted, err = fromCondition(Attribute(name), *value.ComparisonOperator, list)
+		case value.Exists != nil && !*value.Exists:
+			converted = Function{Name: FunctionAttributeNotExists, Path: Attribute(name)}
+		case value.Value != nil:
+			converted = Comparison{Operator: "=", Left: PathOperand{Attribute(name)}, Right: ValueOperand{value.Value}}
+		default:
+			err = fmt.Errorf("expected value for attribute %s needs a value or Exists set to false", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = join(result, converted, operator)
+	}
+	return result, nil
+}
+
+func join(left Condition, right Condition, operator *string) Condition {
+	switch {
+	case left == nil:
+		return right
+	case aws.StringValue(operator) == dynamodb.ConditionalOperatorOr:
+		return Or{Left: left, Right: right}
+	}
+	return And{Left: left, Right: right}
+}
+
+// fromCondition converts a legacy comparison operator and its values
+func fromCondition(path Path, operator string, values []*dynamodb.AttributeValue) (Condition, error) {
+	arguments := map[string]int{
+		dynamodb.ComparisonOperatorEq:          1,
+		dynamodb.ComparisonOperatorNe:          1,
+		dynamodb.ComparisonOperatorLt:          1,
+		dynamodb.ComparisonOperatorLe:          1,
+		dynamodb.ComparisonOperatorGt:          1,
+		dynamodb.ComparisonOperatorGe:          1,
+		dynamodb.ComparisonOperatorNull:        0,
+		dynamodb.ComparisonOperatorNotNull:     0,
+		dynamodb.ComparisonOperatorContains:    1,
+		dynamodb.ComparisonOperatorNotContains: 1,
+		dynamodb.ComparisonOperatorBeginsWith:  1,
+		dynamodb.ComparisonOperatorBetween:     2,
+	}
+	expected, ok := arguments[operator]
+	switch {
+	case operator == dynamodb.ComparisonOperatorIn:
+		if len(values) == 0 {
+			return nil, fmt.Errorf("invalid number of arguments for comparison operator %s on attribute %s", operator, path)
+		}
+	case !ok:
+		return nil, fmt.Errorf("unsupported comparison operator %q on attribute %s", operator, path)
+	case len(values) != expected:
+		return nil, fmt.Errorf("invalid number of arguments for comparison operator %s on attribute %s", operator, path)
+	}
+	for _, value := range values {
+		if TypeOf(value) == "" {
+			return nil, fmt.Errorf("attribute value of comparison operator %s on attribute %s is empty", operator, path)
+		}
+	}
+
+	operand := PathOperand{Path: path}
+	switch operator {
+	case dynamodb.ComparisonOperatorEq:
+		return Comparison{Operator: "=", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorNe:
+		return Comparison{Operator: "<>", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorLt:
+		return Comparison{Operator: "<", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorLe:
+		return Comparison{Operator: "<=", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorGt:
+		return Comparison{Operator: ">", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorGe:
+		return Comparison{Operator: ">=", Left: operand, Right: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorNull:
+		return Function{Name: FunctionAttributeNotExists, Path: path}, nil
+	case dynamodb.ComparisonOperatorNotNull:
+		return Function{Name: FunctionAttributeExists, Path: path}, nil
+	case dynamodb.ComparisonOperatorContains:
+		return Function{Name: FunctionContains, Path: path, Operand: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorNotContains:
+		return Not{Condition: Function{Name: FunctionContains, Path: path, Operand: ValueOperand{values[0]}}}, nil
+	case dynamodb.ComparisonOperatorBeginsWith:
+		return Function{Name: FunctionBeginsWith, Path: path, Operand: ValueOperand{values[0]}}, nil
+	case dynamodb.ComparisonOperatorBetween:
+		return Between{Operand: operand, Low: ValueOperand{values[0]}, High: ValueOperand{values[1]}}, nil
+	}
+
+	list := make([]Operand, len(values))
+	for i, value := range values {
+		list[i] = ValueOperand{value}
+	}
+	return In{Operand: operand, List: list}, nil
+}
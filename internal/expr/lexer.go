@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdentifier
+	tokenName
+	tokenValue
+	tokenNumber
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// is reports if the token is the punctuation or the case insensitive keyword text
+func (t token) is(text string) bool {
+	switch t.kind {
+	case tokenPunct:
+		return t.text == text
+	case tokenIdentifier:
+		return strings.EqualFold(t.text, text)
+	}
+	return false
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// tokenize splits an expression into tokens
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '#' || r == ':':
+			start := i
+			i++
+			for i < len(runes) && isIdentifierRune(runes[i]) {
+				i++
+			}
+			if i == start+1 {
+				return nil, fmt.Errorf("invalid placeholder at position %d", start)
+			}
+			kind := tokenName
+			if r == ':' {
+				kind = tokenValue
+			}
+			tokens = append(tokens, token{kind: kind, text: string(runes[start:i]), pos: start})
+		case r >= '0' && r <= '9':
+			start := i
+			for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case isIdentifierRune(r):
+			start := i
+			for i < len(runes) && isIdentifierRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdentifier, text: string(runes[start:i]), pos: start})
+		case r == '<' || r == '>':
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || r == '<' && runes[i] == '>') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenPunct, text: string(runes[start:i]), pos: start})
+		case strings.ContainsRune("()[],.=+-", r):
+			tokens = append(tokens, token{kind: tokenPunct, text: string(r), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("invalid character %q at position %d", r, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
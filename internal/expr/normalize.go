@@ -0,0 +1,33 @@
+package expr
+
+import "sort"
+
+// Normalize returns the condition with its conjuncts sorted by their formatted form, so conditions that only
+// differ in the order of their AND terms format the same
+func Normalize(condition Condition) Condition {
+	conjuncts := Conjuncts(condition)
+	sort.SliceStable(conjuncts, func(i, j int) bool {
+		return conjuncts[i].String() < conjuncts[j].String()
+	})
+	normalized := conjuncts[0]
+	for _, conjunct := range conjuncts[1:] {
+		normalized = And{Left: normalized, Right: conjunct}
+	}
+	return normalized
+}
+
+// Sort sorts the actions of each clause of the update by their path; paths of an update never overlap, so the
+// result does not depend on the order of the actions in the expression
+func (u *Update) Sort() {
+	sort.Slice(u.Set, func(i, j int) bool {
+		return u.Set[i].Path.String() < u.Set[j].Path.String()
+	})
+	sort.Slice(u.Remove, func(i, j int) bool {
+		return u.Remove[i].String() < u.Remove[j].String()
+	})
+	for _, actions := range [][]ValueAction{u.Add, u.Delete} {
+		sort.Slice(actions, func(i, j int) bool {
+			return actions[i].Path.String() < actions[j].Path.String()
+		})
+	}
+}
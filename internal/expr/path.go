@@ -0,0 +1,152 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// PathElement is an attribute name or a list index of a document path
+type PathElement struct {
+	Name    string
+	Index   int
+	IsIndex bool
+}
+
+// Path is a document path like a.b[1].c with resolved attribute name placeholders
+type Path []PathElement
+
+// Attribute returns the path of a top level attribute
+func Attribute(name string) Path {
+	return Path{{Name: name}}
+}
+
+// String formats the path; names that are not plain identifiers are quoted
+func (p Path) String() string {
+	var b strings.Builder
+	for i, element := range p {
+		switch {
+		case element.IsIndex:
+			b.WriteString("[" + strconv.Itoa(element.Index) + "]")
+		case isIdentifier(element.Name):
+			if i > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(element.Name)
+		default:
+			if i > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(strconv.Quote(element.Name))
+		}
+	}
+	return b.String()
+}
+
+// Equal reports if p and other refer to the same attribute
+func (p Path) Equal(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the value at the path, nil if it does not exist
+func (p Path) Get(item Item) *dynamodb.AttributeValue {
+	if len(p) == 0 || p[0].IsIndex {
+		return nil
+	}
+	value := item[p[0].Name]
+	for _, element := range p[1:] {
+		value = child(value, element)
+		if value == nil {
+			return nil
+		}
+	}
+	return value
+}
+
+func child(value *dynamodb.AttributeValue, element PathElement) *dynamodb.AttributeValue {
+	if value == nil {
+		return nil
+	}
+	if element.IsIndex {
+		if value.L == nil || element.Index >= len(value.L) {
+			return nil
+		}
+		return value.L[element.Index]
+	}
+	if value.M == nil {
+		return nil
+	}
+	return value.M[element.Name]
+}
+
+// Set sets the value at the path; the parent of the path must exist. Indexes beyond the end of a list append to it
+func (p Path) Set(item Item, value *dynamodb.AttributeValue) error {
+	if len(p) == 0 || p[0].IsIndex {
+		return fmt.Errorf("invalid document path %s", p)
+	}
+	if len(p) == 1 {
+		item[p[0].Name] = value
+		return nil
+	}
+
+	parent := p[:len(p)-1].Get(item)
+	last := p[len(p)-1]
+	switch {
+	case last.IsIndex && parent != nil && parent.L != nil:
+		if last.Index >= len(parent.L) {
+			parent.L = append(parent.L, value)
+		} else {
+			parent.L[last.Index] = value
+		}
+		return nil
+	case !last.IsIndex && parent != nil && parent.M != nil:
+		parent.M[last.Name] = value
+		return nil
+	}
+	return fmt.Errorf("the document path %s provided in the update expression is invalid for update", p)
+}
+
+// Remove removes the attribute at the path; removing a list element shifts the following elements
+func (p Path) Remove(item Item) {
+	if len(p) == 0 || p[0].IsIndex {
+		return
+	}
+	if len(p) == 1 {
+		delete(item, p[0].Name)
+		return
+	}
+
+	parent := p[:len(p)-1].Get(item)
+	last := p[len(p)-1]
+	switch {
+	case last.IsIndex && parent != nil && parent.L != nil:
+		if last.Index < len(parent.L) {
+			parent.L = append(parent.L[:last.Index], parent.L[last.Index+1:]...)
+		}
+	case !last.IsIndex && parent != nil && parent.M != nil:
+		delete(parent.M, last.Name)
+	}
+}
+
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		letter := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+		if !letter && (i == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package expr
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Projection parses a projection expression
+func (s *Scope) Projection(expression string) ([]Path, error) {
+	p, err := s.parser(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []Path
+	err = p.list(func() error {
+		path, err := p.path()
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := p.end(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// FormatProjection formats projected paths with resolved placeholders
+func FormatProjection(paths []Path) string {
+	formatted := make([]string, len(paths))
+	for i, path := range paths {
+		formatted[i] = path.String()
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// projection is a tree of projected paths
+type projection struct {
+	all     bool
+	names   map[string]*projection
+	indexes map[int]*projection
+}
+
+func (p *projection) add(path Path) {
+	node := p
+	for _, element := range path {
+		if node.all {
+			return
+		}
+		var next *projection
+		if element.IsIndex {
+			if node.indexes == nil {
+				node.indexes = make(map[int]*projection)
+			}
+			if next = node.indexes[element.Index]; next == nil {
+				next = &projection{}
+				node.indexes[element.Index] = next
+			}
+		} else {
+			if node.names == nil {
+				node.names = make(map[string]*projection)
+			}
+			if next = node.names[element.Name]; next == nil {
+				next = &projection{}
+				node.names[element.Name] = next
+			}
+		}
+		node = next
+	}
+	node.all, node.names, node.indexes = true, nil, nil
+}
+
+func (p *projection) apply(value *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if p.all {
+		return Copy(value)
+	}
+	switch {
+	case value.M != nil && p.names != nil:
+		m := make(map[string]*dynamodb.AttributeValue)
+		for name, node := range p.names {
+			if child, ok := value.M[name]; ok {
+				if projected := node.apply(child); projected != nil {
+					m[name] = projected
+				}
+			}
+		}
+		if len(m) == 0 {
+			return nil
+		}
+		return &dynamodb.AttributeValue{M: m}
+	case value.L != nil && p.indexes != nil:
+		indexes := make([]int, 0, len(p.indexes))
+		for index := range p.indexes {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+		var l []*dynamodb.AttributeValue
+		for _, index := range indexes {
+			if index < len(value.L) {
+				if projected := p.indexes[index].apply(value.L[index]); projected != nil {
+					l = append(l, projected)
+				}
+			}
+		}
+		if l == nil {
+			return nil
+		}
+		return &dynamodb.AttributeValue{L: l}
+	}
+	return nil
+}
+
+// Project returns a copy of item with only the attributes at paths; projected list elements are compacted
+// in the order of their indexes
+func Project(item Item, paths []Path) Item {
+	root := &projection{}
+	for _, path := range paths {
+		root.add(path)
+	}
+	projected := root.apply(&dynamodb.AttributeValue{M: item})
+	if projected == nil {
+		return make(Item)
+	}
+	return projected.M
+}
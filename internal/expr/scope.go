@@ -0,0 +1,202 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Scope resolves the placeholders of the expressions of one request and tracks which of them are used
+type Scope struct {
+	names      map[string]*string
+	values     map[string]*dynamodb.AttributeValue
+	usedNames  map[string]bool
+	usedValues map[string]bool
+	// allowReserved accepts reserved words other than the keywords of the grammar as attribute names
+	allowReserved bool
+}
+
+// NewScope creates a scope for the expression attribute names and values of a request
+func NewScope(names map[string]*string, values map[string]*dynamodb.AttributeValue) *Scope {
+	return &Scope{
+		names:      names,
+		values:     values,
+		usedNames:  make(map[string]bool),
+		usedValues: make(map[string]bool),
+	}
+}
+
+// Unused returns an error if a name or value of the scope is not used by the parsed expressions
+func (s *Scope) Unused() error {
+	var unused []string
+	for placeholder := range s.names {
+		if !s.usedNames[placeholder] {
+			unused = append(unused, placeholder)
+		}
+	}
+	for placeholder := range s.values {
+		if !s.usedValues[placeholder] {
+			unused = append(unused, placeholder)
+		}
+	}
+	if len(unused) == 0 {
+		return nil
+	}
+	sort.Strings(unused)
+	return fmt.Errorf("value provided in expression attribute names or values unused in expressions: %s", strings.Join(unused, ", "))
+}
+
+// AllowReserved accepts reserved words as attribute names, like expressions written by hand for assertions
+func (s *Scope) AllowReserved() *Scope {
+	s.allowReserved = true
+	return s
+}
+
+func (s *Scope) name(placeholder string) (string, error) {
+	name, ok := s.names[placeholder]
+	if !ok || name == nil {
+		return "", fmt.Errorf("an expression attribute name used in the document path is not defined; attribute name: %s", placeholder)
+	}
+	s.usedNames[placeholder] = true
+	return aws.StringValue(name), nil
+}
+
+func (s *Scope) value(placeholder string) (*dynamodb.AttributeValue, error) {
+	value, ok := s.values[placeholder]
+	if !ok || value == nil {
+		return nil, fmt.Errorf("an expression attribute value used in expression is not defined; attribute value: %s", placeholder)
+	}
+	if TypeOf(value) == "" {
+		return nil, fmt.Errorf("expression attribute value %s is empty", placeholder)
+	}
+	s.usedValues[placeholder] = true
+	return value, nil
+}
+
+// parser parses the tokens of one expression
+type parser struct {
+	scope  *Scope
+	tokens []token
+	pos    int
+}
+
+func (s *Scope) parser(expression string) (*parser, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{scope: s, tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) token {
+	if p.pos+offset >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(text string) bool {
+	if p.peek().is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	return fmt.Errorf("syntax error; unexpected %s at position %d", t, t.pos)
+}
+
+func (p *parser) end() error {
+	if p.peek().kind != tokenEOF {
+		return p.unexpected()
+	}
+	return nil
+}
+
+// path parses a document path
+func (p *parser) path() (Path, error) {
+	var path Path
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokenIdentifier:
+			word := strings.ToUpper(t.text)
+			if reserved[word] && (!p.scope.allowReserved || keywords[word]) {
+				return nil, fmt.Errorf("attribute name is a reserved keyword; reserved keyword: %s", t.text)
+			}
+			path = append(path, PathElement{Name: t.text})
+		case tokenName:
+			name, err := p.scope.name(t.text)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, PathElement{Name: name})
+		default:
+			p.pos--
+			return nil, p.unexpected()
+		}
+
+		for p.accept("[") {
+			t := p.next()
+			if t.kind != tokenNumber {
+				p.pos--
+				return nil, p.unexpected()
+			}
+			index, err := strconv.Atoi(t.text)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, PathElement{Index: index, IsIndex: true})
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		}
+
+		if !p.accept(".") {
+			return path, nil
+		}
+	}
+}
+
+// keywords lists the reserved words the grammar of the expressions depends on
+var keywords = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+	"SET": true, "REMOVE": true, "ADD": true, "DELETE": true,
+}
+
+// reserved lists the reserved words of DynamoDB used by expressions; names clashing with them need a placeholder
+var reserved = map[string]bool{
+	"AND": true, "OR": true, "NOT": true, "BETWEEN": true, "IN": true,
+	"SET": true, "REMOVE": true, "ADD": true, "DELETE": true,
+	"SIZE": true, "STRING": true, "NUMBER": true, "BINARY": true, "BOOLEAN": true, "NULL": true,
+	"NAME": true, "VALUE": true, "DATA": true, "DATE": true, "TIME": true, "TIMESTAMP": true, "USER": true, "STATUS": true,
+	"COUNT": true, "KEY": true, "TYPE": true, "TABLE": true, "INDEX": true, "ORDER": true, "GROUP": true, "LIMIT": true,
+	"SELECT": true, "FROM": true, "WHERE": true, "UPDATE": true, "VALUES": true, "YEAR": true, "MONTH": true, "DAY": true,
+	"HOUR": true, "MINUTE": true, "SECOND": true, "LEVEL": true, "SOURCE": true,
+	"COMMENT": true, "SESSION": true, "ROLE": true, "RANGE": true, "HASH": true,
+}
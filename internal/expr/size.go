@@ -0,0 +1,74 @@
+package expr
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Size approximates the size of an item the way DynamoDB calculates it: the length of the attribute names
+// plus the size of the values
+func Size(item Item) int {
+	size := 0
+	for name, value := range item {
+		size += len(name) + ValueSize(value)
+	}
+	return size
+}
+
+// ValueSize is the size of an attribute value
+func ValueSize(value *dynamodb.AttributeValue) int {
+	if value == nil {
+		return 0
+	}
+	switch {
+	case value.S != nil:
+		return len(*value.S)
+	case value.N != nil:
+		return numberSize(*value.N)
+	case value.B != nil:
+		return len(value.B)
+	case value.BOOL != nil, value.NULL != nil:
+		return 1
+	case value.SS != nil:
+		size := 0
+		for _, s := range value.SS {
+			size += len(aws.StringValue(s))
+		}
+		return size
+	case value.NS != nil:
+		size := 0
+		for _, n := range value.NS {
+			size += numberSize(aws.StringValue(n))
+		}
+		return size
+	case value.BS != nil:
+		size := 0
+		for _, b := range value.BS {
+			size += len(b)
+		}
+		return size
+	case value.L != nil:
+		// lists and maps take 3 bytes plus 1 byte per element
+		size := 3
+		for _, element := range value.L {
+			size += 1 + ValueSize(element)
+		}
+		return size
+	case value.M != nil:
+		size := 3
+		for name, element := range value.M {
+			size += 1 + len(name) + ValueSize(element)
+		}
+		return size
+	}
+	return 0
+}
+
+// numberSize is the size of a number: 1 byte per 2 significant digits plus 1 byte
+func numberSize(n string) int {
+	digits := strings.TrimLeft(strings.NewReplacer("-", "", ".", "").Replace(n), "0")
+	digits = strings.TrimRight(digits, "0")
+	return (len(digits)+1)/2 + 1
+}
@@ -0,0 +1,478 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Update is a parsed update expression
+type Update struct {
+	Set    []SetAction
+	Remove []Path
+	Add    []ValueAction
+	Delete []ValueAction
+}
+
+// SetAction sets the attribute at a path to a value
+type SetAction struct {
+	Path  Path
+	Value UpdateValue
+}
+
+// ValueAction adds a value to or deletes it from the attribute at a path
+type ValueAction struct {
+	Path  Path
+	Value *dynamodb.AttributeValue
+}
+
+// UpdateValue is the value of a SET action: an operand, an arithmetic expression or a function
+type UpdateValue interface {
+	// Evaluate returns the value for item; it fails for paths that do not exist
+	Evaluate(item Item) (*dynamodb.AttributeValue, error)
+	String() string
+}
+
+// OperandValue is a path or value operand of a SET action
+type OperandValue struct {
+	Operand Operand
+}
+
+// Evaluate resolves the operand
+func (v OperandValue) Evaluate(item Item) (*dynamodb.AttributeValue, error) {
+	value := v.Operand.Resolve(item)
+	if value == nil {
+		return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item: %s", v.Operand)
+	}
+	return value, nil
+}
+
+func (v OperandValue) String() string {
+	return v.Operand.String()
+}
+
+// Arithmetic adds or subtracts two numbers
+type Arithmetic struct {
+	Operator string
+	Left     UpdateValue
+	Right    UpdateValue
+}
+
+// Evaluate computes the result
+func (v Arithmetic) Evaluate(item Item) (*dynamodb.AttributeValue, error) {
+	left, err := v.Left.Evaluate(item)
+	if err != nil {
+		return nil, err
+	}
+	right, err := v.Right.Evaluate(item)
+	if err != nil {
+		return nil, err
+	}
+	if left.N == nil || right.N == nil {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type: %s", v)
+	}
+	x, err := Number(*left.N)
+	if err != nil {
+		return nil, err
+	}
+	y, err := Number(*right.N)
+	if err != nil {
+		return nil, err
+	}
+	if v.Operator == "+" {
+		x.Add(x, y)
+	} else {
+		x.Sub(x, y)
+	}
+	n := FormatNumber(x)
+	return &dynamodb.AttributeValue{N: &n}, nil
+}
+
+func (v Arithmetic) String() string {
+	return v.Left.String() + " " + v.Operator + " " + v.Right.String()
+}
+
+// Functions of update expressions
+const (
+	FunctionIfNotExists = "if_not_exists"
+	FunctionListAppend  = "list_append"
+)
+
+// IfNotExists is the value at a path or a default if it does not exist
+type IfNotExists struct {
+	Path    Path
+	Default UpdateValue
+}
+
+// Evaluate returns the value at the path or the default
+func (v IfNotExists) Evaluate(item Item) (*dynamodb.AttributeValue, error) {
+	if value := v.Path.Get(item); value != nil {
+		return value, nil
+	}
+	return v.Default.Evaluate(item)
+}
+
+func (v IfNotExists) String() string {
+	return FunctionIfNotExists + "(" + v.Path.String() + ", " + v.Default.String() + ")"
+}
+
+// ListAppend concatenates two lists
+type ListAppend struct {
+	Left  UpdateValue
+	Right UpdateValue
+}
+
+// Evaluate concatenates the lists
+func (v ListAppend) Evaluate(item Item) (*dynamodb.AttributeValue, error) {
+	left, err := v.Left.Evaluate(item)
+	if err != nil {
+		return nil, err
+	}
+	right, err := v.Right.Evaluate(item)
+	if err != nil {
+		return nil, err
+	}
+	if left.L == nil || right.L == nil {
+		return nil, fmt.Errorf("an operand in the update expression has an incorrect data type: %s", v)
+	}
+	list := make([]*dynamodb.AttributeValue, 0, len(left.L)+len(right.L))
+	list = append(list, left.L...)
+	list = append(list, right.L...)
+	return &dynamodb.AttributeValue{L: list}, nil
+}
+
+func (v ListAppend) String() string {
+	return FunctionListAppend + "(" + v.Left.String() + ", " + v.Right.String() + ")"
+}
+
+// Apply returns a copy of item with the update applied; all values are evaluated against item before
+// any action is applied
+func (u *Update) Apply(item Item) (Item, error) {
+	values := make([]*dynamodb.AttributeValue, len(u.Set))
+	for i, action := range u.Set {
+		value, err := action.Value.Evaluate(item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = Copy(value)
+	}
+
+	updated := CopyItem(item)
+	if updated == nil {
+		updated = make(Item)
+	}
+	for i, action := range u.Set {
+		if err := action.Path.Set(updated, values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// remove list elements from the back, so the indexes refer to the elements before the update
+	removals := append([]Path{}, u.Remove...)
+	sort.SliceStable(removals, func(i, j int) bool {
+		a, b := removals[i], removals[j]
+		return len(a) == len(b) && a[len(a)-1].IsIndex && b[len(b)-1].IsIndex && a[len(a)-1].Index > b[len(b)-1].Index
+	})
+	for _, path := range removals {
+		path.Remove(updated)
+	}
+
+	for _, action := range u.Add {
+		if err := add(updated, action); err != nil {
+			return nil, err
+		}
+	}
+	for _, action := range u.Delete {
+		if err := remove(updated, action); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+// add adds a number to a number or elements to a set; missing attributes are set to the value
+func add(item Item, action ValueAction) error {
+	current := action.Path.Get(item)
+	if current == nil {
+		return action.Path.Set(item, Copy(action.Value))
+	}
+
+	switch {
+	case TypeOf(current) == TypeNumber && TypeOf(action.Value) == TypeNumber:
+		sum, err := Arithmetic{
+			Operator: "+",
+			Left:     OperandValue{ValueOperand{current}},
+			Right:    OperandValue{ValueOperand{action.Value}},
+		}.Evaluate(item)
+		if err != nil {
+			return err
+		}
+		return action.Path.Set(item, sum)
+	case isSet(current) && TypeOf(current) == TypeOf(action.Value):
+		elements := SetElements(current)
+		for _, element := range SetElements(action.Value) {
+			if !containsEqual(elements, element) {
+				elements = append(elements, element)
+			}
+		}
+		return action.Path.Set(item, newSet(TypeOf(current), elements))
+	}
+	return fmt.Errorf("an operand in the update expression has an incorrect data type: ADD %s %s", action.Path, Format(action.Value))
+}
+
+// remove deletes elements from a set; empty sets are removed
+func remove(item Item, action ValueAction) error {
+	current := action.Path.Get(item)
+	if current == nil {
+		return nil
+	}
+	if !isSet(current) || TypeOf(current) != TypeOf(action.Value) {
+		return fmt.Errorf("an operand in the update expression has an incorrect data type: DELETE %s %s", action.Path, Format(action.Value))
+	}
+
+	var elements []*dynamodb.AttributeValue
+	deleted := SetElements(action.Value)
+	for _, element := range SetElements(current) {
+		if !containsEqual(deleted, element) {
+			elements = append(elements, element)
+		}
+	}
+	if len(elements) == 0 {
+		action.Path.Remove(item)
+		return nil
+	}
+	return action.Path.Set(item, newSet(TypeOf(current), elements))
+}
+
+func isSet(value *dynamodb.AttributeValue) bool {
+	switch TypeOf(value) {
+	case TypeStringSet, TypeNumberSet, TypeBinarySet:
+		return true
+	}
+	return false
+}
+
+// String formats the update with resolved placeholders; actions keep their order
+func (u *Update) String() string {
+	var clauses []string
+	if len(u.Set) > 0 {
+		actions := make([]string, len(u.Set))
+		for i, action := range u.Set {
+			actions[i] = action.Path.String() + " = " + action.Value.String()
+		}
+		clauses = append(clauses, "SET "+strings.Join(actions, ", "))
+	}
+	if len(u.Remove) > 0 {
+		paths := make([]string, len(u.Remove))
+		for i, path := range u.Remove {
+			paths[i] = path.String()
+		}
+		clauses = append(clauses, "REMOVE "+strings.Join(paths, ", "))
+	}
+	for _, clause := range []struct {
+		keyword string
+		actions []ValueAction
+	}{{"ADD", u.Add}, {"DELETE", u.Delete}} {
+		if len(clause.actions) == 0 {
+			continue
+		}
+		actions := make([]string, len(clause.actions))
+		for i, action := range clause.actions {
+			actions[i] = action.Path.String() + " " + Format(action.Value)
+		}
+		clauses = append(clauses, clause.keyword+" "+strings.Join(actions, ", "))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// Paths returns the paths updated by all actions
+func (u *Update) Paths() []Path {
+	var paths []Path
+	for _, action := range u.Set {
+		paths = append(paths, action.Path)
+	}
+	paths = append(paths, u.Remove...)
+	for _, action := range u.Add {
+		paths = append(paths, action.Path)
+	}
+	for _, action := range u.Delete {
+		paths = append(paths, action.Path)
+	}
+	return paths
+}
+
+// Update parses an update expression
+func (s *Scope) Update(expression string) (*Update, error) {
+	p, err := s.parser(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &Update{}
+	seen := make(map[string]bool)
+	for p.peek().kind != tokenEOF {
+		keyword := strings.ToUpper(p.next().text)
+		if seen[keyword] {
+			return nil, fmt.Errorf("the %s section can only be used once in an update expression", keyword)
+		}
+		seen[keyword] = true
+
+		switch keyword {
+		case "SET":
+			err = p.list(func() error {
+				path, err := p.path()
+				if err != nil {
+					return err
+				}
+				if err := p.expect("="); err != nil {
+					return err
+				}
+				value, err := p.updateValue()
+				if err != nil {
+					return err
+				}
+				update.Set = append(update.Set, SetAction{Path: path, Value: value})
+				return nil
+			})
+		case "REMOVE":
+			err = p.list(func() error {
+				path, err := p.path()
+				if err != nil {
+					return err
+				}
+				update.Remove = append(update.Remove, path)
+				return nil
+			})
+		case "ADD", "DELETE":
+			err = p.list(func() error {
+				path, err := p.path()
+				if err != nil {
+					return err
+				}
+				t := p.next()
+				if t.kind != tokenValue {
+					p.pos--
+					return p.unexpected()
+				}
+				value, err := p.scope.value(t.text)
+				if err != nil {
+					return err
+				}
+				if keyword == "ADD" {
+					update.Add = append(update.Add, ValueAction{Path: path, Value: value})
+				} else {
+					update.Delete = append(update.Delete, ValueAction{Path: path, Value: value})
+				}
+				return nil
+			})
+		default:
+			p.pos--
+			return nil, p.unexpected()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("invalid update expression: the expression can not be empty")
+	}
+	if err := update.overlaps(); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// overlaps returns an error if two actions update the same path or one updates a parent of another
+func (u *Update) overlaps() error {
+	paths := u.Paths()
+	for i := range paths {
+		for j := i + 1; j < len(paths); j++ {
+			shorter, longer := paths[i], paths[j]
+			if len(shorter) > len(longer) {
+				shorter, longer = longer, shorter
+			}
+			if shorter.Equal(longer[:len(shorter)]) {
+				return fmt.Errorf("two document paths overlap with each other: [%s], [%s]", paths[i], paths[j])
+			}
+		}
+	}
+	return nil
+}
+
+// list parses comma separated elements
+func (p *parser) list(element func() error) error {
+	for {
+		if err := element(); err != nil {
+			return err
+		}
+		if !p.accept(",") {
+			return nil
+		}
+	}
+}
+
+func (p *parser) updateValue() (UpdateValue, error) {
+	left, err := p.updateOperand()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.is("+") || t.is("-") {
+		p.next()
+		right, err := p.updateOperand()
+		if err != nil {
+			return nil, err
+		}
+		return Arithmetic{Operator: t.text, Left: left, Right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) updateOperand() (UpdateValue, error) {
+	t := p.peek()
+	if t.kind == tokenIdentifier && p.peekAt(1).is("(") {
+		name := strings.ToLower(t.text)
+		p.next()
+		p.next()
+		switch name {
+		case FunctionIfNotExists:
+			path, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			value, err := p.updateValue()
+			if err != nil {
+				return nil, err
+			}
+			return IfNotExists{Path: path, Default: value}, p.expect(")")
+		case FunctionListAppend:
+			left, err := p.updateValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+			right, err := p.updateValue()
+			if err != nil {
+				return nil, err
+			}
+			return ListAppend{Left: left, Right: right}, p.expect(")")
+		}
+		return nil, fmt.Errorf("invalid function name; function: %s", name)
+	}
+
+	operand, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := operand.(SizeOperand); ok {
+		return nil, fmt.Errorf("invalid update expression: the function is not allowed in an update expression; function: size")
+	}
+	return OperandValue{Operand: operand}, nil
+}
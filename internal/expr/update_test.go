@@ -0,0 +1,91 @@
+package expr_test
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Update", func() {
+	var item expr.Item
+
+	BeforeEach(func() {
+		item = expr.Item{
+			"UUID":   {S: aws.String("uuid")},
+			"Amount": {N: aws.String("1")},
+			"Tags":   {SS: aws.StringSlice([]string{"a", "b"})},
+			"List":   {L: []*dynamodb.AttributeValue{{N: aws.String("0")}, {N: aws.String("1")}, {N: aws.String("2")}}},
+		}
+	})
+	values := map[string]*dynamodb.AttributeValue{
+		":one":  {N: aws.String("1")},
+		":name": {S: aws.String("name")},
+		":tags": {SS: aws.StringSlice([]string{"b", "c"})},
+		":all":  {SS: aws.StringSlice([]string{"a", "b"})},
+		":list": {L: []*dynamodb.AttributeValue{{N: aws.String("3")}}},
+	}
+
+	apply := func(expression string) expr.Item {
+		update, err := expr.NewScope(nil, values).Update(expression)
+		Expect(err).To(BeNil())
+		updated, err := update.Apply(item)
+		Expect(err).To(BeNil())
+		return updated
+	}
+
+	It("should evaluate values against the item before the update", func() {
+		updated := apply("SET Amount = Amount + :one, Previous = Amount, UserName = if_not_exists(UserName, :name)")
+		Expect(updated["Amount"].N).To(Equal(aws.String("2")))
+		Expect(updated["Previous"].N).To(Equal(aws.String("1")))
+		Expect(updated["UserName"].S).To(Equal(aws.String("name")))
+		Expect(item["Amount"].N).To(Equal(aws.String("1")))
+	})
+
+	It("should append to and remove from lists", func() {
+		updated := apply("SET Appended = list_append(List, :list) REMOVE List[0], List[1]")
+		Expect(expr.Format(updated["Appended"])).To(Equal("[0, 1, 2, 3]"))
+		Expect(expr.Format(updated["List"])).To(Equal("[2]"))
+	})
+
+	It("should add to and delete from sets", func() {
+		updated := apply("ADD Tags :tags, Total :one DELETE Other :all")
+		Expect(expr.Format(updated["Tags"])).To(Equal(`<<"a", "b", "c">>`))
+		Expect(updated["Total"].N).To(Equal(aws.String("1")))
+
+		updated = apply("DELETE Tags :all")
+		Expect(updated).NotTo(HaveKey("Tags"))
+	})
+
+	It("should fail for missing operands and overlapping paths", func() {
+		update, err := expr.NewScope(nil, values).Update("SET Amount = Missing + :one")
+		Expect(err).To(BeNil())
+		_, err = update.Apply(item)
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+
+		_, err = expr.NewScope(nil, values).Update("SET List = :list REMOVE List[0]")
+		Expect(err).To(MatchError(ContainSubstring("overlap")))
+	})
+
+	It("should format updates with resolved placeholders", func() {
+		update, err := expr.NewScope(nil, values).Update("set Amount = Amount + :one remove Tags add Total :one")
+		Expect(err).To(BeNil())
+		Expect(update.String()).To(Equal("SET Amount = Amount + 1 REMOVE Tags ADD Total 1"))
+	})
+
+	It("should sort the actions of updates", func() {
+		update, err := expr.NewScope(nil, values).Update("SET UserName = :name, Amount = :one REMOVE Tags, List")
+		Expect(err).To(BeNil())
+		update.Sort()
+		Expect(update.String()).To(Equal(`SET Amount = 1, UserName = "name" REMOVE List, Tags`))
+	})
+
+	It("should project nested paths", func() {
+		projection, err := expr.NewScope(nil, nil).Projection("UUID, List[2], List[0], Missing.Label")
+		Expect(err).To(BeNil())
+		Expect(expr.Format(&dynamodb.AttributeValue{M: expr.Project(item, projection)})).To(Equal(`{"List": [0, 2], "UUID": "uuid"}`))
+	})
+})
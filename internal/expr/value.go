@@ -0,0 +1,318 @@
+// Package expr parses and evaluates DynamoDB condition, update and projection expressions and the legacy
+// conditions of the DynamoDB API against items
+package expr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// Item is an item of a table
+type Item = map[string]*dynamodb.AttributeValue
+
+// Types of attribute values as used by attribute_type
+const (
+	TypeString    = "S"
+	TypeNumber    = "N"
+	TypeBinary    = "B"
+	TypeBool      = "BOOL"
+	TypeNull      = "NULL"
+	TypeStringSet = "SS"
+	TypeNumberSet = "NS"
+	TypeBinarySet = "BS"
+	TypeList      = "L"
+	TypeMap       = "M"
+)
+
+// TypeOf returns the type of value; it is empty for nil or empty values
+func TypeOf(value *dynamodb.AttributeValue) string {
+	switch {
+	case value == nil:
+		return ""
+	case value.S != nil:
+		return TypeString
+	case value.N != nil:
+		return TypeNumber
+	case value.B != nil:
+		return TypeBinary
+	case value.BOOL != nil:
+		return TypeBool
+	case value.NULL != nil:
+		return TypeNull
+	case value.SS != nil:
+		return TypeStringSet
+	case value.NS != nil:
+		return TypeNumberSet
+	case value.BS != nil:
+		return TypeBinarySet
+	case value.L != nil:
+		return TypeList
+	case value.M != nil:
+		return TypeMap
+	}
+	return ""
+}
+
+// Number parses a number value
+func Number(n string) (*big.Float, error) {
+	f, _, err := big.ParseFloat(strings.TrimSpace(n), 10, 256, big.ToNearestEven)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", n)
+	}
+	return f, nil
+}
+
+// FormatNumber formats a number the way DynamoDB returns it
+func FormatNumber(f *big.Float) string {
+	if f.IsInt() {
+		i, _ := f.Int(nil)
+		return i.String()
+	}
+	return f.Text('f', -1)
+}
+
+// Compare compares two strings, numbers or binaries; ok is false if they are of different or other types
+func Compare(a, b *dynamodb.AttributeValue) (result int, ok bool) {
+	if a == nil || b == nil || TypeOf(a) != TypeOf(b) {
+		return 0, false
+	}
+	switch TypeOf(a) {
+	case TypeString:
+		return strings.Compare(*a.S, *b.S), true
+	case TypeBinary:
+		return bytes.Compare(a.B, b.B), true
+	case TypeNumber:
+		x, errA := Number(*a.N)
+		y, errB := Number(*b.N)
+		if errA != nil || errB != nil {
+			return 0, false
+		}
+		return x.Cmp(y), true
+	}
+	return 0, false
+}
+
+// Equal reports if a and b are equal; numbers are compared by value and sets regardless of their order
+func Equal(a, b *dynamodb.AttributeValue) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if TypeOf(a) != TypeOf(b) {
+		return false
+	}
+	switch TypeOf(a) {
+	case TypeString, TypeNumber, TypeBinary:
+		result, ok := Compare(a, b)
+		return ok && result == 0
+	case TypeBool:
+		return *a.BOOL == *b.BOOL
+	case TypeNull:
+		return true
+	case TypeStringSet, TypeNumberSet, TypeBinarySet:
+		elementsA, elementsB := SetElements(a), SetElements(b)
+		if len(elementsA) != len(elementsB) {
+			return false
+		}
+		for _, element := range elementsA {
+			if !containsEqual(elementsB, element) {
+				return false
+			}
+		}
+		return true
+	case TypeList:
+		if len(a.L) != len(b.L) {
+			return false
+		}
+		for i := range a.L {
+			if !Equal(a.L[i], b.L[i]) {
+				return false
+			}
+		}
+		return true
+	case TypeMap:
+		if len(a.M) != len(b.M) {
+			return false
+		}
+		for name, value := range a.M {
+			if !Equal(value, b.M[name]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// SetElements returns the elements of a set as scalar values
+func SetElements(set *dynamodb.AttributeValue) []*dynamodb.AttributeValue {
+	var elements []*dynamodb.AttributeValue
+	switch TypeOf(set) {
+	case TypeStringSet:
+		for _, s := range set.SS {
+			elements = append(elements, &dynamodb.AttributeValue{S: s})
+		}
+	case TypeNumberSet:
+		for _, n := range set.NS {
+			elements = append(elements, &dynamodb.AttributeValue{N: n})
+		}
+	case TypeBinarySet:
+		for _, b := range set.BS {
+			elements = append(elements, &dynamodb.AttributeValue{B: b})
+		}
+	}
+	return elements
+}
+
+// newSet creates a set of type setType from scalar values
+func newSet(setType string, elements []*dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	set := &dynamodb.AttributeValue{}
+	switch setType {
+	case TypeStringSet:
+		set.SS = []*string{}
+		for _, element := range elements {
+			set.SS = append(set.SS, element.S)
+		}
+	case TypeNumberSet:
+		set.NS = []*string{}
+		for _, element := range elements {
+			set.NS = append(set.NS, element.N)
+		}
+	case TypeBinarySet:
+		set.BS = [][]byte{}
+		for _, element := range elements {
+			set.BS = append(set.BS, element.B)
+		}
+	}
+	return set
+}
+
+func containsEqual(values []*dynamodb.AttributeValue, value *dynamodb.AttributeValue) bool {
+	for _, v := range values {
+		if Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy returns a deep copy of value
+func Copy(value *dynamodb.AttributeValue) *dynamodb.AttributeValue {
+	if value == nil {
+		return nil
+	}
+	c := &dynamodb.AttributeValue{
+		S:    copyString(value.S),
+		N:    copyString(value.N),
+		BOOL: copyBool(value.BOOL),
+		NULL: copyBool(value.NULL),
+	}
+	if value.B != nil {
+		c.B = append([]byte{}, value.B...)
+	}
+	if value.SS != nil {
+		c.SS = make([]*string, len(value.SS))
+		for i, s := range value.SS {
+			c.SS[i] = copyString(s)
+		}
+	}
+	if value.NS != nil {
+		c.NS = make([]*string, len(value.NS))
+		for i, n := range value.NS {
+			c.NS[i] = copyString(n)
+		}
+	}
+	if value.BS != nil {
+		c.BS = make([][]byte, len(value.BS))
+		for i, b := range value.BS {
+			c.BS[i] = append([]byte{}, b...)
+		}
+	}
+	if value.L != nil {
+		c.L = make([]*dynamodb.AttributeValue, len(value.L))
+		for i, element := range value.L {
+			c.L[i] = Copy(element)
+		}
+	}
+	if value.M != nil {
+		c.M = CopyItem(value.M)
+	}
+	return c
+}
+
+// CopyItem returns a deep copy of item
+func CopyItem(item Item) Item {
+	if item == nil {
+		return nil
+	}
+	c := make(Item, len(item))
+	for name, value := range item {
+		c[name] = Copy(value)
+	}
+	return c
+}
+
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	return aws.String(*s)
+}
+
+func copyBool(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	return aws.Bool(*b)
+}
+
+// Format formats value as literal, e.g. "name", 42, <<"a", "b">> for sets or {"a": 1} for maps
+func Format(value *dynamodb.AttributeValue) string {
+	switch TypeOf(value) {
+	case TypeString:
+		return strconv.Quote(*value.S)
+	case TypeNumber:
+		if n, err := Number(*value.N); err == nil {
+			return FormatNumber(n)
+		}
+		return *value.N
+	case TypeBinary:
+		return "b" + strconv.Quote(base64.StdEncoding.EncodeToString(value.B))
+	case TypeBool:
+		return strconv.FormatBool(*value.BOOL)
+	case TypeNull:
+		return "null"
+	case TypeStringSet, TypeNumberSet, TypeBinarySet:
+		var elements []string
+		for _, element := range SetElements(value) {
+			elements = append(elements, Format(element))
+		}
+		sort.Strings(elements)
+		return "<<" + strings.Join(elements, ", ") + ">>"
+	case TypeList:
+		var elements []string
+		for _, element := range value.L {
+			elements = append(elements, Format(element))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case TypeMap:
+		names := make([]string, 0, len(value.M))
+		for name := range value.M {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var entries []string
+		for _, name := range names {
+			entries = append(entries, strconv.Quote(name)+": "+Format(value.M[name]))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	return "<invalid>"
+}
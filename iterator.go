@@ -8,6 +8,8 @@ import (
 )
 
 // IteratorInterface ...
+//
+//go:generate mockgen -source=iterator.go -destination=./mock/iterator_interface.go -package=mock .
 type IteratorInterface interface {
 	NextItem(out interface{}) bool
 }
@@ -21,6 +23,8 @@ type Iterator struct {
 	iterator         dynamo.PagingIter
 	dynamoClient     *dynamo.DB
 	ctx              context.Context
+	count            int
+	recordItems      func(count int)
 }
 
 // NextItem unmarshals the next item into out and returns if there are more items following
@@ -29,7 +33,25 @@ func (itr *Iterator) NextItem(out interface{}) bool {
 	if !more && itr.iterator.LastEvaluatedKey() != nil {
 		itr.scan = itr.scan.StartFrom(itr.iterator.LastEvaluatedKey())
 		itr.iterator = itr.scan.Iter()
-		return itr.iterator.NextWithContext(itr.ctx, out)
+		more = itr.iterator.NextWithContext(itr.ctx, out)
 	}
+	itr.countItem(more)
 	return more
 }
+
+// countItem counts the items of the scan and records them once the scan is exhausted
+func (itr *Iterator) countItem(more bool) {
+	if more {
+		itr.count++
+		return
+	}
+	if itr.recordItems != nil && itr.iterator.Err() == nil {
+		itr.recordItems(itr.count)
+	}
+	itr.recordItems = nil
+}
+
+// Err returns the error that stopped the iteration, if any
+func (itr *Iterator) Err() error {
+	return unmaskRetryError(itr.iterator.Err())
+}
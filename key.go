@@ -1,5 +1,12 @@
 package djoemo
 
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
 type key struct {
 	tableName    string
 	hashKeyName  *string
@@ -68,7 +75,40 @@ func (k *key) RangeKey() interface{} {
 	return k.rangeKey
 }
 
+// keyUsage tells which parts of a key a request uses
+type keyUsage int
+
+const (
+	// primaryKey keys identify a single item
+	primaryKey keyUsage = iota
+	// partialKey keys of queries may leave out the range key
+	partialKey
+	// templateKey keys only name the table and its keys, the values are taken from items
+	templateKey
+)
+
+// isValidKey validates the primary key of a single item; a range key name needs a value, and keys of registered
+// tables must match their schema, see RegisterTable
 func isValidKey(key KeyInterface) error {
+	return isValidKeyOf(key, "", primaryKey)
+}
+
+// isValidPartialKey validates keys of queries, which may leave out the range key
+func isValidPartialKey(key KeyInterface) error {
+	return isValidKeyOf(key, "", partialKey)
+}
+
+// isValidTemplateKey validates keys naming the table and keys of the items of batch writes
+func isValidTemplateKey(key KeyInterface) error {
+	return isValidKeyOf(key, "", templateKey)
+}
+
+// isValidIndexKey validates keys of queries on an index
+func isValidIndexKey(key KeyInterface, indexName string) error {
+	return isValidKeyOf(key, indexName, partialKey)
+}
+
+func isValidKeyOf(key KeyInterface, indexName string, usage keyUsage) error {
 	if err := isValidTableName(key); err != nil {
 		return err
 	}
@@ -79,6 +119,80 @@ func isValidKey(key KeyInterface) error {
 		return err
 	}
 
+	subject := "table " + key.TableName()
+	if indexName != "" {
+		subject = "index " + indexName + " of " + subject
+	}
+	hashKeyName := *key.HashKeyName()
+	if usage == primaryKey && key.RangeKeyName() != nil && key.RangeKey() == nil {
+		return fmt.Errorf("%w: range key %s of %s has no value", ErrInvalidRangeKeyValue, *key.RangeKeyName(), subject)
+	}
+
+	schema, registered := RegisteredTable(key.TableName())
+	if !registered && usage == templateKey {
+		return nil
+	}
+	if !registered {
+		if err := isValidKeyValue(ErrInvalidHashKeyValue, "hash key "+hashKeyName, key.HashKey(), "", subject); err != nil {
+			return err
+		}
+		if key.RangeKeyName() != nil && key.RangeKey() != nil {
+			return isValidKeyValue(ErrInvalidRangeKeyValue, "range key "+*key.RangeKeyName(), key.RangeKey(), "", subject)
+		}
+		return nil
+	}
+
+	hashKey, rangeKey, ok := schema.keySchema(indexName)
+	if !ok {
+		return fmt.Errorf("%w: %s is not registered", ErrInvalidIndexName, subject)
+	}
+	if hashKeyName != hashKey {
+		return fmt.Errorf("%w: %s has hash key %s, got %s", ErrInvalidHashKeyName, subject, hashKey, hashKeyName)
+	}
+	if usage != templateKey {
+		if err := isValidKeyValue(ErrInvalidHashKeyValue, "hash key "+hashKey, key.HashKey(), schema.AttributeTypes[hashKey], subject); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case key.RangeKeyName() == nil:
+		if usage == primaryKey && rangeKey != "" {
+			return fmt.Errorf("%w: %s needs range key %s", ErrInvalidRangeKeyName, subject, rangeKey)
+		}
+		return nil
+	case rangeKey == "":
+		return fmt.Errorf("%w: %s has no range key, got %s", ErrInvalidRangeKeyName, subject, *key.RangeKeyName())
+	case *key.RangeKeyName() != rangeKey:
+		return fmt.Errorf("%w: %s has range key %s, got %s", ErrInvalidRangeKeyName, subject, rangeKey, *key.RangeKeyName())
+	case key.RangeKey() == nil || usage == templateKey:
+		return nil
+	}
+	return isValidKeyValue(ErrInvalidRangeKeyValue, "range key "+rangeKey, key.RangeKey(), schema.AttributeTypes[rangeKey], subject)
+}
+
+// isValidKeyValue checks that a key value is not empty and, if attributeType is set, is marshalled to that type
+func isValidKeyValue(invalid error, name string, value any, attributeType string, subject string) error {
+	av, err := dynamo.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w: %s of %s: %v", invalid, name, subject, err)
+	}
+	if av == nil {
+		return fmt.Errorf("%w: %s of %s is empty", invalid, name, subject)
+	}
+
+	var actual string
+	switch {
+	case av.S != nil:
+		actual = dynamodb.ScalarAttributeTypeS
+	case av.N != nil:
+		actual = dynamodb.ScalarAttributeTypeN
+	case av.B != nil:
+		actual = dynamodb.ScalarAttributeTypeB
+	}
+	if attributeType != "" && actual != attributeType {
+		return fmt.Errorf("%w: %s of %s is of type %s, got %T", invalid, name, subject, attributeType, value)
+	}
 	return nil
 }
 
@@ -21,3 +21,15 @@ type LogInterface interface {
 	// error logs error
 	Error(message string)
 }
+
+// DebugLogInterface can be implemented by a logger to log every request to DynamoDB at debug level
+type DebugLogInterface interface {
+	// Debug logs debug
+	Debug(message string)
+}
+
+// DebugLevelInterface can be implemented by a debug logger to skip preparing the fields of requests while debug is disabled
+type DebugLevelInterface interface {
+	// DebugEnabled reports if messages logged at debug level with ctx are written
+	DebugEnabled(ctx context.Context) bool
+}
@@ -0,0 +1,68 @@
+package djoemo
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// slogLog logs to a slog.Logger
+type slogLog struct {
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// NewSlogLog creates a logger on top of logger; it logs requests to DynamoDB at debug level
+func NewSlogLog(logger *slog.Logger) LogInterface {
+	return &slogLog{logger: logger, ctx: context.Background()}
+}
+
+// WithContext adds context to logger; it is passed to the handler of the slog.Logger
+func (l *slogLog) WithContext(ctx context.Context) LogInterface {
+	return &slogLog{logger: l.logger, ctx: ctx}
+}
+
+// WithField adds field with value to logger
+func (l *slogLog) WithField(key string, value any) LogInterface {
+	return &slogLog{logger: l.logger.With(key, value), ctx: l.ctx}
+}
+
+// WithFields adds fields from map string interface to logger
+func (l *slogLog) WithFields(fields map[string]any) LogInterface {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]any, 0, 2*len(keys))
+	for _, key := range keys {
+		args = append(args, key, fields[key])
+	}
+	return &slogLog{logger: l.logger.With(args...), ctx: l.ctx}
+}
+
+// DebugEnabled reports if the handler of the slog.Logger handles records at debug level
+func (l *slogLog) DebugEnabled(ctx context.Context) bool {
+	return l.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// Debug logs debug
+func (l *slogLog) Debug(message string) {
+	l.logger.DebugContext(l.ctx, message)
+}
+
+// Info logs info
+func (l *slogLog) Info(message string) {
+	l.logger.InfoContext(l.ctx, message)
+}
+
+// Warn logs warning
+func (l *slogLog) Warn(message string) {
+	l.logger.WarnContext(l.ctx, message)
+}
+
+// Error logs error
+func (l *slogLog) Error(message string) {
+	l.logger.ErrorContext(l.ctx, message)
+}
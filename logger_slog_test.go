@@ -0,0 +1,127 @@
+package djoemo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slog", func() {
+	const (
+		UserTableName = "UserTable"
+		IndexName     = "email-index"
+	)
+
+	var (
+		dAPIMock   *mock.MockDynamoDBAPI
+		repository djoemo.RepositoryInterface
+		output     *bytes.Buffer
+		key        djoemo.KeyInterface
+	)
+
+	records := func() []map[string]any {
+		var records []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			record := make(map[string]any)
+			Expect(json.Unmarshal([]byte(line), &record)).To(Succeed())
+			records = append(records, record)
+		}
+		return records
+	}
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+
+		output = &bytes.Buffer{}
+		repository.WithSlogHandler(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+	})
+
+	It("should log requests at debug level", func() {
+		dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+
+		found, err := repository.GetItemWithContext(context.Background(), key, &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+
+		Expect(records()).To(HaveLen(2))
+		request := records()[0]
+		Expect(request).To(HaveKeyWithValue("level", "DEBUG"))
+		Expect(request).To(HaveKeyWithValue("msg", "DynamoDB.GetItem"))
+		Expect(request).To(HaveKeyWithValue("Operation", "GetItem"))
+		Expect(request).To(HaveKeyWithValue(djoemo.TableName, UserTableName))
+		Expect(request).To(HaveKeyWithValue("Key", map[string]any{"UUID": "uuid"}))
+		Expect(request).To(HaveKey("Duration"))
+
+		notFound := records()[1]
+		Expect(notFound).To(HaveKeyWithValue("level", "INFO"))
+		Expect(notFound).To(HaveKeyWithValue("msg", djoemo.ErrNoItemFound.Error()))
+	})
+
+	It("should log the key conditions of queries and redact their values", func() {
+		dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{}, nil)
+		repository.WithLogRedactor(djoemo.RedactAll)
+
+		query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("user@example.com")
+		Expect(repository.GIndex(IndexName).QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+		Expect(records()).To(HaveLen(1))
+		Expect(records()[0]).To(HaveKeyWithValue("IndexName", IndexName))
+		Expect(records()[0]).To(HaveKeyWithValue("KeyConditions", map[string]any{"Email": "EQ " + djoemo.Redacted}))
+		Expect(output.String()).ToNot(ContainSubstring("user@example.com"))
+	})
+
+	It("should log the error of failed requests", func() {
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(nil, awserr.New("ValidationException", "invalid", nil))
+
+		Expect(repository.DeleteItemWithContext(context.Background(), key)).ToNot(BeNil())
+
+		Expect(records()).To(HaveLen(1))
+		Expect(records()[0]).To(HaveKeyWithValue("msg", "DynamoDB.DeleteItem"))
+		Expect(records()[0]).To(HaveKeyWithValue("Error", ContainSubstring("invalid")))
+	})
+
+	It("should not log requests above debug level", func() {
+		output.Reset()
+		repository.WithSlogHandler(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+		Expect(repository.DeleteItemWithContext(context.Background(), key)).To(BeNil())
+
+		Expect(output.String()).To(BeEmpty())
+	})
+
+	It("should not prepare the fields of requests while debug is disabled", func() {
+		redacted := 0
+		repository.WithSlogHandler(slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		repository.WithLogRedactor(func(name string, value any) any {
+			redacted++
+			return value
+		})
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+
+		Expect(repository.DeleteItemWithContext(context.Background(), key)).To(BeNil())
+
+		Expect(redacted).To(BeZero())
+	})
+})
@@ -13,6 +13,23 @@ type MetricsInterface interface {
 	Record(ctx context.Context, caller string, key KeyInterface, duration time.Duration, success bool)
 }
 
+// RetryMetricsInterface can be implemented by a metrics publisher to count retried operations
+type RetryMetricsInterface interface {
+	RecordRetry(ctx context.Context, caller string, key KeyInterface, class ErrorClass)
+}
+
+// CapacityMetricsInterface can be implemented by a metrics publisher to count the capacity units consumed per table and index;
+// indexName is empty for the units consumed by the table itself
+type CapacityMetricsInterface interface {
+	RecordConsumedCapacity(ctx context.Context, tableName string, indexName string, capacity Capacity)
+}
+
+// SizeMetricsInterface can be implemented by a metrics publisher to observe the number of items an operation read or wrote
+// and their marshalled size in bytes
+type SizeMetricsInterface interface {
+	RecordItems(ctx context.Context, caller string, key KeyInterface, count int, bytes int)
+}
+
 const (
 	labelSource = "source"
 
@@ -55,6 +72,22 @@ func WithSourceLabel(ctx context.Context, value string) context.Context {
 	return AddMetrics(ctx, labelSource, value)
 }
 
+type indexNameContextKey int
+
+const indexNameCtxKey indexNameContextKey = iota
+
+// withIndexName marks metrics recorded with the returned context as metrics of an index
+func withIndexName(ctx context.Context, indexName string) context.Context {
+	return context.WithValue(ctx, indexNameCtxKey, indexName)
+}
+
+// IndexNameFromContext returns the name of the global secondary index of an operation recorded by a metrics publisher,
+// empty for operations on a table
+func IndexNameFromContext(ctx context.Context) string {
+	indexName, _ := ctx.Value(indexNameCtxKey).(string)
+	return indexName
+}
+
 func LabelsFromContext(ctx context.Context) map[string]string {
 	customLabels, ok := ctx.Value(customLabelsCtxKey).(*customLabels)
 	if !ok || customLabels == nil {
@@ -81,6 +114,42 @@ func (m *Metrics) Record(ctx context.Context, caller string, key KeyInterface, d
 	}
 }
 
+// RecordRetry counts a retry on all metrics publishers implementing RetryMetricsInterface
+func (m *Metrics) RecordRetry(ctx context.Context, caller string, key KeyInterface, class ErrorClass) {
+	for _, metric := range m.metrics {
+		if retryMetric, ok := metric.(RetryMetricsInterface); ok {
+			retryMetric.RecordRetry(ctx, caller, key, class)
+		}
+	}
+}
+
+// RecordConsumedCapacity counts consumed capacity units on all metrics publishers implementing CapacityMetricsInterface
+func (m *Metrics) RecordConsumedCapacity(ctx context.Context, tableName string, indexName string, capacity Capacity) {
+	for _, metric := range m.metrics {
+		if capacityMetric, ok := metric.(CapacityMetricsInterface); ok {
+			capacityMetric.RecordConsumedCapacity(ctx, tableName, indexName, capacity)
+		}
+	}
+}
+
+// RecordItems observes the items of an operation on all metrics publishers implementing SizeMetricsInterface
+func (m *Metrics) RecordItems(ctx context.Context, caller string, key KeyInterface, count int, bytes int) {
+	for _, metric := range m.metrics {
+		if sizeMetric, ok := metric.(SizeMetricsInterface); ok {
+			sizeMetric.RecordItems(ctx, caller, key, count, bytes)
+		}
+	}
+}
+
+// RecordCircuitState reports a circuit breaker state change to all metrics publishers implementing CircuitMetricsInterface
+func (m *Metrics) RecordCircuitState(ctx context.Context, circuit string, state CircuitState) {
+	for _, metric := range m.metrics {
+		if circuitMetric, ok := metric.(CircuitMetricsInterface); ok {
+			circuitMetric.RecordCircuitState(ctx, circuit, state)
+		}
+	}
+}
+
 func (m *Metrics) RecordMultiple(ctx context.Context, caller string, key []KeyInterface, duration time.Duration, success bool) {
 	for _, key := range key {
 		m.Record(ctx, caller, key, duration, success)
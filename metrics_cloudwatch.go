@@ -2,16 +2,234 @@ package djoemo
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
 	"time"
 )
 
-type cloudwatchmetrics struct{}
+// CloudWatchConfig configures the CloudWatch Embedded Metric Format publisher
+type CloudWatchConfig struct {
+	// Namespace of the metrics in CloudWatch; defaults to djoemo
+	Namespace string
+	// FlushInterval is the interval batched metrics are written in; zero only writes on Flush, Close or full batches
+	FlushInterval time.Duration
+	// MaxBatchSize is the number of values of a metric per table, operation and source that are batched before they are written;
+	// capped at 100, the maximum number of values of a metric in one Embedded Metric Format document
+	MaxBatchSize int
+	// OnError is called with errors of the writer while writing full batches or flushing in the background
+	OnError func(err error)
+}
+
+// DefaultCloudWatchConfig flushes every 10 seconds with full batches of 100 values
+func DefaultCloudWatchConfig() CloudWatchConfig {
+	return CloudWatchConfig{
+		Namespace:     "djoemo",
+		FlushInterval: 10 * time.Second,
+		MaxBatchSize:  emfMaxValues,
+	}
+}
+
+const (
+	emfMaxValues = 100
+
+	emfDimensionTable     = "Table"
+	emfDimensionOperation = "Operation"
+	emfDimensionSource    = "Source"
+	emfMetricLatency      = "Latency"
+	emfMetricCount        = "Count"
+	emfMetricErrors       = "Errors"
+	emfMetricItems        = "Items"
+	emfMetricPayload      = "PayloadBytes"
+)
+
+// CloudWatchMetrics publishes metrics of operations as CloudWatch Embedded Metric Format documents, one JSON document per line,
+// e.g. to stdout of a Lambda function; metrics are batched per table, operation and source
+type CloudWatchMetrics struct {
+	sync.Mutex
+	writer  io.Writer
+	config  CloudWatchConfig
+	batches map[emfDimensions]*emfBatch
+	order   []emfDimensions
+	done    chan struct{}
+	closed  sync.Once
+}
+
+type emfDimensions struct {
+	table     string
+	operation string
+	source    string
+}
+
+type emfBatch struct {
+	start     time.Time
+	latencies []float64
+	items     []float64
+	payload   []float64
+	count     int
+	errors    int
+}
+
+// full reports if a metric of the batch holds the maximum number of values
+func (b *emfBatch) full(maxValues int) bool {
+	return len(b.latencies) >= maxValues || len(b.items) >= maxValues
+}
+
+// NewCloudWatchMetrics creates a CloudWatch Embedded Metric Format publisher writing to writer;
+// Close stops flushing in the background and writes the remaining metrics
+func NewCloudWatchMetrics(writer io.Writer, config CloudWatchConfig) *CloudWatchMetrics {
+	if config.Namespace == "" {
+		config.Namespace = DefaultCloudWatchConfig().Namespace
+	}
+	if config.MaxBatchSize <= 0 || config.MaxBatchSize > emfMaxValues {
+		config.MaxBatchSize = emfMaxValues
+	}
+
+	m := &CloudWatchMetrics{
+		writer:  writer,
+		config:  config,
+		batches: make(map[emfDimensions]*emfBatch),
+		done:    make(chan struct{}),
+	}
+	if config.FlushInterval > 0 {
+		go m.flushPeriodically()
+	}
+	return m
+}
 
-func (m *cloudwatchmetrics) Record(ctx context.Context, caller string, key KeyInterface, duration time.Duration, err error) {
-	// TODO: implement legacy CloudWatch if required. Return err, use WithCloudWatch/Add, etc
-	// m.Publish()
+// Record adds the duration and outcome of an operation to the batch of its table, operation and source
+func (m *CloudWatchMetrics) Record(ctx context.Context, caller string, key KeyInterface, duration time.Duration, success bool) {
+	m.add(ctx, caller, key, func(batch *emfBatch) {
+		batch.latencies = append(batch.latencies, float64(duration)/float64(time.Millisecond))
+		batch.count++
+		if !success {
+			batch.errors++
+		}
+	})
 }
 
-// Publish publishes metrics
-func (m *cloudwatchmetrics) Publish(ctx context.Context, key string, metricName string, metricValue float64) {
+// RecordItems adds the number of items of an operation and their size to the batch of its table, operation and source
+func (m *CloudWatchMetrics) RecordItems(ctx context.Context, caller string, key KeyInterface, count int, bytes int) {
+	m.add(ctx, caller, key, func(batch *emfBatch) {
+		batch.items = append(batch.items, float64(count))
+		batch.payload = append(batch.payload, float64(bytes))
+	})
+}
+
+func (m *CloudWatchMetrics) add(ctx context.Context, caller string, key KeyInterface, update func(batch *emfBatch)) {
+	dimensions := emfDimensions{
+		table:     tableNameOf(key),
+		operation: caller,
+		source:    LabelsFromContext(ctx)[labelSource],
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	batch, ok := m.batches[dimensions]
+	if !ok {
+		batch = &emfBatch{start: time.Now()}
+		m.batches[dimensions] = batch
+		m.order = append(m.order, dimensions)
+	}
+	update(batch)
+
+	if batch.full(m.config.MaxBatchSize) {
+		if err := m.writeBatch(dimensions, batch); err != nil && m.config.OnError != nil {
+			m.config.OnError(err)
+		}
+		delete(m.batches, dimensions)
+		m.order = slices.DeleteFunc(m.order, func(d emfDimensions) bool { return d == dimensions })
+	}
+}
+
+// Flush writes all batched metrics
+func (m *CloudWatchMetrics) Flush() error {
+	m.Lock()
+	defer m.Unlock()
+
+	var err error
+	for _, dimensions := range m.order {
+		batch := m.batches[dimensions]
+		if writeErr := m.writeBatch(dimensions, batch); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}
+	m.batches = make(map[emfDimensions]*emfBatch)
+	m.order = nil
+	return err
+}
+
+// Close stops flushing in the background and writes all batched metrics
+func (m *CloudWatchMetrics) Close() error {
+	m.closed.Do(func() {
+		close(m.done)
+	})
+	return m.Flush()
+}
+
+func (m *CloudWatchMetrics) flushPeriodically() {
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			if err := m.Flush(); err != nil && m.config.OnError != nil {
+				m.config.OnError(err)
+			}
+		}
+	}
+}
+
+// writeBatch writes batch as one Embedded Metric Format document; it must be called with the lock held
+func (m *CloudWatchMetrics) writeBatch(dimensions emfDimensions, batch *emfBatch) error {
+	dimensionNames := []string{emfDimensionTable, emfDimensionOperation}
+	document := map[string]any{
+		emfDimensionTable:     dimensions.table,
+		emfDimensionOperation: dimensions.operation,
+		emfMetricCount:        batch.count,
+		emfMetricErrors:       batch.errors,
+	}
+	metrics := []map[string]string{
+		{"Name": emfMetricCount, "Unit": "Count"},
+		{"Name": emfMetricErrors, "Unit": "Count"},
+	}
+	// metrics without values are left out, e.g. the items of single item operations
+	if len(batch.latencies) > 0 {
+		document[emfMetricLatency] = batch.latencies
+		metrics = append(metrics, map[string]string{"Name": emfMetricLatency, "Unit": "Milliseconds"})
+	}
+	if len(batch.items) > 0 {
+		document[emfMetricItems] = batch.items
+		document[emfMetricPayload] = batch.payload
+		metrics = append(metrics,
+			map[string]string{"Name": emfMetricItems, "Unit": "Count"},
+			map[string]string{"Name": emfMetricPayload, "Unit": "Bytes"},
+		)
+	}
+	// CloudWatch rejects empty dimension values
+	if dimensions.source != "" {
+		dimensionNames = append(dimensionNames, emfDimensionSource)
+		document[emfDimensionSource] = dimensions.source
+	}
+
+	document["_aws"] = map[string]any{
+		"Timestamp": batch.start.UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  m.config.Namespace,
+			"Dimensions": [][]string{dimensionNames},
+			"Metrics":    metrics,
+		}},
+	}
+
+	line, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	_, err = m.writer.Write(append(line, '\n'))
+	return err
 }
@@ -0,0 +1,151 @@
+package djoemo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adjoeio/djoemo"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// syncBuffer is a buffer safe to read while metrics are flushed in the background
+type syncBuffer struct {
+	sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buffer.String()
+}
+
+var _ = Describe("CloudWatchMetrics", func() {
+	const (
+		UserTableName = "UserTable"
+	)
+
+	var (
+		output *syncBuffer
+		key    djoemo.KeyInterface
+	)
+
+	documents := func() []map[string]any {
+		var documents []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			document := make(map[string]any)
+			Expect(json.Unmarshal([]byte(line), &document)).To(Succeed())
+			documents = append(documents, document)
+		}
+		return documents
+	}
+
+	BeforeEach(func() {
+		output = &syncBuffer{}
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+	})
+
+	It("should write batched metrics in embedded metric format", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{Namespace: "users"})
+		ctx := djoemo.WithSourceLabel(context.Background(), "worker")
+
+		metrics.Record(ctx, djoemo.OpRead, key, 2*time.Millisecond, true)
+		metrics.Record(ctx, djoemo.OpRead, key, 4*time.Millisecond, false)
+		Expect(output.String()).To(BeEmpty())
+		Expect(metrics.Flush()).To(Succeed())
+
+		Expect(documents()).To(HaveLen(1))
+		document := documents()[0]
+		Expect(document).To(HaveKeyWithValue("Table", "usertable"))
+		Expect(document).To(HaveKeyWithValue("Operation", djoemo.OpRead))
+		Expect(document).To(HaveKeyWithValue("Source", "worker"))
+		Expect(document).To(HaveKeyWithValue("Latency", []any{2.0, 4.0}))
+		Expect(document).To(HaveKeyWithValue("Count", 2.0))
+		Expect(document).To(HaveKeyWithValue("Errors", 1.0))
+
+		directive := document["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		Expect(directive).To(HaveKeyWithValue("Namespace", "users"))
+		Expect(directive).To(HaveKeyWithValue("Dimensions", []any{[]any{"Table", "Operation", "Source"}}))
+	})
+
+	It("should batch per dimensions and omit an empty source", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{})
+
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+		metrics.Record(context.Background(), djoemo.OpCommit, key, time.Millisecond, true)
+		Expect(metrics.Flush()).To(Succeed())
+
+		Expect(documents()).To(HaveLen(2))
+		Expect(documents()[0]).ToNot(HaveKey("Source"))
+		directive := documents()[0]["_aws"].(map[string]any)["CloudWatchMetrics"].([]any)[0].(map[string]any)
+		Expect(directive).To(HaveKeyWithValue("Namespace", "djoemo"))
+		Expect(directive).To(HaveKeyWithValue("Dimensions", []any{[]any{"Table", "Operation"}}))
+	})
+
+	It("should write items and payload size with the operation", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{})
+
+		metrics.RecordItems(context.Background(), djoemo.OpRead, key, 3, 120)
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+		Expect(metrics.Flush()).To(Succeed())
+
+		Expect(documents()).To(HaveLen(1))
+		Expect(documents()[0]).To(HaveKeyWithValue("Items", []any{3.0}))
+		Expect(documents()[0]).To(HaveKeyWithValue("PayloadBytes", []any{120.0}))
+	})
+
+	It("should write full batches right away", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{MaxBatchSize: 2})
+
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+		Expect(output.String()).To(BeEmpty())
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+
+		Expect(documents()).To(HaveLen(1))
+	})
+
+	It("should write every value once when batches fill between flushes", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{MaxBatchSize: 2})
+
+		for i := 0; i < 3; i++ {
+			metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+		}
+		Expect(metrics.Flush()).To(Succeed())
+		Expect(metrics.Flush()).To(Succeed())
+
+		count := 0.0
+		for _, document := range documents() {
+			count += document["Count"].(float64)
+		}
+		Expect(documents()).To(HaveLen(2))
+		Expect(count).To(Equal(3.0))
+	})
+
+	It("should flush periodically until closed", func() {
+		metrics := djoemo.NewCloudWatchMetrics(output, djoemo.CloudWatchConfig{FlushInterval: 10 * time.Millisecond})
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+
+		Eventually(documents).Should(HaveLen(1))
+
+		metrics.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+		Expect(metrics.Close()).To(Succeed())
+		Expect(documents()).To(HaveLen(2))
+	})
+})
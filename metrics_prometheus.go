@@ -2,77 +2,299 @@ package djoemo
 
 import (
 	"context"
-	"maps"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
-type prometheusmetrics struct {
-	registry *prometheus.Registry
-	// cfg           config.PrometheusMetrics
-	queryCount    map[string]*prometheus.CounterVec
-	queryDuration map[string]*prometheus.HistogramVec
+// PrometheusConfig configures the prometheus metrics publisher
+type PrometheusConfig struct {
+	// Namespace prefixes the names of all metrics; defaults to djoemo
+	Namespace string
+	// Buckets of the operation duration histogram in seconds; defaults to prometheus.DefBuckets
+	Buckets []float64
+	// ItemBuckets of the histogram of items read or written by an operation; defaults to powers of 4 up to 16384
+	ItemBuckets []float64
+	// PayloadBuckets of the histogram of the item size of an operation in bytes; defaults to powers of 4 from 256 bytes to 4 MB
+	PayloadBuckets []float64
+	// Labels lists the labels added with AddMetrics that are published in addition to source; others are dropped
+	// to keep the cardinality of the metrics under control
+	Labels []string
 }
 
-func (m *prometheusmetrics) newCounter(caller string) *prometheus.CounterVec {
-	opts := prometheus.CounterOpts{
-		Name: strings.ToLower(caller),
-		Help: "counter for function " + caller,
+// DefaultPrometheusConfig returns the configuration used by NewPrometheusMetrics
+func DefaultPrometheusConfig() PrometheusConfig {
+	return PrometheusConfig{
+		Namespace:      "djoemo",
+		Buckets:        prometheus.DefBuckets,
+		ItemBuckets:    defaultItemBuckets(),
+		PayloadBuckets: defaultPayloadBuckets(),
 	}
-	counter := prometheus.NewCounterVec(opts, []string{statusLabel})
-	m.registry.MustRegister(counter)
-	return counter
 }
 
-func (m *prometheusmetrics) newHistogramVec(caller string) *prometheus.HistogramVec {
-	opts := prometheus.HistogramOpts{
-		Name: strings.ToLower(caller),
-		Help: "histogram duration for function " + caller,
-		// WARNING: reduce the buckets after initial analysis
-		Buckets: []float64{4, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55, 60, 120},
-	}
-	// WARNING: add high cardinality labels like sdkhash, etc with caution
-	histogram := prometheus.NewHistogramVec(opts, []string{statusLabel})
-	m.registry.MustRegister(histogram)
-	return histogram
+func defaultItemBuckets() []float64 {
+	return prometheus.ExponentialBuckets(1, 4, 8)
+}
+
+func defaultPayloadBuckets() []float64 {
+	return prometheus.ExponentialBuckets(256, 4, 8)
+}
+
+// prometheusmetrics publishes metrics of operations; all collectors are created up front, so it is safe for concurrent use
+type prometheusmetrics struct {
+	customLabels      []string
+	operationCount    *prometheus.CounterVec
+	operationDuration *prometheus.HistogramVec
+	itemCount         *prometheus.HistogramVec
+	payloadSize       *prometheus.HistogramVec
+	retryCount        *prometheus.CounterVec
+	circuitState      *prometheus.GaugeVec
+	readCapacity      *prometheus.CounterVec
+	writeCapacity     *prometheus.CounterVec
 }
 
 const (
-	statusLabel = "status"
-	callerLabel = "caller" // NOTE: used separate metrics for now
-	sourceLabel = "source"
-	tableLabel  = "table"
+	operationLabel  = "operation"
+	tableLabel      = "table"
+	indexLabel      = "index"
+	statusLabel     = "status"
+	sourceLabel     = labelSource
+	errorClassLabel = "error_class"
+	circuitLabel    = "circuit"
+	stateLabel      = "state"
+	traceIDLabel    = "trace_id"
+	spanIDLabel     = "span_id"
 )
 
-func NewPrometheusMetrics(registry *prometheus.Registry) *prometheusmetrics {
-	m := &prometheusmetrics{
-		registry:      registry,
-		queryCount:    make(map[string]*prometheus.CounterVec),
-		queryDuration: make(map[string]*prometheus.HistogramVec),
+// NewPrometheusMetrics creates a prometheus metrics publisher with the default configuration registered at registry
+func NewPrometheusMetrics(registry *prometheus.Registry) (*prometheusmetrics, error) {
+	return NewPrometheusMetricsWithConfig(registry, DefaultPrometheusConfig())
+}
+
+// NewPrometheusMetricsWithConfig creates a prometheus metrics publisher registered at registerer; publishers of repositories
+// and indexes sharing a registerer and configuration share their collectors. It fails if a collector cannot be registered,
+// e.g. because a different collector of the same name is registered at registerer
+func NewPrometheusMetricsWithConfig(registerer prometheus.Registerer, config PrometheusConfig) (*prometheusmetrics, error) {
+	buckets := config.Buckets
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+	itemBuckets := config.ItemBuckets
+	if len(itemBuckets) == 0 {
+		itemBuckets = defaultItemBuckets()
+	}
+	payloadBuckets := config.PayloadBuckets
+	if len(payloadBuckets) == 0 {
+		payloadBuckets = defaultPayloadBuckets()
 	}
-	return m
+
+	var customLabels []string
+	for _, label := range config.Labels {
+		if label != sourceLabel && !slices.Contains(customLabels, label) {
+			customLabels = append(customLabels, label)
+		}
+	}
+	operationLabels := append([]string{operationLabel, tableLabel, indexLabel, statusLabel, sourceLabel}, customLabels...)
+
+	m := &prometheusmetrics{customLabels: customLabels}
+	r := registration{registerer: registerer}
+	m.operationCount = register(&r, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      "operations_total",
+		Help:      "counter of repository and index operations",
+	}, operationLabels))
+	m.operationDuration = register(&r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "duration of repository and index operations in seconds",
+		Buckets:   buckets,
+	}, operationLabels))
+	m.itemCount = register(&r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Name:      "operation_items",
+		Help:      "number of items read or written by queries, scans and batch operations",
+		Buckets:   itemBuckets,
+	}, []string{operationLabel, tableLabel, indexLabel, sourceLabel}))
+	m.payloadSize = register(&r, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: config.Namespace,
+		Name:      "operation_payload_bytes",
+		Help:      "marshalled size of the items read or written by queries, scans and batch operations in bytes",
+		Buckets:   payloadBuckets,
+	}, []string{operationLabel, tableLabel, indexLabel, sourceLabel}))
+	m.retryCount = register(&r, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      "retries_total",
+		Help:      "counter of retried operations",
+	}, []string{operationLabel, tableLabel, indexLabel, errorClassLabel, sourceLabel}))
+	m.circuitState = register(&r, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: config.Namespace,
+		Name:      "circuit_breaker_state",
+		Help:      "state of circuit breakers per table and index, 1 for the current state",
+	}, []string{circuitLabel, stateLabel}))
+	m.readCapacity = register(&r, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      "consumed_read_capacity_units_total",
+		Help:      "counter of consumed read capacity units per table, index and source",
+	}, []string{tableLabel, indexLabel, sourceLabel}))
+	m.writeCapacity = register(&r, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: config.Namespace,
+		Name:      "consumed_write_capacity_units_total",
+		Help:      "counter of consumed write capacity units per table, index and source",
+	}, []string{tableLabel, indexLabel, sourceLabel}))
+	if r.err != nil {
+		r.rollback()
+		return nil, r.err
+	}
+	return m, nil
 }
 
-func (m *prometheusmetrics) Record(ctx context.Context, caller string, key KeyInterface, duration time.Duration, success bool) {
-	if m.queryCount[caller] == nil || m.queryDuration[caller] == nil {
-		m.queryCount[caller] = m.newCounter(caller)
-		m.queryDuration[caller] = m.newHistogramVec(caller)
+// registration keeps the collectors registered for a publisher and the first error of registering them
+type registration struct {
+	registerer prometheus.Registerer
+	registered []prometheus.Collector
+	err        error
+}
+
+// rollback unregisters the collectors registered before an error
+func (r *registration) rollback() {
+	for _, collector := range r.registered {
+		r.registerer.Unregister(collector)
 	}
+}
 
+// register registers collector or returns the equal collector registered before; after an error it does nothing
+func register[C prometheus.Collector](r *registration, collector C) C {
+	if r.err != nil {
+		return collector
+	}
+	if err := r.registerer.Register(collector); err != nil {
+		registered, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			r.err = err
+			return collector
+		}
+		existing, ok := registered.ExistingCollector.(C)
+		if !ok {
+			r.err = err
+			return collector
+		}
+		return existing
+	}
+	r.registered = append(r.registered, collector)
+	return collector
+}
+
+func (m *prometheusmetrics) Record(ctx context.Context, caller string, key KeyInterface, duration time.Duration, success bool) {
 	status := StatusFailure
 	if success {
 		status = StatusSuccess
 	}
 
-	labels := prometheus.Labels{statusLabel: status}
-	if key.TableName() != "" {
-		labels[tableLabel] = strings.ToLower(key.TableName())
+	contextLabels := LabelsFromContext(ctx)
+	labels := prometheus.Labels{
+		operationLabel: caller,
+		tableLabel:     tableNameOf(key),
+		indexLabel:     labelName(IndexNameFromContext(ctx)),
+		statusLabel:    status,
+		sourceLabel:    contextLabels[labelSource],
+	}
+	for _, label := range m.customLabels {
+		labels[label] = contextLabels[label]
+	}
+
+	exemplar := exemplarFromContext(ctx)
+	addWithExemplar(m.operationCount.With(labels), 1, exemplar)
+	observeWithExemplar(m.operationDuration.With(labels), duration.Seconds(), exemplar)
+}
+
+func (m *prometheusmetrics) RecordItems(ctx context.Context, caller string, key KeyInterface, count int, bytes int) {
+	labels := prometheus.Labels{
+		operationLabel: caller,
+		tableLabel:     tableNameOf(key),
+		indexLabel:     labelName(IndexNameFromContext(ctx)),
+		sourceLabel:    LabelsFromContext(ctx)[labelSource],
+	}
+
+	exemplar := exemplarFromContext(ctx)
+	observeWithExemplar(m.itemCount.With(labels), float64(count), exemplar)
+	observeWithExemplar(m.payloadSize.With(labels), float64(bytes), exemplar)
+}
+
+func (m *prometheusmetrics) RecordRetry(ctx context.Context, caller string, key KeyInterface, class ErrorClass) {
+	addWithExemplar(m.retryCount.With(prometheus.Labels{
+		operationLabel:  caller,
+		tableLabel:      tableNameOf(key),
+		indexLabel:      labelName(IndexNameFromContext(ctx)),
+		errorClassLabel: string(class),
+		sourceLabel:     LabelsFromContext(ctx)[labelSource],
+	}), 1, exemplarFromContext(ctx))
+}
+
+func (m *prometheusmetrics) RecordCircuitState(ctx context.Context, circuit string, state CircuitState) {
+	for _, s := range []CircuitState{CircuitClosed, CircuitOpen, CircuitHalfOpen} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		m.circuitState.With(prometheus.Labels{circuitLabel: labelName(circuit), stateLabel: string(s)}).Set(value)
+	}
+}
+
+func (m *prometheusmetrics) RecordConsumedCapacity(ctx context.Context, tableName string, indexName string, capacity Capacity) {
+	labels := prometheus.Labels{
+		tableLabel:  labelName(tableName),
+		indexLabel:  labelName(indexName),
+		sourceLabel: LabelsFromContext(ctx)[labelSource],
 	}
 
-	maps.Copy(labels, LabelsFromContext(ctx))
+	exemplar := exemplarFromContext(ctx)
+	if capacity.ReadUnits > 0 {
+		addWithExemplar(m.readCapacity.With(labels), capacity.ReadUnits, exemplar)
+	}
+	if capacity.WriteUnits > 0 {
+		addWithExemplar(m.writeCapacity.With(labels), capacity.WriteUnits, exemplar)
+	}
+}
 
-	m.queryCount[caller].With(labels).Inc()
-	m.queryDuration[caller].With(labels).Observe(float64(duration))
+func tableNameOf(key KeyInterface) string {
+	if key == nil {
+		return ""
+	}
+	return labelName(key.TableName())
+}
+
+// labelName normalises the names of tables, indexes and circuits used as label values of all metrics
+func labelName(name string) string {
+	return strings.ToLower(name)
+}
+
+// exemplarFromContext links samples to the sampled span in ctx; nil if there is none
+func exemplarFromContext(ctx context.Context) prometheus.Labels {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsSampled() {
+		return nil
+	}
+	return prometheus.Labels{
+		traceIDLabel: spanContext.TraceID().String(),
+		spanIDLabel:  spanContext.SpanID().String(),
+	}
+}
+
+func addWithExemplar(counter prometheus.Counter, value float64, exemplar prometheus.Labels) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && exemplar != nil {
+		adder.AddWithExemplar(value, exemplar)
+		return
+	}
+	counter.Add(value)
+}
+
+func observeWithExemplar(observer prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		exemplarObserver.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
 }
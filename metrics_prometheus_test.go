@@ -0,0 +1,258 @@
+package djoemo_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusMetrics", func() {
+	const (
+		UserTableName = "UserTable"
+		IndexName     = "Email-Index"
+	)
+
+	var (
+		registry *prometheus.Registry
+		key      djoemo.KeyInterface
+	)
+
+	metricNamed := func(name string) []*dto.Metric {
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+		for _, family := range families {
+			if family.GetName() == name {
+				return family.GetMetric()
+			}
+		}
+		return nil
+	}
+
+	labelsOf := func(metric *dto.Metric) map[string]string {
+		labels := make(map[string]string)
+		for _, label := range metric.GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		return labels
+	}
+
+	BeforeEach(func() {
+		registry = prometheus.NewRegistry()
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+	})
+
+	Describe("Repository", func() {
+		var (
+			dAPIMock   *mock.MockDynamoDBAPI
+			repository djoemo.RepositoryInterface
+		)
+
+		BeforeEach(func() {
+			mockCtrl := gomock.NewController(GinkgoT())
+			dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+			repository = djoemo.NewRepository(dAPIMock)
+			repository.WithPrometheusMetrics(registry)
+		})
+
+		It("should record operations with the declared labels", func() {
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil)
+
+			ctx := djoemo.WithSourceLabel(context.Background(), "worker")
+			_, err := repository.GetItemWithContext(ctx, key, &User{})
+			Expect(err).To(BeNil())
+
+			counters := metricNamed("djoemo_operations_total")
+			Expect(counters).To(HaveLen(1))
+			Expect(labelsOf(counters[0])).To(Equal(map[string]string{
+				"operation": djoemo.OpRead,
+				"table":     "usertable",
+				"index":     "",
+				"status":    djoemo.StatusSuccess,
+				"source":    "worker",
+			}))
+			Expect(counters[0].GetCounter().GetValue()).To(Equal(1.0))
+
+			histograms := metricNamed("djoemo_operation_duration_seconds")
+			Expect(histograms).To(HaveLen(1))
+			Expect(histograms[0].GetHistogram().GetSampleCount()).To(Equal(uint64(1)))
+			Expect(histograms[0].GetHistogram().GetSampleSum()).To(BeNumerically("<", 1))
+		})
+
+		It("should record the index of index operations", func() {
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{}, nil)
+
+			query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("email")
+			Expect(repository.GIndex(IndexName).QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+			counters := metricNamed("djoemo_operations_total")
+			Expect(counters).To(HaveLen(1))
+			Expect(labelsOf(counters[0])).To(HaveKeyWithValue("index", "email-index"))
+		})
+
+		It("should observe the items and payload size of queries", func() {
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{
+				Items: []map[string]*dynamodb.AttributeValue{
+					{"UUID": {S: aws.String("uuid1")}},
+					{"UUID": {S: aws.String("uuid2")}, "Meta": {M: map[string]*dynamodb.AttributeValue{"a": {S: aws.String("bc")}}}},
+				},
+			}, nil)
+
+			query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+			Expect(repository.QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+			items := metricNamed("djoemo_operation_items")
+			Expect(items).To(HaveLen(1))
+			Expect(labelsOf(items[0])).To(HaveKeyWithValue("operation", djoemo.OpRead))
+			Expect(items[0].GetHistogram().GetSampleSum()).To(Equal(2.0))
+
+			// 4+5 bytes, 4+5 bytes and 4 bytes name, 3 bytes map, 1+1 bytes entry, 2 bytes value
+			payload := metricNamed("djoemo_operation_payload_bytes")
+			Expect(payload).To(HaveLen(1))
+			Expect(payload[0].GetHistogram().GetSampleSum()).To(Equal(29.0))
+		})
+
+		It("should observe the payload size of the last attempt of retried queries", func() {
+			first := &dynamodb.QueryOutput{
+				Items:            []map[string]*dynamodb.AttributeValue{{"UUID": {S: aws.String("uuid1")}}},
+				LastEvaluatedKey: map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("uuid1")}},
+			}
+			second := &dynamodb.QueryOutput{Items: []map[string]*dynamodb.AttributeValue{{"UUID": {S: aws.String("uuid2")}}}}
+			throttled := awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throughput exceeded", nil), 400, "request-id")
+			gomock.InOrder(
+				dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(first, nil),
+				dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled),
+				dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(first, nil),
+				dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(second, nil),
+			)
+			repository.WithRetryPolicy(djoemo.RetryPolicy{MaxAttempts: 2, RetryOn: []djoemo.ErrorClass{djoemo.ErrorClassThroughputExceeded}})
+
+			query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+			users := []User{}
+			Expect(repository.QueryWithContext(context.Background(), query, &users)).To(BeNil())
+			Expect(users).To(HaveLen(2))
+
+			// 4+5 bytes of each item
+			Expect(metricNamed("djoemo_operation_items")[0].GetHistogram().GetSampleSum()).To(Equal(2.0))
+			Expect(metricNamed("djoemo_operation_payload_bytes")[0].GetHistogram().GetSampleSum()).To(Equal(18.0))
+		})
+
+		It("should observe the items and payload size of batch writes", func() {
+			dAPIMock.EXPECT().BatchWriteItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.BatchWriteItemOutput{}, nil)
+
+			users := []User{{UUID: "uuid1"}, {UUID: "uuid2"}}
+			Expect(repository.SaveItemsWithContext(context.Background(), key, users)).To(BeNil())
+
+			items := metricNamed("djoemo_operation_items")
+			Expect(items).To(HaveLen(1))
+			Expect(labelsOf(items[0])).To(HaveKeyWithValue("operation", djoemo.OpCommit))
+			Expect(items[0].GetHistogram().GetSampleSum()).To(Equal(2.0))
+			Expect(metricNamed("djoemo_operation_payload_bytes")[0].GetHistogram().GetSampleSum()).To(BeNumerically(">", 0))
+		})
+
+		It("should not observe the items of failed operations", func() {
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).Return(nil, awserr.New("ValidationException", "invalid", nil))
+
+			query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+			Expect(repository.QueryWithContext(context.Background(), query, &[]User{})).ToNot(BeNil())
+
+			Expect(metricNamed("djoemo_operation_items")).To(BeEmpty())
+		})
+	})
+
+	It("should only publish allowed context labels", func() {
+		metrics, err := djoemo.NewPrometheusMetricsWithConfig(registry, djoemo.PrometheusConfig{
+			Namespace: "app",
+			Labels:    []string{"tenant"},
+		})
+		Expect(err).To(BeNil())
+
+		ctx := djoemo.AddMetrics(context.Background(), "tenant", "acme")
+		ctx = djoemo.AddMetrics(ctx, "user", "uuid")
+		metrics.Record(ctx, djoemo.OpCommit, key, time.Millisecond, false)
+
+		counters := metricNamed("app_operations_total")
+		Expect(counters).To(HaveLen(1))
+		Expect(labelsOf(counters[0])).To(HaveKeyWithValue("tenant", "acme"))
+		Expect(labelsOf(counters[0])).To(HaveKeyWithValue("status", djoemo.StatusFailure))
+		Expect(labelsOf(counters[0])).ToNot(HaveKey("user"))
+	})
+
+	It("should observe durations in seconds with the configured buckets", func() {
+		metrics, err := djoemo.NewPrometheusMetricsWithConfig(registry, djoemo.PrometheusConfig{
+			Buckets: []float64{0.1, 1},
+		})
+		Expect(err).To(BeNil())
+
+		metrics.Record(context.Background(), djoemo.OpRead, key, 500*time.Millisecond, true)
+
+		histograms := metricNamed("operation_duration_seconds")
+		Expect(histograms).To(HaveLen(1))
+		buckets := histograms[0].GetHistogram().GetBucket()
+		Expect(buckets).To(HaveLen(2))
+		Expect(buckets[0].GetCumulativeCount()).To(Equal(uint64(0)))
+		Expect(buckets[1].GetCumulativeCount()).To(Equal(uint64(1)))
+	})
+
+	It("should share collectors between publishers and record concurrently", func() {
+		first, err := djoemo.NewPrometheusMetrics(registry)
+		Expect(err).To(BeNil())
+		second, err := djoemo.NewPrometheusMetrics(registry)
+		Expect(err).To(BeNil())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				first.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+			}()
+			go func() {
+				defer wg.Done()
+				second.Record(context.Background(), djoemo.OpRead, key, time.Millisecond, true)
+			}()
+		}
+		wg.Wait()
+
+		counters := metricNamed("djoemo_operations_total")
+		Expect(counters).To(HaveLen(1))
+		Expect(counters[0].GetCounter().GetValue()).To(Equal(20.0))
+	})
+
+	It("should fail if a different collector of the same name is registered", func() {
+		registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Namespace: "djoemo", Name: "retries_total", Help: "other"}))
+
+		_, err := djoemo.NewPrometheusMetrics(registry)
+
+		Expect(err).ToNot(BeNil())
+		Expect(metricNamed("djoemo_operations_total")).To(BeEmpty())
+		_, err = djoemo.NewPrometheusMetricsWithConfig(registry, djoemo.PrometheusConfig{Namespace: "djoemo"})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("should label tables and indexes alike in all metrics", func() {
+		metrics, err := djoemo.NewPrometheusMetrics(registry)
+		Expect(err).To(BeNil())
+
+		metrics.RecordConsumedCapacity(context.Background(), UserTableName, IndexName, djoemo.Capacity{ReadUnits: 1})
+
+		capacity := metricNamed("djoemo_consumed_read_capacity_units_total")
+		Expect(capacity).To(HaveLen(1))
+		Expect(labelsOf(capacity[0])).To(HaveKeyWithValue("table", "usertable"))
+		Expect(labelsOf(capacity[0])).To(HaveKeyWithValue("index", "email-index"))
+	})
+})
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Usage of Command
+const Usage = `usage: <command>
+
+commands:
+  up        apply pending migrations
+  dry-run   run pending migrations without writing and count the skipped writes
+  status    list migrations with their status
+`
+
+// Command runs the command in args[0], like a subcommand of a binary that registers the migrations:
+//
+//	if err := migrator.Command(ctx, os.Args[1:], os.Stdout); err != nil {
+//		log.Fatal(err)
+//	}
+func (m *Migrator) Command(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return fmt.Errorf("migrate: expected one command\n%s", Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := m.Up(ctx); err != nil {
+			return err
+		}
+		records, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return writeRecords(out, records)
+	case "dry-run":
+		records, err := m.DryRun(ctx)
+		if writeErr := writeRecords(out, records); writeErr != nil && err == nil {
+			err = writeErr
+		}
+		return err
+	case "status":
+		records, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return writeRecords(out, records)
+	}
+	return fmt.Errorf("migrate: unknown command %q\n%s", args[0], Usage)
+}
+
+// writeRecords writes records as table; skipped writes are listed for dry runs
+func writeRecords(out io.Writer, records []Record) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tFINISHED\tDETAILS")
+	for _, record := range records {
+		finished := "-"
+		if !record.FinishedAt.IsZero() {
+			finished = record.FinishedAt.UTC().Format(time.RFC3339)
+		}
+		details := record.Description
+		switch {
+		case record.Status == Running:
+			details = "claimed by " + record.Owner
+		case record.Error != "":
+			details = record.Error
+		case record.SkippedWrites > 0:
+			details = fmt.Sprintf("skipped writes: %d", record.SkippedWrites)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", record.ID, record.Status, finished, details)
+	}
+	return w.Flush()
+}
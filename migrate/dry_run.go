@@ -0,0 +1,190 @@
+package migrate
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// dryRunClient passes reads to DynamoDB and skips writes of items and changes of tables; skipped writes are logged
+// and counted
+type dryRunClient struct {
+	dynamodbiface.DynamoDBAPI
+	log   djoemo.LogInterface
+	count atomic.Int64
+	// waived holds the tables whose creation or deletion was skipped, so waiting for them returns right away
+	waived sync.Map
+}
+
+func (c *dryRunClient) skip(ctx aws.Context, operation string, tableName string) {
+	c.count.Add(1)
+	log := c.log.WithContext(ctx).WithField("Operation", operation)
+	if tableName != "" {
+		log = log.WithField(djoemo.TableName, tableName)
+	}
+	log.Info("dry run: write skipped")
+}
+
+func (c *dryRunClient) skipped() int {
+	return int(c.count.Load())
+}
+
+// PutItem skips the put
+func (c *dryRunClient) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return c.PutItemWithContext(aws.BackgroundContext(), input)
+}
+
+// PutItemWithContext skips the put
+func (c *dryRunClient) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	c.skip(ctx, "PutItem", aws.StringValue(input.TableName))
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+// UpdateItem skips the update
+func (c *dryRunClient) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return c.UpdateItemWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateItemWithContext skips the update
+func (c *dryRunClient) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	c.skip(ctx, "UpdateItem", aws.StringValue(input.TableName))
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// DeleteItem skips the delete
+func (c *dryRunClient) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return c.DeleteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteItemWithContext skips the delete
+func (c *dryRunClient) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	c.skip(ctx, "DeleteItem", aws.StringValue(input.TableName))
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+// BatchWriteItem skips all writes of the batch
+func (c *dryRunClient) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	return c.BatchWriteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchWriteItemWithContext skips all writes of the batch
+func (c *dryRunClient) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	for tableName, requests := range input.RequestItems {
+		for range requests {
+			c.skip(ctx, "BatchWriteItem", tableName)
+		}
+	}
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// TransactWriteItems skips all writes of the transaction
+func (c *dryRunClient) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return c.TransactWriteItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactWriteItemsWithContext skips all writes of the transaction
+func (c *dryRunClient) TransactWriteItemsWithContext(ctx aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	for _, item := range input.TransactItems {
+		switch {
+		case item.Put != nil:
+			c.skip(ctx, "TransactWriteItems", aws.StringValue(item.Put.TableName))
+		case item.Update != nil:
+			c.skip(ctx, "TransactWriteItems", aws.StringValue(item.Update.TableName))
+		case item.Delete != nil:
+			c.skip(ctx, "TransactWriteItems", aws.StringValue(item.Delete.TableName))
+		}
+	}
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+// CreateTable skips the creation of the table
+func (c *dryRunClient) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	return c.CreateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateTableWithContext skips the creation of the table
+func (c *dryRunClient) CreateTableWithContext(ctx aws.Context, input *dynamodb.CreateTableInput, opts ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	c.skip(ctx, "CreateTable", aws.StringValue(input.TableName))
+	c.waived.Store(aws.StringValue(input.TableName), true)
+	return &dynamodb.CreateTableOutput{TableDescription: &dynamodb.TableDescription{
+		TableName:   input.TableName,
+		TableStatus: aws.String(dynamodb.TableStatusActive),
+	}}, nil
+}
+
+// UpdateTable skips the update of the table
+func (c *dryRunClient) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	return c.UpdateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTableWithContext skips the update of the table
+func (c *dryRunClient) UpdateTableWithContext(ctx aws.Context, input *dynamodb.UpdateTableInput, opts ...request.Option) (*dynamodb.UpdateTableOutput, error) {
+	c.skip(ctx, "UpdateTable", aws.StringValue(input.TableName))
+	return &dynamodb.UpdateTableOutput{}, nil
+}
+
+// DeleteTable skips the deletion of the table
+func (c *dryRunClient) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	return c.DeleteTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteTableWithContext skips the deletion of the table
+func (c *dryRunClient) DeleteTableWithContext(ctx aws.Context, input *dynamodb.DeleteTableInput, opts ...request.Option) (*dynamodb.DeleteTableOutput, error) {
+	c.skip(ctx, "DeleteTable", aws.StringValue(input.TableName))
+	c.waived.Store(aws.StringValue(input.TableName), true)
+	return &dynamodb.DeleteTableOutput{}, nil
+}
+
+// UpdateTimeToLive skips the update of time to live
+func (c *dryRunClient) UpdateTimeToLive(input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return c.UpdateTimeToLiveWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTimeToLiveWithContext skips the update of time to live
+func (c *dryRunClient) UpdateTimeToLiveWithContext(ctx aws.Context, input *dynamodb.UpdateTimeToLiveInput, opts ...request.Option) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	c.skip(ctx, "UpdateTimeToLive", aws.StringValue(input.TableName))
+	return &dynamodb.UpdateTimeToLiveOutput{TimeToLiveSpecification: input.TimeToLiveSpecification}, nil
+}
+
+// UpdateContinuousBackups skips the update of continuous backups
+func (c *dryRunClient) UpdateContinuousBackups(input *dynamodb.UpdateContinuousBackupsInput) (*dynamodb.UpdateContinuousBackupsOutput, error) {
+	return c.UpdateContinuousBackupsWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateContinuousBackupsWithContext skips the update of continuous backups
+func (c *dryRunClient) UpdateContinuousBackupsWithContext(ctx aws.Context, input *dynamodb.UpdateContinuousBackupsInput, opts ...request.Option) (*dynamodb.UpdateContinuousBackupsOutput, error) {
+	c.skip(ctx, "UpdateContinuousBackups", aws.StringValue(input.TableName))
+	return &dynamodb.UpdateContinuousBackupsOutput{}, nil
+}
+
+// WaitUntilTableExists returns right away for tables whose creation was skipped
+func (c *dryRunClient) WaitUntilTableExists(input *dynamodb.DescribeTableInput) error {
+	return c.WaitUntilTableExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableExistsWithContext returns right away for tables whose creation was skipped
+func (c *dryRunClient) WaitUntilTableExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	if _, ok := c.waived.Load(aws.StringValue(input.TableName)); ok {
+		return nil
+	}
+	return c.DynamoDBAPI.WaitUntilTableExistsWithContext(ctx, input, opts...)
+}
+
+// WaitUntilTableNotExists returns right away for tables whose deletion was skipped
+func (c *dryRunClient) WaitUntilTableNotExists(input *dynamodb.DescribeTableInput) error {
+	return c.WaitUntilTableNotExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableNotExistsWithContext returns right away for tables whose deletion was skipped
+func (c *dryRunClient) WaitUntilTableNotExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	if _, ok := c.waived.Load(aws.StringValue(input.TableName)); ok {
+		return nil
+	}
+	return c.DynamoDBAPI.WaitUntilTableNotExistsWithContext(ctx, input, opts...)
+}
@@ -0,0 +1,355 @@
+// Package migrate applies data migrations written in Go, like backfilling the attribute of an index or renaming a
+// field. Applied migrations are tracked in a table; a migration is claimed by a conditional write before it runs, so
+// only one instance runs it even if several instances start at the same time.
+//
+//	migrator := migrate.New(client)
+//	err := migrator.Register(migrate.Migration{
+//		ID:          "2024-05-01-backfill-email",
+//		Description: "set Email of users for the email index",
+//		Up: func(ctx context.Context, env migrate.Env) error {
+//			return env.Repository.UpdateWithContext(ctx, djoemo.Set, key, map[string]any{"Email": email})
+//		},
+//	})
+//	err = migrator.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// DefaultTableName is the name of the table tracking applied migrations
+const DefaultTableName = "djoemo_migrations"
+
+// DefaultLease is how long a claimed migration is reserved for its instance; the lease is extended while the
+// migration runs, so it only expires if the instance stops
+const DefaultLease = 5 * time.Minute
+
+// ErrMigrationRunning migration is claimed by another instance
+var ErrMigrationRunning = errors.New("migration is running")
+
+// ErrLeaseLost lease of a running migration expired and the migration was claimed by another instance
+var ErrLeaseLost = errors.New("lease of migration lost")
+
+// Status of a migration
+type Status string
+
+// Statuses of migrations
+const (
+	Pending Status = "pending"
+	Running Status = "running"
+	Applied Status = "applied"
+	Failed  Status = "failed"
+)
+
+// Migration is a data migration
+type Migration struct {
+	// ID identifies the migration; migrations are applied in the order of their IDs, so prefix them by a date
+	ID          string
+	Description string
+	// Up migrates the data; it should be safe to run again after it failed
+	Up func(ctx context.Context, env Env) error
+}
+
+// Env is passed to migrations
+type Env struct {
+	// Repository to read and write data; writes and changes of tables are skipped in dry runs
+	Repository djoemo.RepositoryInterface
+	// DryRun is true if the migration should only report what it would change
+	DryRun bool
+	// Log of the migrator with the field Migration set to the ID of the migration
+	Log djoemo.LogInterface
+}
+
+// Record tracks a migration in the migrations table
+type Record struct {
+	ID          string `dynamo:",hash"`
+	Description string
+	Status      Status
+	// Owner is the instance that claimed the migration
+	Owner     string
+	StartedAt time.Time
+	// LeaseExpires is the unix time the claim of a running migration expires at
+	LeaseExpires int64
+	FinishedAt   time.Time `dynamo:",omitempty"`
+	Error        string    `dynamo:",omitempty"`
+	// SkippedWrites counts the writes skipped by a dry run; it is not stored
+	SkippedWrites int `dynamo:"-"`
+}
+
+// Migrator applies registered migrations
+type Migrator struct {
+	client     dynamodbiface.DynamoDBAPI
+	repository djoemo.RepositoryInterface
+	tableName  string
+	owner      string
+	lease      time.Duration
+	log        djoemo.LogInterface
+	migrations map[string]Migration
+	now        func() time.Time
+}
+
+// New factory method for a migrator tracking migrations in DefaultTableName; client is used by the repositories
+// passed to migrations as well
+func New(client dynamodbiface.DynamoDBAPI) *Migrator {
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		client:     client,
+		repository: djoemo.NewRepository(client),
+		tableName:  DefaultTableName,
+		owner:      fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		lease:      DefaultLease,
+		log:        djoemo.NewNopLog(),
+		migrations: make(map[string]Migration),
+		now:        time.Now,
+	}
+}
+
+// WithTableName sets the name of the table tracking migrations
+func (m *Migrator) WithTableName(tableName string) *Migrator {
+	m.tableName = tableName
+	return m
+}
+
+// WithOwner sets the name of the instance recorded for the migrations it runs; hostname and pid by default
+func (m *Migrator) WithOwner(owner string) *Migrator {
+	m.owner = owner
+	return m
+}
+
+// WithLease sets how long a claimed migration is reserved for this instance without being extended; leases are
+// stored in seconds, so a lease under a second falls back to DefaultLease
+func (m *Migrator) WithLease(lease time.Duration) *Migrator {
+	if lease < time.Second {
+		lease = DefaultLease
+	}
+	m.lease = lease
+	return m
+}
+
+// WithLog enables logging of the migrator and of the repositories passed to migrations
+func (m *Migrator) WithLog(log djoemo.LogInterface) *Migrator {
+	m.log = log
+	m.repository.WithLog(log)
+	return m
+}
+
+// Register adds migrations; IDs must be unique
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.ID == "" || migration.Up == nil {
+			return fmt.Errorf("migrate: migration %q needs an ID and an Up function", migration.ID)
+		}
+		if _, ok := m.migrations[migration.ID]; ok {
+			return fmt.Errorf("migrate: migration %s is registered twice", migration.ID)
+		}
+		m.migrations[migration.ID] = migration
+	}
+	return nil
+}
+
+// Up applies all pending migrations in the order of their IDs and stops at the first failed migration; migrations
+// that failed before are retried. It creates the migrations table if it does not exist.
+func (m *Migrator) Up(ctx context.Context) error {
+	err := m.repository.EnsureTable(ctx, djoemo.TableSchema{
+		Name:           m.tableName,
+		HashKey:        "ID",
+		AttributeTypes: map[string]string{"ID": dynamodb.ScalarAttributeTypeS},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range m.sorted() {
+		record, err := m.record(ctx, migration)
+		if err != nil {
+			return err
+		}
+		if record.Status == Applied {
+			continue
+		}
+		if err := m.apply(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DryRun runs all pending migrations with Env.DryRun set and a repository skipping writes of items and
+// changes of tables; the returned records count the skipped writes. Nothing is tracked in the migrations table.
+func (m *Migrator) DryRun(ctx context.Context) ([]Record, error) {
+	var records []Record
+	for _, migration := range m.sorted() {
+		record, err := m.record(ctx, migration)
+		if err != nil {
+			return records, err
+		}
+		if record.Status == Applied {
+			continue
+		}
+
+		client := &dryRunClient{DynamoDBAPI: m.client, log: m.log.WithField("Migration", migration.ID)}
+		repository := djoemo.NewRepository(client)
+		repository.WithLog(m.log)
+		err = migration.Up(ctx, Env{Repository: repository, DryRun: true, Log: client.log})
+		record.SkippedWrites = client.skipped()
+		records = append(records, record)
+		if err != nil {
+			return records, fmt.Errorf("migration %s: %w", migration.ID, err)
+		}
+	}
+	return records, nil
+}
+
+// Status returns the records of all registered migrations in the order they are applied; migrations that are not
+// tracked yet are Pending
+func (m *Migrator) Status(ctx context.Context) ([]Record, error) {
+	var records []Record
+	for _, migration := range m.sorted() {
+		record, err := m.record(ctx, migration)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (m *Migrator) sorted() []Migration {
+	migrations := make([]Migration, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+	return migrations
+}
+
+func (m *Migrator) key(id string) djoemo.KeyInterface {
+	return djoemo.Key().WithTableName(m.tableName).WithHashKeyName("ID").WithHashKey(id)
+}
+
+// record returns the record of a migration; migrations without record or without migrations table are Pending
+func (m *Migrator) record(ctx context.Context, migration Migration) (Record, error) {
+	record := Record{}
+	found, err := m.repository.GetItemWithContext(ctx, m.key(migration.ID), &record)
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		found, err = false, nil
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	if !found {
+		return Record{ID: migration.ID, Description: migration.Description, Status: Pending}, nil
+	}
+	return record, nil
+}
+
+// apply claims a migration, runs it while extending its lease and records the result
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	log := m.log.WithContext(ctx).WithField("Migration", migration.ID)
+	record := Record{
+		ID:           migration.ID,
+		Description:  migration.Description,
+		Status:       Running,
+		Owner:        m.owner,
+		StartedAt:    m.now().UTC(),
+		LeaseExpires: m.now().Add(m.lease).Unix(),
+	}
+	claimed, err := m.repository.ConditionalUpdateWithContext(ctx, m.key(migration.ID), record,
+		"attribute_not_exists($) OR $ = ? OR ($ = ? AND $ < ?)",
+		"ID", "Status", Failed, "Status", Running, "LeaseExpires", m.now().Unix())
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		current, err := m.record(ctx, migration)
+		if err != nil {
+			return err
+		}
+		if current.Status == Applied {
+			return nil
+		}
+		return fmt.Errorf("%w: %s is claimed by %s until %s", ErrMigrationRunning, migration.ID, current.Owner,
+			time.Unix(current.LeaseExpires, 0).UTC().Format(time.RFC3339))
+	}
+
+	log.Info("running migration")
+	runCtx, cancel := context.WithCancel(ctx)
+	extending := m.extendLease(runCtx, cancel, record)
+	err = migration.Up(runCtx, Env{Repository: m.repository, Log: log})
+	cancel()
+	if lost := extending.Wait(); lost != nil {
+		err = errors.Join(lost, err)
+	}
+
+	record.FinishedAt = m.now().UTC()
+	record.LeaseExpires = 0
+	record.Status = Applied
+	if err != nil {
+		record.Status = Failed
+		record.Error = err.Error()
+	}
+	finished, updateErr := m.repository.ConditionalUpdateWithContext(ctx, m.key(migration.ID), record,
+		"$ = ? AND $ = ?", "Owner", m.owner, "Status", Running)
+	switch {
+	case updateErr != nil:
+		err = errors.Join(err, updateErr)
+	case !finished:
+		err = errors.Join(err, fmt.Errorf("%w: %s", ErrLeaseLost, migration.ID))
+	}
+	if err != nil {
+		log.WithField("Error", err.Error()).Error("migration failed")
+		return fmt.Errorf("migration %s: %w", migration.ID, err)
+	}
+	log.Info("migration applied")
+	return nil
+}
+
+// lease extends the lease of a running migration until its context is done; the migration is canceled if the lease
+// is lost
+type lease struct {
+	done chan struct{}
+	err  error
+}
+
+// Wait returns ErrLeaseLost if the lease could not be extended because another instance claimed the migration
+func (l *lease) Wait() error {
+	<-l.done
+	return l.err
+}
+
+func (m *Migrator) extendLease(ctx context.Context, cancel context.CancelFunc, record Record) *lease {
+	l := &lease{done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(m.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			record.LeaseExpires = m.now().Add(m.lease).Unix()
+			extended, err := m.repository.ConditionalUpdateWithContext(ctx, m.key(record.ID), record,
+				"$ = ? AND $ = ?", "Owner", m.owner, "Status", Running)
+			if err == nil && !extended {
+				l.err = fmt.Errorf("%w: %s", ErrLeaseLost, record.ID)
+				cancel()
+				return
+			}
+		}
+	}()
+	return l
+}
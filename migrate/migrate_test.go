@@ -0,0 +1,276 @@
+package migrate_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/migrate"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	UUID     string
+	UserName string
+	Name     string
+}
+
+var _ = Describe("Migrator", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New().WithTable(UserTableName, "UUID", "")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	migrationKey := func(id string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(migrate.DefaultTableName).WithHashKeyName("ID").WithHashKey(id)
+	}
+
+	// renameUserName renames the attribute UserName of users to Name
+	renameUserName := migrate.Migration{
+		ID:          "2024-01-02-rename-user-name",
+		Description: "rename UserName to Name",
+		Up: func(ctx context.Context, env migrate.Env) error {
+			users := []User{}
+			iterator, err := env.Repository.ScanIteratorWithContext(ctx, djoemo.Key().WithTableName(UserTableName), 100)
+			if err != nil {
+				return err
+			}
+			for user := (User{}); iterator.NextItem(&user); user = (User{}) {
+				users = append(users, user)
+			}
+			for _, user := range users {
+				user.Name, user.UserName = user.UserName, ""
+				if err := env.Repository.SaveItemWithContext(ctx, userKey(user.UUID), user); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	saveUsers := func(names ...string) {
+		for _, name := range names {
+			Expect(repository.SaveItemWithContext(ctx, userKey(name), User{UUID: name, UserName: name})).To(Succeed())
+		}
+	}
+
+	record := func(id string) migrate.Record {
+		record := migrate.Record{}
+		found, err := repository.GetItemWithContext(ctx, migrationKey(id), &record)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		return record
+	}
+
+	It("should apply pending migrations once in the order of their IDs", func() {
+		saveUsers("a", "b")
+		var order []string
+		migrator := migrate.New(db).WithOwner("instance").WithLease(0)
+		Expect(migrator.Register(renameUserName, migrate.Migration{
+			ID: "2024-01-01-first",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				order = append(order, "2024-01-01-first")
+				return nil
+			},
+		})).To(Succeed())
+
+		Expect(migrator.Up(ctx)).To(Succeed())
+		Expect(migrator.Up(ctx)).To(Succeed())
+		Expect(order).To(Equal([]string{"2024-01-01-first"}))
+
+		user := User{}
+		_, err := repository.GetItemWithContext(ctx, userKey("a"), &user)
+		Expect(err).To(BeNil())
+		Expect(user).To(Equal(User{UUID: "a", Name: "a"}))
+
+		applied := record(renameUserName.ID)
+		Expect(applied.Status).To(Equal(migrate.Applied))
+		Expect(applied.Owner).To(Equal("instance"))
+		Expect(applied.Description).To(Equal("rename UserName to Name"))
+		Expect(applied.FinishedAt).NotTo(BeZero())
+	})
+
+	It("should record failed migrations, stop and retry them", func() {
+		attempts := 0
+		later := false
+		migrator := migrate.New(db)
+		Expect(migrator.Register(migrate.Migration{
+			ID: "1-flaky",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				attempts++
+				if attempts == 1 {
+					return errors.New("boom")
+				}
+				return nil
+			},
+		}, migrate.Migration{
+			ID: "2-later",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				later = true
+				return nil
+			},
+		})).To(Succeed())
+
+		err := migrator.Up(ctx)
+		Expect(err).To(MatchError("migration 1-flaky: boom"))
+		Expect(later).To(BeFalse())
+		Expect(record("1-flaky").Status).To(Equal(migrate.Failed))
+		Expect(record("1-flaky").Error).To(Equal("boom"))
+
+		Expect(migrator.Up(ctx)).To(Succeed())
+		Expect(attempts).To(Equal(2))
+		Expect(later).To(BeTrue())
+		Expect(record("1-flaky").Status).To(Equal(migrate.Applied))
+		Expect(record("1-flaky").Error).To(BeEmpty())
+	})
+
+	It("should not run migrations claimed by another instance until their lease expired", func() {
+		runs := 0
+		migrator := migrate.New(db)
+		Expect(migrator.Register(migrate.Migration{
+			ID: "1-claimed",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				runs++
+				return nil
+			},
+		})).To(Succeed())
+		Expect(migrator.Status(ctx)).To(HaveLen(1))
+		Expect(migrator.Up(ctx)).To(Succeed())
+		claimed := migrate.Record{ID: "1-claimed", Status: migrate.Running, Owner: "other", LeaseExpires: time.Now().Add(time.Minute).Unix()}
+		Expect(repository.SaveItemWithContext(ctx, migrationKey("1-claimed"), claimed)).To(Succeed())
+
+		err := migrator.Up(ctx)
+		Expect(err).To(MatchError(migrate.ErrMigrationRunning))
+		Expect(err.Error()).To(ContainSubstring("1-claimed is claimed by other until"))
+		Expect(runs).To(Equal(1))
+
+		claimed.LeaseExpires = time.Now().Add(-time.Minute).Unix()
+		Expect(repository.SaveItemWithContext(ctx, migrationKey("1-claimed"), claimed)).To(Succeed())
+		Expect(migrator.Up(ctx)).To(Succeed())
+		Expect(runs).To(Equal(2))
+	})
+
+	It("should run each migration in one of several instances", func() {
+		var runs atomic.Int32
+		migration := migrate.Migration{
+			ID: "1-once",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				runs.Add(1)
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			},
+		}
+
+		var wg sync.WaitGroup
+		errs := make([]error, 4)
+		for i := range errs {
+			migrator := migrate.New(db)
+			Expect(migrator.Register(migration)).To(Succeed())
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer GinkgoRecover()
+				errs[i] = migrator.Up(ctx)
+			}()
+		}
+		wg.Wait()
+
+		Expect(runs.Load()).To(Equal(int32(1)))
+		for _, err := range errs {
+			if err != nil {
+				Expect(err).To(MatchError(migrate.ErrMigrationRunning))
+			}
+		}
+		Expect(record("1-once").Status).To(Equal(migrate.Applied))
+	})
+
+	It("should skip writes in dry runs", func() {
+		saveUsers("a", "b", "c")
+		migrator := migrate.New(db)
+		Expect(migrator.Register(renameUserName)).To(Succeed())
+
+		records, err := migrator.DryRun(ctx)
+		Expect(err).To(BeNil())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].Status).To(Equal(migrate.Pending))
+		Expect(records[0].SkippedWrites).To(Equal(3))
+
+		user := User{}
+		_, err = repository.GetItemWithContext(ctx, userKey("a"), &user)
+		Expect(err).To(BeNil())
+		Expect(user.UserName).To(Equal("a"))
+		Expect(db.Items(migrate.DefaultTableName)).To(BeNil())
+	})
+
+	It("should skip changes of tables in dry runs", func() {
+		migrator := migrate.New(db)
+		Expect(migrator.Register(migrate.Migration{
+			ID: "1-tables",
+			Up: func(ctx context.Context, env migrate.Env) error {
+				schema := djoemo.TableSchema{
+					Name:           "OrderTable",
+					HashKey:        "ID",
+					AttributeTypes: map[string]string{"ID": "S"},
+					TTLAttribute:   "ExpiresAt",
+				}
+				return env.Repository.CreateTable(ctx, schema)
+			},
+		})).To(Succeed())
+
+		records, err := migrator.DryRun(ctx)
+		Expect(err).To(BeNil())
+		Expect(records[0].SkippedWrites).To(Equal(2))
+		_, err = repository.DescribeTable(ctx, "OrderTable")
+		Expect(err).NotTo(BeNil())
+	})
+
+	It("should run commands", func() {
+		saveUsers("a")
+		migrator := migrate.New(db)
+		Expect(migrator.Register(renameUserName)).To(Succeed())
+
+		out := &bytes.Buffer{}
+		Expect(migrator.Command(ctx, []string{"status"}, out)).To(Succeed())
+		Expect(out.String()).To(Equal("" +
+			"ID                           STATUS   FINISHED  DETAILS\n" +
+			"2024-01-02-rename-user-name  pending  -         rename UserName to Name\n"))
+
+		out.Reset()
+		Expect(migrator.Command(ctx, []string{"dry-run"}, out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("pending  -         skipped writes: 1\n"))
+
+		out.Reset()
+		Expect(migrator.Command(ctx, []string{"up"}, out)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring("2024-01-02-rename-user-name  applied  "))
+
+		Expect(migrator.Command(ctx, []string{"down"}, out)).To(MatchError(ContainSubstring(`unknown command "down"`)))
+	})
+
+	It("should reject invalid migrations", func() {
+		migrator := migrate.New(db)
+		Expect(migrator.Register(migrate.Migration{ID: "1"})).To(MatchError(ContainSubstring("needs an ID and an Up function")))
+		Expect(migrator.Register(renameUserName)).To(Succeed())
+		Expect(migrator.Register(renameUserName)).To(MatchError(ContainSubstring("is registered twice")))
+	})
+})
@@ -0,0 +1,276 @@
+package mock
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// writeRequest holds the parts of a write or scan request the InputMatcher verifies
+type writeRequest struct {
+	table        *string
+	key          map[string]*dynamodb.AttributeValue
+	update       *string
+	condition    *string
+	filter       *string
+	names        map[string]*string
+	values       map[string]*dynamodb.AttributeValue
+	returnValues *string
+}
+
+// expectedExpression is an expression of an assertion with ? for its args
+type expectedExpression struct {
+	expression string
+	args       []interface{}
+}
+
+// scope replaces the ? of the expression by value placeholders and returns a scope resolving them
+func (e expectedExpression) scope() (string, *expr.Scope, error) {
+	values := make(map[string]*dynamodb.AttributeValue, len(e.args))
+	var b strings.Builder
+	n := 0
+	for _, r := range e.expression {
+		if r != '?' {
+			b.WriteRune(r)
+			continue
+		}
+		if n >= len(e.args) {
+			return "", nil, fmt.Errorf("missing argument for ? %d of %q", n+1, e.expression)
+		}
+		av, err := dynamo.Marshal(e.args[n])
+		if err != nil {
+			return "", nil, err
+		}
+		placeholder := ":v" + strconv.Itoa(n)
+		values[placeholder] = av
+		b.WriteString(placeholder)
+		n++
+	}
+	if n != len(e.args) {
+		return "", nil, fmt.Errorf("%d arguments for %d ? of %q", len(e.args), n, e.expression)
+	}
+	return b.String(), expr.NewScope(nil, values).AllowReserved(), nil
+}
+
+func (e expectedExpression) condition() ([]string, error) {
+	expression, scope, err := e.scope()
+	if err != nil {
+		return nil, err
+	}
+	condition, err := scope.Condition(expression)
+	if err != nil {
+		return nil, err
+	}
+	return conditionTerms(condition), nil
+}
+
+func (e expectedExpression) update() ([]string, error) {
+	expression, scope, err := e.scope()
+	if err != nil {
+		return nil, err
+	}
+	update, err := scope.Update(expression)
+	if err != nil {
+		return nil, err
+	}
+	return updateActions(update), nil
+}
+
+// String formats the expression with resolved args
+func (e expectedExpression) String() string {
+	expression, scope, err := e.scope()
+	if err != nil {
+		return e.expression
+	}
+	if condition, err := scope.Condition(expression); err == nil {
+		return condition.String()
+	}
+	if update, err := scope.Update(expression); err == nil {
+		return update.String()
+	}
+	return e.expression
+}
+
+// matchRequest returns an error describing the first expectation the request does not meet
+func (i InputMatcher) matchRequest(r writeRequest) error {
+	if i.TableName != "" && aws.StringValue(r.table) != i.TableName {
+		return fmt.Errorf("table %s is not %s", aws.StringValue(r.table), i.TableName)
+	}
+	for name, value := range i.keys {
+		av, err := dynamo.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if !expr.Equal(r.key[name], av) {
+			return fmt.Errorf("key %s is %s, not %s", name, expr.Format(r.key[name]), expr.Format(av))
+		}
+	}
+	if i.returnValues != nil && aws.StringValue(r.returnValues) != *i.returnValues {
+		return fmt.Errorf("return values %s are not %s", aws.StringValue(r.returnValues), *i.returnValues)
+	}
+
+	scope := expr.NewScope(r.names, r.values)
+	if i.update != nil || len(i.updateActions) > 0 {
+		if r.update == nil {
+			return fmt.Errorf("request has no update expression")
+		}
+		update, err := scope.Update(*r.update)
+		if err != nil {
+			return err
+		}
+		actual := updateActions(update)
+		if i.update != nil {
+			expected, err := i.update.update()
+			if err != nil {
+				return err
+			}
+			if !equalTerms(actual, expected) {
+				return fmt.Errorf("update %s is not %s", update, i.update)
+			}
+		}
+		for _, action := range i.updateActions {
+			expected, err := action.update()
+			if err != nil {
+				return err
+			}
+			if !containsTerms(actual, expected) {
+				return fmt.Errorf("update %s does not contain %s", update, action)
+			}
+		}
+	}
+	if err := matchCondition(scope, "condition", r.condition, i.condition); err != nil {
+		return err
+	}
+	return matchCondition(scope, "filter", r.filter, i.filter)
+}
+
+func matchCondition(scope *expr.Scope, kind string, actual *string, expected *expectedExpression) error {
+	if expected == nil {
+		return nil
+	}
+	if actual == nil {
+		return fmt.Errorf("request has no %s expression", kind)
+	}
+	condition, err := scope.Condition(*actual)
+	if err != nil {
+		return err
+	}
+	terms, err := expected.condition()
+	if err != nil {
+		return err
+	}
+	if !equalTerms(conditionTerms(condition), terms) {
+		return fmt.Errorf("%s %s is not %s", kind, condition, expected)
+	}
+	return nil
+}
+
+func (i InputMatcher) matchTransactWriteItemsInput(input *dynamodb.TransactWriteItemsInput) bool {
+	if i.transactItems == nil {
+		return i.TableName == "" && len(i.Fields) == 0
+	}
+	if len(input.TransactItems) != len(i.transactItems) {
+		return false
+	}
+	for n, item := range input.TransactItems {
+		if !i.transactItems[n].Matches(transactWriteInput(item)) {
+			return false
+		}
+	}
+	return true
+}
+
+// transactWriteInput converts an item of a transaction to the input of the single operation
+func transactWriteInput(item *dynamodb.TransactWriteItem) interface{} {
+	switch {
+	case item.Put != nil:
+		return &dynamodb.PutItemInput{
+			TableName:                 item.Put.TableName,
+			Item:                      item.Put.Item,
+			ConditionExpression:       item.Put.ConditionExpression,
+			ExpressionAttributeNames:  item.Put.ExpressionAttributeNames,
+			ExpressionAttributeValues: item.Put.ExpressionAttributeValues,
+			ReturnValues:              item.Put.ReturnValuesOnConditionCheckFailure,
+		}
+	case item.Update != nil:
+		return &dynamodb.UpdateItemInput{
+			TableName:                 item.Update.TableName,
+			Key:                       item.Update.Key,
+			UpdateExpression:          item.Update.UpdateExpression,
+			ConditionExpression:       item.Update.ConditionExpression,
+			ExpressionAttributeNames:  item.Update.ExpressionAttributeNames,
+			ExpressionAttributeValues: item.Update.ExpressionAttributeValues,
+			ReturnValues:              item.Update.ReturnValuesOnConditionCheckFailure,
+		}
+	case item.Delete != nil:
+		return &dynamodb.DeleteItemInput{
+			TableName:                 item.Delete.TableName,
+			Key:                       item.Delete.Key,
+			ConditionExpression:       item.Delete.ConditionExpression,
+			ExpressionAttributeNames:  item.Delete.ExpressionAttributeNames,
+			ExpressionAttributeValues: item.Delete.ExpressionAttributeValues,
+			ReturnValues:              item.Delete.ReturnValuesOnConditionCheckFailure,
+		}
+	}
+	return item.ConditionCheck
+}
+
+// conditionTerms returns the formatted conditions joined by AND, sorted
+func conditionTerms(condition expr.Condition) []string {
+	conjuncts := expr.Conjuncts(condition)
+	terms := make([]string, len(conjuncts))
+	for n, conjunct := range conjuncts {
+		terms[n] = conjunct.String()
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// updateActions returns the formatted actions of the update, sorted
+func updateActions(update *expr.Update) []string {
+	var actions []string
+	for _, action := range update.Set {
+		actions = append(actions, "SET "+action.Path.String()+" = "+action.Value.String())
+	}
+	for _, path := range update.Remove {
+		actions = append(actions, "REMOVE "+path.String())
+	}
+	for _, action := range update.Add {
+		actions = append(actions, "ADD "+action.Path.String()+" "+expr.Format(action.Value))
+	}
+	for _, action := range update.Delete {
+		actions = append(actions, "DELETE "+action.Path.String()+" "+expr.Format(action.Value))
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func equalTerms(actual []string, expected []string) bool {
+	return len(actual) == len(expected) && containsTerms(actual, expected)
+}
+
+func containsTerms(actual []string, expected []string) bool {
+	for _, term := range expected {
+		n := sort.SearchStrings(actual, term)
+		if n == len(actual) || actual[n] != term {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -34,6 +34,7 @@ type DynamoMock struct {
 	Calls                     []call
 	Conditions                map[string]*dynamodb.Condition
 	InputMatcher              gomock.Matcher
+	Filter                    *InputMatcher
 	Limit                     int64
 	Desc                      bool
 	ConditionExpression       *string
@@ -56,6 +57,7 @@ func (d *DynamoMock) Should() *DynamoMock {
 	d.Desc = false
 	d.Limit = 0
 	d.InputMatcher = &InputMatcher{}
+	d.Filter = nil
 	d.Range = make(map[string]*dynamodb.AttributeValue)
 	return d
 }
@@ -102,7 +104,11 @@ func (d *DynamoMock) ScanAll(opts ...DynamoDBOption) *DynamoMock {
 	if d.ScanAllOutput == nil {
 		err = dynamo.ErrNotFound
 	}
-	return d.addCall("ScanWithContext", d.scanInput(), d.ScanAllOutput, err)
+	var input interface{} = d.scanInput()
+	if d.Filter != nil {
+		input = d.Filter
+	}
+	return d.addCall("ScanWithContext", input, d.ScanAllOutput, err)
 }
 
 // Save register call for DynamoMock PutItemWithContext with its option
@@ -123,6 +129,16 @@ func (d *DynamoMock) Update(opts ...DynamoDBOption) *DynamoMock {
 	return d.addCall("UpdateItemWithContext", d.InputMatcher, nil, d.Err)
 }
 
+// TransactWrite register call for DynamoMock TransactWriteItemsWithContext with its option;
+// the items are matched by WithMatch(InputExpect().TransactItemsEq(...))
+func (d *DynamoMock) TransactWrite(opts ...DynamoDBOption) *DynamoMock {
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d.addCall("TransactWriteItemsWithContext", d.InputMatcher, &dynamodb.TransactWriteItemsOutput{}, d.Err)
+}
+
 func (d *DynamoMock) SaveAll(opts ...DynamoDBOption) *DynamoMock {
 	for _, opt := range opts {
 		opt(d)
@@ -201,9 +217,10 @@ func (d *DynamoMock) WithInput(value map[string]interface{}) DynamoDBOption {
 	return func(args *DynamoMock) {
 		av, _ := dynamodbattribute.MarshalMap(value)
 		args.Input = &dynamodb.PutItemInput{
-			Item:         av,
-			TableName:    aws.String(d.TableName),
-			ReturnValues: aws.String("NONE"),
+			Item:                   av,
+			TableName:              aws.String(d.TableName),
+			ReturnValues:           aws.String("NONE"),
+			ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 		}
 		if d.ConditionExpression != nil {
 			args.Input.ConditionExpression = d.ConditionExpression
@@ -218,9 +235,10 @@ func (d *DynamoMock) WithDeleteInput(value map[string]interface{}) DynamoDBOptio
 	return func(args *DynamoMock) {
 		av, _ := dynamodbattribute.MarshalMap(value)
 		args.DeleteItemInput = &dynamodb.DeleteItemInput{
-			Key:          av,
-			TableName:    aws.String(d.TableName),
-			ReturnValues: aws.String("NONE"),
+			Key:                    av,
+			TableName:              aws.String(d.TableName),
+			ReturnValues:           aws.String("NONE"),
+			ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 		}
 	}
 }
@@ -242,7 +260,7 @@ func (d *DynamoMock) WithInputs(values []map[string]interface{}) DynamoDBOption
 		requestItems[d.TableName] = writeRequestArray
 		args.Inputs = &dynamodb.BatchWriteItemInput{
 			RequestItems:                requestItems,
-			ReturnConsumedCapacity:      nil,
+			ReturnConsumedCapacity:      aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 			ReturnItemCollectionMetrics: nil,
 		}
 
@@ -273,7 +291,7 @@ func (d *DynamoMock) WithDeleteInputs(values []map[string]interface{}) DynamoDBO
 		requestItems[d.TableName] = writeRequestArray
 		args.DeleteInputs = &dynamodb.BatchWriteItemInput{
 			RequestItems:                requestItems,
-			ReturnConsumedCapacity:      nil,
+			ReturnConsumedCapacity:      aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 			ReturnItemCollectionMetrics: nil,
 		}
 
@@ -310,6 +328,14 @@ func (d *DynamoMock) WithCondition(field string, value interface{}, operator str
 	}
 }
 
+// WithFilter register option filter expression of ScanAll; ? are replaced by values in order,
+// placeholder names are ignored
+func (d *DynamoMock) WithFilter(expression string, values ...interface{}) DynamoDBOption {
+	return func(args *DynamoMock) {
+		args.Filter = InputExpect().TableEq(args.TableName).FilterEq(expression, values...)
+	}
+}
+
 // WithConditionExpression register option dynamodb GetItemOutput
 func (d *DynamoMock) WithConditionExpression(expression string, value interface{}) DynamoDBOption {
 	return func(args *DynamoMock) {
@@ -445,8 +471,9 @@ func (d *DynamoMock) getItemInput() *dynamodb.GetItemInput {
 		d.Hash[k] = v
 	}
 	req := &dynamodb.GetItemInput{
-		TableName: aws.String(d.TableName),
-		Key:       d.Hash,
+		TableName:              aws.String(d.TableName),
+		Key:                    d.Hash,
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 	}
 	return req
 }
@@ -454,8 +481,9 @@ func (d *DynamoMock) getItemInput() *dynamodb.GetItemInput {
 // getItemInput return query input from registered hash , range and table name
 func (d *DynamoMock) queryInput() *dynamodb.QueryInput {
 	req := &dynamodb.QueryInput{
-		TableName:     aws.String(d.TableName),
-		KeyConditions: d.Conditions,
+		TableName:              aws.String(d.TableName),
+		KeyConditions:          d.Conditions,
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 	}
 	if d.Index != "" {
 		req.IndexName = aws.String(d.Index)
@@ -489,6 +517,7 @@ func (d *DynamoMock) batchGetInput() *dynamodb.BatchGetItemInput {
 				Keys:           keys,
 			},
 		},
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 	}
 
 	return req
@@ -497,8 +526,9 @@ func (d *DynamoMock) batchGetInput() *dynamodb.BatchGetItemInput {
 func (d *DynamoMock) scanInput() *dynamodb.ScanInput {
 	b := false
 	req := &dynamodb.ScanInput{
-		TableName:      aws.String(d.TableName),
-		ConsistentRead: &b,
+		TableName:              aws.String(d.TableName),
+		ConsistentRead:         &b,
+		ReturnConsumedCapacity: aws.String(dynamodb.ReturnConsumedCapacityIndexes),
 	}
 	if d.Limit != 0 {
 		req.Limit = aws.Int64(d.Limit)
@@ -39,21 +39,103 @@ dmock := mocks.NewDynamoMock(dynamoMock)
 type InputMatcher struct {
 	Fields    map[string]interface{}
 	TableName string
+
+	keys          map[string]interface{}
+	update        *expectedExpression
+	updateActions []expectedExpression
+	condition     *expectedExpression
+	filter        *expectedExpression
+	returnValues  *string
+	transactItems []*InputMatcher
 }
 
 // Matches match registered field with actual value mock received
 func (i InputMatcher) Matches(x interface{}) bool {
 	switch x := x.(type) {
 	case *dynamodb.PutItemInput:
-		return i.matchPutItemInput(x)
+		return i.matchPutItemInput(x) && i.matchRequest(writeRequest{
+			table:        x.TableName,
+			key:          x.Item,
+			condition:    x.ConditionExpression,
+			names:        x.ExpressionAttributeNames,
+			values:       x.ExpressionAttributeValues,
+			returnValues: x.ReturnValues,
+		}) == nil
 	case *dynamodb.UpdateItemInput:
-		return i.matchUpdateItemInput(x)
+		return i.matchUpdateItemInput(x) && i.matchRequest(writeRequest{
+			table:        x.TableName,
+			key:          x.Key,
+			update:       x.UpdateExpression,
+			condition:    x.ConditionExpression,
+			names:        x.ExpressionAttributeNames,
+			values:       x.ExpressionAttributeValues,
+			returnValues: x.ReturnValues,
+		}) == nil
+	case *dynamodb.DeleteItemInput:
+		return i.matchRequest(writeRequest{
+			table:        x.TableName,
+			key:          x.Key,
+			condition:    x.ConditionExpression,
+			names:        x.ExpressionAttributeNames,
+			values:       x.ExpressionAttributeValues,
+			returnValues: x.ReturnValues,
+		}) == nil
+	case *dynamodb.ConditionCheck:
+		return len(i.Fields) == 0 && i.matchRequest(writeRequest{
+			table:        x.TableName,
+			key:          x.Key,
+			condition:    x.ConditionExpression,
+			names:        x.ExpressionAttributeNames,
+			values:       x.ExpressionAttributeValues,
+			returnValues: x.ReturnValuesOnConditionCheckFailure,
+		}) == nil
+	case *dynamodb.ScanInput:
+		return len(i.Fields) == 0 && i.matchRequest(writeRequest{
+			table:  x.TableName,
+			filter: x.FilterExpression,
+			names:  x.ExpressionAttributeNames,
+			values: x.ExpressionAttributeValues,
+		}) == nil
+	case *dynamodb.TransactWriteItemsInput:
+		return i.matchTransactWriteItemsInput(x)
 	}
 	return false
 }
 
 func (i InputMatcher) String() string {
-	return ""
+	var expectations []string
+	if i.TableName != "" {
+		expectations = append(expectations, "table "+i.TableName)
+	}
+	for _, name := range sortedKeys(i.keys) {
+		expectations = append(expectations, fmt.Sprintf("key %s = %v", name, i.keys[name]))
+	}
+	for _, name := range sortedKeys(i.Fields) {
+		expectations = append(expectations, fmt.Sprintf("field %s = %v", name, i.Fields[name]))
+	}
+	if i.update != nil {
+		expectations = append(expectations, "update "+i.update.String())
+	}
+	for _, action := range i.updateActions {
+		expectations = append(expectations, "update containing "+action.String())
+	}
+	if i.condition != nil {
+		expectations = append(expectations, "condition "+i.condition.String())
+	}
+	if i.filter != nil {
+		expectations = append(expectations, "filter "+i.filter.String())
+	}
+	if i.returnValues != nil {
+		expectations = append(expectations, "return values "+*i.returnValues)
+	}
+	if i.transactItems != nil {
+		items := make([]string, len(i.transactItems))
+		for n, item := range i.transactItems {
+			items[n] = "{" + item.String() + "}"
+		}
+		expectations = append(expectations, "transaction of "+strings.Join(items, ", "))
+	}
+	return strings.Join(expectations, ", ")
 }
 
 // InputExpect init matcher with empty fields
@@ -69,6 +151,85 @@ func (i *InputMatcher) FieldEq(name string, value interface{}) *InputMatcher {
 	return i
 }
 
+// TableEq expects the request to target the table name
+func (i *InputMatcher) TableEq(name string) *InputMatcher {
+	i.TableName = name
+	return i
+}
+
+// KeyEq expects the key attribute name of the written item to have the value
+func (i *InputMatcher) KeyEq(name string, value interface{}) *InputMatcher {
+	if i.keys == nil {
+		i.keys = make(map[string]interface{})
+	}
+	i.keys[name] = value
+	return i
+}
+
+// UpdateEq expects the whole update expression; ? are replaced by args in order, e.g.
+// UpdateEq("SET Name = ?, Version = Version + ? REMOVE Token", "name", 1).
+// Placeholder names and the order of the actions are ignored.
+func (i *InputMatcher) UpdateEq(expression string, args ...interface{}) *InputMatcher {
+	i.update = &expectedExpression{expression: expression, args: args}
+	return i
+}
+
+// SetEq expects the update to set the path to the value
+func (i *InputMatcher) SetEq(path string, value interface{}) *InputMatcher {
+	return i.SetExprEq(path, "?", value)
+}
+
+// SetExprEq expects the update to set the path to the value expression, e.g. SetExprEq("Count", "Count + ?", 1)
+func (i *InputMatcher) SetExprEq(path string, expression string, args ...interface{}) *InputMatcher {
+	i.updateActions = append(i.updateActions, expectedExpression{expression: "SET " + path + " = " + expression, args: args})
+	return i
+}
+
+// AddEq expects the update to add the value to the number or set of path
+func (i *InputMatcher) AddEq(path string, value interface{}) *InputMatcher {
+	i.updateActions = append(i.updateActions, expectedExpression{expression: "ADD " + path + " ?", args: []interface{}{value}})
+	return i
+}
+
+// DeleteEq expects the update to delete the elements of value from the set of path
+func (i *InputMatcher) DeleteEq(path string, value interface{}) *InputMatcher {
+	i.updateActions = append(i.updateActions, expectedExpression{expression: "DELETE " + path + " ?", args: []interface{}{value}})
+	return i
+}
+
+// RemoveEq expects the update to remove the path
+func (i *InputMatcher) RemoveEq(path string) *InputMatcher {
+	i.updateActions = append(i.updateActions, expectedExpression{expression: "REMOVE " + path})
+	return i
+}
+
+// ConditionEq expects the condition expression; ? are replaced by args in order.
+// Placeholder names, redundant parentheses and the order of conditions joined by AND are ignored.
+func (i *InputMatcher) ConditionEq(expression string, args ...interface{}) *InputMatcher {
+	i.condition = &expectedExpression{expression: expression, args: args}
+	return i
+}
+
+// FilterEq expects the filter expression of a scan like ConditionEq
+func (i *InputMatcher) FilterEq(expression string, args ...interface{}) *InputMatcher {
+	i.filter = &expectedExpression{expression: expression, args: args}
+	return i
+}
+
+// ReturnValuesEq expects the return values of the request, e.g. dynamodb.ReturnValueAllNew;
+// for items of transactions it is the ReturnValuesOnConditionCheckFailure
+func (i *InputMatcher) ReturnValuesEq(returnValues string) *InputMatcher {
+	i.returnValues = &returnValues
+	return i
+}
+
+// TransactItemsEq expects a transaction of exactly the items in order; each item is matched by its matcher,
+// a put item by the fields of its matcher
+func (i *InputMatcher) TransactItemsEq(items ...*InputMatcher) *InputMatcher {
+	i.transactItems = items
+	return i
+}
+
 func (i *InputMatcher) matchPutItemInput(x interface{}) bool {
 	inputItem := x.(*dynamodb.PutItemInput)
 	inputFields := make(map[string]interface{})
@@ -92,6 +253,9 @@ func (i *InputMatcher) matchPutItemInput(x interface{}) bool {
 
 func (i *InputMatcher) matchUpdateItemInput(x interface{}) bool {
 	inputItem := x.(*dynamodb.UpdateItemInput)
+	if len(i.Fields) == 0 {
+		return true
+	}
 	// remove spaces & Set & if_not_exists(Field
 	reg := regexp.MustCompile(`if_not_exists|\(([^ ]+)|\)|SET|ADD| `)
 	updateExpression := reg.ReplaceAllString(*inputItem.UpdateExpression, "")
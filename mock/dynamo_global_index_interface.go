@@ -1,22 +1,30 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: dynamo_global_index_interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=dynamo_global_index_interface.go -destination=./mock/dynamo_global_index_interface.go -package=mock .
+//
 
 // Package mock is a generated GoMock package.
 package mock
 
 import (
 	context "context"
+	slog "log/slog"
 	reflect "reflect"
 
 	djoemo "github.com/adjoeio/djoemo"
 	prometheus "github.com/prometheus/client_golang/prometheus"
-	"go.uber.org/mock/gomock"
+	trace "go.opentelemetry.io/otel/trace"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockGlobalIndexInterface is a mock of GlobalIndexInterface interface.
 type MockGlobalIndexInterface struct {
 	ctrl     *gomock.Controller
 	recorder *MockGlobalIndexInterfaceMockRecorder
+	isgomock struct{}
 }
 
 // MockGlobalIndexInterfaceMockRecorder is the mock recorder for MockGlobalIndexInterface.
@@ -37,7 +45,7 @@ func (m *MockGlobalIndexInterface) EXPECT() *MockGlobalIndexInterfaceMockRecorde
 }
 
 // GetItemWithContext mocks base method.
-func (m *MockGlobalIndexInterface) GetItemWithContext(ctx context.Context, key djoemo.KeyInterface, item interface{}) (bool, error) {
+func (m *MockGlobalIndexInterface) GetItemWithContext(ctx context.Context, key djoemo.KeyInterface, item any) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetItemWithContext", ctx, key, item)
 	ret0, _ := ret[0].(bool)
@@ -46,13 +54,13 @@ func (m *MockGlobalIndexInterface) GetItemWithContext(ctx context.Context, key d
 }
 
 // GetItemWithContext indicates an expected call of GetItemWithContext.
-func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemWithContext(ctx, key, item interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemWithContext(ctx, key, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemWithContext", reflect.TypeOf((*MockGlobalIndexInterface)(nil).GetItemWithContext), ctx, key, item)
 }
 
 // GetItemsWithContext mocks base method.
-func (m *MockGlobalIndexInterface) GetItemsWithContext(ctx context.Context, key djoemo.KeyInterface, items interface{}) (bool, error) {
+func (m *MockGlobalIndexInterface) GetItemsWithContext(ctx context.Context, key djoemo.KeyInterface, items any) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetItemsWithContext", ctx, key, items)
 	ret0, _ := ret[0].(bool)
@@ -61,13 +69,13 @@ func (m *MockGlobalIndexInterface) GetItemsWithContext(ctx context.Context, key
 }
 
 // GetItemsWithContext indicates an expected call of GetItemsWithContext.
-func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemsWithContext(ctx, key, items interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemsWithContext(ctx, key, items any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsWithContext", reflect.TypeOf((*MockGlobalIndexInterface)(nil).GetItemsWithContext), ctx, key, items)
 }
 
 // GetItemsWithRangeWithContext mocks base method.
-func (m *MockGlobalIndexInterface) GetItemsWithRangeWithContext(ctx context.Context, key djoemo.KeyInterface, items interface{}) (bool, error) {
+func (m *MockGlobalIndexInterface) GetItemsWithRangeWithContext(ctx context.Context, key djoemo.KeyInterface, items any) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetItemsWithRangeWithContext", ctx, key, items)
 	ret0, _ := ret[0].(bool)
@@ -76,13 +84,13 @@ func (m *MockGlobalIndexInterface) GetItemsWithRangeWithContext(ctx context.Cont
 }
 
 // GetItemsWithRangeWithContext indicates an expected call of GetItemsWithRangeWithContext.
-func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemsWithRangeWithContext(ctx, key, items interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) GetItemsWithRangeWithContext(ctx, key, items any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsWithRangeWithContext", reflect.TypeOf((*MockGlobalIndexInterface)(nil).GetItemsWithRangeWithContext), ctx, key, items)
 }
 
 // QueryWithContext mocks base method.
-func (m *MockGlobalIndexInterface) QueryWithContext(ctx context.Context, query djoemo.QueryInterface, item interface{}) error {
+func (m *MockGlobalIndexInterface) QueryWithContext(ctx context.Context, query djoemo.QueryInterface, item any) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "QueryWithContext", ctx, query, item)
 	ret0, _ := ret[0].(error)
@@ -90,7 +98,7 @@ func (m *MockGlobalIndexInterface) QueryWithContext(ctx context.Context, query d
 }
 
 // QueryWithContext indicates an expected call of QueryWithContext.
-func (mr *MockGlobalIndexInterfaceMockRecorder) QueryWithContext(ctx, query, item interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) QueryWithContext(ctx, query, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryWithContext", reflect.TypeOf((*MockGlobalIndexInterface)(nil).QueryWithContext), ctx, query, item)
 }
@@ -102,7 +110,7 @@ func (m *MockGlobalIndexInterface) WithLog(log djoemo.LogInterface) {
 }
 
 // WithLog indicates an expected call of WithLog.
-func (mr *MockGlobalIndexInterfaceMockRecorder) WithLog(log interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithLog(log any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLog", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithLog), log)
 }
@@ -114,7 +122,7 @@ func (m *MockGlobalIndexInterface) WithMetrics(metricsInterface djoemo.MetricsIn
 }
 
 // WithMetrics indicates an expected call of WithMetrics.
-func (mr *MockGlobalIndexInterfaceMockRecorder) WithMetrics(metricsInterface interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithMetrics(metricsInterface any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithMetrics", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithMetrics), metricsInterface)
 }
@@ -128,7 +136,43 @@ func (m *MockGlobalIndexInterface) WithPrometheusMetrics(registry *prometheus.Re
 }
 
 // WithPrometheusMetrics indicates an expected call of WithPrometheusMetrics.
-func (mr *MockGlobalIndexInterfaceMockRecorder) WithPrometheusMetrics(registry interface{}) *gomock.Call {
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithPrometheusMetrics(registry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPrometheusMetrics", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithPrometheusMetrics), registry)
 }
+
+// WithRetryPolicy mocks base method.
+func (m *MockGlobalIndexInterface) WithRetryPolicy(policy djoemo.RetryPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithRetryPolicy", policy)
+}
+
+// WithRetryPolicy indicates an expected call of WithRetryPolicy.
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithRetryPolicy(policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRetryPolicy", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithRetryPolicy), policy)
+}
+
+// WithSlogHandler mocks base method.
+func (m *MockGlobalIndexInterface) WithSlogHandler(handler slog.Handler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithSlogHandler", handler)
+}
+
+// WithSlogHandler indicates an expected call of WithSlogHandler.
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithSlogHandler(handler any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithSlogHandler", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithSlogHandler), handler)
+}
+
+// WithTracerProvider mocks base method.
+func (m *MockGlobalIndexInterface) WithTracerProvider(provider trace.TracerProvider) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithTracerProvider", provider)
+}
+
+// WithTracerProvider indicates an expected call of WithTracerProvider.
+func (mr *MockGlobalIndexInterfaceMockRecorder) WithTracerProvider(provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTracerProvider", reflect.TypeOf((*MockGlobalIndexInterface)(nil).WithTracerProvider), provider)
+}
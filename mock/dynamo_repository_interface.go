@@ -1,22 +1,30 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: dynamo_repository_interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=dynamo_repository_interface.go -destination=./mock/dynamo_repository_interface.go -package=mock .
+//
 
 // Package mock is a generated GoMock package.
 package mock
 
 import (
 	context "context"
+	slog "log/slog"
 	reflect "reflect"
 
 	djoemo "github.com/adjoeio/djoemo"
 	prometheus "github.com/prometheus/client_golang/prometheus"
-	"go.uber.org/mock/gomock"
+	trace "go.opentelemetry.io/otel/trace"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockRepositoryInterface is a mock of RepositoryInterface interface.
 type MockRepositoryInterface struct {
 	ctrl     *gomock.Controller
 	recorder *MockRepositoryInterfaceMockRecorder
+	isgomock struct{}
 }
 
 // MockRepositoryInterfaceMockRecorder is the mock recorder for MockRepositoryInterface.
@@ -36,6 +44,21 @@ func (m *MockRepositoryInterface) EXPECT() *MockRepositoryInterfaceMockRecorder
 	return m.recorder
 }
 
+// Backfill mocks base method.
+func (m *MockRepositoryInterface) Backfill(ctx context.Context, key djoemo.KeyInterface, fn djoemo.BackfillFunc, settings djoemo.BackfillSettings) (djoemo.BackfillStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", ctx, key, fn, settings)
+	ret0, _ := ret[0].(djoemo.BackfillStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backfill indicates an expected call of Backfill.
+func (mr *MockRepositoryInterfaceMockRecorder) Backfill(ctx, key, fn, settings any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockRepositoryInterface)(nil).Backfill), ctx, key, fn, settings)
+}
+
 // BatchGetItemsWithContext mocks base method.
 func (m *MockRepositoryInterface) BatchGetItemsWithContext(ctx context.Context, keys []djoemo.KeyInterface, out any) (bool, error) {
 	m.ctrl.T.Helper()
@@ -46,7 +69,7 @@ func (m *MockRepositoryInterface) BatchGetItemsWithContext(ctx context.Context,
 }
 
 // BatchGetItemsWithContext indicates an expected call of BatchGetItemsWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) BatchGetItemsWithContext(ctx, keys, out interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) BatchGetItemsWithContext(ctx, keys, out any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGetItemsWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).BatchGetItemsWithContext), ctx, keys, out)
 }
@@ -54,7 +77,7 @@ func (mr *MockRepositoryInterfaceMockRecorder) BatchGetItemsWithContext(ctx, key
 // ConditionalUpdateWithContext mocks base method.
 func (m *MockRepositoryInterface) ConditionalUpdateWithContext(ctx context.Context, key djoemo.KeyInterface, item any, expression string, expressionArgs ...any) (bool, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, key, item, expression}
+	varargs := []any{ctx, key, item, expression}
 	for _, a := range expressionArgs {
 		varargs = append(varargs, a)
 	}
@@ -65,16 +88,16 @@ func (m *MockRepositoryInterface) ConditionalUpdateWithContext(ctx context.Conte
 }
 
 // ConditionalUpdateWithContext indicates an expected call of ConditionalUpdateWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) ConditionalUpdateWithContext(ctx, key, item, expression interface{}, expressionArgs ...interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) ConditionalUpdateWithContext(ctx, key, item, expression any, expressionArgs ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, key, item, expression}, expressionArgs...)
+	varargs := append([]any{ctx, key, item, expression}, expressionArgs...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalUpdateWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).ConditionalUpdateWithContext), varargs...)
 }
 
 // ConditionalUpdateWithUpdateExpressionsAndReturnValue mocks base method.
 func (m *MockRepositoryInterface) ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx context.Context, key djoemo.KeyInterface, item any, updateExpressions djoemo.UpdateExpressions, conditionExpression string, conditionArgs ...any) (bool, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{ctx, key, item, updateExpressions, conditionExpression}
+	varargs := []any{ctx, key, item, updateExpressions, conditionExpression}
 	for _, a := range conditionArgs {
 		varargs = append(varargs, a)
 	}
@@ -85,12 +108,26 @@ func (m *MockRepositoryInterface) ConditionalUpdateWithUpdateExpressionsAndRetur
 }
 
 // ConditionalUpdateWithUpdateExpressionsAndReturnValue indicates an expected call of ConditionalUpdateWithUpdateExpressionsAndReturnValue.
-func (mr *MockRepositoryInterfaceMockRecorder) ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions, conditionExpression interface{}, conditionArgs ...interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) ConditionalUpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions, conditionExpression any, conditionArgs ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	varargs := append([]interface{}{ctx, key, item, updateExpressions, conditionExpression}, conditionArgs...)
+	varargs := append([]any{ctx, key, item, updateExpressions, conditionExpression}, conditionArgs...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConditionalUpdateWithUpdateExpressionsAndReturnValue", reflect.TypeOf((*MockRepositoryInterface)(nil).ConditionalUpdateWithUpdateExpressionsAndReturnValue), varargs...)
 }
 
+// CreateTable mocks base method.
+func (m *MockRepositoryInterface) CreateTable(ctx context.Context, model any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTable", ctx, model)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTable indicates an expected call of CreateTable.
+func (mr *MockRepositoryInterfaceMockRecorder) CreateTable(ctx, model any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTable", reflect.TypeOf((*MockRepositoryInterface)(nil).CreateTable), ctx, model)
+}
+
 // DeleteItemWithContext mocks base method.
 func (m *MockRepositoryInterface) DeleteItemWithContext(ctx context.Context, key djoemo.KeyInterface) error {
 	m.ctrl.T.Helper()
@@ -100,7 +137,7 @@ func (m *MockRepositoryInterface) DeleteItemWithContext(ctx context.Context, key
 }
 
 // DeleteItemWithContext indicates an expected call of DeleteItemWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) DeleteItemWithContext(ctx, key interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteItemWithContext(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteItemWithContext), ctx, key)
 }
@@ -114,11 +151,40 @@ func (m *MockRepositoryInterface) DeleteItemsWithContext(ctx context.Context, ke
 }
 
 // DeleteItemsWithContext indicates an expected call of DeleteItemsWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) DeleteItemsWithContext(ctx, key interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) DeleteItemsWithContext(ctx, key any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemsWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).DeleteItemsWithContext), ctx, key)
 }
 
+// DescribeTable mocks base method.
+func (m *MockRepositoryInterface) DescribeTable(ctx context.Context, tableName string) (djoemo.TableSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DescribeTable", ctx, tableName)
+	ret0, _ := ret[0].(djoemo.TableSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DescribeTable indicates an expected call of DescribeTable.
+func (mr *MockRepositoryInterfaceMockRecorder) DescribeTable(ctx, tableName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DescribeTable", reflect.TypeOf((*MockRepositoryInterface)(nil).DescribeTable), ctx, tableName)
+}
+
+// EnsureTable mocks base method.
+func (m *MockRepositoryInterface) EnsureTable(ctx context.Context, model any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureTable", ctx, model)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnsureTable indicates an expected call of EnsureTable.
+func (mr *MockRepositoryInterfaceMockRecorder) EnsureTable(ctx, model any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureTable", reflect.TypeOf((*MockRepositoryInterface)(nil).EnsureTable), ctx, model)
+}
+
 // GIndex mocks base method.
 func (m *MockRepositoryInterface) GIndex(name string) djoemo.GlobalIndexInterface {
 	m.ctrl.T.Helper()
@@ -128,7 +194,7 @@ func (m *MockRepositoryInterface) GIndex(name string) djoemo.GlobalIndexInterfac
 }
 
 // GIndex indicates an expected call of GIndex.
-func (mr *MockRepositoryInterfaceMockRecorder) GIndex(name interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) GIndex(name any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GIndex", reflect.TypeOf((*MockRepositoryInterface)(nil).GIndex), name)
 }
@@ -143,7 +209,7 @@ func (m *MockRepositoryInterface) GetItemWithContext(ctx context.Context, key dj
 }
 
 // GetItemWithContext indicates an expected call of GetItemWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) GetItemWithContext(ctx, key, item interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) GetItemWithContext(ctx, key, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).GetItemWithContext), ctx, key, item)
 }
@@ -158,7 +224,7 @@ func (m *MockRepositoryInterface) GetItemsWithContext(ctx context.Context, key d
 }
 
 // GetItemsWithContext indicates an expected call of GetItemsWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) GetItemsWithContext(ctx, key, out interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) GetItemsWithContext(ctx, key, out any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetItemsWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).GetItemsWithContext), ctx, key, out)
 }
@@ -173,7 +239,7 @@ func (m *MockRepositoryInterface) OptimisticLockSaveWithContext(ctx context.Cont
 }
 
 // OptimisticLockSaveWithContext indicates an expected call of OptimisticLockSaveWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) OptimisticLockSaveWithContext(ctx, key, item interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) OptimisticLockSaveWithContext(ctx, key, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OptimisticLockSaveWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).OptimisticLockSaveWithContext), ctx, key, item)
 }
@@ -187,7 +253,7 @@ func (m *MockRepositoryInterface) QueryWithContext(ctx context.Context, query dj
 }
 
 // QueryWithContext indicates an expected call of QueryWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) QueryWithContext(ctx, query, item interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) QueryWithContext(ctx, query, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).QueryWithContext), ctx, query, item)
 }
@@ -201,7 +267,7 @@ func (m *MockRepositoryInterface) SaveItemWithContext(ctx context.Context, key d
 }
 
 // SaveItemWithContext indicates an expected call of SaveItemWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) SaveItemWithContext(ctx, key, item interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) SaveItemWithContext(ctx, key, item any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveItemWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).SaveItemWithContext), ctx, key, item)
 }
@@ -215,7 +281,7 @@ func (m *MockRepositoryInterface) SaveItemsWithContext(ctx context.Context, key
 }
 
 // SaveItemsWithContext indicates an expected call of SaveItemsWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) SaveItemsWithContext(ctx, key, items interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) SaveItemsWithContext(ctx, key, items any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveItemsWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).SaveItemsWithContext), ctx, key, items)
 }
@@ -230,7 +296,7 @@ func (m *MockRepositoryInterface) ScanIteratorWithContext(ctx context.Context, k
 }
 
 // ScanIteratorWithContext indicates an expected call of ScanIteratorWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) ScanIteratorWithContext(ctx, key, searchLimit interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) ScanIteratorWithContext(ctx, key, searchLimit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanIteratorWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).ScanIteratorWithContext), ctx, key, searchLimit)
 }
@@ -244,7 +310,7 @@ func (m *MockRepositoryInterface) UpdateWithContext(ctx context.Context, express
 }
 
 // UpdateWithContext indicates an expected call of UpdateWithContext.
-func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithContext(ctx, expression, key, values interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithContext(ctx, expression, key, values any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithContext", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateWithContext), ctx, expression, key, values)
 }
@@ -258,7 +324,7 @@ func (m *MockRepositoryInterface) UpdateWithUpdateExpressions(ctx context.Contex
 }
 
 // UpdateWithUpdateExpressions indicates an expected call of UpdateWithUpdateExpressions.
-func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithUpdateExpressions(ctx, key, updateExpressions interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithUpdateExpressions(ctx, key, updateExpressions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithUpdateExpressions", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateWithUpdateExpressions), ctx, key, updateExpressions)
 }
@@ -272,7 +338,7 @@ func (m *MockRepositoryInterface) UpdateWithUpdateExpressionsAndReturnValue(ctx
 }
 
 // UpdateWithUpdateExpressionsAndReturnValue indicates an expected call of UpdateWithUpdateExpressionsAndReturnValue.
-func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) UpdateWithUpdateExpressionsAndReturnValue(ctx, key, item, updateExpressions any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithUpdateExpressionsAndReturnValue", reflect.TypeOf((*MockRepositoryInterface)(nil).UpdateWithUpdateExpressionsAndReturnValue), ctx, key, item, updateExpressions)
 }
@@ -284,11 +350,23 @@ func (m *MockRepositoryInterface) WithLog(log djoemo.LogInterface) {
 }
 
 // WithLog indicates an expected call of WithLog.
-func (mr *MockRepositoryInterfaceMockRecorder) WithLog(log interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) WithLog(log any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLog", reflect.TypeOf((*MockRepositoryInterface)(nil).WithLog), log)
 }
 
+// WithLogRedactor mocks base method.
+func (m *MockRepositoryInterface) WithLogRedactor(redactor djoemo.Redactor) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithLogRedactor", redactor)
+}
+
+// WithLogRedactor indicates an expected call of WithLogRedactor.
+func (mr *MockRepositoryInterfaceMockRecorder) WithLogRedactor(redactor any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLogRedactor", reflect.TypeOf((*MockRepositoryInterface)(nil).WithLogRedactor), redactor)
+}
+
 // WithMetrics mocks base method.
 func (m *MockRepositoryInterface) WithMetrics(metricsInterface djoemo.MetricsInterface) {
 	m.ctrl.T.Helper()
@@ -296,11 +374,23 @@ func (m *MockRepositoryInterface) WithMetrics(metricsInterface djoemo.MetricsInt
 }
 
 // WithMetrics indicates an expected call of WithMetrics.
-func (mr *MockRepositoryInterfaceMockRecorder) WithMetrics(metricsInterface interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) WithMetrics(metricsInterface any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithMetrics", reflect.TypeOf((*MockRepositoryInterface)(nil).WithMetrics), metricsInterface)
 }
 
+// WithOutboxTable mocks base method.
+func (m *MockRepositoryInterface) WithOutboxTable(tableName string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithOutboxTable", tableName)
+}
+
+// WithOutboxTable indicates an expected call of WithOutboxTable.
+func (mr *MockRepositoryInterfaceMockRecorder) WithOutboxTable(tableName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithOutboxTable", reflect.TypeOf((*MockRepositoryInterface)(nil).WithOutboxTable), tableName)
+}
+
 // WithPrometheusMetrics mocks base method.
 func (m *MockRepositoryInterface) WithPrometheusMetrics(registry *prometheus.Registry) djoemo.RepositoryInterface {
 	m.ctrl.T.Helper()
@@ -310,7 +400,67 @@ func (m *MockRepositoryInterface) WithPrometheusMetrics(registry *prometheus.Reg
 }
 
 // WithPrometheusMetrics indicates an expected call of WithPrometheusMetrics.
-func (mr *MockRepositoryInterfaceMockRecorder) WithPrometheusMetrics(registry interface{}) *gomock.Call {
+func (mr *MockRepositoryInterfaceMockRecorder) WithPrometheusMetrics(registry any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithPrometheusMetrics", reflect.TypeOf((*MockRepositoryInterface)(nil).WithPrometheusMetrics), registry)
 }
+
+// WithRateLimiter mocks base method.
+func (m *MockRepositoryInterface) WithRateLimiter(limiter *djoemo.RateLimiter) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithRateLimiter", limiter)
+}
+
+// WithRateLimiter indicates an expected call of WithRateLimiter.
+func (mr *MockRepositoryInterfaceMockRecorder) WithRateLimiter(limiter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRateLimiter", reflect.TypeOf((*MockRepositoryInterface)(nil).WithRateLimiter), limiter)
+}
+
+// WithRetryPolicy mocks base method.
+func (m *MockRepositoryInterface) WithRetryPolicy(policy djoemo.RetryPolicy) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithRetryPolicy", policy)
+}
+
+// WithRetryPolicy indicates an expected call of WithRetryPolicy.
+func (mr *MockRepositoryInterfaceMockRecorder) WithRetryPolicy(policy any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithRetryPolicy", reflect.TypeOf((*MockRepositoryInterface)(nil).WithRetryPolicy), policy)
+}
+
+// WithSlogHandler mocks base method.
+func (m *MockRepositoryInterface) WithSlogHandler(handler slog.Handler) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithSlogHandler", handler)
+}
+
+// WithSlogHandler indicates an expected call of WithSlogHandler.
+func (mr *MockRepositoryInterfaceMockRecorder) WithSlogHandler(handler any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithSlogHandler", reflect.TypeOf((*MockRepositoryInterface)(nil).WithSlogHandler), handler)
+}
+
+// WithSlowLog mocks base method.
+func (m *MockRepositoryInterface) WithSlowLog(slowLog *djoemo.SlowLog) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithSlowLog", slowLog)
+}
+
+// WithSlowLog indicates an expected call of WithSlowLog.
+func (mr *MockRepositoryInterfaceMockRecorder) WithSlowLog(slowLog any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithSlowLog", reflect.TypeOf((*MockRepositoryInterface)(nil).WithSlowLog), slowLog)
+}
+
+// WithTracerProvider mocks base method.
+func (m *MockRepositoryInterface) WithTracerProvider(provider trace.TracerProvider) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "WithTracerProvider", provider)
+}
+
+// WithTracerProvider indicates an expected call of WithTracerProvider.
+func (mr *MockRepositoryInterfaceMockRecorder) WithTracerProvider(provider any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTracerProvider", reflect.TypeOf((*MockRepositoryInterface)(nil).WithTracerProvider), provider)
+}
@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: logger_interface.go
+//
+// Generated by this command:
+//
+//	mockgen -source=logger_interface.go -destination=./mock/log_interface.go -package=mock .
+//
 
 // Package mock is a generated GoMock package.
 package mock
@@ -9,13 +14,14 @@ import (
 	reflect "reflect"
 
 	djoemo "github.com/adjoeio/djoemo"
-	"go.uber.org/mock/gomock"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockLogInterface is a mock of LogInterface interface.
 type MockLogInterface struct {
 	ctrl     *gomock.Controller
 	recorder *MockLogInterfaceMockRecorder
+	isgomock struct{}
 }
 
 // MockLogInterfaceMockRecorder is the mock recorder for MockLogInterface.
@@ -42,7 +48,7 @@ func (m *MockLogInterface) Error(message string) {
 }
 
 // Error indicates an expected call of Error.
-func (mr *MockLogInterfaceMockRecorder) Error(message interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) Error(message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockLogInterface)(nil).Error), message)
 }
@@ -54,7 +60,7 @@ func (m *MockLogInterface) Info(message string) {
 }
 
 // Info indicates an expected call of Info.
-func (mr *MockLogInterfaceMockRecorder) Info(message interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) Info(message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockLogInterface)(nil).Info), message)
 }
@@ -66,7 +72,7 @@ func (m *MockLogInterface) Warn(message string) {
 }
 
 // Warn indicates an expected call of Warn.
-func (mr *MockLogInterfaceMockRecorder) Warn(message interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) Warn(message any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warn", reflect.TypeOf((*MockLogInterface)(nil).Warn), message)
 }
@@ -80,7 +86,7 @@ func (m *MockLogInterface) WithContext(ctx context.Context) djoemo.LogInterface
 }
 
 // WithContext indicates an expected call of WithContext.
-func (mr *MockLogInterfaceMockRecorder) WithContext(ctx interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) WithContext(ctx any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithContext", reflect.TypeOf((*MockLogInterface)(nil).WithContext), ctx)
 }
@@ -94,7 +100,7 @@ func (m *MockLogInterface) WithField(key string, value any) djoemo.LogInterface
 }
 
 // WithField indicates an expected call of WithField.
-func (mr *MockLogInterfaceMockRecorder) WithField(key, value interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) WithField(key, value any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithField", reflect.TypeOf((*MockLogInterface)(nil).WithField), key, value)
 }
@@ -108,7 +114,81 @@ func (m *MockLogInterface) WithFields(fields map[string]any) djoemo.LogInterface
 }
 
 // WithFields indicates an expected call of WithFields.
-func (mr *MockLogInterfaceMockRecorder) WithFields(fields interface{}) *gomock.Call {
+func (mr *MockLogInterfaceMockRecorder) WithFields(fields any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithFields", reflect.TypeOf((*MockLogInterface)(nil).WithFields), fields)
 }
+
+// MockDebugLogInterface is a mock of DebugLogInterface interface.
+type MockDebugLogInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDebugLogInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDebugLogInterfaceMockRecorder is the mock recorder for MockDebugLogInterface.
+type MockDebugLogInterfaceMockRecorder struct {
+	mock *MockDebugLogInterface
+}
+
+// NewMockDebugLogInterface creates a new mock instance.
+func NewMockDebugLogInterface(ctrl *gomock.Controller) *MockDebugLogInterface {
+	mock := &MockDebugLogInterface{ctrl: ctrl}
+	mock.recorder = &MockDebugLogInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDebugLogInterface) EXPECT() *MockDebugLogInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Debug mocks base method.
+func (m *MockDebugLogInterface) Debug(message string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Debug", message)
+}
+
+// Debug indicates an expected call of Debug.
+func (mr *MockDebugLogInterfaceMockRecorder) Debug(message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockDebugLogInterface)(nil).Debug), message)
+}
+
+// MockDebugLevelInterface is a mock of DebugLevelInterface interface.
+type MockDebugLevelInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockDebugLevelInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockDebugLevelInterfaceMockRecorder is the mock recorder for MockDebugLevelInterface.
+type MockDebugLevelInterfaceMockRecorder struct {
+	mock *MockDebugLevelInterface
+}
+
+// NewMockDebugLevelInterface creates a new mock instance.
+func NewMockDebugLevelInterface(ctrl *gomock.Controller) *MockDebugLevelInterface {
+	mock := &MockDebugLevelInterface{ctrl: ctrl}
+	mock.recorder = &MockDebugLevelInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDebugLevelInterface) EXPECT() *MockDebugLevelInterfaceMockRecorder {
+	return m.recorder
+}
+
+// DebugEnabled mocks base method.
+func (m *MockDebugLevelInterface) DebugEnabled(ctx context.Context) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebugEnabled", ctx)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// DebugEnabled indicates an expected call of DebugEnabled.
+func (mr *MockDebugLevelInterfaceMockRecorder) DebugEnabled(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebugEnabled", reflect.TypeOf((*MockDebugLevelInterface)(nil).DebugEnabled), ctx)
+}
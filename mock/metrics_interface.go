@@ -1,5 +1,10 @@
 // Code generated by MockGen. DO NOT EDIT.
 // Source: metrics.go
+//
+// Generated by this command:
+//
+//	mockgen -source=metrics.go -destination=./mock/metrics_interface.go -package=mock .
+//
 
 // Package mock is a generated GoMock package.
 package mock
@@ -10,13 +15,14 @@ import (
 	time "time"
 
 	djoemo "github.com/adjoeio/djoemo"
-	"go.uber.org/mock/gomock"
+	gomock "go.uber.org/mock/gomock"
 )
 
 // MockMetricsInterface is a mock of MetricsInterface interface.
 type MockMetricsInterface struct {
 	ctrl     *gomock.Controller
 	recorder *MockMetricsInterfaceMockRecorder
+	isgomock struct{}
 }
 
 // MockMetricsInterfaceMockRecorder is the mock recorder for MockMetricsInterface.
@@ -43,7 +49,79 @@ func (m *MockMetricsInterface) Record(ctx context.Context, caller string, key dj
 }
 
 // Record indicates an expected call of Record.
-func (mr *MockMetricsInterfaceMockRecorder) Record(ctx, caller, key, duration, success interface{}) *gomock.Call {
+func (mr *MockMetricsInterfaceMockRecorder) Record(ctx, caller, key, duration, success any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockMetricsInterface)(nil).Record), ctx, caller, key, duration, success)
 }
+
+// MockRetryMetricsInterface is a mock of RetryMetricsInterface interface.
+type MockRetryMetricsInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockRetryMetricsInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockRetryMetricsInterfaceMockRecorder is the mock recorder for MockRetryMetricsInterface.
+type MockRetryMetricsInterfaceMockRecorder struct {
+	mock *MockRetryMetricsInterface
+}
+
+// NewMockRetryMetricsInterface creates a new mock instance.
+func NewMockRetryMetricsInterface(ctrl *gomock.Controller) *MockRetryMetricsInterface {
+	mock := &MockRetryMetricsInterface{ctrl: ctrl}
+	mock.recorder = &MockRetryMetricsInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRetryMetricsInterface) EXPECT() *MockRetryMetricsInterfaceMockRecorder {
+	return m.recorder
+}
+
+// RecordRetry mocks base method.
+func (m *MockRetryMetricsInterface) RecordRetry(ctx context.Context, caller string, key djoemo.KeyInterface, class djoemo.ErrorClass) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordRetry", ctx, caller, key, class)
+}
+
+// RecordRetry indicates an expected call of RecordRetry.
+func (mr *MockRetryMetricsInterfaceMockRecorder) RecordRetry(ctx, caller, key, class any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRetry", reflect.TypeOf((*MockRetryMetricsInterface)(nil).RecordRetry), ctx, caller, key, class)
+}
+
+// MockCapacityMetricsInterface is a mock of CapacityMetricsInterface interface.
+type MockCapacityMetricsInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockCapacityMetricsInterfaceMockRecorder
+	isgomock struct{}
+}
+
+// MockCapacityMetricsInterfaceMockRecorder is the mock recorder for MockCapacityMetricsInterface.
+type MockCapacityMetricsInterfaceMockRecorder struct {
+	mock *MockCapacityMetricsInterface
+}
+
+// NewMockCapacityMetricsInterface creates a new mock instance.
+func NewMockCapacityMetricsInterface(ctrl *gomock.Controller) *MockCapacityMetricsInterface {
+	mock := &MockCapacityMetricsInterface{ctrl: ctrl}
+	mock.recorder = &MockCapacityMetricsInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCapacityMetricsInterface) EXPECT() *MockCapacityMetricsInterfaceMockRecorder {
+	return m.recorder
+}
+
+// RecordConsumedCapacity mocks base method.
+func (m *MockCapacityMetricsInterface) RecordConsumedCapacity(ctx context.Context, tableName, indexName string, capacity djoemo.Capacity) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordConsumedCapacity", ctx, tableName, indexName, capacity)
+}
+
+// RecordConsumedCapacity indicates an expected call of RecordConsumedCapacity.
+func (mr *MockCapacityMetricsInterfaceMockRecorder) RecordConsumedCapacity(ctx, tableName, indexName, capacity any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordConsumedCapacity", reflect.TypeOf((*MockCapacityMetricsInterface)(nil).RecordConsumedCapacity), ctx, tableName, indexName, capacity)
+}
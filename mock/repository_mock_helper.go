@@ -0,0 +1,537 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/guregu/dynamo"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+)
+
+/*
+RepositoryMock fluent helper around MockRepositoryInterface; expectations fill the out-params of the repository
+with the items they return, so tests need no DoAndReturn closures.
+## Usage
+repoMock := mock.NewRepositoryMock(mockCtrl)
+
+	repoMock.ExpectGet(key).Return(User{UUID: "uuid"})
+	repoMock.ExpectQuery(query).ReturnItems([]User{{UUID: "uuid"}})
+	repoMock.ExpectSave(key).WithItemMatching(func(item any) bool {
+		return item.(*User).UUID == "uuid"
+	})
+	repoMock.ExpectIndex("email-index").ExpectGet(key).Return(User{UUID: "uuid"})
+	repoMock.ExpectScan(key).ReturnItems([]User{{UUID: "uuid"}})
+
+Items of another type than the out-param, like a User for a *map[string]any, are converted by marshalling
+them the way the repository does. Expectations embed the *gomock.Call, so Times, AnyTimes or After can follow.
+*/
+type RepositoryMock struct {
+	*MockRepositoryInterface
+	ctrl    *gomock.Controller
+	indexes map[string]*GlobalIndexMock
+}
+
+// NewRepositoryMock creates a RepositoryMock
+func NewRepositoryMock(ctrl *gomock.Controller) *RepositoryMock {
+	return &RepositoryMock{
+		MockRepositoryInterface: NewMockRepositoryInterface(ctrl),
+		ctrl:                    ctrl,
+		indexes:                 make(map[string]*GlobalIndexMock),
+	}
+}
+
+// ExpectGet expects GetItemWithContext with key
+func (m *RepositoryMock) ExpectGet(key djoemo.KeyInterface) *ReadExpectation {
+	e := &ReadExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().GetItemWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.KeyInterface, item any) (bool, error) {
+			return e.read(item)
+		})
+	return e
+}
+
+// ExpectGetItems expects GetItemsWithContext with key
+func (m *RepositoryMock) ExpectGetItems(key djoemo.KeyInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().GetItemsWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.KeyInterface, out any) (bool, error) {
+			return e.read(out)
+		})
+	return e
+}
+
+// ExpectBatchGet expects BatchGetItemsWithContext with keys
+func (m *RepositoryMock) ExpectBatchGet(keys ...djoemo.KeyInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().BatchGetItemsWithContext(gomock.Any(), KeysEq(keys...), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ []djoemo.KeyInterface, out any) (bool, error) {
+			return e.read(out)
+		})
+	return e
+}
+
+// ExpectQuery expects QueryWithContext with query
+func (m *RepositoryMock) ExpectQuery(query djoemo.QueryInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().QueryWithContext(gomock.Any(), KeyEq(query), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.QueryInterface, item any) error {
+			_, err := e.read(item)
+			return err
+		})
+	return e
+}
+
+// ExpectSave expects SaveItemWithContext with key
+func (m *RepositoryMock) ExpectSave(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().SaveItemWithContext(gomock.Any(), KeyEq(key), e.item).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, any) error {
+			return e.err
+		})
+	return e
+}
+
+// ExpectSaveItems expects SaveItemsWithContext with key; WithItemMatching receives the items
+func (m *RepositoryMock) ExpectSaveItems(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().SaveItemsWithContext(gomock.Any(), KeyEq(key), e.item).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, any) error {
+			return e.err
+		})
+	return e
+}
+
+// ExpectUpdate expects UpdateWithUpdateExpressions with key; WithItemMatching receives the djoemo.UpdateExpressions
+func (m *RepositoryMock) ExpectUpdate(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().UpdateWithUpdateExpressions(gomock.Any(), KeyEq(key), e.item).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, djoemo.UpdateExpressions) error {
+			return e.err
+		})
+	return e
+}
+
+// ExpectConditionalUpdate expects ConditionalUpdateWithContext with key; it succeeds unless Reject is called
+func (m *RepositoryMock) ExpectConditionalUpdate(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().ConditionalUpdateWithContext(gomock.Any(), KeyEq(key), e.item, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, any, string, ...any) (bool, error) {
+			return e.conditional()
+		})
+	return e
+}
+
+// ExpectOptimisticLockSave expects OptimisticLockSaveWithContext with key; it succeeds unless Reject is called
+func (m *RepositoryMock) ExpectOptimisticLockSave(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().OptimisticLockSaveWithContext(gomock.Any(), KeyEq(key), e.item).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, any) (bool, error) {
+			return e.conditional()
+		})
+	return e
+}
+
+// ExpectDelete expects DeleteItemWithContext with key
+func (m *RepositoryMock) ExpectDelete(key djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().DeleteItemWithContext(gomock.Any(), KeyEq(key)).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface) error {
+			return e.err
+		})
+	return e
+}
+
+// ExpectDeleteItems expects DeleteItemsWithContext with keys
+func (m *RepositoryMock) ExpectDeleteItems(keys ...djoemo.KeyInterface) *WriteExpectation {
+	e := newWriteExpectation()
+	e.Call = m.EXPECT().DeleteItemsWithContext(gomock.Any(), KeysEq(keys...)).
+		DoAndReturn(func(context.Context, []djoemo.KeyInterface) error {
+			return e.err
+		})
+	return e
+}
+
+// ExpectScan expects ScanIteratorWithContext with key; the returned iterator yields the items of ReturnItems
+func (m *RepositoryMock) ExpectScan(key djoemo.KeyInterface) *ScanExpectation {
+	e := &ScanExpectation{ctrl: m.ctrl}
+	e.Call = m.EXPECT().ScanIteratorWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(context.Context, djoemo.KeyInterface, int64) (djoemo.IteratorInterface, error) {
+			if e.err != nil {
+				return nil, e.err
+			}
+			return NewIteratorMock(e.ctrl, e.items), nil
+		})
+	return e
+}
+
+// ExpectIndex returns the GlobalIndexMock returned by GIndex for name, any number of times
+func (m *RepositoryMock) ExpectIndex(name string) *GlobalIndexMock {
+	if index, ok := m.indexes[name]; ok {
+		return index
+	}
+	index := NewGlobalIndexMock(m.ctrl)
+	m.indexes[name] = index
+	m.EXPECT().GIndex(name).Return(index).AnyTimes()
+	return index
+}
+
+// GlobalIndexMock fluent helper around MockGlobalIndexInterface, see RepositoryMock
+type GlobalIndexMock struct {
+	*MockGlobalIndexInterface
+	ctrl *gomock.Controller
+}
+
+// NewGlobalIndexMock creates a GlobalIndexMock
+func NewGlobalIndexMock(ctrl *gomock.Controller) *GlobalIndexMock {
+	return &GlobalIndexMock{MockGlobalIndexInterface: NewMockGlobalIndexInterface(ctrl), ctrl: ctrl}
+}
+
+// ExpectGet expects GetItemWithContext with key
+func (m *GlobalIndexMock) ExpectGet(key djoemo.KeyInterface) *ReadExpectation {
+	e := &ReadExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().GetItemWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.KeyInterface, item any) (bool, error) {
+			return e.read(item)
+		})
+	return e
+}
+
+// ExpectGetItems expects GetItemsWithContext with key
+func (m *GlobalIndexMock) ExpectGetItems(key djoemo.KeyInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().GetItemsWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.KeyInterface, items any) (bool, error) {
+			return e.read(items)
+		})
+	return e
+}
+
+// ExpectGetItemsWithRange expects GetItemsWithRangeWithContext with key
+func (m *GlobalIndexMock) ExpectGetItemsWithRange(key djoemo.KeyInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().GetItemsWithRangeWithContext(gomock.Any(), KeyEq(key), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.KeyInterface, items any) (bool, error) {
+			return e.read(items)
+		})
+	return e
+}
+
+// ExpectQuery expects QueryWithContext with query
+func (m *GlobalIndexMock) ExpectQuery(query djoemo.QueryInterface) *ItemsExpectation {
+	e := &ItemsExpectation{t: m.ctrl.T}
+	e.Call = m.EXPECT().QueryWithContext(gomock.Any(), KeyEq(query), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ djoemo.QueryInterface, item any) error {
+			_, err := e.read(item)
+			return err
+		})
+	return e
+}
+
+// NewIteratorMock creates a MockIteratorInterface whose NextItem fills out with the elements of items, a slice,
+// one after the other and returns false once they are exhausted
+func NewIteratorMock(ctrl *gomock.Controller, items any) *MockIteratorInterface {
+	iterator := NewMockIteratorInterface(ctrl)
+	elements := reflect.ValueOf(items)
+	if items != nil && elements.Kind() != reflect.Slice {
+		ctrl.T.Fatalf("iterator items must be a slice, got %T", items)
+	}
+	next := 0
+	iterator.EXPECT().NextItem(gomock.Any()).DoAndReturn(func(out any) bool {
+		if items == nil || next >= elements.Len() {
+			return false
+		}
+		fill(ctrl.T, out, elements.Index(next).Interface())
+		next++
+		return true
+	}).AnyTimes()
+	return iterator
+}
+
+// ReadExpectation expected read of one item; without Return the item is not found
+type ReadExpectation struct {
+	*gomock.Call
+	t     gomock.TestHelper
+	item  any
+	found bool
+	err   error
+}
+
+// Return fills the out-param with item and reports it as found
+func (e *ReadExpectation) Return(item any) *ReadExpectation {
+	e.item, e.found, e.err = item, true, nil
+	return e
+}
+
+// NotFound reports the item as not found
+func (e *ReadExpectation) NotFound() *ReadExpectation {
+	e.item, e.found, e.err = nil, false, nil
+	return e
+}
+
+// ReturnError fails the read with err
+func (e *ReadExpectation) ReturnError(err error) *ReadExpectation {
+	e.err = err
+	return e
+}
+
+func (e *ReadExpectation) read(out any) (bool, error) {
+	if e.err != nil || !e.found {
+		return false, e.err
+	}
+	fill(e.t, out, e.item)
+	return true, nil
+}
+
+// ItemsExpectation expected read of several items; without ReturnItems no items are found
+type ItemsExpectation struct {
+	*gomock.Call
+	t     gomock.TestHelper
+	items any
+	err   error
+}
+
+// ReturnItems fills the out-param with items, a slice; the items are found if it is not empty
+func (e *ItemsExpectation) ReturnItems(items any) *ItemsExpectation {
+	e.items, e.err = items, nil
+	return e
+}
+
+// NotFound returns no items
+func (e *ItemsExpectation) NotFound() *ItemsExpectation {
+	e.items, e.err = nil, nil
+	return e
+}
+
+// ReturnError fails the read with err
+func (e *ItemsExpectation) ReturnError(err error) *ItemsExpectation {
+	e.err = err
+	return e
+}
+
+func (e *ItemsExpectation) read(out any) (bool, error) {
+	if e.err != nil || e.items == nil {
+		return false, e.err
+	}
+	fill(e.t, out, e.items)
+	return reflect.ValueOf(e.items).Len() > 0, nil
+}
+
+// WriteExpectation expected write; it succeeds unless ReturnError or Reject is called
+type WriteExpectation struct {
+	*gomock.Call
+	item     *itemMatcher
+	err      error
+	rejected bool
+}
+
+func newWriteExpectation() *WriteExpectation {
+	return &WriteExpectation{item: &itemMatcher{}}
+}
+
+// WithItemMatching only matches calls whose item satisfies match
+func (e *WriteExpectation) WithItemMatching(match func(item any) bool) *WriteExpectation {
+	e.item.match = match
+	return e
+}
+
+// ReturnError fails the write with err
+func (e *WriteExpectation) ReturnError(err error) *WriteExpectation {
+	e.err = err
+	return e
+}
+
+// Reject reports a conditional write as not written because its condition failed
+func (e *WriteExpectation) Reject() *WriteExpectation {
+	e.rejected = true
+	return e
+}
+
+func (e *WriteExpectation) conditional() (bool, error) {
+	if e.err != nil {
+		return false, e.err
+	}
+	return !e.rejected, nil
+}
+
+// ScanExpectation expected scan; without ReturnItems the iterator yields no items
+type ScanExpectation struct {
+	*gomock.Call
+	ctrl  *gomock.Controller
+	items any
+	err   error
+}
+
+// ReturnItems lets the iterator yield the elements of items, a slice
+func (e *ScanExpectation) ReturnItems(items any) *ScanExpectation {
+	e.items, e.err = items, nil
+	return e
+}
+
+// ReturnError fails the scan with err
+func (e *ScanExpectation) ReturnError(err error) *ScanExpectation {
+	e.err = err
+	return e
+}
+
+// itemMatcher matches any item until a match function is set
+type itemMatcher struct {
+	match func(item any) bool
+}
+
+func (m *itemMatcher) Matches(x any) bool {
+	return m.match == nil || m.match(x)
+}
+
+func (m *itemMatcher) String() string {
+	if m.match == nil {
+		return "is anything"
+	}
+	return "matches item"
+}
+
+// KeyEq returns a matcher for keys with the same table, key names and values; queries also need the same range
+// operator, limit and order. A nil key matches any key
+func KeyEq(key djoemo.KeyInterface) gomock.Matcher {
+	if key == nil {
+		return gomock.Any()
+	}
+	return keyMatcher{key: key}
+}
+
+// KeysEq returns a matcher for slices of keys matching keys in order
+func KeysEq(keys ...djoemo.KeyInterface) gomock.Matcher {
+	return keysMatcher(keys)
+}
+
+type keyMatcher struct {
+	key djoemo.KeyInterface
+}
+
+func (m keyMatcher) Matches(x any) bool {
+	other, ok := x.(djoemo.KeyInterface)
+	if !ok || other == nil {
+		return false
+	}
+	if m.key.TableName() != other.TableName() ||
+		!reflect.DeepEqual(m.key.HashKeyName(), other.HashKeyName()) ||
+		!reflect.DeepEqual(m.key.RangeKeyName(), other.RangeKeyName()) ||
+		!reflect.DeepEqual(m.key.HashKey(), other.HashKey()) ||
+		!reflect.DeepEqual(m.key.RangeKey(), other.RangeKey()) {
+		return false
+	}
+
+	query, ok := m.key.(djoemo.QueryInterface)
+	if !ok {
+		return true
+	}
+	otherQuery, ok := other.(djoemo.QueryInterface)
+	return ok && query.RangeOp() == otherQuery.RangeOp() &&
+		reflect.DeepEqual(query.Limit(), otherQuery.Limit()) &&
+		query.Descending() == otherQuery.Descending()
+}
+
+func (m keyMatcher) String() string {
+	return "is key " + formatKey(m.key)
+}
+
+type keysMatcher []djoemo.KeyInterface
+
+func (m keysMatcher) Matches(x any) bool {
+	keys, ok := x.([]djoemo.KeyInterface)
+	if !ok || len(keys) != len(m) {
+		return false
+	}
+	for i, key := range m {
+		if !KeyEq(key).Matches(keys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m keysMatcher) String() string {
+	s := "are keys ["
+	for i, key := range m {
+		if i > 0 {
+			s += ", "
+		}
+		s += formatKey(key)
+	}
+	return s + "]"
+}
+
+func formatKey(key djoemo.KeyInterface) string {
+	s := "{table: " + key.TableName()
+	if name := key.HashKeyName(); name != nil {
+		s += fmt.Sprintf(", %s: %v", *name, key.HashKey())
+	}
+	if name := key.RangeKeyName(); name != nil {
+		s += fmt.Sprintf(", %s: %v", *name, key.RangeKey())
+	}
+	if query, ok := key.(djoemo.QueryInterface); ok {
+		s += fmt.Sprintf(", op: %s, descending: %t", query.RangeOp(), query.Descending())
+		if limit := query.Limit(); limit != nil {
+			s += fmt.Sprintf(", limit: %d", *limit)
+		}
+	}
+	return s + "}"
+}
+
+// fill assigns value to the value out points to; values of other types are converted by marshalling them
+func fill(t gomock.TestHelper, out any, value any) {
+	t.Helper()
+	if err := assign(out, value); err != nil {
+		t.Fatalf("can not fill %T with %T: %v", out, value, err)
+	}
+}
+
+func assign(out any, value any) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("out-param is not a pointer")
+	}
+	target = target.Elem()
+
+	source := reflect.ValueOf(value)
+	for source.Kind() == reflect.Ptr && !source.IsNil() && !source.Type().AssignableTo(target.Type()) {
+		source = source.Elem()
+	}
+	if source.IsValid() && source.Type().AssignableTo(target.Type()) {
+		if source.Kind() == reflect.Slice && !source.IsNil() {
+			// copy slices, so code under test can not modify the items of the expectation
+			copied := reflect.MakeSlice(source.Type(), source.Len(), source.Len())
+			reflect.Copy(copied, source)
+			source = copied
+		}
+		target.Set(source)
+		return nil
+	}
+
+	if target.Kind() == reflect.Slice && source.Kind() == reflect.Slice {
+		items := reflect.MakeSlice(target.Type(), source.Len(), source.Len())
+		for i := 0; i < source.Len(); i++ {
+			if err := assign(items.Index(i).Addr().Interface(), source.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		target.Set(items)
+		return nil
+	}
+
+	av, err := dynamo.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if av == nil || av.M == nil {
+		return fmt.Errorf("%T is not an item", value)
+	}
+	if target.Kind() == reflect.Ptr {
+		// UnmarshalItem only allocates nested pointers
+		target.Set(reflect.New(target.Type().Elem()))
+		out = target.Interface()
+	}
+	return dynamo.UnmarshalItem(av.M, out)
+}
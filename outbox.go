@@ -0,0 +1,167 @@
+package djoemo
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
+// DefaultOutboxTable is the name of the table domain events are written to
+const DefaultOutboxTable = "djoemo_outbox"
+
+// OutboxUnsentIndex is the sparse global index of an outbox table holding the unsent events only
+const OutboxUnsentIndex = "unsent-index"
+
+// DomainEvent is an event raised by a write; it is written to the outbox in the same transaction as the write
+type DomainEvent struct {
+	// Aggregate identifies the item the event belongs to, like "user#123"; events of an aggregate are relayed in the
+	// order they were written, see OutboxEvent.Sequence
+	Aggregate string
+	// Type names the event, like "UserRegistered"
+	Type string
+	// Payload is marshalled like items
+	Payload any
+}
+
+// OutboxEvent is a domain event stored in the outbox
+type OutboxEvent struct {
+	Aggregate string `dynamo:",hash"`
+	// Sequence orders the events of an aggregate by the clock of the writer; events of the same write keep their
+	// order, but events written by hosts with skewed clocks at about the same time may be ordered differently than
+	// they were written. Serialize the writes of an aggregate, like with a version checked by
+	// OptimisticLockSaveWithContext, and carry the version in the payload if consumers depend on the order.
+	Sequence  string `dynamo:",range"`
+	Type      string
+	Payload   *dynamodb.AttributeValue
+	CreatedAt time.Time
+	// Unsent is the aggregate of an event that is not published yet; it keys OutboxUnsentIndex and is removed once
+	// the event is sent
+	Unsent string `dynamo:",omitempty"`
+	// SentAt is set once the event is published
+	SentAt time.Time `dynamo:",omitempty"`
+	// ExpiresAt is the unix time a sent event is deleted by time to live
+	ExpiresAt int64 `dynamo:",omitempty"`
+}
+
+// DecodePayload unmarshals the payload into out
+func (e OutboxEvent) DecodePayload(out any) error {
+	switch {
+	case e.Payload == nil:
+		return nil
+	case e.Payload.M != nil:
+		return dynamo.UnmarshalItem(e.Payload.M, out)
+	}
+	return dynamo.Unmarshal(e.Payload, out)
+}
+
+// OutboxSchema returns the schema of an outbox table; unsent events are found by OutboxUnsentIndex, sent events
+// expire by time to live of ExpiresAt
+func OutboxSchema(tableName string) TableSchema {
+	return TableSchema{
+		Name:     tableName,
+		HashKey:  "Aggregate",
+		RangeKey: "Sequence",
+		AttributeTypes: map[string]string{
+			"Aggregate": dynamodb.ScalarAttributeTypeS,
+			"Sequence":  dynamodb.ScalarAttributeTypeS,
+			"Unsent":    dynamodb.ScalarAttributeTypeS,
+		},
+		GlobalIndexes: []IndexSchema{
+			{Name: OutboxUnsentIndex, HashKey: "Unsent", RangeKey: "Sequence"},
+		},
+		TTLAttribute: "ExpiresAt",
+	}
+}
+
+type domainEventsContextKey int
+
+const domainEventsCtxKey domainEventsContextKey = iota
+
+// WithDomainEvents returns a context whose SaveItemWithContext, SaveItemsWithContext, UpdateWithContext,
+// UpdateWithUpdateExpressions, DeleteItemWithContext and DeleteItemsWithContext calls write events to the outbox in the
+// same transaction; events are added to the events of ctx. Every write with the returned context writes the events,
+// so derive it for a single write. Within a unit of work, the events are written by Commit.
+func WithDomainEvents(ctx context.Context, events ...DomainEvent) context.Context {
+	all := append(append([]DomainEvent{}, DomainEventsFromContext(ctx)...), events...)
+	return context.WithValue(ctx, domainEventsCtxKey, all)
+}
+
+// DomainEventsFromContext returns the domain events of ctx
+func DomainEventsFromContext(ctx context.Context) []DomainEvent {
+	events, _ := ctx.Value(domainEventsCtxKey).([]DomainEvent)
+	return events
+}
+
+// outboxPut is the write of a domain event to the outbox
+type outboxPut struct {
+	tableName string
+	event     OutboxEvent
+}
+
+// outboxPuts prepares the domain events of ctx for the outbox of the repository
+func (repository Repository) outboxPuts(ctx context.Context) ([]outboxPut, error) {
+	events := DomainEventsFromContext(ctx)
+	puts := make([]outboxPut, 0, len(events))
+	now := time.Now().UTC()
+	for i, event := range events {
+		if event.Aggregate == "" || event.Type == "" {
+			return nil, fmt.Errorf("%w: domain event needs an aggregate and a type", ErrInvalidDomainEvent)
+		}
+		payload, err := dynamo.Marshal(event.Payload)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidDomainEvent, event.Type, err)
+		}
+		puts = append(puts, outboxPut{tableName: repository.outboxTableName(), event: OutboxEvent{
+			Aggregate: event.Aggregate,
+			// the index keeps the order of events of the same write, the random suffix makes sequences unique
+			Sequence:  fmt.Sprintf("%019d-%04d-%08x", now.UnixNano(), i, rand.Uint32()),
+			Type:      event.Type,
+			Payload:   payload,
+			CreatedAt: now,
+			Unsent:    event.Aggregate,
+		}})
+	}
+	return puts, nil
+}
+
+func (repository Repository) outboxTableName() string {
+	if repository.outboxTable == "" {
+		return DefaultOutboxTable
+	}
+	return repository.outboxTable
+}
+
+// transactWithEvents runs the writes added by add and the puts of the domain events of ctx as a single transaction
+func (repository Repository) transactWithEvents(ctx context.Context, op string, key KeyInterface, writes int, add func(tx *dynamo.WriteTx)) error {
+	puts, err := repository.outboxPuts(ctx)
+	if err != nil {
+		return err
+	}
+	if writes+len(puts) > MaxTransactionItems {
+		return fmt.Errorf("%w: %d writes and %d events", ErrOutboxTransactionTooLarge, writes, len(puts))
+	}
+
+	// every attempt sends the same client request token, so a retry of a transaction that succeeded is not applied again
+	tx := repository.dynamoClient.WriteTx().Idempotent(true)
+	add(tx)
+	addOutboxPuts(repository, tx, puts)
+	return repository.retry(ctx, op, key, tx.RunWithContext)
+}
+
+func addOutboxPuts(repository Repository, tx *dynamo.WriteTx, puts []outboxPut) {
+	for _, put := range puts {
+		tx.Put(repository.table(put.tableName).Put(put.event))
+	}
+}
+
+// hasDomainEvents rejects domain events for writes that can not be combined with the outbox in a transaction
+func hasDomainEvents(ctx context.Context) error {
+	if len(DomainEventsFromContext(ctx)) > 0 {
+		return ErrDomainEventsNotSupported
+	}
+	return nil
+}
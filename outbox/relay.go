@@ -0,0 +1,194 @@
+// Package outbox relays domain events written to the outbox by repository writes, see djoemo.WithDomainEvents, to a
+// publisher. Events of an aggregate are published in the order of their sequence, see djoemo.OutboxEvent; an event
+// that fails to publish holds back the later events of its aggregate until it is published. Published events are
+// marked sent. Unsent events are found by the sparse djoemo.OutboxUnsentIndex, so a pass reads the unsent events only.
+//
+//	relay := outbox.NewRelay(client, outbox.PublisherFunc(func(ctx context.Context, event djoemo.OutboxEvent) error {
+//		return queue.Send(ctx, event.Type, event.Payload)
+//	}))
+//	err := relay.Run(ctx)
+//
+// Events are published at least once: an event is published again if marking it sent fails, several relays run or
+// the eventually consistent unsent index still holds it in the next pass.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// DefaultPollInterval is how long Run waits between passes over the outbox
+const DefaultPollInterval = time.Second
+
+// DefaultRetention is how long sent events are kept before they expire
+const DefaultRetention = 7 * 24 * time.Hour
+
+// Publisher publishes domain events, like to a queue or a topic
+type Publisher interface {
+	Publish(ctx context.Context, event djoemo.OutboxEvent) error
+}
+
+// PublisherFunc adapts a func to a Publisher
+type PublisherFunc func(ctx context.Context, event djoemo.OutboxEvent) error
+
+// Publish calls fn
+func (fn PublisherFunc) Publish(ctx context.Context, event djoemo.OutboxEvent) error {
+	return fn(ctx, event)
+}
+
+// Relay publishes the unsent events of an outbox
+type Relay struct {
+	db           *dynamo.DB
+	repository   djoemo.RepositoryInterface
+	publisher    Publisher
+	tableName    string
+	pollInterval time.Duration
+	retention    time.Duration
+	log          djoemo.LogInterface
+	now          func() time.Time
+}
+
+// NewRelay factory method for a relay of DefaultOutboxTable
+func NewRelay(client dynamodbiface.DynamoDBAPI, publisher Publisher) *Relay {
+	return &Relay{
+		db:           dynamo.NewFromIface(client),
+		repository:   djoemo.NewRepository(client),
+		publisher:    publisher,
+		tableName:    djoemo.DefaultOutboxTable,
+		pollInterval: DefaultPollInterval,
+		retention:    DefaultRetention,
+		log:          djoemo.NewNopLog(),
+		now:          time.Now,
+	}
+}
+
+// WithTableName sets the name of the outbox table
+func (r *Relay) WithTableName(tableName string) *Relay {
+	r.tableName = tableName
+	return r
+}
+
+// WithPollInterval sets how long Run waits between passes over the outbox
+func (r *Relay) WithPollInterval(interval time.Duration) *Relay {
+	r.pollInterval = interval
+	return r
+}
+
+// WithRetention sets how long sent events are kept; they are kept forever if retention is 0
+func (r *Relay) WithRetention(retention time.Duration) *Relay {
+	r.retention = retention
+	return r
+}
+
+// WithLog enables logging of the relay and of the repository creating the outbox table
+func (r *Relay) WithLog(log djoemo.LogInterface) *Relay {
+	r.log = log
+	r.repository.WithLog(log)
+	return r
+}
+
+// EnsureTable creates the outbox table if it does not exist
+func (r *Relay) EnsureTable(ctx context.Context) error {
+	return r.repository.EnsureTable(ctx, djoemo.OutboxSchema(r.tableName))
+}
+
+// Run publishes unsent events every poll interval until ctx is canceled and returns nil then; failures are logged
+// and retried by the next pass
+func (r *Relay) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+		if _, err := r.PublishPending(ctx); err != nil && ctx.Err() == nil {
+			r.log.WithContext(ctx).WithField("Error", err.Error()).Warn("outbox: relaying events failed")
+		}
+		timer.Reset(r.pollInterval)
+	}
+}
+
+// PublishPending publishes all unsent events once and returns how many were published. Failures of aggregates are
+// joined; the events of other aggregates are published anyway.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	aggregates, err := r.pendingAggregates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var errs []error
+	for _, aggregate := range aggregates {
+		count, err := r.relayAggregate(ctx, aggregate)
+		published += count
+		if err != nil {
+			errs = append(errs, fmt.Errorf("outbox: aggregate %s: %w", aggregate, err))
+			if ctx.Err() != nil {
+				break
+			}
+		}
+	}
+	return published, errors.Join(errs...)
+}
+
+// pendingAggregates returns the aggregates with unsent events in the order they are scanned from the unsent index
+func (r *Relay) pendingAggregates(ctx context.Context) ([]string, error) {
+	iterator := r.db.Table(r.tableName).Scan().
+		Index(djoemo.OutboxUnsentIndex).
+		Project("Aggregate").
+		Iter()
+
+	var aggregates []string
+	seen := make(map[string]bool)
+	for event := (djoemo.OutboxEvent{}); iterator.NextWithContext(ctx, &event); event = (djoemo.OutboxEvent{}) {
+		if !seen[event.Aggregate] {
+			seen[event.Aggregate] = true
+			aggregates = append(aggregates, event.Aggregate)
+		}
+	}
+	return aggregates, iterator.Err()
+}
+
+// relayAggregate publishes the unsent events of an aggregate in order, read from the unsent index, and stops at the
+// first failure
+func (r *Relay) relayAggregate(ctx context.Context, aggregate string) (int, error) {
+	var events []djoemo.OutboxEvent
+	err := r.db.Table(r.tableName).Get("Unsent", aggregate).
+		Index(djoemo.OutboxUnsentIndex).
+		Order(dynamo.Ascending).
+		AllWithContext(ctx, &events)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range events {
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			return i, fmt.Errorf("event %s %s: %w", event.Type, event.Sequence, err)
+		}
+		if err := r.markSent(ctx, event); err != nil {
+			return i + 1, fmt.Errorf("event %s %s: marking sent: %w", event.Type, event.Sequence, err)
+		}
+	}
+	return len(events), nil
+}
+
+// markSent sets SentAt and removes Unsent, which drops the event from the unsent index
+func (r *Relay) markSent(ctx context.Context, event djoemo.OutboxEvent) error {
+	update := r.db.Table(r.tableName).Update("Aggregate", event.Aggregate).
+		Range("Sequence", event.Sequence).
+		Set("SentAt", r.now().UTC()).
+		Remove("Unsent")
+	if r.retention > 0 {
+		update.Set("ExpiresAt", r.now().Add(r.retention).Unix())
+	}
+	return update.RunWithContext(ctx)
+}
@@ -0,0 +1,138 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/outbox"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	UUID     string
+	UserName string
+}
+
+// publisher records published events as "<aggregate> <type>" and fails for the types in failing
+type publisher struct {
+	mu        sync.Mutex
+	published []string
+	failing   map[string]bool
+}
+
+func (p *publisher) Publish(ctx context.Context, event djoemo.OutboxEvent) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing[event.Type] {
+		return errors.New("unavailable")
+	}
+	p.published = append(p.published, event.Aggregate+" "+event.Type)
+	return nil
+}
+
+func (p *publisher) list() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.published...)
+}
+
+var _ = Describe("Relay", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+		events     *publisher
+		relay      *outbox.Relay
+	)
+
+	BeforeEach(func() {
+		db = fake.New().WithTable(UserTableName, "UUID", "")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+		events = &publisher{failing: map[string]bool{}}
+		relay = outbox.NewRelay(db, events)
+		Expect(relay.EnsureTable(ctx)).To(Succeed())
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	rename := func(uuid string, userName string, eventTypes ...string) {
+		var domainEvents []djoemo.DomainEvent
+		for _, eventType := range eventTypes {
+			domainEvents = append(domainEvents, djoemo.DomainEvent{Aggregate: "user#" + uuid, Type: eventType, Payload: userName})
+		}
+		eventCtx := djoemo.WithDomainEvents(ctx, domainEvents...)
+		Expect(repository.SaveItemWithContext(eventCtx, userKey(uuid), User{UUID: uuid, UserName: userName})).To(Succeed())
+	}
+
+	It("should publish events of aggregates in order and mark them sent", func() {
+		rename("a", "alice", "UserRegistered")
+		rename("b", "bob", "UserRegistered")
+		rename("a", "alicia", "UserRenamed", "NameChecked")
+
+		published, err := relay.PublishPending(ctx)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(4))
+		Expect(events.list()).To(ContainElements("user#b UserRegistered"))
+		var ofA []string
+		for _, event := range events.list() {
+			if event[:6] == "user#a" {
+				ofA = append(ofA, event)
+			}
+		}
+		Expect(ofA).To(Equal([]string{"user#a UserRegistered", "user#a UserRenamed", "user#a NameChecked"}))
+
+		for _, item := range db.Items(djoemo.DefaultOutboxTable) {
+			Expect(item["SentAt"]).NotTo(BeNil())
+			Expect(item["ExpiresAt"]).NotTo(BeNil())
+			Expect(item["Unsent"]).To(BeNil())
+		}
+		published, err = relay.PublishPending(ctx)
+		Expect(err).To(BeNil())
+		Expect(published).To(Equal(0))
+	})
+
+	It("should hold back later events of an aggregate until a failed event is published", func() {
+		rename("a", "alice", "UserRegistered", "UserRenamed")
+		rename("b", "bob", "UserRegistered")
+		events.failing["UserRegistered"] = true
+
+		published, err := relay.PublishPending(ctx)
+		Expect(err).To(MatchError(ContainSubstring("unavailable")))
+		Expect(published).To(Equal(0))
+
+		events.failing = map[string]bool{"UserRenamed": true}
+		published, err = relay.PublishPending(ctx)
+		Expect(err).To(MatchError(ContainSubstring("aggregate user#a: event UserRenamed")))
+		Expect(published).To(Equal(2))
+
+		events.failing = map[string]bool{}
+		Expect(relay.PublishPending(ctx)).To(Equal(1))
+		Expect(events.list()).To(HaveLen(3))
+	})
+
+	It("should publish until it is canceled", func() {
+		runCtx, cancel := context.WithCancel(ctx)
+		stopped := make(chan error)
+		go func() {
+			stopped <- relay.WithPollInterval(10 * time.Millisecond).WithRetention(0).Run(runCtx)
+		}()
+
+		rename("a", "alice", "UserRegistered")
+		Eventually(events.list).Should(Equal([]string{"user#a UserRegistered"}))
+		cancel()
+		Eventually(stopped).Should(Receive(BeNil()))
+		Expect(db.Items(djoemo.DefaultOutboxTable)[0]["ExpiresAt"]).To(BeNil())
+	})
+})
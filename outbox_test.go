@@ -0,0 +1,121 @@
+package djoemo_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Outbox", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New().WithTable(UserTableName, "UUID", "")
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+		Expect(repository.CreateTable(ctx, djoemo.OutboxSchema(djoemo.DefaultOutboxTable))).To(Succeed())
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	registered := func(uuid string) djoemo.DomainEvent {
+		return djoemo.DomainEvent{Aggregate: "user#" + uuid, Type: "UserRegistered", Payload: User{UUID: uuid, UserName: "name"}}
+	}
+
+	outbox := func() []djoemo.OutboxEvent {
+		var events []djoemo.OutboxEvent
+		for _, item := range db.Items(djoemo.DefaultOutboxTable) {
+			event := djoemo.OutboxEvent{}
+			Expect(dynamo.UnmarshalItem(item, &event)).To(Succeed())
+			events = append(events, event)
+		}
+		return events
+	}
+
+	It("should write events in the transaction of saves, updates and deletes", func() {
+		eventCtx := djoemo.WithDomainEvents(ctx, registered("a"))
+		Expect(repository.SaveItemWithContext(eventCtx, userKey("a"), User{UUID: "a", UserName: "name"})).To(Succeed())
+		Expect(db.Items(UserTableName)).To(HaveLen(1))
+
+		events := outbox()
+		Expect(events).To(HaveLen(1))
+		Expect(events[0].Aggregate).To(Equal("user#a"))
+		Expect(events[0].Type).To(Equal("UserRegistered"))
+		Expect(events[0].SentAt.IsZero()).To(BeTrue())
+		Expect(events[0].Unsent).To(Equal("user#a"))
+		payload := User{}
+		Expect(events[0].DecodePayload(&payload)).To(Succeed())
+		Expect(payload.UserName).To(Equal("name"))
+
+		renamed := djoemo.DomainEvent{Aggregate: "user#a", Type: "UserRenamed", Payload: map[string]string{"UserName": "alice"}}
+		deleted := djoemo.DomainEvent{Aggregate: "user#a", Type: "UserDeleted"}
+		Expect(repository.UpdateWithContext(djoemo.WithDomainEvents(ctx, renamed), djoemo.Set, userKey("a"), map[string]any{"UserName": "alice"})).To(Succeed())
+		Expect(repository.DeleteItemWithContext(djoemo.WithDomainEvents(ctx, deleted), userKey("a"))).To(Succeed())
+		Expect(db.Items(UserTableName)).To(BeEmpty())
+
+		events = outbox()
+		Expect([]string{events[0].Type, events[1].Type, events[2].Type}).To(Equal([]string{"UserRegistered", "UserRenamed", "UserDeleted"}))
+	})
+
+	It("should write neither the item nor the events if the transaction fails", func() {
+		repository.WithOutboxTable("MissingOutbox")
+		err := repository.SaveItemsWithContext(djoemo.WithDomainEvents(ctx, registered("a"), registered("b")), userKey(""),
+			[]User{{UUID: "a"}, {UUID: "b"}})
+		Expect(err.(awserr.Error).Code()).To(Equal(dynamodb.ErrCodeResourceNotFoundException))
+		Expect(db.Items(UserTableName)).To(BeEmpty())
+	})
+
+	It("should write the events of a unit of work on commit", func() {
+		uowCtx, uow := djoemo.NewUnitOfWork(ctx)
+		Expect(repository.SaveItemWithContext(djoemo.WithDomainEvents(uowCtx, registered("a")), userKey("a"), User{UUID: "a"})).To(Succeed())
+		Expect(repository.SaveItemWithContext(djoemo.WithDomainEvents(uowCtx, registered("b")), userKey("b"), User{UUID: "b"})).To(Succeed())
+		Expect(outbox()).To(BeEmpty())
+
+		Expect(uow.Commit(uowCtx)).To(Succeed())
+		Expect(db.Items(UserTableName)).To(HaveLen(2))
+		Expect(outbox()).To(HaveLen(2))
+
+		Expect(repository.SaveItemWithContext(djoemo.WithDomainEvents(uowCtx, registered("c")), userKey("c"), User{UUID: "c"})).To(Succeed())
+		uow.Discard()
+		Expect(uow.Commit(uowCtx)).To(Succeed())
+		Expect(outbox()).To(HaveLen(2))
+	})
+
+	It("should reject invalid events and writes that do not support them", func() {
+		eventCtx := djoemo.WithDomainEvents(ctx, registered("a"))
+		_, err := repository.ConditionalUpdateWithContext(eventCtx, userKey("a"), User{UUID: "a"}, "attribute_not_exists($)", "UUID")
+		Expect(err).To(MatchError(djoemo.ErrDomainEventsNotSupported))
+
+		err = repository.SaveItemWithContext(djoemo.WithDomainEvents(ctx, djoemo.DomainEvent{Type: "UserRegistered"}), userKey("a"), User{UUID: "a"})
+		Expect(err).To(MatchError(djoemo.ErrInvalidDomainEvent))
+		Expect(db.Items(UserTableName)).To(BeEmpty())
+	})
+
+	It("should not register a write of a unit of work with invalid events", func() {
+		uowCtx, uow := djoemo.NewUnitOfWork(ctx)
+		err := repository.SaveItemWithContext(djoemo.WithDomainEvents(uowCtx, djoemo.DomainEvent{Type: "UserRegistered"}), userKey("a"), User{UUID: "a"})
+		Expect(err).To(MatchError(djoemo.ErrInvalidDomainEvent))
+
+		pending, err := uow.Pending()
+		Expect(err).To(BeNil())
+		Expect(pending).To(Equal(0))
+		Expect(uow.Commit(uowCtx)).To(Succeed())
+		Expect(db.Items(UserTableName)).To(BeEmpty())
+	})
+})
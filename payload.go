@@ -0,0 +1,101 @@
+package djoemo
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync/atomic"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// payloadSize sums the marshalled size of the items read or written by the requests of an operation
+type payloadSize struct {
+	bytes atomic.Int64
+}
+
+type payloadSizeContextKey int
+
+const payloadSizeCtxKey payloadSizeContextKey = iota
+
+// withPayloadSize returns a context that sums the size of the items of all requests to DynamoDB executed with it
+func withPayloadSize(ctx context.Context) (context.Context, *payloadSize) {
+	size := &payloadSize{}
+	return context.WithValue(ctx, payloadSizeCtxKey, size), size
+}
+
+// recordItems measures the size of the items of all requests executed with the returned context; the returned function
+// records the number of items in items, a slice or pointer to a slice, and their size if the operation succeeded
+func recordItems(ctx context.Context, metrics *Metrics, op string, key KeyInterface, items any, err *error) (context.Context, func()) {
+	ctx, size := withPayloadSize(ctx)
+	return ctx, func() {
+		if isOpSuccess(err) {
+			metrics.RecordItems(ctx, op, key, itemCount(items), size.total())
+		}
+	}
+}
+
+// itemCount returns the length of items, a slice, pointer to a slice or Polymorphic
+func itemCount(items any) int {
+	if p, ok := items.(*Polymorphic); ok {
+		return p.Len()
+	}
+	val := reflect.ValueOf(items)
+	if val.Kind() == reflect.Ptr {
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Array && val.Kind() != reflect.Slice {
+		return 0
+	}
+	return val.Len()
+}
+
+// payloadSizeFromContext returns the payload size summed with ctx, nil if the size is not measured
+func payloadSizeFromContext(ctx context.Context) *payloadSize {
+	size, _ := ctx.Value(payloadSizeCtxKey).(*payloadSize)
+	return size
+}
+
+// add adds the size of items; it is a no-op on nil
+func (s *payloadSize) add(items ...map[string]*dynamodb.AttributeValue) {
+	if s != nil {
+		s.bytes.Add(int64(itemsSize(items)))
+	}
+}
+
+// reset drops the size summed by a failed attempt, like truncateSlice drops its items, before an operation is
+// retried; it is a no-op on nil
+func (s *payloadSize) reset() {
+	if s != nil {
+		s.bytes.Store(0)
+	}
+}
+
+func (s *payloadSize) total() int {
+	return int(s.bytes.Load())
+}
+
+func itemsSize(items []map[string]*dynamodb.AttributeValue) int {
+	size := 0
+	for _, item := range items {
+		size += expr.Size(item)
+	}
+	return size
+}
+
+// processedItems returns the items put by the requests of a batch write that are not unprocessed
+func processedItems(requests []*dynamodb.WriteRequest, unprocessed []*dynamodb.WriteRequest) []map[string]*dynamodb.AttributeValue {
+	var items []map[string]*dynamodb.AttributeValue
+	for _, request := range requests {
+		if request.PutRequest == nil {
+			continue
+		}
+		if !slices.ContainsFunc(unprocessed, func(u *dynamodb.WriteRequest) bool {
+			return u.PutRequest != nil && reflect.DeepEqual(u.PutRequest.Item, request.PutRequest.Item)
+		}) {
+			items = append(items, request.PutRequest.Item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,180 @@
+package djoemo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+)
+
+// TypeRegistry maps the values of a discriminator attribute to the types items are decoded into, so items of
+// different types stored in one table, like the profile, orders and addresses of a user, are read by one query
+type TypeRegistry struct {
+	attribute string
+	types     map[string]reflect.Type
+}
+
+// NewTypeRegistry factory method for a registry of the discriminator attribute
+func NewTypeRegistry(attribute string) *TypeRegistry {
+	return &TypeRegistry{attribute: attribute, types: make(map[string]reflect.Type)}
+}
+
+// RegisterType registers T as the type of items whose discriminator is name; several names may share a type.
+// Like gob.Register, it panics if name is registered for another type.
+func RegisterType[T any](registry *TypeRegistry, name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if registered, ok := registry.types[name]; ok && registered != t {
+		panic(fmt.Sprintf("djoemo: type %q registered for %s and %s", name, registered, t))
+	}
+	registry.types[name] = t
+}
+
+// Attribute returns the name of the discriminator attribute
+func (r *TypeRegistry) Attribute() string {
+	return r.attribute
+}
+
+// Decode decodes an item into a value of the type registered for its discriminator; it fails with
+// ErrUnknownItemType if the discriminator is missing or not registered
+func (r *TypeRegistry) Decode(item map[string]*dynamodb.AttributeValue) (any, error) {
+	value, err := r.decode(item)
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// decode returns a pointer to the decoded item
+func (r *TypeRegistry) decode(item map[string]*dynamodb.AttributeValue) (reflect.Value, error) {
+	name := ""
+	if discriminator := item[r.attribute]; discriminator != nil {
+		name = aws.StringValue(discriminator.S)
+	}
+	t, ok := r.types[name]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("%w: %s %q", ErrUnknownItemType, r.attribute, name)
+	}
+	value := reflect.New(t)
+	if err := dynamo.UnmarshalItem(item, value.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("%s %q: %w", r.attribute, name, err)
+	}
+	return value.Elem(), nil
+}
+
+// Into returns an output of QueryWithContext decoding every item into the type registered for its discriminator.
+// A pointer to []any receives all items in the order they are read; pointers to slices of a registered type, or of
+// pointers to it, receive the items of that type. Items of registered types without slice are skipped.
+//
+//	var (
+//		profiles []Profile
+//		orders   []Order
+//	)
+//	err := repository.QueryWithContext(ctx, query, registry.Into(&profiles, &orders))
+func (r *TypeRegistry) Into(outs ...any) *Polymorphic {
+	p := &Polymorphic{registry: r, typed: make(map[reflect.Type]polymorphicOut)}
+	registered := make(map[reflect.Type]bool)
+	for _, t := range r.types {
+		registered[t] = true
+	}
+	for _, out := range outs {
+		if !IsPointerOFSlice(out) {
+			p.err = fmt.Errorf("%w: %T", ErrInvalidPointerSliceType, out)
+			return p
+		}
+		slice := reflect.ValueOf(out).Elem()
+		elem := slice.Type().Elem()
+		switch {
+		case elem.Kind() == reflect.Interface && elem.NumMethod() == 0:
+			p.all = append(p.all, slice)
+		case registered[elem] && !p.has(elem):
+			p.typed[elem] = polymorphicOut{slice: slice}
+		case elem.Kind() == reflect.Ptr && registered[elem.Elem()] && !p.has(elem.Elem()):
+			p.typed[elem.Elem()] = polymorphicOut{slice: slice, pointer: true}
+		default:
+			p.err = fmt.Errorf("%w: %T is no slice of a registered type or given twice", ErrInvalidPointerSliceType, out)
+			return p
+		}
+	}
+	return p
+}
+
+// Polymorphic is an output of QueryWithContext decoding items by a TypeRegistry, see TypeRegistry.Into
+type Polymorphic struct {
+	registry *TypeRegistry
+	all      []reflect.Value
+	typed    map[reflect.Type]polymorphicOut
+	count    int
+	err      error
+}
+
+// polymorphicOut is a slice receiving the items of a type
+type polymorphicOut struct {
+	slice   reflect.Value
+	pointer bool
+}
+
+func (p *Polymorphic) has(t reflect.Type) bool {
+	_, ok := p.typed[t]
+	return ok
+}
+
+// Len returns the number of items decoded
+func (p *Polymorphic) Len() int {
+	return p.count
+}
+
+// append decodes items and appends them to the outputs; nothing is appended if an item fails to decode
+func (p *Polymorphic) append(items []map[string]*dynamodb.AttributeValue) error {
+	values := make([]reflect.Value, 0, len(items))
+	for _, item := range items {
+		value, err := p.registry.decode(item)
+		if err != nil {
+			return err
+		}
+		values = append(values, value)
+	}
+
+	for _, value := range values {
+		for _, all := range p.all {
+			all.Set(reflect.Append(all, value))
+		}
+		if out, ok := p.typed[value.Type()]; ok {
+			if out.pointer {
+				pointer := reflect.New(value.Type())
+				pointer.Elem().Set(value)
+				value = pointer
+			}
+			out.slice.Set(reflect.Append(out.slice, value))
+		}
+	}
+	p.count += len(values)
+	return nil
+}
+
+// validateOutput checks that the output of a query is a pointer to a slice or a valid Polymorphic
+func validateOutput(out any) error {
+	if p, ok := out.(*Polymorphic); ok {
+		return p.err
+	}
+	if !IsPointerOFSlice(out) {
+		return ErrInvalidPointerSliceType
+	}
+	return nil
+}
+
+// queryAll reads all items of q into out, decoding them by their discriminator if out is a Polymorphic; items of a
+// Polymorphic are appended only once all were read, so retries do not duplicate them
+func queryAll(ctx context.Context, q *dynamo.Query, out any) error {
+	p, ok := out.(*Polymorphic)
+	if !ok {
+		return q.AllWithContext(ctx, out)
+	}
+	var items []map[string]*dynamodb.AttributeValue
+	if err := q.AllWithContext(ctx, &items); err != nil {
+		return err
+	}
+	return p.append(items)
+}
@@ -0,0 +1,127 @@
+package djoemo_test
+
+import (
+	"context"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// AppProfile, AppOrder and AppAddress share the partition of a user in a single table
+type AppProfile struct {
+	PK, SK, Type string
+	Name         string
+}
+
+type AppOrder struct {
+	PK, SK, Type string
+	Total        int
+}
+
+type AppAddress struct {
+	PK, SK, Type string
+	City         string
+}
+
+var _ = Describe("Polymorphic", func() {
+	const AppTableName = "App"
+
+	var (
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+		registry   *djoemo.TypeRegistry
+		query      djoemo.QueryInterface
+	)
+
+	save := func(pk string, sk string, item any) {
+		key := djoemo.Key().WithTableName(AppTableName).
+			WithHashKeyName("PK").WithHashKey(pk).
+			WithRangeKeyName("SK").WithRangeKey(sk)
+		Expect(repository.SaveItemWithContext(ctx, key, item)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		repository = djoemo.NewRepository(fake.New().
+			WithTable(AppTableName, "PK", "SK").
+			WithGlobalIndex(AppTableName, "TypeIndex", "Type", "SK"))
+		ctx = context.Background()
+
+		registry = djoemo.NewTypeRegistry("Type")
+		djoemo.RegisterType[AppProfile](registry, "Profile")
+		djoemo.RegisterType[AppOrder](registry, "Order")
+		djoemo.RegisterType[AppAddress](registry, "Address")
+
+		save("USER#1", "PROFILE", AppProfile{PK: "USER#1", SK: "PROFILE", Type: "Profile", Name: "Ada"})
+		save("USER#1", "ORDER#1", AppOrder{PK: "USER#1", SK: "ORDER#1", Type: "Order", Total: 10})
+		save("USER#1", "ORDER#2", AppOrder{PK: "USER#1", SK: "ORDER#2", Type: "Order", Total: 20})
+		save("USER#1", "ADDRESS#home", AppAddress{PK: "USER#1", SK: "ADDRESS#home", Type: "Address", City: "Berlin"})
+		save("USER#2", "ORDER#3", AppOrder{PK: "USER#2", SK: "ORDER#3", Type: "Order", Total: 30})
+
+		query = djoemo.Query().WithTableName(AppTableName).WithHashKeyName("PK").WithHashKey("USER#1")
+	})
+
+	It("decodes items into their registered types", func() {
+		var items []any
+		Expect(repository.QueryWithContext(ctx, query, registry.Into(&items))).To(Succeed())
+
+		Expect(items).To(Equal([]any{
+			AppAddress{PK: "USER#1", SK: "ADDRESS#home", Type: "Address", City: "Berlin"},
+			AppOrder{PK: "USER#1", SK: "ORDER#1", Type: "Order", Total: 10},
+			AppOrder{PK: "USER#1", SK: "ORDER#2", Type: "Order", Total: 20},
+			AppProfile{PK: "USER#1", SK: "PROFILE", Type: "Profile", Name: "Ada"},
+		}))
+	})
+
+	It("fills a slice per type and skips types without slice", func() {
+		var (
+			profiles []AppProfile
+			orders   []*AppOrder
+		)
+		output := registry.Into(&profiles, &orders)
+		Expect(repository.QueryWithContext(ctx, query, output)).To(Succeed())
+
+		Expect(output.Len()).To(Equal(4))
+		Expect(profiles).To(Equal([]AppProfile{{PK: "USER#1", SK: "PROFILE", Type: "Profile", Name: "Ada"}}))
+		Expect(orders).To(HaveLen(2))
+		Expect(orders[0].Total).To(Equal(10))
+		Expect(orders[1].Total).To(Equal(20))
+	})
+
+	It("decodes the items of a global index", func() {
+		var orders []AppOrder
+		indexQuery := djoemo.Query().WithTableName(AppTableName).WithHashKeyName("Type").WithHashKey("Order")
+		Expect(repository.GIndex("TypeIndex").QueryWithContext(ctx, indexQuery, registry.Into(&orders))).To(Succeed())
+
+		Expect(orders).To(HaveLen(3))
+	})
+
+	It("fails for items of unknown types without filling outputs", func() {
+		save("USER#1", "PAYMENT#1", map[string]any{"PK": "USER#1", "SK": "PAYMENT#1", "Type": "Payment"})
+
+		var items []any
+		err := repository.QueryWithContext(ctx, query, registry.Into(&items))
+		Expect(err).To(MatchError(djoemo.ErrUnknownItemType))
+		Expect(items).To(BeEmpty())
+
+		_, err = registry.Decode(nil)
+		Expect(err).To(MatchError(djoemo.ErrUnknownItemType))
+	})
+
+	It("rejects outputs that are no slices of registered types", func() {
+		var users []User
+		err := repository.QueryWithContext(ctx, query, registry.Into(&users))
+		Expect(err).To(MatchError(djoemo.ErrInvalidPointerSliceType))
+
+		var orders []AppOrder
+		err = repository.QueryWithContext(ctx, query, registry.Into(orders))
+		Expect(err).To(MatchError(djoemo.ErrInvalidPointerSliceType))
+	})
+
+	It("panics if a name is registered for another type", func() {
+		djoemo.RegisterType[AppOrder](registry, "Order")
+		Expect(func() { djoemo.RegisterType[AppProfile](registry, "Order") }).To(Panic())
+	})
+})
@@ -51,7 +51,7 @@ func (q *query) WithHashKey(hashKey interface{}) *query {
 	return q
 }
 
-// WithRangeKey set djoemo key range key value
+// WithRangeKey set djoemo key range key value; the range key of Between is a slice or array of the lower and upper bound
 func (q *query) WithRangeKey(rangeKey interface{}) *query {
 	q.rangeKey = rangeKey
 	return q
@@ -0,0 +1,176 @@
+package djoemo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit limits the capacity units per second used on a table or index; zero values are unlimited
+type RateLimit struct {
+	// ReadUnits is the number of read capacity units per second
+	ReadUnits float64
+	// WriteUnits is the number of write capacity units per second
+	WriteUnits float64
+	// Burst is the number of seconds of capacity that can be used at once; defaults to one second
+	Burst float64
+}
+
+// RateLimiter holds client-side token buckets for read and write units per table and per index.
+// Repository operations wait on the buckets of the table or index before every request to DynamoDB,
+// which lets background jobs share provisioned capacity with online traffic
+type RateLimiter struct {
+	sync.Mutex
+	buckets map[string]*capacityBuckets
+}
+
+type capacityBuckets struct {
+	read  *tokenBucket
+	write *tokenBucket
+}
+
+// NewRateLimiter factory method for a rate limiter without limits
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*capacityBuckets),
+	}
+}
+
+// WithTableLimit limits the capacity units used on a table
+func (l *RateLimiter) WithTableLimit(tableName string, limit RateLimit) *RateLimiter {
+	return l.withLimit(limiterName(tableName, ""), limit)
+}
+
+// WithIndexLimit limits the capacity units used on a global secondary index of a table
+func (l *RateLimiter) WithIndexLimit(tableName string, indexName string, limit RateLimit) *RateLimiter {
+	return l.withLimit(limiterName(tableName, indexName), limit)
+}
+
+func (l *RateLimiter) withLimit(name string, limit RateLimit) *RateLimiter {
+	l.Lock()
+	defer l.Unlock()
+
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	l.buckets[name] = &capacityBuckets{
+		read:  newTokenBucket(limit.ReadUnits, burst),
+		write: newTokenBucket(limit.WriteUnits, burst),
+	}
+	return l
+}
+
+// Wait blocks until the requested units are available on the table or index, or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, tableName string, indexName string, readUnits float64, writeUnits float64) error {
+	buckets := l.limits(tableName, indexName)
+	if buckets == nil {
+		return nil
+	}
+
+	readDelay := buckets.read.reserve(readUnits)
+	writeDelay := buckets.write.reserve(writeUnits)
+	delay := max(readDelay, writeDelay)
+	if delay <= 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		buckets.read.refund(readUnits)
+		buckets.write.refund(writeUnits)
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		buckets.read.refund(readUnits)
+		buckets.write.refund(writeUnits)
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Consume takes units used in addition to the ones waited for from the buckets of the table or index, without waiting;
+// following operations wait until the debt is paid off. Negative units return overestimated units to the buckets
+func (l *RateLimiter) Consume(tableName string, indexName string, readUnits float64, writeUnits float64) {
+	buckets := l.limits(tableName, indexName)
+	if buckets == nil {
+		return
+	}
+
+	buckets.read.consume(readUnits)
+	buckets.write.consume(writeUnits)
+}
+
+func (l *RateLimiter) limits(tableName string, indexName string) *capacityBuckets {
+	l.Lock()
+	defer l.Unlock()
+	return l.buckets[limiterName(tableName, indexName)]
+}
+
+func limiterName(tableName string, indexName string) string {
+	if indexName == "" {
+		return tableName
+	}
+	return tableName + "/" + indexName
+}
+
+// tokenBucket refills rate tokens per second up to burst seconds of capacity; tokens can be reserved ahead,
+// leaving the bucket in debt until it refilled
+type tokenBucket struct {
+	sync.Mutex
+	rate   float64
+	size   float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst float64) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		size:   rate * burst,
+		tokens: rate * burst,
+		last:   time.Now(),
+	}
+}
+
+// reserve takes n tokens and returns the time to wait until they are covered
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	if b.rate <= 0 || n <= 0 {
+		return 0
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	b.tokens = min(b.size, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) consume(n float64) {
+	if n < 0 {
+		b.refund(-n)
+		return
+	}
+	b.reserve(n)
+}
+
+func (b *tokenBucket) refund(n float64) {
+	if b.rate <= 0 || n <= 0 {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+	b.tokens = min(b.size, b.tokens+n)
+}
@@ -0,0 +1,132 @@
+package djoemo_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RateLimiter", func() {
+	const (
+		UserTableName = "UserTable"
+		IndexName     = "email-index"
+	)
+
+	var (
+		dAPIMock   *mock.MockDynamoDBAPI
+		repository djoemo.RepositoryInterface
+		limiter    *djoemo.RateLimiter
+		key        djoemo.KeyInterface
+	)
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+
+		limiter = djoemo.NewRateLimiter()
+		repository.WithRateLimiter(limiter)
+
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+	})
+
+	Describe("Repository", func() {
+		It("should not call DynamoDB once the write units are used up", func() {
+			limiter.WithTableLimit(UserTableName, djoemo.RateLimit{WriteUnits: 1})
+			dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil).Times(1)
+
+			Expect(repository.SaveItemWithContext(context.Background(), key, &User{UUID: "uuid"})).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(repository.SaveItemWithContext(ctx, key, &User{UUID: "uuid"})).To(Equal(context.DeadlineExceeded))
+		})
+
+		It("should not limit reads by the write units", func() {
+			limiter.WithTableLimit(UserTableName, djoemo.RateLimit{WriteUnits: 1})
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil).Times(3)
+
+			for i := 0; i < 3; i++ {
+				_, err := repository.GetItemWithContext(context.Background(), key, &User{})
+				Expect(err).To(BeNil())
+			}
+		})
+
+		It("should limit queries on an index separately from the table", func() {
+			limiter.WithIndexLimit(UserTableName, IndexName, djoemo.RateLimit{ReadUnits: 0.5})
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ aws.Context, input *dynamodb.QueryInput, _ ...interface{}) (*dynamodb.QueryOutput, error) {
+					Expect(aws.StringValue(input.IndexName)).To(Equal(IndexName))
+					return &dynamodb.QueryOutput{}, nil
+				}).Times(1)
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{}, nil).Times(1)
+
+			query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("email")
+			index := repository.GIndex(IndexName)
+			Expect(index.QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			Expect(index.QueryWithContext(ctx, query, &[]User{})).To(Equal(context.DeadlineExceeded))
+
+			_, err := repository.GetItemWithContext(ctx, key, &User{})
+			Expect(err).To(BeNil())
+		})
+
+		It("should refund the units of other tables if waiting for a table of a transaction fails", func() {
+			const OrderTableName = "OrderTable"
+			limiter.WithTableLimit(UserTableName, djoemo.RateLimit{WriteUnits: 2})
+			limiter.WithTableLimit(OrderTableName, djoemo.RateLimit{WriteUnits: 1})
+			Expect(limiter.Wait(context.Background(), OrderTableName, "", 0, 1)).To(BeNil())
+			dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil).Times(1)
+
+			orderKey := djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("UUID").WithHashKey("order")
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			// the tables are waited for in random order, so commit a few times to reserve the user table first
+			for i := 0; i < 5; i++ {
+				uowCtx, uow := djoemo.NewUnitOfWork(ctx)
+				Expect(repository.SaveItemWithContext(uowCtx, key, &User{UUID: "uuid"})).To(Succeed())
+				Expect(repository.SaveItemWithContext(uowCtx, orderKey, &User{UUID: "order"})).To(Succeed())
+				Expect(uow.Commit(uowCtx)).To(MatchError(context.DeadlineExceeded))
+			}
+
+			Expect(repository.SaveItemWithContext(ctx, key, &User{UUID: "uuid"})).To(BeNil())
+		})
+	})
+
+	Describe("Wait", func() {
+		It("should wait until the bucket is refilled", func() {
+			limiter.WithTableLimit(UserTableName, djoemo.RateLimit{ReadUnits: 1000})
+			Expect(limiter.Wait(context.Background(), UserTableName, "", 1000, 0)).To(BeNil())
+
+			start := time.Now()
+			Expect(limiter.Wait(context.Background(), UserTableName, "", 50, 0)).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically(">=", 40*time.Millisecond))
+		})
+
+		It("should return when the context is cancelled", func() {
+			limiter.WithTableLimit(UserTableName, djoemo.RateLimit{ReadUnits: 1})
+			Expect(limiter.Wait(context.Background(), UserTableName, "", 1, 0)).To(BeNil())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			Expect(limiter.Wait(ctx, UserTableName, "", 1, 0)).To(Equal(context.Canceled))
+		})
+
+		It("should not wait for tables without limits", func() {
+			Expect(limiter.Wait(context.Background(), "OtherTable", "", 1000, 1000)).To(BeNil())
+		})
+	})
+})
@@ -26,3 +26,22 @@ func IsPointerOFSlice(item interface{}) bool {
 	s := reflect.ValueOf(item)
 	return s.Kind() == reflect.Ptr && s.Elem().Kind() == reflect.Slice
 }
+
+// sliceLen returns the length of the slice out points to, or 0 if out is no pointer of slice
+func sliceLen(out interface{}) int {
+	if !IsPointerOFSlice(out) {
+		return 0
+	}
+	return reflect.ValueOf(out).Elem().Len()
+}
+
+// truncateSlice shrinks the slice out points to back to length n; it drops partial results before an operation is retried
+func truncateSlice(out interface{}, n int) {
+	if !IsPointerOFSlice(out) {
+		return
+	}
+	s := reflect.ValueOf(out).Elem()
+	if s.Len() > n {
+		s.SetLen(n)
+	}
+}
@@ -0,0 +1,225 @@
+package replay
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+)
+
+// BatchGetItem records or replays the request
+func (c *Client) BatchGetItem(input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+	return c.BatchGetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchGetItemWithContext records or replays the request
+func (c *Client) BatchGetItemWithContext(ctx aws.Context, input *dynamodb.BatchGetItemInput, opts ...request.Option) (*dynamodb.BatchGetItemOutput, error) {
+	return call(c, "BatchGetItem", input, func() (*dynamodb.BatchGetItemOutput, error) {
+		return c.DynamoDBAPI.BatchGetItemWithContext(ctx, input, opts...)
+	})
+}
+
+// BatchWriteItem records or replays the request
+func (c *Client) BatchWriteItem(input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+	return c.BatchWriteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// BatchWriteItemWithContext records or replays the request
+func (c *Client) BatchWriteItemWithContext(ctx aws.Context, input *dynamodb.BatchWriteItemInput, opts ...request.Option) (*dynamodb.BatchWriteItemOutput, error) {
+	return call(c, "BatchWriteItem", input, func() (*dynamodb.BatchWriteItemOutput, error) {
+		return c.DynamoDBAPI.BatchWriteItemWithContext(ctx, input, opts...)
+	})
+}
+
+// CreateTable records or replays the request
+func (c *Client) CreateTable(input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+	return c.CreateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateTableWithContext records or replays the request
+func (c *Client) CreateTableWithContext(ctx aws.Context, input *dynamodb.CreateTableInput, opts ...request.Option) (*dynamodb.CreateTableOutput, error) {
+	return call(c, "CreateTable", input, func() (*dynamodb.CreateTableOutput, error) {
+		return c.DynamoDBAPI.CreateTableWithContext(ctx, input, opts...)
+	})
+}
+
+// DeleteItem records or replays the request
+func (c *Client) DeleteItem(input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+	return c.DeleteItemWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteItemWithContext records or replays the request
+func (c *Client) DeleteItemWithContext(ctx aws.Context, input *dynamodb.DeleteItemInput, opts ...request.Option) (*dynamodb.DeleteItemOutput, error) {
+	return call(c, "DeleteItem", input, func() (*dynamodb.DeleteItemOutput, error) {
+		return c.DynamoDBAPI.DeleteItemWithContext(ctx, input, opts...)
+	})
+}
+
+// DeleteTable records or replays the request
+func (c *Client) DeleteTable(input *dynamodb.DeleteTableInput) (*dynamodb.DeleteTableOutput, error) {
+	return c.DeleteTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteTableWithContext records or replays the request
+func (c *Client) DeleteTableWithContext(ctx aws.Context, input *dynamodb.DeleteTableInput, opts ...request.Option) (*dynamodb.DeleteTableOutput, error) {
+	return call(c, "DeleteTable", input, func() (*dynamodb.DeleteTableOutput, error) {
+		return c.DynamoDBAPI.DeleteTableWithContext(ctx, input, opts...)
+	})
+}
+
+// DescribeTable records or replays the request
+func (c *Client) DescribeTable(input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+	return c.DescribeTableWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeTableWithContext records or replays the request
+func (c *Client) DescribeTableWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	return call(c, "DescribeTable", input, func() (*dynamodb.DescribeTableOutput, error) {
+		return c.DynamoDBAPI.DescribeTableWithContext(ctx, input, opts...)
+	})
+}
+
+// DescribeTimeToLive records or replays the request
+func (c *Client) DescribeTimeToLive(input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return c.DescribeTimeToLiveWithContext(aws.BackgroundContext(), input)
+}
+
+// DescribeTimeToLiveWithContext records or replays the request
+func (c *Client) DescribeTimeToLiveWithContext(ctx aws.Context, input *dynamodb.DescribeTimeToLiveInput, opts ...request.Option) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return call(c, "DescribeTimeToLive", input, func() (*dynamodb.DescribeTimeToLiveOutput, error) {
+		return c.DynamoDBAPI.DescribeTimeToLiveWithContext(ctx, input, opts...)
+	})
+}
+
+// GetItem records or replays the request
+func (c *Client) GetItem(input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+	return c.GetItemWithContext(aws.BackgroundContext(), input)
+}
+
+// GetItemWithContext records or replays the request
+func (c *Client) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+	return call(c, "GetItem", input, func() (*dynamodb.GetItemOutput, error) {
+		return c.DynamoDBAPI.GetItemWithContext(ctx, input, opts...)
+	})
+}
+
+// PutItem records or replays the request
+func (c *Client) PutItem(input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+	return c.PutItemWithContext(aws.BackgroundContext(), input)
+}
+
+// PutItemWithContext records or replays the request
+func (c *Client) PutItemWithContext(ctx aws.Context, input *dynamodb.PutItemInput, opts ...request.Option) (*dynamodb.PutItemOutput, error) {
+	return call(c, "PutItem", input, func() (*dynamodb.PutItemOutput, error) {
+		return c.DynamoDBAPI.PutItemWithContext(ctx, input, opts...)
+	})
+}
+
+// Query records or replays the request
+func (c *Client) Query(input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+	return c.QueryWithContext(aws.BackgroundContext(), input)
+}
+
+// QueryWithContext records or replays the request
+func (c *Client) QueryWithContext(ctx aws.Context, input *dynamodb.QueryInput, opts ...request.Option) (*dynamodb.QueryOutput, error) {
+	return call(c, "Query", input, func() (*dynamodb.QueryOutput, error) {
+		return c.DynamoDBAPI.QueryWithContext(ctx, input, opts...)
+	})
+}
+
+// Scan records or replays the request
+func (c *Client) Scan(input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return c.ScanWithContext(aws.BackgroundContext(), input)
+}
+
+// ScanWithContext records or replays the request
+func (c *Client) ScanWithContext(ctx aws.Context, input *dynamodb.ScanInput, opts ...request.Option) (*dynamodb.ScanOutput, error) {
+	return call(c, "Scan", input, func() (*dynamodb.ScanOutput, error) {
+		return c.DynamoDBAPI.ScanWithContext(ctx, input, opts...)
+	})
+}
+
+// TransactGetItems records or replays the request
+func (c *Client) TransactGetItems(input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+	return c.TransactGetItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactGetItemsWithContext records or replays the request
+func (c *Client) TransactGetItemsWithContext(ctx aws.Context, input *dynamodb.TransactGetItemsInput, opts ...request.Option) (*dynamodb.TransactGetItemsOutput, error) {
+	return call(c, "TransactGetItems", input, func() (*dynamodb.TransactGetItemsOutput, error) {
+		return c.DynamoDBAPI.TransactGetItemsWithContext(ctx, input, opts...)
+	})
+}
+
+// TransactWriteItems records or replays the request
+func (c *Client) TransactWriteItems(input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+	return c.TransactWriteItemsWithContext(aws.BackgroundContext(), input)
+}
+
+// TransactWriteItemsWithContext records or replays the request
+func (c *Client) TransactWriteItemsWithContext(ctx aws.Context, input *dynamodb.TransactWriteItemsInput, opts ...request.Option) (*dynamodb.TransactWriteItemsOutput, error) {
+	return call(c, "TransactWriteItems", input, func() (*dynamodb.TransactWriteItemsOutput, error) {
+		return c.DynamoDBAPI.TransactWriteItemsWithContext(ctx, input, opts...)
+	})
+}
+
+// UpdateItem records or replays the request
+func (c *Client) UpdateItem(input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+	return c.UpdateItemWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateItemWithContext records or replays the request
+func (c *Client) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	return call(c, "UpdateItem", input, func() (*dynamodb.UpdateItemOutput, error) {
+		return c.DynamoDBAPI.UpdateItemWithContext(ctx, input, opts...)
+	})
+}
+
+// UpdateTable records or replays the request
+func (c *Client) UpdateTable(input *dynamodb.UpdateTableInput) (*dynamodb.UpdateTableOutput, error) {
+	return c.UpdateTableWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTableWithContext records or replays the request
+func (c *Client) UpdateTableWithContext(ctx aws.Context, input *dynamodb.UpdateTableInput, opts ...request.Option) (*dynamodb.UpdateTableOutput, error) {
+	return call(c, "UpdateTable", input, func() (*dynamodb.UpdateTableOutput, error) {
+		return c.DynamoDBAPI.UpdateTableWithContext(ctx, input, opts...)
+	})
+}
+
+// UpdateTimeToLive records or replays the request
+func (c *Client) UpdateTimeToLive(input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return c.UpdateTimeToLiveWithContext(aws.BackgroundContext(), input)
+}
+
+// UpdateTimeToLiveWithContext records or replays the request
+func (c *Client) UpdateTimeToLiveWithContext(ctx aws.Context, input *dynamodb.UpdateTimeToLiveInput, opts ...request.Option) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return call(c, "UpdateTimeToLive", input, func() (*dynamodb.UpdateTimeToLiveOutput, error) {
+		return c.DynamoDBAPI.UpdateTimeToLiveWithContext(ctx, input, opts...)
+	})
+}
+
+// WaitUntilTableExists records or replays the result of the waiter
+func (c *Client) WaitUntilTableExists(input *dynamodb.DescribeTableInput) error {
+	return c.WaitUntilTableExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableExistsWithContext records or replays the result of the waiter
+func (c *Client) WaitUntilTableExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	_, err := call(c, "WaitUntilTableExists", input, func() (*struct{}, error) {
+		return nil, c.DynamoDBAPI.WaitUntilTableExistsWithContext(ctx, input, opts...)
+	})
+	return err
+}
+
+// WaitUntilTableNotExists records or replays the result of the waiter
+func (c *Client) WaitUntilTableNotExists(input *dynamodb.DescribeTableInput) error {
+	return c.WaitUntilTableNotExistsWithContext(aws.BackgroundContext(), input)
+}
+
+// WaitUntilTableNotExistsWithContext records or replays the result of the waiter
+func (c *Client) WaitUntilTableNotExistsWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.WaiterOption) error {
+	_, err := call(c, "WaitUntilTableNotExists", input, func() (*struct{}, error) {
+		return nil, c.DynamoDBAPI.WaitUntilTableNotExistsWithContext(ctx, input, opts...)
+	})
+	return err
+}
@@ -0,0 +1,210 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// conditionFields are the fields of requests holding condition expressions
+var conditionFields = []string{"KeyConditionExpression", "FilterExpression", "ConditionExpression"}
+
+// normalize returns the request as compact JSON, with the placeholders of its expressions resolved; conditions and
+// update actions are sorted, so requests only differing in the naming of placeholders or in the order of
+// conditions joined by AND or of update actions are equal; ignored attributes are removed from put items
+func normalize(input any, ignored []string) (json.RawMessage, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	copied := reflect.New(reflect.TypeOf(input).Elem())
+	if err := json.Unmarshal(data, copied.Interface()); err != nil {
+		return nil, err
+	}
+	resolve(copied, ignored)
+	return encode(copied.Interface())
+}
+
+// encode returns v as compact JSON without null values and without escaping HTML, to keep golden files readable
+func encode(v any) (json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(withoutNulls(value)); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSpace(b.Bytes()), nil
+}
+
+func withoutNulls(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, element := range value {
+			if element == nil {
+				delete(value, key)
+				continue
+			}
+			value[key] = withoutNulls(element)
+		}
+	case []any:
+		for i, element := range value {
+			value[i] = withoutNulls(element)
+		}
+	}
+	return value
+}
+
+// resolve resolves the expressions of all requests in v, like the items of transactions
+func resolve(v reflect.Value, ignored []string) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			resolve(v.Elem(), ignored)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			resolve(v.Index(i), ignored)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() == reflect.Ptr || v.Type().Elem().Kind() == reflect.Slice {
+			iterator := v.MapRange()
+			for iterator.Next() {
+				resolve(iterator.Value(), ignored)
+			}
+		}
+	case reflect.Struct:
+		if _, ok := v.Type().FieldByName("ExpressionAttributeValues"); ok {
+			resolveExpressions(v)
+		}
+		if field := v.FieldByName("Item"); field.IsValid() {
+			item, _ := field.Interface().(map[string]*dynamodb.AttributeValue)
+			for _, name := range ignored {
+				delete(item, name)
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				resolve(v.Field(i), ignored)
+			}
+		}
+	}
+}
+
+// resolveExpressions replaces the expressions of a request by their resolved form and drops the placeholders;
+// requests with expressions that can not be parsed are kept as they are
+func resolveExpressions(request reflect.Value) {
+	names, _ := request.FieldByName("ExpressionAttributeNames").Interface().(map[string]*string)
+	values, _ := request.FieldByName("ExpressionAttributeValues").Interface().(map[string]*dynamodb.AttributeValue)
+	scope := expr.NewScope(names, values)
+
+	resolved := make(map[string]string)
+	for _, field := range conditionFields {
+		expression := stringField(request, field)
+		if expression == nil {
+			continue
+		}
+		condition, err := scope.Condition(*expression)
+		if err != nil {
+			return
+		}
+		resolved[field] = expr.Normalize(condition).String()
+	}
+	if expression := stringField(request, "UpdateExpression"); expression != nil {
+		update, err := scope.Update(*expression)
+		if err != nil {
+			return
+		}
+		update.Sort()
+		resolved["UpdateExpression"] = update.String()
+	}
+	if expression := stringField(request, "ProjectionExpression"); expression != nil {
+		projection, err := scope.Projection(*expression)
+		if err != nil {
+			return
+		}
+		resolved["ProjectionExpression"] = expr.FormatProjection(projection)
+	}
+
+	for field, expression := range resolved {
+		expression := expression
+		request.FieldByName(field).Set(reflect.ValueOf(&expression))
+	}
+	for _, field := range []string{"ExpressionAttributeNames", "ExpressionAttributeValues"} {
+		if value := request.FieldByName(field); value.IsValid() {
+			value.Set(reflect.Zero(value.Type()))
+		}
+	}
+}
+
+func stringField(request reflect.Value, name string) *string {
+	field := request.FieldByName(name)
+	if !field.IsValid() {
+		return nil
+	}
+	value, _ := field.Interface().(*string)
+	return value
+}
+
+// indent formats JSON for golden files and diffs
+func indent(data json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Indent(&b, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return b.String()
+}
+
+// diff returns the lines of recorded and actual; lines only in recorded are prefixed by -, lines only in actual by +
+func diff(recorded string, actual string) string {
+	a := strings.Split(recorded, "\n")
+	b := strings.Split(actual, "\n")
+
+	// lengths of the longest common subsequences of the suffixes of a and b
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
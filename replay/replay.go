@@ -0,0 +1,242 @@
+// Package replay records the DynamoDB traffic of tests to golden files and serves it back, so tests of code built on
+// a Repository can run fast and hermetic after being captured once against DynamoDB or DynamoDB Local:
+//
+//	client := replay.New(t, "testdata/save_user.json", replay.ModeFromEnv(), dynamoDBLocal)
+//	repository := djoemo.NewRepository(client)
+//
+// Run the tests with DJOEMO_RECORD=1 to record the golden files, the client then is only used while recording.
+// Requests are compared after resolving their expression placeholders, a changed request fails the test with a
+// diff of the recorded and the actual request.
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// ErrCodeReplayMismatch is the error code of requests that differ from the recorded ones
+const ErrCodeReplayMismatch = "ReplayMismatch"
+
+// RecordEnv is the environment variable which switches ModeFromEnv to Record
+const RecordEnv = "DJOEMO_RECORD"
+
+// Mode decides if a Client records or replays interactions
+type Mode int
+
+const (
+	// Replay serves the responses of the golden file
+	Replay Mode = iota
+	// Record sends requests to DynamoDB and writes the golden file when the test ends
+	Record
+)
+
+// ModeFromEnv returns Record if RecordEnv is set and Replay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) != "" {
+		return Record
+	}
+	return Replay
+}
+
+// TestingT is the part of testing.T the client uses; GinkgoT() implements it as well
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// Interaction is a recorded request with its response or error
+type Interaction struct {
+	Operation string          `json:"operation"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Error     *Error          `json:"error,omitempty"`
+}
+
+// Error is a recorded awserr.Error
+type Error struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	RequestID  string `json:"requestId,omitempty"`
+}
+
+// Client implements dynamodbiface.DynamoDBAPI by recording or replaying item, query, scan, batch, transaction and
+// table requests; it is safe for concurrent use, but requests are replayed in the recorded order
+type Client struct {
+	// DynamoDBAPI is the recorded client, calls of methods the client does not record are passed to it
+	dynamodbiface.DynamoDBAPI
+
+	t       TestingT
+	path    string
+	mode    Mode
+	ignored []string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// New creates a client for the golden file at path; in Record mode it sends the requests to client, in Replay mode
+// client may be nil. The golden file is written, or checked for interactions that were not replayed, on cleanup of t.
+func New(t TestingT, path string, mode Mode, client dynamodbiface.DynamoDBAPI) *Client {
+	t.Helper()
+	c := &Client{DynamoDBAPI: client, t: t, path: path, mode: mode}
+	switch mode {
+	case Record:
+		if client == nil {
+			t.Fatalf("replay: recording %s needs a client", path)
+		}
+	case Replay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("replay: can not read golden file, record it with %s=1: %v", RecordEnv, err)
+		}
+		if err := json.Unmarshal(data, &c.interactions); err != nil {
+			t.Fatalf("replay: invalid golden file %s: %v", path, err)
+		}
+	}
+	t.Cleanup(func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("replay: %v", err)
+		}
+	})
+	return c
+}
+
+// Close writes the golden file in Record mode; in Replay mode it fails if recorded interactions were not replayed
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode == Replay {
+		if remaining := len(c.interactions) - c.next; remaining > 0 {
+			return fmt.Errorf("%d of %d recorded interactions of %s were not replayed, next is %s",
+				remaining, len(c.interactions), c.path, c.interactions[c.next].Operation)
+		}
+		return nil
+	}
+
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c.interactions); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, b.Bytes(), 0o644)
+}
+
+// IgnoreAttributes removes the attributes from the items of put requests before they are recorded or compared,
+// like timestamps which change on every run
+func (c *Client) IgnoreAttributes(names ...string) *Client {
+	c.ignored = append(c.ignored, names...)
+	return c
+}
+
+// Interactions returns the interactions recorded or loaded so far
+func (c *Client) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// call records or replays one request; send calls the recorded client
+func call[I any, O any](c *Client, operation string, input *I, send func() (*O, error)) (*O, error) {
+	request, err := normalize(input, c.ignored)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode == Record {
+		output, err := send()
+		interaction := Interaction{Operation: operation, Request: request, Error: recordError(err)}
+		if err == nil && output != nil {
+			if interaction.Response, err = encode(output); err != nil {
+				return nil, err
+			}
+		}
+		c.mu.Lock()
+		c.interactions = append(c.interactions, interaction)
+		c.mu.Unlock()
+		return output, err
+	}
+
+	interaction, err := c.replay(operation, request)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != nil {
+		return nil, interaction.Error.err()
+	}
+	output := new(O)
+	if len(interaction.Response) > 0 {
+		if err := json.Unmarshal(interaction.Response, output); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// replay returns the next interaction if it has the operation and request; otherwise the test fails with a diff
+func (c *Client) replay(operation string, request json.RawMessage) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t.Helper()
+
+	if c.next >= len(c.interactions) {
+		c.t.Errorf("replay: unexpected %s request after all %d interactions of %s were replayed:\n%s",
+			operation, len(c.interactions), c.path, indent(request))
+		return Interaction{}, awserr.New(ErrCodeReplayMismatch, "unexpected "+operation+" request", nil)
+	}
+
+	interaction := c.interactions[c.next]
+	recorded := indent(interaction.Request)
+	actual := indent(request)
+	if interaction.Operation != operation || recorded != actual {
+		c.t.Errorf("replay: request %d of %s differs from the recording (-recorded +actual):\n%s",
+			c.next+1, c.path, diff(interaction.Operation+" "+recorded, operation+" "+actual))
+		return Interaction{}, awserr.New(ErrCodeReplayMismatch, operation+" request differs from the recording", nil)
+	}
+	c.next++
+	return interaction, nil
+}
+
+func recordError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	recorded := &Error{Message: err.Error()}
+	if awsErr, ok := err.(awserr.Error); ok {
+		recorded.Code = awsErr.Code()
+		recorded.Message = awsErr.Message()
+	}
+	if failure, ok := err.(awserr.RequestFailure); ok {
+		recorded.StatusCode = failure.StatusCode()
+		recorded.RequestID = failure.RequestID()
+	}
+	return recorded
+}
+
+func (e *Error) err() error {
+	if e.Code == "" {
+		return errors.New(e.Message)
+	}
+	err := awserr.New(e.Code, e.Message, nil)
+	if e.StatusCode != 0 {
+		return awserr.NewRequestFailure(err, e.StatusCode, e.RequestID)
+	}
+	return err
+}
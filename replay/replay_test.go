@@ -0,0 +1,164 @@
+package replay_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/replay"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	djoemo.Model
+	UUID     string
+	UserName string
+}
+
+// testingT collects the errors of a client and runs its cleanups on demand
+type testingT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *testingT) Helper() {}
+
+func (t *testingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *testingT) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	panic(t.errors[len(t.errors)-1])
+}
+
+func (t *testingT) Cleanup(cleanup func()) {
+	t.cleanups = append(t.cleanups, cleanup)
+}
+
+func (t *testingT) cleanup() {
+	for _, cleanup := range t.cleanups {
+		cleanup()
+	}
+}
+
+var _ = Describe("Client", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		path string
+		ctx  context.Context
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "testdata", "users.json")
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	// run uses a repository like a test would
+	run := func(client *replay.Client) (User, bool) {
+		repository := djoemo.NewRepository(client)
+		Expect(repository.OptimisticLockSaveWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "name"})).To(BeTrue())
+		Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]interface{}{"UserName": "new", "Visits": 1})).To(Succeed())
+
+		user := User{}
+		found, err := repository.GetItemWithContext(ctx, userKey("uuid"), &user)
+		Expect(found).To(BeTrue())
+		Expect(err).To(BeNil())
+		updated, err := repository.ConditionalUpdateWithContext(ctx, userKey("uuid"), &User{UUID: "uuid"}, "UserName = ?", "other")
+		Expect(err).To(BeNil())
+		return user, updated
+	}
+
+	record := func() {
+		t := &testingT{}
+		db := fake.New().WithTable(UserTableName, "UUID", "")
+		_, updated := run(replay.New(t, path, replay.Record, db).IgnoreAttributes("CreatedAt", "UpdatedAt"))
+		Expect(updated).To(BeFalse())
+		t.cleanup()
+		Expect(t.errors).To(BeEmpty())
+	}
+
+	It("should replay recorded responses and errors", func() {
+		record()
+		Expect(path).To(BeAnExistingFile())
+
+		t := &testingT{}
+		client := replay.New(t, path, replay.Replay, nil).IgnoreAttributes("CreatedAt", "UpdatedAt")
+		user, updated := run(client)
+		Expect(user.UserName).To(Equal("new"))
+		Expect(updated).To(BeFalse())
+		Expect(client.Interactions()).To(HaveLen(4))
+		Expect(client.Interactions()[3].Error.Code).To(Equal(dynamodb.ErrCodeConditionalCheckFailedException))
+
+		t.cleanup()
+		Expect(t.errors).To(BeEmpty())
+	})
+
+	It("should compare requests with resolved placeholders", func() {
+		t := &testingT{}
+		client := replay.New(t, path, replay.Record, fake.New().WithTable(UserTableName, "UUID", ""))
+		_, err := client.PutItem(&dynamodb.PutItemInput{
+			TableName:                 aws.String(UserTableName),
+			Item:                      map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("uuid")}},
+			ConditionExpression:       aws.String("attribute_not_exists(#a) AND #b <> :a"),
+			ExpressionAttributeNames:  map[string]*string{"#a": aws.String("UUID"), "#b": aws.String("Status")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":a": {S: aws.String("deleted")}},
+		})
+		Expect(err).To(BeNil())
+		t.cleanup()
+
+		client = replay.New(t, path, replay.Replay, nil)
+		_, err = client.PutItem(&dynamodb.PutItemInput{
+			TableName:                 aws.String(UserTableName),
+			Item:                      map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("uuid")}},
+			ConditionExpression:       aws.String("#s <> :deleted AND attribute_not_exists(UUID)"),
+			ExpressionAttributeNames:  map[string]*string{"#s": aws.String("Status")},
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":deleted": {S: aws.String("deleted")}},
+		})
+		Expect(err).To(BeNil())
+		t.cleanup()
+		Expect(t.errors).To(BeEmpty())
+
+		data, err := os.ReadFile(path)
+		Expect(err).To(BeNil())
+		Expect(string(data)).To(ContainSubstring(`"ConditionExpression": "Status <> \"deleted\" AND attribute_not_exists(UUID)"`))
+	})
+
+	It("should fail with a diff if a request changed", func() {
+		record()
+
+		t := &testingT{}
+		repository := djoemo.NewRepository(replay.New(t, path, replay.Replay, nil))
+		err := repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "changed"})
+		Expect(err.(awserr.Error).Code()).To(Equal(replay.ErrCodeReplayMismatch))
+		Expect(t.errors).To(HaveLen(1))
+		Expect(t.errors[0]).To(ContainSubstring("request 1 of " + path + " differs from the recording"))
+		Expect(t.errors[0]).To(ContainSubstring("\n  PutItem {\n"))
+		Expect(t.errors[0]).To(ContainSubstring("\n-       \"S\": \"name\"\n+       \"S\": \"changed\"\n"))
+
+		t.cleanup()
+		Expect(t.errors).To(HaveLen(2))
+		Expect(t.errors[1]).To(ContainSubstring("4 of 4 recorded interactions"))
+	})
+
+	It("should fail without golden file", func() {
+		t := &testingT{}
+		Expect(func() { replay.New(t, path, replay.Replay, nil) }).To(Panic())
+		Expect(t.errors[0]).To(ContainSubstring("record it with DJOEMO_RECORD=1"))
+	})
+})
@@ -0,0 +1,111 @@
+package djoemo_test
+
+import (
+	"context"
+	"errors"
+
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RepositoryMock", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		repoMock   *mock.RepositoryMock
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		repoMock = mock.NewRepositoryMock(gomock.NewController(GinkgoT()))
+		repository = repoMock
+		ctx = context.Background()
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	It("should fill the out-param of get", func() {
+		repoMock.ExpectGet(userKey("uuid")).Return(User{UUID: "uuid", UserName: "name"})
+		repoMock.ExpectGet(userKey("other")).NotFound()
+
+		user := &User{}
+		found, err := repository.GetItemWithContext(ctx, userKey("uuid"), user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(user.UserName).To(Equal("name"))
+
+		found, err = repository.GetItemWithContext(ctx, userKey("other"), user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should convert items to the type of the out-param", func() {
+		repoMock.ExpectGet(userKey("uuid")).Return(&User{UUID: "uuid", UserName: "name"})
+
+		user := map[string]any{}
+		found, err := repository.GetItemWithContext(ctx, userKey("uuid"), &user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(user).To(HaveKeyWithValue("UserName", "name"))
+	})
+
+	It("should fill the out-param of queries matching the query", func() {
+		query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid").WithLimit(2)
+		repoMock.ExpectQuery(query).ReturnItems([]User{{UUID: "uuid"}, {UUID: "uuid", UserName: "name"}})
+
+		users := []User{}
+		otherQuery := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid").WithLimit(2)
+		Expect(repository.QueryWithContext(ctx, otherQuery, &users)).To(Succeed())
+		Expect(users).To(HaveLen(2))
+		Expect(users[1].UserName).To(Equal("name"))
+	})
+
+	It("should match saved items and return errors", func() {
+		failure := errors.New("failure")
+		repoMock.ExpectSave(userKey("uuid")).WithItemMatching(func(item any) bool {
+			return item.(*User).UserName == "name"
+		}).ReturnError(failure)
+
+		err := repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid", UserName: "name"})
+		Expect(err).To(Equal(failure))
+	})
+
+	It("should reject conditional writes", func() {
+		repoMock.ExpectConditionalUpdate(userKey("uuid")).Reject()
+
+		updated, err := repository.ConditionalUpdateWithContext(ctx, userKey("uuid"), &User{}, "Version = ?", 1)
+		Expect(err).To(BeNil())
+		Expect(updated).To(BeFalse())
+	})
+
+	It("should mock global indexes", func() {
+		key := djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UserName").WithHashKey("name")
+		repoMock.ExpectIndex("name-index").ExpectGetItems(key).ReturnItems([]*User{{UUID: "1"}, {UUID: "2"}})
+
+		users := []*User{}
+		found, err := repository.GIndex("name-index").GetItemsWithContext(ctx, key, &users)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(users).To(HaveLen(2))
+	})
+
+	It("should mock scan iterators", func() {
+		repoMock.ExpectScan(djoemo.Key().WithTableName(UserTableName)).ReturnItems([]User{{UUID: "1"}, {UUID: "2"}})
+
+		iterator, err := repository.ScanIteratorWithContext(ctx, djoemo.Key().WithTableName(UserTableName), 10)
+		Expect(err).To(BeNil())
+		var uuids []string
+		user := &User{}
+		for iterator.NextItem(user) {
+			uuids = append(uuids, user.UUID)
+		}
+		Expect(uuids).To(Equal([]string{"1", "2"}))
+	})
+})
@@ -0,0 +1,232 @@
+package djoemo
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+)
+
+// Redactor replaces the values of keys, key conditions and expression attribute values before a request is logged,
+// e.g. to mask personal data; name is the attribute name or the placeholder of the expression attribute value
+type Redactor func(name string, value any) any
+
+// Redacted replaces values redacted by RedactAll
+const Redacted = "[REDACTED]"
+
+// RedactAll is a Redactor that replaces every value
+func RedactAll(name string, value any) any {
+	return Redacted
+}
+
+// noRedaction is the Redactor used by default
+func noRedaction(name string, value any) any {
+	return value
+}
+
+// requestLog collects the fields a request to DynamoDB is logged with
+type requestLog struct {
+	fields map[string]any
+	redact Redactor
+}
+
+// logRequest logs a request to DynamoDB at debug level, if the logger implements DebugLogInterface and debug is
+// enabled, and keeps it in the slow log if it was slow or failed; slow requests are logged as warning
+func (c *apiClient) logRequest(ctx context.Context, name string, input any, duration time.Duration, err error) {
+	_, debug := c.log.(DebugLogInterface)
+	if level, ok := c.log.(DebugLevelInterface); ok && debug {
+		debug = level.DebugEnabled(ctx)
+	}
+	slow := c.slowLog != nil && c.slowLog.keeps(duration, err)
+	if !debug && !slow {
+		return
+	}
+
+	redact := c.redact
+	if redact == nil {
+		redact = noRedaction
+	}
+	l := requestLog{fields: make(map[string]any), redact: redact}
+	l.input(input)
+
+	if slow {
+		entry := SlowLogEntry{
+			Time:      time.Now(),
+			Scope:     SlowLogRequest,
+			Operation: name,
+			Duration:  duration,
+			Request:   maps.Clone(l.fields),
+		}
+		entry.TableName, _ = l.fields[TableName].(string)
+		entry.IndexName, _ = l.fields["IndexName"].(string)
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		c.slowLog.add(entry)
+	}
+
+	l.fields["Operation"] = name
+	l.fields["Duration"] = duration
+	if err != nil {
+		l.fields["Error"] = err.Error()
+	}
+	log := c.log.WithContext(ctx).WithFields(l.fields)
+	if slow && c.slowLog.isSlow(duration) {
+		if op := slowOperationFromContext(ctx); op != nil {
+			op.slowRequest.Store(true)
+		}
+		log.Warn("slow request: " + spanNameCallPrefix + name)
+	}
+	if debugLog, ok := log.(DebugLogInterface); ok && debug {
+		debugLog.Debug(spanNameCallPrefix + name)
+	}
+}
+
+func (l requestLog) input(input any) {
+	switch input := input.(type) {
+	case *dynamodb.GetItemInput:
+		l.string(TableName, input.TableName)
+		l.attributes("Key", input.Key)
+		l.string("ProjectionExpression", input.ProjectionExpression)
+		l.names(input.ExpressionAttributeNames)
+	case *dynamodb.PutItemInput:
+		l.string(TableName, input.TableName)
+		l.fields["Attributes"] = len(input.Item)
+		l.string("ConditionExpression", input.ConditionExpression)
+		l.names(input.ExpressionAttributeNames)
+		l.attributes("ExpressionAttributeValues", input.ExpressionAttributeValues)
+	case *dynamodb.UpdateItemInput:
+		l.string(TableName, input.TableName)
+		l.attributes("Key", input.Key)
+		l.string("UpdateExpression", input.UpdateExpression)
+		l.string("ConditionExpression", input.ConditionExpression)
+		l.names(input.ExpressionAttributeNames)
+		l.attributes("ExpressionAttributeValues", input.ExpressionAttributeValues)
+	case *dynamodb.DeleteItemInput:
+		l.string(TableName, input.TableName)
+		l.attributes("Key", input.Key)
+		l.string("ConditionExpression", input.ConditionExpression)
+		l.names(input.ExpressionAttributeNames)
+		l.attributes("ExpressionAttributeValues", input.ExpressionAttributeValues)
+	case *dynamodb.QueryInput:
+		l.string(TableName, input.TableName)
+		l.string("IndexName", input.IndexName)
+		l.conditions("KeyConditions", input.KeyConditions)
+		l.string("KeyConditionExpression", input.KeyConditionExpression)
+		l.string("FilterExpression", input.FilterExpression)
+		l.string("ProjectionExpression", input.ProjectionExpression)
+		l.names(input.ExpressionAttributeNames)
+		l.attributes("ExpressionAttributeValues", input.ExpressionAttributeValues)
+		if input.Limit != nil {
+			l.fields["Limit"] = *input.Limit
+		}
+	case *dynamodb.ScanInput:
+		l.string(TableName, input.TableName)
+		l.string("IndexName", input.IndexName)
+		l.string("FilterExpression", input.FilterExpression)
+		l.string("ProjectionExpression", input.ProjectionExpression)
+		l.names(input.ExpressionAttributeNames)
+		l.attributes("ExpressionAttributeValues", input.ExpressionAttributeValues)
+		if input.Segment != nil {
+			l.fields["Segment"] = fmt.Sprintf("%d/%d", *input.Segment, aws.Int64Value(input.TotalSegments))
+		}
+	case *dynamodb.BatchGetItemInput:
+		requests := make(map[string]int)
+		for tableName, keys := range input.RequestItems {
+			requests[tableName] = len(keys.Keys)
+		}
+		l.requests(requests)
+	case *dynamodb.BatchWriteItemInput:
+		requests := make(map[string]int)
+		for tableName, writes := range input.RequestItems {
+			requests[tableName] = len(writes)
+		}
+		l.requests(requests)
+	case *dynamodb.TransactGetItemsInput:
+		requests := make(map[string]int)
+		for _, item := range input.TransactItems {
+			if item.Get != nil {
+				requests[aws.StringValue(item.Get.TableName)]++
+			}
+		}
+		l.requests(requests)
+	case *dynamodb.TransactWriteItemsInput:
+		requests := make(map[string]int)
+		for _, item := range input.TransactItems {
+			requests[transactWriteTableName(item)]++
+		}
+		l.requests(requests)
+	}
+}
+
+func (l requestLog) string(field string, value *string) {
+	if value != nil {
+		l.fields[field] = *value
+	}
+}
+
+func (l requestLog) names(names map[string]*string) {
+	if len(names) == 0 {
+		return
+	}
+	values := make(map[string]string, len(names))
+	for placeholder, name := range names {
+		values[placeholder] = aws.StringValue(name)
+	}
+	l.fields["ExpressionAttributeNames"] = values
+}
+
+// attributes logs the redacted values of attributes
+func (l requestLog) attributes(field string, attributes map[string]*dynamodb.AttributeValue) {
+	if len(attributes) == 0 {
+		return
+	}
+	values := make(map[string]any, len(attributes))
+	for name, attribute := range attributes {
+		values[name] = l.redact(name, unmarshalAttribute(attribute))
+	}
+	l.fields[field] = values
+}
+
+// conditions logs the legacy key conditions the underlying client builds queries with
+func (l requestLog) conditions(field string, conditions map[string]*dynamodb.Condition) {
+	if len(conditions) == 0 {
+		return
+	}
+	values := make(map[string]string, len(conditions))
+	for name, condition := range conditions {
+		operands := make([]string, 0, len(condition.AttributeValueList))
+		for _, attribute := range condition.AttributeValueList {
+			operands = append(operands, fmt.Sprint(l.redact(name, unmarshalAttribute(attribute))))
+		}
+		values[name] = aws.StringValue(condition.ComparisonOperator) + " " + strings.Join(operands, ", ")
+	}
+	l.fields[field] = values
+}
+
+// requests logs the tables of a batch or transaction and the number of their requests
+func (l requestLog) requests(requests map[string]int) {
+	tableNames := make([]string, 0, len(requests))
+	count := 0
+	for tableName, n := range requests {
+		tableNames = append(tableNames, tableName)
+		count += n
+	}
+	sort.Strings(tableNames)
+	l.fields[TableName] = strings.Join(tableNames, ",")
+	l.fields["Requests"] = count
+}
+
+func unmarshalAttribute(attribute *dynamodb.AttributeValue) any {
+	var value any
+	if err := dynamodbattribute.Unmarshal(attribute, &value); err != nil {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,287 @@
+package djoemo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorClass is the category of an error returned by DynamoDB, used to decide if an operation is retried
+type ErrorClass string
+
+const (
+	// ErrorClassNone is the class of a nil error
+	ErrorClassNone ErrorClass = ""
+	// ErrorClassThrottling request rate is too high for the account or the API
+	ErrorClassThrottling ErrorClass = "throttling"
+	// ErrorClassThroughputExceeded provisioned throughput of a table or index is exceeded
+	ErrorClassThroughputExceeded ErrorClass = "throughput_exceeded"
+	// ErrorClassTransactionConflict item is modified concurrently by a transaction
+	ErrorClassTransactionConflict ErrorClass = "transaction_conflict"
+	// ErrorClassServerError DynamoDB failed with a 5xx status
+	ErrorClassServerError ErrorClass = "server_error"
+	// ErrorClassOther any other error, e.g. validation or condition failures
+	ErrorClassOther ErrorClass = "other"
+)
+
+// ClassifyError returns the class of err
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassNone
+	}
+
+	var awsErr awserr.Error
+	if !errors.As(err, &awsErr) {
+		return ErrorClassOther
+	}
+
+	switch awsErr.Code() {
+	case "ThrottlingException", "Throttling", "RequestLimitExceeded":
+		return ErrorClassThrottling
+	case dynamodb.ErrCodeProvisionedThroughputExceededException:
+		return ErrorClassThroughputExceeded
+	case dynamodb.ErrCodeTransactionConflictException:
+		return ErrorClassTransactionConflict
+	case dynamodb.ErrCodeTransactionCanceledException:
+		if strings.Contains(awsErr.Message(), "TransactionConflict") {
+			return ErrorClassTransactionConflict
+		}
+	case dynamodb.ErrCodeInternalServerError, "ServiceUnavailable":
+		return ErrorClassServerError
+	}
+
+	var requestFailure awserr.RequestFailure
+	if errors.As(err, &requestFailure) && requestFailure.StatusCode() >= 500 {
+		return ErrorClassServerError
+	}
+
+	return ErrorClassOther
+}
+
+// IsRetryableError returns true if err is a throttling, throughput, transaction conflict or server error
+func IsRetryableError(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorClassThrottling, ErrorClassThroughputExceeded, ErrorClassTransactionConflict, ErrorClassServerError:
+		return true
+	}
+	return false
+}
+
+// BackoffStrategy returns the delay before the given retry; attempt starts at 1 for the first retry
+type BackoffStrategy func(attempt int) time.Duration
+
+// ConstantBackoff waits delay before every retry
+func ConstantBackoff(delay time.Duration) BackoffStrategy {
+	return func(int) time.Duration {
+		return delay
+	}
+}
+
+// ExponentialBackoff doubles the delay with every retry, starting with base and capped at max
+func ExponentialBackoff(base, max time.Duration) BackoffStrategy {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && delay < max; i++ {
+			delay *= 2
+		}
+		return min(delay, max)
+	}
+}
+
+// Jitter randomizes backoff delays to spread retries of concurrent callers
+type Jitter string
+
+const (
+	// NoJitter uses the backoff delay as is
+	NoJitter Jitter = "none"
+	// FullJitter waits a random duration between zero and the backoff delay
+	FullJitter Jitter = "full"
+	// EqualJitter waits half of the backoff delay plus a random duration up to the other half
+	EqualJitter Jitter = "equal"
+)
+
+// RetryPolicy configures how failed operations are retried
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one; values below 2 disable retries
+	MaxAttempts int
+	// Backoff returns the delay before a retry; no delay is applied if nil
+	Backoff BackoffStrategy
+	// Jitter randomizes the backoff delay
+	Jitter Jitter
+	// RetryOn lists the error classes that are retried
+	RetryOn []ErrorClass
+}
+
+// DefaultRetryPolicy retries throttling, throughput, transaction conflict and server errors up to three times
+// with exponential backoff and full jitter
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		Backoff:     ExponentialBackoff(50*time.Millisecond, 5*time.Second),
+		Jitter:      FullJitter,
+		RetryOn: []ErrorClass{
+			ErrorClassThrottling,
+			ErrorClassThroughputExceeded,
+			ErrorClassTransactionConflict,
+			ErrorClassServerError,
+		},
+	}
+}
+
+// NoRetryPolicy never retries; unlike not configuring any policy it also turns off the retries of the underlying client
+func NoRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// Retryable returns true if the class of err is retried by the policy
+func (p RetryPolicy) Retryable(err error) bool {
+	return slices.Contains(p.RetryOn, ClassifyError(err))
+}
+
+// Delay returns the delay before the given retry, with jitter applied
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if p.Backoff == nil {
+		return 0
+	}
+
+	delay := p.Backoff(attempt)
+	if delay <= 0 {
+		return 0
+	}
+
+	switch p.Jitter {
+	case FullJitter:
+		return rand.N(delay + 1)
+	case EqualJitter:
+		return delay/2 + rand.N(delay/2+1)
+	}
+	return delay
+}
+
+type retryPolicyContextKey int
+
+const retryPolicyCtxKey retryPolicyContextKey = iota
+
+// WithOperationRetryPolicy overrides the retry policy of the repository for operations executed with the returned context
+func WithOperationRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyCtxKey, &policy)
+}
+
+func retryPolicyFromContext(ctx context.Context) *RetryPolicy {
+	policy, ok := ctx.Value(retryPolicyCtxKey).(*RetryPolicy)
+	if !ok {
+		return nil
+	}
+	return policy
+}
+
+// retry runs fn until it succeeds, fails with an error the effective policy does not retry, or attempts are exhausted;
+// the policy of ctx takes precedence over the given one, without any policy fn is run once
+func retry(ctx context.Context, policy *RetryPolicy, log LogInterface, metrics *Metrics, op string, key KeyInterface, fn func(ctx context.Context) error) error {
+	if override := retryPolicyFromContext(ctx); override != nil {
+		policy = override
+	}
+	if policy == nil {
+		return fn(ctx)
+	}
+	ctx = context.WithValue(ctx, retryPolicyCtxKey, policy)
+
+	for attempt := 1; ; attempt++ {
+		err := runAttempt(ctx, attempt, fn)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return unmaskRetryError(err)
+		}
+
+		class := ClassifyError(err)
+		log.WithContext(ctx).WithFields(map[string]any{
+			TableName:    key.TableName(),
+			"Operation":  op,
+			"Attempt":    attempt,
+			"ErrorClass": class,
+		}).Warn("retrying operation: " + err.Error())
+		metrics.RecordRetry(ctx, op, key, class)
+
+		timer := time.NewTimer(policy.Delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// runAttempt runs fn; retries are traced as child spans of the operation
+func runAttempt(ctx context.Context, attempt int, fn func(ctx context.Context) error) error {
+	if attempt == 1 {
+		return fn(ctx)
+	}
+
+	ctx, span := childTracer(ctx).Start(ctx, spanNameRetry, trace.WithAttributes(attrAttempt.Int(attempt)))
+	defer span.End()
+
+	err := fn(ctx)
+	if err != nil {
+		recordSpanError(span, unmaskRetryError(err))
+	}
+	return err
+}
+
+// noRetryer is the retryer of requests of operations with a retry policy, so the underlying client does not retry them
+type noRetryer struct{}
+
+func (noRetryer) RetryRules(*request.Request) time.Duration { return 0 }
+func (noRetryer) ShouldRetry(*request.Request) bool         { return false }
+func (noRetryer) MaxRetries() int                           { return 0 }
+
+// withoutClientRetries adds an option turning off the retries of the underlying client to opts if ctx is of an
+// operation with a retry policy; only the policy decides about retries then and every attempt is counted
+func withoutClientRetries(ctx context.Context, opts []request.Option) []request.Option {
+	if retryPolicyFromContext(ctx) == nil {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], func(r *request.Request) {
+		r.Retryer = noRetryer{}
+	})
+}
+
+// maskedError hides a request failure from the retry loop of guregu/dynamo, so only the retry policy decides about
+// retries; it still unwraps to the original error for classification
+type maskedError struct {
+	err awserr.Error
+}
+
+func (e *maskedError) Error() string   { return e.err.Error() }
+func (e *maskedError) Code() string    { return e.err.Code() }
+func (e *maskedError) Message() string { return e.err.Message() }
+func (e *maskedError) OrigErr() error  { return e.err.OrigErr() }
+func (e *maskedError) Unwrap() error   { return e.err }
+
+func maskRetryError(ctx context.Context, err error) error {
+	if err == nil || retryPolicyFromContext(ctx) == nil {
+		return err
+	}
+	if requestFailure, ok := err.(awserr.RequestFailure); ok {
+		return &maskedError{err: requestFailure}
+	}
+	return err
+}
+
+func unmaskRetryError(err error) error {
+	if masked, ok := err.(*maskedError); ok {
+		return masked.err
+	}
+	return err
+}
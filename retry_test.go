@@ -0,0 +1,205 @@
+package djoemo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry", func() {
+	const (
+		UserTableName = "UserTable"
+	)
+
+	var (
+		dAPIMock     *mock.MockDynamoDBAPI
+		repository   djoemo.RepositoryInterface
+		logMock      *mock.MockLogInterface
+		metricsMock  *mock.MockMetricsInterface
+		retryMetrics *mock.MockRetryMetricsInterface
+		key          djoemo.KeyInterface
+	)
+
+	throttled := func() error {
+		return awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throughput exceeded", nil), 400, "request-id")
+	}
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		logMock = mock.NewMockLogInterface(mockCtrl)
+		metricsMock = mock.NewMockMetricsInterface(mockCtrl)
+		retryMetrics = mock.NewMockRetryMetricsInterface(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+		repository.WithLog(logMock)
+		repository.WithMetrics(struct {
+			djoemo.MetricsInterface
+			djoemo.RetryMetricsInterface
+		}{metricsMock, retryMetrics})
+		repository.WithRetryPolicy(djoemo.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     djoemo.ConstantBackoff(time.Millisecond),
+			Jitter:      djoemo.NoJitter,
+			RetryOn:     []djoemo.ErrorClass{djoemo.ErrorClassThroughputExceeded},
+		})
+
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+
+		logMock.EXPECT().WithContext(gomock.Any()).Return(logMock).AnyTimes()
+		logMock.EXPECT().WithFields(gomock.Any()).Return(logMock).AnyTimes()
+		metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpRead, key, gomock.Any(), gomock.Any()).AnyTimes()
+	})
+
+	Describe("Repository", func() {
+		It("should retry retryable errors", func() {
+			item, _ := dynamodbattribute.MarshalMap(map[string]interface{}{"UUID": "uuid"})
+			gomock.InOrder(
+				dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled()),
+				dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{Item: item}, nil),
+			)
+			logMock.EXPECT().Warn(gomock.Any()).Times(1)
+			retryMetrics.EXPECT().RecordRetry(gomock.Any(), djoemo.OpRead, key, djoemo.ErrorClassThroughputExceeded).Times(1)
+
+			user := &User{}
+			found, err := repository.GetItemWithContext(context.Background(), key, user)
+
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(user.UUID).To(Equal("uuid"))
+		})
+
+		It("should return the original error when attempts are exhausted", func() {
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled()).Times(3)
+			logMock.EXPECT().Warn(gomock.Any()).Times(2)
+			retryMetrics.EXPECT().RecordRetry(gomock.Any(), djoemo.OpRead, key, djoemo.ErrorClassThroughputExceeded).Times(2)
+
+			found, err := repository.GetItemWithContext(context.Background(), key, &User{})
+
+			var requestFailure awserr.RequestFailure
+			Expect(errors.As(err, &requestFailure)).To(BeTrue())
+			Expect(requestFailure.Code()).To(Equal(dynamodb.ErrCodeProvisionedThroughputExceededException))
+			Expect(found).To(BeFalse())
+		})
+
+		It("should not retry errors of other classes", func() {
+			validationErr := awserr.NewRequestFailure(awserr.New("ValidationException", "invalid", nil), 400, "request-id")
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, validationErr).Times(1)
+
+			_, getErr := repository.GetItemWithContext(context.Background(), key, &User{})
+
+			Expect(djoemo.ClassifyError(getErr)).To(Equal(djoemo.ErrorClassOther))
+		})
+
+		It("should use the retry policy of the operation", func() {
+			dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled()).Times(1)
+
+			ctx := djoemo.WithOperationRetryPolicy(context.Background(), djoemo.NoRetryPolicy())
+			_, err := repository.GetItemWithContext(ctx, key, &User{})
+
+			Expect(djoemo.ClassifyError(err)).To(Equal(djoemo.ErrorClassThroughputExceeded))
+		})
+
+		It("should return the request failure of scan iterators", func() {
+			dAPIMock.EXPECT().ScanWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled()).Times(1)
+
+			ctx := djoemo.WithOperationRetryPolicy(context.Background(), djoemo.NoRetryPolicy())
+			iterator, err := repository.ScanIteratorWithContext(ctx, key, 10)
+			Expect(err).To(BeNil())
+			Expect(iterator.NextItem(&User{})).To(BeFalse())
+
+			requestFailure, ok := iterator.(*djoemo.Iterator).Err().(awserr.RequestFailure)
+			Expect(ok).To(BeTrue())
+			Expect(requestFailure.StatusCode()).To(Equal(400))
+		})
+	})
+
+	Describe("Client", func() {
+		var (
+			server   *httptest.Server
+			attempts atomic.Int32
+			client   djoemo.RepositoryInterface
+		)
+
+		BeforeEach(func() {
+			attempts.Store(0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError","message":"unavailable"}`))
+			}))
+			DeferCleanup(server.Close)
+
+			sess := session.Must(session.NewSession(&aws.Config{
+				Endpoint:    aws.String(server.URL),
+				Region:      aws.String("eu-west-1"),
+				Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+				MaxRetries:  aws.Int(3),
+			}))
+			client = djoemo.NewRepository(dynamodb.New(sess))
+		})
+
+		It("should only send the attempts of the retry policy", func() {
+			client.WithRetryPolicy(djoemo.RetryPolicy{
+				MaxAttempts: 2,
+				RetryOn:     []djoemo.ErrorClass{djoemo.ErrorClassServerError},
+			})
+
+			_, err := client.GetItemWithContext(context.Background(), key, &User{})
+
+			Expect(djoemo.ClassifyError(err)).To(Equal(djoemo.ErrorClassServerError))
+			Expect(attempts.Load()).To(Equal(int32(2)))
+		})
+
+		It("should send a single attempt with no retry policy", func() {
+			ctx := djoemo.WithOperationRetryPolicy(context.Background(), djoemo.NoRetryPolicy())
+			_, err := client.GetItemWithContext(ctx, key, &User{})
+
+			Expect(djoemo.ClassifyError(err)).To(Equal(djoemo.ErrorClassServerError))
+			Expect(attempts.Load()).To(Equal(int32(1)))
+		})
+	})
+
+	Describe("ClassifyError", func() {
+		DescribeTable("should classify errors",
+			func(err error, class djoemo.ErrorClass) {
+				Expect(djoemo.ClassifyError(err)).To(Equal(class))
+			},
+			Entry("nil", nil, djoemo.ErrorClassNone),
+			Entry("throttling", awserr.New("ThrottlingException", "", nil), djoemo.ErrorClassThrottling),
+			Entry("transaction conflict", awserr.New(dynamodb.ErrCodeTransactionCanceledException, "reasons [TransactionConflict]", nil), djoemo.ErrorClassTransactionConflict),
+			Entry("server error", awserr.NewRequestFailure(awserr.New("Unknown", "", nil), 503, ""), djoemo.ErrorClassServerError),
+			Entry("other", errors.New("other"), djoemo.ErrorClassOther),
+		)
+	})
+
+	Describe("ExponentialBackoff", func() {
+		It("should double the delay up to the maximum", func() {
+			backoff := djoemo.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+			Expect(backoff(1)).To(Equal(10 * time.Millisecond))
+			Expect(backoff(2)).To(Equal(20 * time.Millisecond))
+			Expect(backoff(3)).To(Equal(40 * time.Millisecond))
+			Expect(backoff(4)).To(Equal(50 * time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package djoemo
+
+import "sync"
+
+// schemas holds the registered schemas by table name
+var schemas = struct {
+	sync.RWMutex
+	tables map[string]TableSchema
+}{tables: make(map[string]TableSchema)}
+
+// RegisterTable registers the key schema of a table; model is passed to SchemaOf. Keys of registered tables and their
+// indexes are validated against the schema before a request is sent, so wrong key names, missing range keys and
+// values of the wrong type fail with ErrInvalidHashKeyName, ErrInvalidRangeKeyName and the value errors. A schema
+// registered before for the same table is replaced.
+func RegisterTable(model any) error {
+	schema, err := SchemaOf(model)
+	if err != nil {
+		return err
+	}
+
+	schemas.Lock()
+	defer schemas.Unlock()
+	schemas.tables[schema.Name] = schema
+	return nil
+}
+
+// UnregisterTable removes the schema of a table; its keys are not validated against a schema anymore
+func UnregisterTable(tableName string) {
+	schemas.Lock()
+	defer schemas.Unlock()
+	delete(schemas.tables, tableName)
+}
+
+// RegisteredTable returns the registered schema of a table; returns false if the table is not registered
+func RegisteredTable(tableName string) (TableSchema, bool) {
+	schemas.RLock()
+	defer schemas.RUnlock()
+	schema, ok := schemas.tables[tableName]
+	return schema, ok
+}
+
+// keySchema returns the hash and range key of a registered table or of one of its indexes
+func (schema TableSchema) keySchema(indexName string) (hashKey string, rangeKey string, ok bool) {
+	if indexName == "" {
+		return schema.HashKey, schema.RangeKey, true
+	}
+	for _, index := range append(append([]IndexSchema(nil), schema.GlobalIndexes...), schema.LocalIndexes...) {
+		if index.Name == indexName {
+			return index.HashKey, index.RangeKey, true
+		}
+	}
+	return "", "", false
+}
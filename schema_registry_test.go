@@ -0,0 +1,119 @@
+package djoemo_test
+
+import (
+	"context"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Schema registry", func() {
+	const OrderTableName = "OrderTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New()
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+		Expect(repository.CreateTable(ctx, Order{})).To(Succeed())
+		Expect(djoemo.RegisterTable(Order{})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		djoemo.UnregisterTable(OrderTableName)
+	})
+
+	orderKey := func(customerID any, orderID any) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(OrderTableName).
+			WithHashKeyName("CustomerID").WithHashKey(customerID).
+			WithRangeKeyName("OrderID").WithRangeKey(orderID)
+	}
+
+	It("should register the schema of a model", func() {
+		schema, ok := djoemo.RegisteredTable(OrderTableName)
+		Expect(ok).To(BeTrue())
+		Expect(schema.RangeKey).To(Equal("OrderID"))
+
+		djoemo.UnregisterTable(OrderTableName)
+		_, ok = djoemo.RegisteredTable(OrderTableName)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should accept keys matching the schema", func() {
+		Expect(repository.SaveItemWithContext(ctx, orderKey("customer", "order"), &Order{CustomerID: "customer", OrderID: "order"})).To(Succeed())
+
+		found, err := repository.GetItemWithContext(ctx, orderKey("customer", "order"), &Order{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+
+		orders := []Order{}
+		query := djoemo.Query().WithTableName(OrderTableName).WithHashKeyName("CustomerID").WithHashKey("customer")
+		Expect(repository.QueryWithContext(ctx, query, &orders)).To(Succeed())
+		Expect(orders).To(HaveLen(1))
+	})
+
+	It("should fail for wrong key names", func() {
+		key := djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("UUID").WithHashKey("customer")
+		_, err := repository.GetItemWithContext(ctx, key, &Order{})
+		Expect(err).To(MatchError(djoemo.ErrInvalidHashKeyName))
+		Expect(err.Error()).To(Equal("invalid hash key name: table OrderTable has hash key CustomerID, got UUID"))
+
+		key = djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("CustomerID").WithHashKey("customer").
+			WithRangeKeyName("Status").WithRangeKey("open")
+		_, err = repository.GetItemWithContext(ctx, key, &Order{})
+		Expect(err.Error()).To(Equal("invalid range key name: table OrderTable has range key OrderID, got Status"))
+	})
+
+	It("should fail for missing range keys of composite tables", func() {
+		key := djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("CustomerID").WithHashKey("customer")
+		err := repository.DeleteItemWithContext(ctx, key)
+		Expect(err).To(MatchError(djoemo.ErrInvalidRangeKeyName))
+		Expect(err.Error()).To(Equal("invalid range key name: table OrderTable needs range key OrderID"))
+	})
+
+	It("should fail for values of the wrong type", func() {
+		_, err := repository.GetItemWithContext(ctx, orderKey(42, "order"), &Order{})
+		Expect(err).To(MatchError(djoemo.ErrInvalidHashKeyValue))
+		Expect(err.Error()).To(Equal("invalid hash key value: hash key CustomerID of table OrderTable is of type S, got int"))
+	})
+
+	It("should validate keys of indexes", func() {
+		orders := []Order{}
+		query := djoemo.Query().WithTableName(OrderTableName).WithHashKeyName("Status").WithHashKey("open").
+			WithRangeKeyName("Total").WithRangeKey("100").WithRangeOp(djoemo.GreaterOrEqual)
+		err := repository.GIndex("status-index").QueryWithContext(ctx, query, &orders)
+		Expect(err).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+		Expect(err.Error()).To(Equal("invalid range key value: range key Total of index status-index of table OrderTable is of type N, got string"))
+
+		err = repository.GIndex("unknown-index").QueryWithContext(ctx, query, &orders)
+		Expect(err).To(MatchError(djoemo.ErrInvalidIndexName))
+	})
+
+	Describe("unregistered tables", func() {
+		BeforeEach(func() {
+			djoemo.UnregisterTable(OrderTableName)
+		})
+
+		It("should fail for a range key name without value", func() {
+			key := djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("CustomerID").WithHashKey("customer").
+				WithRangeKeyName("OrderID")
+			_, err := repository.GetItemWithContext(ctx, key, &Order{})
+			Expect(err).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+			Expect(err.Error()).To(Equal("invalid range key value: range key OrderID of table OrderTable has no value"))
+		})
+
+		It("should fail for an empty range key instead of dropping it", func() {
+			_, err := repository.GetItemWithContext(ctx, orderKey("customer", ""), &Order{})
+			Expect(err).To(MatchError(djoemo.ErrInvalidRangeKeyValue))
+			Expect(err.Error()).To(Equal("invalid range key value: range key OrderID of table OrderTable is empty"))
+		})
+	})
+})
@@ -0,0 +1,204 @@
+// Package singletable models many entity types in one table. Keys are composed of a prefix and typed segments by Key
+// codecs, and every item is written with a Type attribute naming its entity, so items of different entities sharing
+// a partition can be told apart:
+//
+//	var (
+//		userKey  = singletable.MustKey[struct{ ID string }]("USER")
+//		orderKey = singletable.MustKey[OrderKey]("ORDER")
+//	)
+//
+//	orders := singletable.Entity[Order]{
+//		TableName: "App",
+//		Type:      "Order",
+//		HashKey:   func(o *Order) (string, error) { return userKey.Encode(struct{ ID string }{o.UserID}) },
+//		RangeKey:  func(o *Order) (string, error) { return orderKey.Encode(OrderKey{Month: o.Created, OrderID: o.ID}) },
+//	}
+//	err := orders.Save(ctx, repository, &order)
+//
+//	// the orders of a user in January 2024
+//	prefix, err := orderKey.Prefix(OrderKey{Month: january}, 1)
+//	found, err := orders.QueryItems(ctx, repository, orders.Query(pk, djoemo.BeginsWith, prefix))
+package singletable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// TypeAttribute is the attribute naming the entity of an item
+const TypeAttribute = "Type"
+
+// Default names of the key attributes of single tables
+const (
+	DefaultHashKeyName  = "PK"
+	DefaultRangeKeyName = "SK"
+)
+
+// ErrEntityTypeMismatch item is of another entity
+var ErrEntityTypeMismatch = errors.New("item is of another entity type")
+
+// Entity maps items of type T to a single table
+type Entity[T any] struct {
+	TableName string
+	// Type is written to the Type attribute of the items of the entity
+	Type string
+	// HashKeyName and RangeKeyName are the key attributes of the table; PK and SK by default
+	HashKeyName  string
+	RangeKeyName string
+	// HashKey and RangeKey return the keys of an item, usually encoded by a Key; RangeKey is nil for tables without
+	// range key
+	HashKey  func(item *T) (string, error)
+	RangeKey func(item *T) (string, error)
+}
+
+func (e Entity[T]) hashKeyName() string {
+	if e.HashKeyName == "" {
+		return DefaultHashKeyName
+	}
+	return e.HashKeyName
+}
+
+func (e Entity[T]) rangeKeyName() string {
+	if e.RangeKey == nil {
+		return ""
+	}
+	if e.RangeKeyName == "" {
+		return DefaultRangeKeyName
+	}
+	return e.RangeKeyName
+}
+
+// KeyOf returns the key of encoded keys, like the keys of an item to get
+func (e Entity[T]) KeyOf(hashKey string, rangeKey string) djoemo.KeyInterface {
+	key := djoemo.Key().WithTableName(e.TableName).WithHashKeyName(e.hashKeyName()).WithHashKey(hashKey)
+	if name := e.rangeKeyName(); name != "" {
+		key.WithRangeKeyName(name).WithRangeKey(rangeKey)
+	}
+	return key
+}
+
+// Key returns the key of an item
+func (e Entity[T]) Key(item *T) (djoemo.KeyInterface, error) {
+	hashKey, err := e.HashKey(item)
+	if err != nil {
+		return nil, err
+	}
+	rangeKey := ""
+	if e.RangeKey != nil {
+		if rangeKey, err = e.RangeKey(item); err != nil {
+			return nil, err
+		}
+	}
+	return e.KeyOf(hashKey, rangeKey), nil
+}
+
+// Query returns a query of the items with a hash key whose range keys compare to rangeKey by rangeOp, like a prefix
+// by BeginsWith or the bounds returned by Key.Between by Between; a nil rangeKey queries the whole partition
+func (e Entity[T]) Query(hashKey string, rangeOp djoemo.Operator, rangeKey any) djoemo.QueryInterface {
+	query := djoemo.Query().WithTableName(e.TableName).WithHashKeyName(e.hashKeyName()).WithHashKey(hashKey)
+	if name := e.rangeKeyName(); name != "" && rangeKey != nil {
+		query.WithRangeKeyName(name).WithRangeOp(rangeOp).WithRangeKey(rangeKey)
+	}
+	return query
+}
+
+// Marshal marshals an item like a repository and adds its keys and Type attribute
+func (e Entity[T]) Marshal(item *T) (map[string]*dynamodb.AttributeValue, error) {
+	_, av, err := e.marshal(item)
+	return av, err
+}
+
+func (e Entity[T]) marshal(item *T) (djoemo.KeyInterface, map[string]*dynamodb.AttributeValue, error) {
+	key, err := e.Key(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	av, err := dynamo.MarshalItem(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	av[e.hashKeyName()] = &dynamodb.AttributeValue{S: aws.String(key.HashKey().(string))}
+	if name := e.rangeKeyName(); name != "" {
+		av[name] = &dynamodb.AttributeValue{S: aws.String(key.RangeKey().(string))}
+	}
+	av[TypeAttribute] = &dynamodb.AttributeValue{S: aws.String(e.Type)}
+	return key, av, nil
+}
+
+// Unmarshal unmarshals an item into out; it fails with ErrEntityTypeMismatch for items of other entities
+func (e Entity[T]) Unmarshal(av map[string]*dynamodb.AttributeValue, out *T) error {
+	if typ := TypeOf(av); typ != e.Type {
+		return fmt.Errorf("%w: %q is not %s", ErrEntityTypeMismatch, typ, e.Type)
+	}
+	return dynamo.UnmarshalItem(av, out)
+}
+
+// Is reports if an item is of the entity
+func (e Entity[T]) Is(av map[string]*dynamodb.AttributeValue) bool {
+	return TypeOf(av) == e.Type
+}
+
+// TypeOf returns the Type attribute of an item, empty if it has none
+func TypeOf(av map[string]*dynamodb.AttributeValue) string {
+	if typ := av[TypeAttribute]; typ != nil {
+		return aws.StringValue(typ.S)
+	}
+	return ""
+}
+
+// Save saves an item with its keys and Type attribute
+func (e Entity[T]) Save(ctx context.Context, repository djoemo.RepositoryInterface, item *T) error {
+	key, av, err := e.marshal(item)
+	if err != nil {
+		return err
+	}
+	return repository.SaveItemWithContext(ctx, key, av)
+}
+
+// Get reads the item of key into out; it fails with ErrEntityTypeMismatch if the item is of another entity
+func (e Entity[T]) Get(ctx context.Context, repository djoemo.RepositoryInterface, key djoemo.KeyInterface, out *T) (bool, error) {
+	av := map[string]*dynamodb.AttributeValue{}
+	found, err := repository.GetItemWithContext(ctx, key, &av)
+	if err != nil || !found {
+		return false, err
+	}
+	return true, e.Unmarshal(av, out)
+}
+
+// QueryItems returns the items of the entity matching query; items of other entities in the partition are skipped
+func (e Entity[T]) QueryItems(ctx context.Context, repository djoemo.RepositoryInterface, query djoemo.QueryInterface) ([]T, error) {
+	var avs []map[string]*dynamodb.AttributeValue
+	if err := repository.QueryWithContext(ctx, query, &avs); err != nil {
+		return nil, err
+	}
+	items := make([]T, 0, len(avs))
+	for _, av := range avs {
+		if !e.Is(av) {
+			continue
+		}
+		var item T
+		if err := dynamo.UnmarshalItem(av, &item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// NewTypeRegistry returns a registry of entities by their Type attribute, so a query returns the items of several
+// entities sharing a partition, see Entity.Register
+func NewTypeRegistry() *djoemo.TypeRegistry {
+	return djoemo.NewTypeRegistry(TypeAttribute)
+}
+
+// Register registers T as the type of the items of the entity with a registry of NewTypeRegistry
+func (e Entity[T]) Register(registry *djoemo.TypeRegistry) {
+	djoemo.RegisterType[T](registry, e.Type)
+}
@@ -0,0 +1,238 @@
+package singletable
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Separator separates the prefix and the segments of composite keys
+const Separator = "#"
+
+// ErrInvalidKey key can not be encoded or decoded by a key codec
+var ErrInvalidKey = errors.New("invalid composite key")
+
+// maxSuffix is appended to the upper bound of Between, so it includes all keys starting with the bound; no UTF-8
+// encoded string sorts after the largest rune
+var maxSuffix = string(utf8.MaxRune)
+
+// Key encodes composite keys of a prefix and the exported fields of K as segments, like ORDER#2024-01#42 of
+//
+//	type OrderKey struct {
+//		Month   time.Time `key:"layout=2006-01"`
+//		OrderID string
+//	}
+//
+// Fields are strings, integers or times. Integers are padded with zeros to the width of the tag width, so they sort
+// like numbers; negative integers are rejected. Times are encoded in UTC with the layout of the tag layout, RFC 3339
+// by default. Strings must not contain the separator. Fields tagged key:"-" are skipped.
+type Key[K any] struct {
+	prefix   string
+	segments []segment
+}
+
+// segment is the codec of a field of a key
+type segment struct {
+	name   string
+	index  int
+	kind   reflect.Kind
+	time   bool
+	layout string
+	width  int
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewKey returns the codec of keys with prefix and the fields of K; K must be a struct
+func NewKey[K any](prefix string) (*Key[K], error) {
+	t := reflect.TypeOf((*K)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s is not a struct", ErrInvalidKey, t)
+	}
+	if prefix == "" || strings.Contains(prefix, Separator) {
+		return nil, fmt.Errorf("%w: prefix %q must not be empty or contain %s", ErrInvalidKey, prefix, Separator)
+	}
+
+	k := &Key[K]{prefix: prefix}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("key")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		s := segment{name: field.Name, index: i, kind: field.Type.Kind(), time: field.Type == timeType, layout: time.RFC3339}
+		for _, option := range strings.Split(tag, ",") {
+			name, value, _ := strings.Cut(option, "=")
+			switch name {
+			case "":
+			case "layout":
+				s.layout = value
+			case "width":
+				width, err := strconv.Atoi(value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: field %s: width %q is not a number", ErrInvalidKey, field.Name, value)
+				}
+				s.width = width
+			default:
+				return nil, fmt.Errorf("%w: field %s: unknown option %q", ErrInvalidKey, field.Name, name)
+			}
+		}
+		if !s.time && s.kind != reflect.String && !isInt(s.kind) && !isUint(s.kind) {
+			return nil, fmt.Errorf("%w: field %s of type %s is not a string, integer or time", ErrInvalidKey, field.Name, field.Type)
+		}
+		k.segments = append(k.segments, s)
+	}
+	return k, nil
+}
+
+// MustKey is like NewKey but panics if K can not be a key; it simplifies declaring keys as package variables
+func MustKey[K any](prefix string) *Key[K] {
+	k, err := NewKey[K](prefix)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// Encode returns the key of values
+func (k *Key[K]) Encode(values K) (string, error) {
+	return k.encode(values, len(k.segments), false)
+}
+
+// Prefix returns the prefix of keys whose first segments equal those of values, ending with the separator; it is
+// the value of BeginsWith queries. Prefix(values, 0) matches all keys of the codec. A prefix of all segments is
+// rejected, the key of all segments is returned by Encode
+func (k *Key[K]) Prefix(values K, segments int) (string, error) {
+	if segments < 0 || segments >= len(k.segments) {
+		return "", fmt.Errorf("%w: %s has %d segments, a prefix has up to %d, not %d", ErrInvalidKey, k.prefix, len(k.segments), len(k.segments)-1, segments)
+	}
+	return k.encode(values, segments, true)
+}
+
+// Between returns the bounds of Between queries for keys whose first segments are between those of from and to,
+// including the keys starting with the segments of to; with all segments, the bounds are the keys of from and to
+func (k *Key[K]) Between(from K, to K, segments int) ([]string, error) {
+	if segments == len(k.segments) {
+		lower, err := k.Encode(from)
+		if err != nil {
+			return nil, err
+		}
+		upper, err := k.Encode(to)
+		if err != nil {
+			return nil, err
+		}
+		return []string{lower, upper}, nil
+	}
+
+	lower, err := k.Prefix(from, segments)
+	if err != nil {
+		return nil, err
+	}
+	upper, err := k.Prefix(to, segments)
+	if err != nil {
+		return nil, err
+	}
+	return []string{lower, upper + maxSuffix}, nil
+}
+
+func (k *Key[K]) encode(values K, segments int, prefix bool) (string, error) {
+	v := reflect.ValueOf(values)
+	var b strings.Builder
+	b.WriteString(k.prefix)
+	for _, s := range k.segments[:segments] {
+		encoded, err := s.encode(v.Field(s.index))
+		if err != nil {
+			return "", fmt.Errorf("%w: %s field %s: %w", ErrInvalidKey, k.prefix, s.name, err)
+		}
+		b.WriteString(Separator)
+		b.WriteString(encoded)
+	}
+	if prefix {
+		b.WriteString(Separator)
+	}
+	return b.String(), nil
+}
+
+// Decode returns the values of a key; it fails for keys of other prefixes
+func (k *Key[K]) Decode(key string) (K, error) {
+	var values K
+	parts := strings.Split(key, Separator)
+	if parts[0] != k.prefix || len(parts) != len(k.segments)+1 {
+		return values, fmt.Errorf("%w: %q is not a key of %s with %d segments", ErrInvalidKey, key, k.prefix, len(k.segments))
+	}
+	v := reflect.ValueOf(&values).Elem()
+	for i, s := range k.segments {
+		if err := s.decode(parts[i+1], v.Field(s.index)); err != nil {
+			return values, fmt.Errorf("%w: %s field %s: %w", ErrInvalidKey, k.prefix, s.name, err)
+		}
+	}
+	return values, nil
+}
+
+// Matches reports if key has the prefix of the codec
+func (k *Key[K]) Matches(key string) bool {
+	return strings.HasPrefix(key, k.prefix+Separator) || (len(k.segments) == 0 && key == k.prefix)
+}
+
+func (s segment) encode(v reflect.Value) (string, error) {
+	switch {
+	case s.time:
+		return v.Interface().(time.Time).UTC().Format(s.layout), nil
+	case s.kind == reflect.String:
+		if strings.Contains(v.String(), Separator) {
+			return "", fmt.Errorf("%q contains %s", v.String(), Separator)
+		}
+		return v.String(), nil
+	case isInt(s.kind):
+		if v.Int() < 0 {
+			return "", fmt.Errorf("%d is negative", v.Int())
+		}
+		return s.pad(strconv.FormatInt(v.Int(), 10))
+	}
+	return s.pad(strconv.FormatUint(v.Uint(), 10))
+}
+
+func (s segment) pad(digits string) (string, error) {
+	if s.width > 0 && len(digits) > s.width {
+		return "", fmt.Errorf("%s exceeds width %d", digits, s.width)
+	}
+	return strings.Repeat("0", max(s.width-len(digits), 0)) + digits, nil
+}
+
+func (s segment) decode(encoded string, v reflect.Value) error {
+	switch {
+	case s.time:
+		t, err := time.Parse(s.layout, encoded)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+	case s.kind == reflect.String:
+		v.SetString(encoded)
+	case isInt(s.kind):
+		n, err := strconv.ParseInt(encoded, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(encoded, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	}
+	return nil
+}
+
+func isInt(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Int64
+}
+
+func isUint(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
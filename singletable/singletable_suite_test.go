@@ -0,0 +1,13 @@
+package singletable_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSingletable(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Singletable Suite")
+}
@@ -0,0 +1,241 @@
+package singletable_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/singletable"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type UserKey struct {
+	ID string
+}
+
+type OrderKey struct {
+	Month   time.Time `key:"layout=2006-01"`
+	Number  int       `key:"width=6"`
+	OrderID string
+}
+
+type User struct {
+	ID   string
+	Name string
+}
+
+type Order struct {
+	UserID  string
+	ID      string
+	Number  int
+	Created time.Time
+	Total   int
+}
+
+var (
+	userKey  = singletable.MustKey[UserKey]("USER")
+	orderKey = singletable.MustKey[OrderKey]("ORDER")
+	profile  = singletable.MustKey[struct{}]("PROFILE")
+)
+
+var _ = Describe("Key", func() {
+	january := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+	It("encodes and decodes composite keys", func() {
+		key, err := orderKey.Encode(OrderKey{Month: january, Number: 42, OrderID: "o1"})
+		Expect(err).To(BeNil())
+		Expect(key).To(Equal("ORDER#2024-01#000042#o1"))
+
+		values, err := orderKey.Decode(key)
+		Expect(err).To(BeNil())
+		Expect(values).To(Equal(OrderKey{Month: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), Number: 42, OrderID: "o1"}))
+		Expect(orderKey.Matches(key)).To(BeTrue())
+		Expect(userKey.Matches(key)).To(BeFalse())
+	})
+
+	It("encodes prefixes and bounds of the first segments", func() {
+		prefix, err := orderKey.Prefix(OrderKey{Month: january}, 1)
+		Expect(err).To(BeNil())
+		Expect(prefix).To(Equal("ORDER#2024-01#"))
+
+		all, err := orderKey.Prefix(OrderKey{}, 0)
+		Expect(err).To(BeNil())
+		Expect(all).To(Equal("ORDER#"))
+
+		bounds, err := orderKey.Between(OrderKey{Month: january}, OrderKey{Month: january.AddDate(0, 1, 0)}, 1)
+		Expect(err).To(BeNil())
+		Expect(bounds).To(HaveLen(2))
+		Expect(bounds[0]).To(Equal("ORDER#2024-01#"))
+		Expect(bounds[1]).To(HavePrefix("ORDER#2024-02#"))
+
+		bounds, err = orderKey.Between(OrderKey{Month: january, Number: 1, OrderID: "a"}, OrderKey{Month: january, Number: 2, OrderID: "b"}, 3)
+		Expect(err).To(BeNil())
+		Expect(bounds).To(Equal([]string{"ORDER#2024-01#000001#a", "ORDER#2024-01#000002#b"}))
+
+		_, err = orderKey.Prefix(OrderKey{}, 3)
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+		_, err = orderKey.Prefix(OrderKey{}, 4)
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+	})
+
+	It("rejects invalid segments and keys", func() {
+		_, err := orderKey.Encode(OrderKey{Month: january, OrderID: "o#1"})
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+
+		_, err = orderKey.Encode(OrderKey{Month: january, Number: -1, OrderID: "o1"})
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+
+		_, err = orderKey.Encode(OrderKey{Month: january, Number: 1234567, OrderID: "o1"})
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+
+		_, err = orderKey.Decode("USER#u1")
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+
+		_, err = singletable.NewKey[struct{ Price float64 }]("PRICE")
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+
+		_, err = singletable.NewKey[UserKey]("US#ER")
+		Expect(err).To(MatchError(singletable.ErrInvalidKey))
+	})
+})
+
+var _ = Describe("Entity", func() {
+	const TableName = "App"
+
+	var (
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+		users      singletable.Entity[User]
+		orders     singletable.Entity[Order]
+		pk         string
+	)
+
+	BeforeEach(func() {
+		repository = djoemo.NewRepository(fake.New().WithTable(TableName, "PK", "SK"))
+		ctx = context.Background()
+
+		users = singletable.Entity[User]{
+			TableName: TableName,
+			Type:      "User",
+			HashKey:   func(u *User) (string, error) { return userKey.Encode(UserKey{ID: u.ID}) },
+			RangeKey:  func(u *User) (string, error) { return profile.Encode(struct{}{}) },
+		}
+		orders = singletable.Entity[Order]{
+			TableName: TableName,
+			Type:      "Order",
+			HashKey:   func(o *Order) (string, error) { return userKey.Encode(UserKey{ID: o.UserID}) },
+			RangeKey: func(o *Order) (string, error) {
+				return orderKey.Encode(OrderKey{Month: o.Created, Number: o.Number, OrderID: o.ID})
+			},
+		}
+
+		var err error
+		pk, err = userKey.Encode(UserKey{ID: "u1"})
+		Expect(err).To(BeNil())
+
+		Expect(users.Save(ctx, repository, &User{ID: "u1", Name: "Ada"})).To(Succeed())
+		for i, created := range []time.Time{
+			time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.January, 20, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		} {
+			order := Order{UserID: "u1", ID: created.Format("0102"), Number: i + 1, Created: created, Total: 10 * (i + 1)}
+			Expect(orders.Save(ctx, repository, &order)).To(Succeed())
+		}
+	})
+
+	It("saves items with their keys and type", func() {
+		item := map[string]*dynamodb.AttributeValue{}
+		found, err := repository.GetItemWithContext(ctx, users.KeyOf(pk, "PROFILE"), &item)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(*item["PK"].S).To(Equal("USER#u1"))
+		Expect(*item["SK"].S).To(Equal("PROFILE"))
+		Expect(singletable.TypeOf(item)).To(Equal("User"))
+
+		user := User{}
+		found, err = users.Get(ctx, repository, users.KeyOf(pk, "PROFILE"), &user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		Expect(user).To(Equal(User{ID: "u1", Name: "Ada"}))
+	})
+
+	It("rejects getting items of other entities", func() {
+		order := Order{}
+		found, err := orders.Get(ctx, repository, orders.KeyOf(pk, "PROFILE"), &order)
+		Expect(found).To(BeTrue())
+		Expect(err).To(MatchError(singletable.ErrEntityTypeMismatch))
+
+		Expect(orders.Unmarshal(map[string]*dynamodb.AttributeValue{}, &order)).To(MatchError(singletable.ErrEntityTypeMismatch))
+	})
+
+	It("queries items by begins with a prefix and skips other entities", func() {
+		prefix, err := orderKey.Prefix(OrderKey{Month: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}, 1)
+		Expect(err).To(BeNil())
+
+		found, err := orders.QueryItems(ctx, repository, orders.Query(pk, djoemo.BeginsWith, prefix))
+		Expect(err).To(BeNil())
+		Expect(found).To(HaveLen(2))
+		Expect(found[0].ID).To(Equal("0102"))
+		Expect(found[1].ID).To(Equal("0120"))
+
+		all, err := orders.QueryItems(ctx, repository, orders.Query(pk, djoemo.Equal, nil))
+		Expect(err).To(BeNil())
+		Expect(all).To(HaveLen(5))
+
+		profiles, err := users.QueryItems(ctx, repository, users.Query(pk, djoemo.Equal, nil))
+		Expect(err).To(BeNil())
+		Expect(profiles).To(Equal([]User{{ID: "u1", Name: "Ada"}}))
+	})
+
+	It("queries items between the bounds of months", func() {
+		bounds, err := orderKey.Between(
+			OrderKey{Month: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+			OrderKey{Month: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+			1,
+		)
+		Expect(err).To(BeNil())
+
+		found, err := orders.QueryItems(ctx, repository, orders.Query(pk, djoemo.Between, bounds))
+		Expect(err).To(BeNil())
+		Expect(found).To(HaveLen(3))
+		Expect(found[0].ID).To(Equal("0102"))
+		Expect(found[2].ID).To(Equal("0203"))
+	})
+
+	It("queries items between keys including the bounds", func() {
+		bounds, err := orderKey.Between(
+			OrderKey{Month: time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC), Number: 2, OrderID: "0102"},
+			OrderKey{Month: time.Date(2024, time.February, 3, 0, 0, 0, 0, time.UTC), Number: 4, OrderID: "0203"},
+			3,
+		)
+		Expect(err).To(BeNil())
+
+		found, err := orders.QueryItems(ctx, repository, orders.Query(pk, djoemo.Between, bounds))
+		Expect(err).To(BeNil())
+		Expect(found).To(HaveLen(3))
+		Expect(found[0].ID).To(Equal("0102"))
+		Expect(found[2].ID).To(Equal("0203"))
+	})
+
+	It("queries the items of several entities of a partition", func() {
+		registry := singletable.NewTypeRegistry()
+		users.Register(registry)
+		orders.Register(registry)
+
+		var (
+			profiles []User
+			placed   []Order
+		)
+		Expect(repository.QueryWithContext(ctx, users.Query(pk, djoemo.Equal, nil), registry.Into(&profiles, &placed))).To(Succeed())
+		Expect(profiles).To(Equal([]User{{ID: "u1", Name: "Ada"}}))
+		Expect(placed).To(HaveLen(5))
+	})
+})
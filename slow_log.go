@@ -0,0 +1,219 @@
+package djoemo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SlowLogScope tells if an entry of the slow log is a single request to DynamoDB or a whole repository or index operation
+type SlowLogScope string
+
+const (
+	// SlowLogRequest entries are requests to DynamoDB, e.g. a page of a query or a chunk of a batch
+	SlowLogRequest SlowLogScope = "request"
+	// SlowLogOperation entries are repository or index operations that were slow although none of their requests was
+	SlowLogOperation SlowLogScope = "operation"
+)
+
+// SlowLogEntry is a slow or failed request to DynamoDB, or a slow operation
+type SlowLogEntry struct {
+	Time      time.Time
+	Scope     SlowLogScope
+	Operation string
+	TableName string
+	IndexName string
+	Duration  time.Duration
+	// Error is empty for requests that succeeded
+	Error string
+	// Request holds the redacted shape of the request: key, key conditions, expressions and their values;
+	// it is empty for operations
+	Request map[string]any
+}
+
+// SlowLog keeps the most recent requests to DynamoDB that took longer than a threshold or failed in a bounded ring;
+// operations taking longer than the threshold, e.g. a query over many fast pages or a batch with retries, are kept too
+// unless one of their requests was slow already. It is an http.Handler listing them per table, e.g. registered at /debug/djoemo/
+type SlowLog struct {
+	sync.Mutex
+	threshold time.Duration
+	entries   []SlowLogEntry
+	next      int
+	full      bool
+}
+
+// NewSlowLog creates a slow log keeping the last size requests that took at least threshold or failed
+func NewSlowLog(threshold time.Duration, size int) *SlowLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &SlowLog{
+		threshold: threshold,
+		entries:   make([]SlowLogEntry, size),
+	}
+}
+
+// Threshold returns the duration from which requests are slow
+func (l *SlowLog) Threshold() time.Duration {
+	return l.threshold
+}
+
+// isSlow reports if a request took at least the threshold
+func (l *SlowLog) isSlow(duration time.Duration) bool {
+	return duration >= l.threshold
+}
+
+// keeps reports if a request is kept
+func (l *SlowLog) keeps(duration time.Duration, err error) bool {
+	return err != nil || l.isSlow(duration)
+}
+
+// slowOperation measures a repository or index operation for the slow log
+type slowOperation struct {
+	client    *apiClient
+	start     time.Time
+	operation string
+	tableName string
+	indexName string
+	// slowRequest is set once a request of the operation was slow
+	slowRequest atomic.Bool
+}
+
+type slowOperationContextKey int
+
+const slowOperationCtxKey slowOperationContextKey = iota
+
+// withSlowOperation returns a context measuring an operation if client has a slow log
+func withSlowOperation(ctx context.Context, client *apiClient, operation string, tableNames []string, indexName string) (context.Context, *slowOperation) {
+	if client == nil || client.slowLog == nil {
+		return ctx, nil
+	}
+	op := &slowOperation{
+		client:    client,
+		start:     time.Now(),
+		operation: operation,
+		tableName: strings.Join(tableNames, ","),
+		indexName: indexName,
+	}
+	return context.WithValue(ctx, slowOperationCtxKey, op), op
+}
+
+// slowOperationFromContext returns the operation measured with ctx, nil if there is none
+func slowOperationFromContext(ctx context.Context) *slowOperation {
+	op, _ := ctx.Value(slowOperationCtxKey).(*slowOperation)
+	return op
+}
+
+// end keeps and logs the operation if it was slow and none of its requests was; it is a no-op on nil
+func (op *slowOperation) end(ctx context.Context, err error) {
+	if op == nil || op.slowRequest.Load() {
+		return
+	}
+	duration := time.Since(op.start)
+	if !op.client.slowLog.isSlow(duration) {
+		return
+	}
+
+	entry := SlowLogEntry{
+		Time:      time.Now(),
+		Scope:     SlowLogOperation,
+		Operation: op.operation,
+		TableName: op.tableName,
+		IndexName: op.indexName,
+		Duration:  duration,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	op.client.slowLog.add(entry)
+
+	fields := map[string]any{"Operation": op.operation, TableName: op.tableName, "Duration": duration}
+	if op.indexName != "" {
+		fields["IndexName"] = op.indexName
+	}
+	op.client.log.WithContext(ctx).WithFields(fields).Warn("slow operation: " + op.operation)
+}
+
+func (l *SlowLog) add(entry SlowLogEntry) {
+	l.Lock()
+	defer l.Unlock()
+
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// Entries returns the kept requests, the most recent first
+func (l *SlowLog) Entries() []SlowLogEntry {
+	l.Lock()
+	defer l.Unlock()
+
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	entries := make([]SlowLogEntry, 0, n)
+	for i := 1; i <= n; i++ {
+		entries = append(entries, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return entries
+}
+
+// slowLogView is the JSON representation of an entry
+type slowLogView struct {
+	Time      time.Time      `json:"time"`
+	Scope     SlowLogScope   `json:"scope"`
+	Operation string         `json:"operation"`
+	IndexName string         `json:"index,omitempty"`
+	Duration  string         `json:"duration"`
+	Error     string         `json:"error,omitempty"`
+	Request   map[string]any `json:"request,omitempty"`
+}
+
+// ServeHTTP lists the kept requests per table as JSON, the most recent first; the query parameter table limits the list
+// to one table and failed=true to failed requests
+func (l *SlowLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	table := r.URL.Query().Get("table")
+	failedOnly := r.URL.Query().Get("failed") == "true"
+
+	tables := make(map[string][]slowLogView)
+	for _, entry := range l.Entries() {
+		if table != "" && !strings.EqualFold(entry.TableName, table) {
+			continue
+		}
+		if failedOnly && entry.Error == "" {
+			continue
+		}
+		tables[entry.TableName] = append(tables[entry.TableName], slowLogView{
+			Time:      entry.Time,
+			Scope:     entry.Scope,
+			Operation: entry.Operation,
+			IndexName: entry.IndexName,
+			Duration:  entry.Duration.String(),
+			Error:     entry.Error,
+			Request:   entry.Request,
+		})
+	}
+
+	// encoding/json sorts the tables by name
+	response := struct {
+		Threshold string                   `json:"threshold"`
+		Tables    map[string][]slowLogView `json:"tables"`
+	}{
+		Threshold: l.threshold.String(),
+		Tables:    tables,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,165 @@
+package djoemo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SlowLog", func() {
+	const (
+		UserTableName  = "UserTable"
+		OrderTableName = "OrderTable"
+	)
+
+	var (
+		dAPIMock   *mock.MockDynamoDBAPI
+		repository djoemo.RepositoryInterface
+		logMock    *mock.MockLogInterface
+		userKey    djoemo.KeyInterface
+		orderKey   djoemo.KeyInterface
+	)
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		logMock = mock.NewMockLogInterface(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+		repository.WithLog(logMock)
+
+		userKey = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+		orderKey = djoemo.Key().WithTableName(OrderTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("order")
+	})
+
+	It("should keep and log slow requests", func() {
+		slowLog := djoemo.NewSlowLog(0, 10)
+		repository.WithSlowLog(slowLog)
+		repository.WithLogRedactor(djoemo.RedactAll)
+
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+		logMock.EXPECT().WithContext(gomock.Any()).Return(logMock)
+		logMock.EXPECT().WithFields(gomock.Any()).Return(logMock)
+		logMock.EXPECT().Warn("slow request: DynamoDB.DeleteItem")
+
+		Expect(repository.DeleteItemWithContext(context.Background(), userKey)).To(BeNil())
+
+		entries := slowLog.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Scope).To(Equal(djoemo.SlowLogRequest))
+		Expect(entries[0].Operation).To(Equal("DeleteItem"))
+		Expect(entries[0].TableName).To(Equal(UserTableName))
+		Expect(entries[0].Error).To(BeEmpty())
+		Expect(entries[0].Request).To(HaveKeyWithValue("Key", map[string]any{"UUID": djoemo.Redacted}))
+	})
+
+	It("should keep slow operations of fast requests", func() {
+		slowLog := djoemo.NewSlowLog(30*time.Millisecond, 10)
+		repository.WithSlowLog(slowLog)
+
+		page := func(lastKey map[string]*dynamodb.AttributeValue) func(aws.Context, *dynamodb.QueryInput, ...request.Option) (*dynamodb.QueryOutput, error) {
+			return func(aws.Context, *dynamodb.QueryInput, ...request.Option) (*dynamodb.QueryOutput, error) {
+				time.Sleep(20 * time.Millisecond)
+				return &dynamodb.QueryOutput{LastEvaluatedKey: lastKey}, nil
+			}
+		}
+		gomock.InOrder(
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).DoAndReturn(page(map[string]*dynamodb.AttributeValue{"UUID": {S: aws.String("uuid")}})),
+			dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).DoAndReturn(page(nil)),
+		)
+		logMock.EXPECT().WithContext(gomock.Any()).Return(logMock)
+		logMock.EXPECT().WithFields(gomock.Any()).Return(logMock)
+		logMock.EXPECT().Warn("slow operation: Query")
+
+		query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey("uuid")
+		Expect(repository.QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+		entries := slowLog.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Scope).To(Equal(djoemo.SlowLogOperation))
+		Expect(entries[0].Operation).To(Equal("Query"))
+		Expect(entries[0].TableName).To(Equal(UserTableName))
+		Expect(entries[0].Duration).To(BeNumerically(">=", 30*time.Millisecond))
+	})
+
+	It("should keep failed requests below the threshold without logging them", func() {
+		slowLog := djoemo.NewSlowLog(time.Hour, 10)
+		repository.WithSlowLog(slowLog)
+
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.DeleteItemOutput{}, nil)
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(nil, awserr.New("ValidationException", "invalid", nil))
+		logMock.EXPECT().WithContext(gomock.Any()).Return(logMock)
+		logMock.EXPECT().WithFields(gomock.Any()).Return(logMock)
+
+		Expect(repository.DeleteItemWithContext(context.Background(), userKey)).To(BeNil())
+		Expect(repository.DeleteItemWithContext(context.Background(), userKey)).ToNot(BeNil())
+
+		entries := slowLog.Entries()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Error).To(ContainSubstring("invalid"))
+	})
+
+	It("should only keep the most recent requests", func() {
+		slowLog := djoemo.NewSlowLog(time.Hour, 2)
+		repository.WithSlowLog(slowLog)
+		repository.WithLog(djoemo.NewNopLog())
+
+		failure := awserr.New("ValidationException", "invalid", nil)
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(nil, failure).Times(2)
+		dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).Return(nil, failure)
+
+		Expect(repository.DeleteItemWithContext(context.Background(), userKey)).ToNot(BeNil())
+		Expect(repository.DeleteItemWithContext(context.Background(), orderKey)).ToNot(BeNil())
+		_, err := repository.GetItemWithContext(context.Background(), userKey, &User{})
+		Expect(err).ToNot(BeNil())
+
+		entries := slowLog.Entries()
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Operation).To(Equal("GetItem"))
+		Expect(entries[1].TableName).To(Equal(OrderTableName))
+	})
+
+	It("should list the requests per table over http", func() {
+		slowLog := djoemo.NewSlowLog(time.Hour, 10)
+		repository.WithSlowLog(slowLog)
+		repository.WithLog(djoemo.NewNopLog())
+
+		failure := awserr.New("ValidationException", "invalid", nil)
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(nil, failure).Times(2)
+		Expect(repository.DeleteItemWithContext(context.Background(), userKey)).ToNot(BeNil())
+		Expect(repository.DeleteItemWithContext(context.Background(), orderKey)).ToNot(BeNil())
+
+		response := httptest.NewRecorder()
+		slowLog.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/debug/djoemo/?table=usertable", nil))
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		body := struct {
+			Threshold string
+			Tables    map[string][]map[string]any
+		}{}
+		Expect(json.Unmarshal(response.Body.Bytes(), &body)).To(Succeed())
+		Expect(body.Threshold).To(Equal("1h0m0s"))
+		Expect(body.Tables).To(HaveLen(1))
+		Expect(body.Tables[UserTableName]).To(HaveLen(1))
+		Expect(body.Tables[UserTableName][0]).To(HaveKeyWithValue("operation", "DeleteItem"))
+		Expect(body.Tables[UserTableName][0]).To(HaveKeyWithValue("error", ContainSubstring("invalid")))
+	})
+})
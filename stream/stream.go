@@ -0,0 +1,508 @@
+// Package stream processes the DynamoDB Stream of a table with typed handlers. Images are decoded like items read by
+// a repository, shards are processed in parallel and children of split shards only after their parents. Positions
+// are checkpointed per shard in a table; handlers are called at least once per record, so they should be idempotent.
+//
+//	consumer := stream.NewConsumer(client, dynamodbstreams.New(sess), "UserTable").WithName("mailer")
+//	stream.Handle(consumer, stream.Handler[User]{
+//		OnInsert: func(ctx context.Context, event stream.Event[User]) error {
+//			return mailer.Welcome(ctx, event.New.Email)
+//		},
+//	})
+//	err := consumer.Run(ctx)
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo"
+)
+
+// DefaultCheckpointTable is the name of the table the positions of consumers are stored in
+const DefaultCheckpointTable = "djoemo_stream_checkpoints"
+
+// DefaultName is the name of consumers; consumers with different names process the same stream independently
+const DefaultName = "default"
+
+// DefaultPollInterval is how long a consumer waits after a shard returned no records or a request failed
+const DefaultPollInterval = time.Second
+
+// DefaultBatchSize is the maximum number of records read per request
+const DefaultBatchSize = 100
+
+// shardsInterval is how often Run looks for new shards besides when a shard is finished
+const shardsInterval = time.Minute
+
+// ErrStreamNotEnabled table has no stream
+var ErrStreamNotEnabled = errors.New("stream is not enabled")
+
+// Event is a change of an item
+type Event[T any] struct {
+	// Name is dynamodbstreams.OperationTypeInsert, OperationTypeModify or OperationTypeRemove
+	Name           string
+	ShardID        string
+	SequenceNumber string
+	CreatedAt      time.Time
+	Keys           map[string]*dynamodb.AttributeValue
+	// New is the item after the change; nil for removals or if the stream has no new images
+	New *T
+	// Old is the item before the change; nil for inserts or if the stream has no old images
+	Old *T
+}
+
+// Handler handles the changes of items of type T; changes without function are skipped
+type Handler[T any] struct {
+	// Match reports if a change is handled, e.g. by the prefix of a key if a table stores several types; it gets the
+	// new image, the old image of removals or the keys if the stream has no images. All changes match if it is nil
+	Match    func(item map[string]*dynamodb.AttributeValue) bool
+	OnInsert func(ctx context.Context, event Event[T]) error
+	OnModify func(ctx context.Context, event Event[T]) error
+	OnRemove func(ctx context.Context, event Event[T]) error
+}
+
+// handler handles a record of a shard
+type handler func(ctx context.Context, shardID string, record *dynamodbstreams.Record) error
+
+// Handle registers a handler; records are passed to all registered handlers in the order of their registration
+func Handle[T any](c *Consumer, h Handler[T]) *Consumer {
+	c.handlers = append(c.handlers, func(ctx context.Context, shardID string, record *dynamodbstreams.Record) error {
+		change := record.Dynamodb
+		if h.Match != nil && !h.Match(image(change)) {
+			return nil
+		}
+		var fn func(ctx context.Context, event Event[T]) error
+		switch aws.StringValue(record.EventName) {
+		case dynamodbstreams.OperationTypeInsert:
+			fn = h.OnInsert
+		case dynamodbstreams.OperationTypeModify:
+			fn = h.OnModify
+		case dynamodbstreams.OperationTypeRemove:
+			fn = h.OnRemove
+		}
+		if fn == nil {
+			return nil
+		}
+
+		event := Event[T]{
+			Name:           aws.StringValue(record.EventName),
+			ShardID:        shardID,
+			SequenceNumber: aws.StringValue(change.SequenceNumber),
+			CreatedAt:      aws.TimeValue(change.ApproximateCreationDateTime),
+			Keys:           change.Keys,
+		}
+		if change.NewImage != nil {
+			event.New = new(T)
+			if err := dynamo.UnmarshalItem(change.NewImage, event.New); err != nil {
+				return fmt.Errorf("new image: %w", err)
+			}
+		}
+		if change.OldImage != nil {
+			event.Old = new(T)
+			if err := dynamo.UnmarshalItem(change.OldImage, event.Old); err != nil {
+				return fmt.Errorf("old image: %w", err)
+			}
+		}
+		return fn(ctx, event)
+	})
+	return c
+}
+
+// image returns the new image, the old image or the keys of a change
+func image(change *dynamodbstreams.StreamRecord) map[string]*dynamodb.AttributeValue {
+	switch {
+	case change.NewImage != nil:
+		return change.NewImage
+	case change.OldImage != nil:
+		return change.OldImage
+	}
+	return change.Keys
+}
+
+// checkpoint is the position of a consumer in a shard
+type checkpoint struct {
+	// ID is the name of the consumer and the shard ID separated by #
+	ID string `dynamo:",hash"`
+	// SequenceNumber of the last handled record
+	SequenceNumber string
+	// Done is true once all records of a closed shard are handled
+	Done      bool
+	UpdatedAt time.Time
+}
+
+// Consumer processes the stream of a table. Only one instance of a consumer name may run at a time, shards are not
+// leased between instances
+type Consumer struct {
+	client          dynamodbiface.DynamoDBAPI
+	streams         dynamodbstreamsiface.DynamoDBStreamsAPI
+	repository      djoemo.RepositoryInterface
+	tableName       string
+	name            string
+	checkpointTable string
+	log             djoemo.LogInterface
+	pollInterval    time.Duration
+	batchSize       int64
+	handlers        []handler
+}
+
+// NewConsumer factory method for a consumer of the stream of a table; checkpoints are stored through client in
+// DefaultCheckpointTable
+func NewConsumer(client dynamodbiface.DynamoDBAPI, streams dynamodbstreamsiface.DynamoDBStreamsAPI, tableName string) *Consumer {
+	return &Consumer{
+		client:          client,
+		streams:         streams,
+		repository:      djoemo.NewRepository(client),
+		tableName:       tableName,
+		name:            DefaultName,
+		checkpointTable: DefaultCheckpointTable,
+		log:             djoemo.NewNopLog(),
+		pollInterval:    DefaultPollInterval,
+		batchSize:       DefaultBatchSize,
+	}
+}
+
+// WithName sets the name of the consumer its checkpoints are stored under
+func (c *Consumer) WithName(name string) *Consumer {
+	c.name = name
+	return c
+}
+
+// WithCheckpointTable sets the name of the table checkpoints are stored in
+func (c *Consumer) WithCheckpointTable(tableName string) *Consumer {
+	c.checkpointTable = tableName
+	return c
+}
+
+// WithLog enables logging of the consumer and of the repository storing checkpoints
+func (c *Consumer) WithLog(log djoemo.LogInterface) *Consumer {
+	c.log = log
+	c.repository.WithLog(log)
+	return c
+}
+
+// WithPollInterval sets how long the consumer waits after a shard returned no records or a request failed
+func (c *Consumer) WithPollInterval(interval time.Duration) *Consumer {
+	c.pollInterval = interval
+	return c
+}
+
+// WithBatchSize sets the maximum number of records read per request; DynamoDB allows up to 1000
+func (c *Consumer) WithBatchSize(size int64) *Consumer {
+	c.batchSize = size
+	return c
+}
+
+// Run processes the stream until ctx is canceled and returns nil then. Failed handlers and requests are logged and
+// retried from the last checkpoint after the poll interval. It creates the checkpoint table if it does not exist.
+func (c *Consumer) Run(ctx context.Context) error {
+	return c.consume(ctx, false)
+}
+
+// Drain processes the records available in all shards, including children of shards that are finished, and
+// returns. It stops a shard at its first error, which is returned once the other shards are drained; records after
+// the last checkpoint are passed again by the next Run or Drain. It is meant for tests with a stream stand-in.
+func (c *Consumer) Drain(ctx context.Context) error {
+	return c.consume(ctx, true)
+}
+
+// shardResult is returned by the goroutine processing a shard
+type shardResult struct {
+	shardID string
+	closed  bool
+	err     error
+}
+
+func (c *Consumer) consume(ctx context.Context, drain bool) error {
+	if len(c.handlers) == 0 {
+		return errors.New("stream: no handler registered")
+	}
+	err := c.repository.EnsureTable(ctx, djoemo.TableSchema{
+		Name:           c.checkpointTable,
+		HashKey:        "ID",
+		AttributeTypes: map[string]string{"ID": dynamodb.ScalarAttributeTypeS},
+	})
+	if err != nil {
+		return err
+	}
+	streamArn, err := c.streamArn(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan shardResult)
+	running := make(map[string]bool)
+	// stopped shards are finished, drained or failed and not started again
+	stopped := make(map[string]bool)
+	finished := make(map[string]bool)
+	var errs []error
+	var tick <-chan time.Time
+	if !drain {
+		ticker := time.NewTicker(shardsInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if ctx.Err() == nil {
+			shards, err := c.shards(ctx, streamArn)
+			switch {
+			case err != nil && drain:
+				errs = append(errs, err)
+				cancel()
+			case err != nil:
+				c.log.WithContext(ctx).WithField("Error", err.Error()).Warn("stream: describing shards failed")
+			}
+			c.start(ctx, streamArn, shards, running, stopped, finished, drain, results)
+		}
+		if len(running) == 0 && (drain || ctx.Err() != nil) {
+			return errors.Join(errs...)
+		}
+
+		var canceled <-chan struct{}
+		if ctx.Err() == nil {
+			canceled = ctx.Done()
+		}
+		select {
+		case result := <-results:
+			delete(running, result.shardID)
+			stopped[result.shardID] = true
+			finished[result.shardID] = result.closed
+			if result.err != nil {
+				errs = append(errs, fmt.Errorf("stream: shard %s: %w", result.shardID, result.err))
+			}
+		case <-tick:
+		case <-canceled:
+		}
+	}
+}
+
+// start processes the shards that are not processed yet and whose parents are finished or trimmed
+func (c *Consumer) start(ctx context.Context, streamArn string, shards []*dynamodbstreams.Shard, running, stopped, finished map[string]bool, drain bool, results chan<- shardResult) {
+	known := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		known[aws.StringValue(shard.ShardId)] = true
+	}
+	for _, shard := range shards {
+		shardID := aws.StringValue(shard.ShardId)
+		parent := aws.StringValue(shard.ParentShardId)
+		if running[shardID] || stopped[shardID] || (known[parent] && !finished[parent]) {
+			continue
+		}
+		running[shardID] = true
+		go func() {
+			closed, err := c.consumeShard(ctx, streamArn, shardID, drain)
+			results <- shardResult{shardID: shardID, closed: closed, err: err}
+		}()
+	}
+}
+
+// streamArn returns the ARN of the latest stream of the table
+func (c *Consumer) streamArn(ctx context.Context) (string, error) {
+	output, err := c.client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(c.tableName)})
+	if err != nil {
+		return "", err
+	}
+	if output.Table.LatestStreamArn == nil {
+		return "", fmt.Errorf("%w: table %s", ErrStreamNotEnabled, c.tableName)
+	}
+	return *output.Table.LatestStreamArn, nil
+}
+
+// shards returns all shards of a stream
+func (c *Consumer) shards(ctx context.Context, streamArn string) ([]*dynamodbstreams.Shard, error) {
+	var shards []*dynamodbstreams.Shard
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(streamArn)}
+	for {
+		output, err := c.streams.DescribeStreamWithContext(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, output.StreamDescription.Shards...)
+		if output.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		input.ExclusiveStartShardId = output.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// consumeShard handles the records of a shard after its checkpoint and reports if the shard is closed and all its
+// records are handled. Without drain it returns once that happened or ctx is canceled.
+func (c *Consumer) consumeShard(ctx context.Context, streamArn string, shardID string, drain bool) (bool, error) {
+	log := c.log.WithContext(ctx).WithField("Shard", shardID)
+	// retry waits after failures of Run, Drain returns them
+	retry := func(message string, err error) error {
+		if drain {
+			return err
+		}
+		log.WithField("Error", err.Error()).Warn(message)
+		sleep(ctx, c.pollInterval)
+		return nil
+	}
+
+	current, err := c.checkpoint(ctx, shardID)
+	for err != nil {
+		if err := retry("stream: reading checkpoint failed", err); err != nil || ctx.Err() != nil {
+			return false, err
+		}
+		current, err = c.checkpoint(ctx, shardID)
+	}
+	if current.Done {
+		return true, nil
+	}
+
+	var iterator *string
+	for ctx.Err() == nil {
+		if iterator == nil {
+			iterator, err = c.iterator(ctx, streamArn, shardID, current.SequenceNumber)
+			if err != nil {
+				if err := retry("stream: getting shard iterator failed", err); err != nil {
+					return false, err
+				}
+				continue
+			}
+		}
+
+		output, err := c.streams.GetRecordsWithContext(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         aws.Int64(c.batchSize),
+		})
+		if err != nil {
+			// iterators are acquired again from the checkpoint, which renews expired iterators
+			iterator = nil
+			if hasCode(err, dynamodbstreams.ErrCodeExpiredIteratorException) {
+				continue
+			}
+			if err := retry("stream: reading records failed", err); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		handled, handleErr := c.handle(ctx, shardID, output.Records)
+		if handled != "" {
+			current.SequenceNumber = handled
+			if err := c.save(ctx, current); err != nil {
+				if err := retry("stream: saving checkpoint failed", err); err != nil {
+					return false, err
+				}
+			}
+		}
+		if handleErr != nil {
+			iterator = nil
+			if err := retry("stream: handler failed", handleErr); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if output.NextShardIterator == nil {
+			current.Done = true
+			if err := c.save(ctx, current); err != nil {
+				// children are processed anyway; the records of the shard are passed again after a restart
+				if err := retry("stream: saving checkpoint failed", err); err != nil {
+					return false, err
+				}
+			}
+			log.Info("stream: shard finished")
+			return true, nil
+		}
+		iterator = output.NextShardIterator
+		if len(output.Records) == 0 {
+			if drain {
+				return false, nil
+			}
+			sleep(ctx, c.pollInterval)
+		}
+	}
+	if drain {
+		return false, ctx.Err()
+	}
+	return false, nil
+}
+
+// handle passes records to the handlers and returns the sequence number of the last record all handlers succeeded for
+func (c *Consumer) handle(ctx context.Context, shardID string, records []*dynamodbstreams.Record) (string, error) {
+	handled := ""
+	for _, record := range records {
+		sequenceNumber := aws.StringValue(record.Dynamodb.SequenceNumber)
+		for _, h := range c.handlers {
+			if err := h(ctx, shardID, record); err != nil {
+				return handled, fmt.Errorf("record %s: %w", sequenceNumber, err)
+			}
+		}
+		handled = sequenceNumber
+	}
+	return handled, nil
+}
+
+// iterator returns an iterator after the sequence number of the checkpoint or at the start of the shard; if the
+// records after the checkpoint were trimmed, processing continues at the oldest record left
+func (c *Consumer) iterator(ctx context.Context, streamArn string, shardID string, sequenceNumber string) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(streamArn),
+		ShardId:           aws.String(shardID),
+		ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
+	}
+	if sequenceNumber != "" {
+		input.ShardIteratorType = aws.String(dynamodbstreams.ShardIteratorTypeAfterSequenceNumber)
+		input.SequenceNumber = aws.String(sequenceNumber)
+	}
+	output, err := c.streams.GetShardIteratorWithContext(ctx, input)
+	if hasCode(err, dynamodbstreams.ErrCodeTrimmedDataAccessException) {
+		c.log.WithContext(ctx).WithField("Shard", shardID).WithField("SequenceNumber", sequenceNumber).
+			Warn("stream: records after checkpoint were trimmed")
+		return c.iterator(ctx, streamArn, shardID, "")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.ShardIterator, nil
+}
+
+func (c *Consumer) key(id string) djoemo.KeyInterface {
+	return djoemo.Key().WithTableName(c.checkpointTable).WithHashKeyName("ID").WithHashKey(id)
+}
+
+// checkpoint returns the checkpoint of a shard; shards without checkpoint start at their oldest record
+func (c *Consumer) checkpoint(ctx context.Context, shardID string) (checkpoint, error) {
+	current := checkpoint{ID: c.name + "#" + shardID}
+	found, err := c.repository.GetItemWithContext(ctx, c.key(current.ID), &current)
+	if err != nil {
+		return checkpoint{}, err
+	}
+	if !found {
+		return checkpoint{ID: current.ID}, nil
+	}
+	return current, nil
+}
+
+func (c *Consumer) save(ctx context.Context, current checkpoint) error {
+	current.UpdatedAt = time.Now().UTC()
+	return c.repository.SaveItemWithContext(ctx, c.key(current.ID), current)
+}
+
+func hasCode(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
+
+// sleep waits for d or until ctx is canceled
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
@@ -0,0 +1,187 @@
+package stream_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/stream"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// User model with hash key only
+type User struct {
+	UUID     string
+	UserName string
+}
+
+// events records handled events as "<name> <uuid> <old user name>-><new user name>"
+type events struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (e *events) add(event stream.Event[User]) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var uuid, old, updated string
+	if event.Old != nil {
+		uuid, old = event.Old.UUID, event.Old.UserName
+	}
+	if event.New != nil {
+		uuid, updated = event.New.UUID, event.New.UserName
+	}
+	e.names = append(e.names, fmt.Sprintf("%s %s %s->%s", event.Name, uuid, old, updated))
+}
+
+func (e *events) list() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.names...)
+}
+
+var _ = Describe("Consumer", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+		handled    *events
+	)
+
+	BeforeEach(func() {
+		db = fake.New().WithTable(UserTableName, "UUID", "").WithStream(UserTableName, dynamodb.StreamViewTypeNewAndOldImages)
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+		handled = &events{}
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	saveUser := func(uuid string, userName string) {
+		Expect(repository.SaveItemWithContext(ctx, userKey(uuid), User{UUID: uuid, UserName: userName})).To(Succeed())
+	}
+
+	record := func(ctx context.Context, event stream.Event[User]) error {
+		handled.add(event)
+		return nil
+	}
+
+	newConsumer := func() *stream.Consumer {
+		consumer := stream.NewConsumer(db, db.Streams(), UserTableName)
+		return stream.Handle(consumer, stream.Handler[User]{OnInsert: record, OnModify: record, OnRemove: record})
+	}
+
+	It("should pass typed inserts, modifications and removals", func() {
+		saveUser("a", "alice")
+		saveUser("a", "alicia")
+		Expect(repository.DeleteItemWithContext(ctx, userKey("a"))).To(Succeed())
+
+		var keys []map[string]*dynamodb.AttributeValue
+		consumer := stream.Handle(newConsumer(), stream.Handler[User]{
+			OnRemove: func(ctx context.Context, event stream.Event[User]) error {
+				keys = append(keys, event.Keys)
+				return nil
+			},
+		})
+		Expect(consumer.Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice", "MODIFY a alice->alicia", "REMOVE a alicia->"}))
+		Expect(keys).To(Equal([]map[string]*dynamodb.AttributeValue{{"UUID": {S: aws.String("a")}}}))
+	})
+
+	It("should resume after the checkpoint of its name", func() {
+		saveUser("a", "alice")
+		Expect(newConsumer().Drain(ctx)).To(Succeed())
+		saveUser("b", "bob")
+		Expect(newConsumer().Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice", "INSERT b ->bob"}))
+
+		handled = &events{}
+		Expect(newConsumer().WithName("other").Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(HaveLen(2))
+		Expect(db.Items(stream.DefaultCheckpointTable)).To(HaveLen(2))
+	})
+
+	It("should pass records again after a handler failed", func() {
+		saveUser("a", "alice")
+		saveUser("b", "bob")
+		saveUser("c", "carol")
+
+		failing := errors.New("failing")
+		fail := true
+		consumer := stream.Handle(stream.NewConsumer(db, db.Streams(), UserTableName), stream.Handler[User]{
+			OnInsert: func(ctx context.Context, event stream.Event[User]) error {
+				if event.New.UUID == "b" && fail {
+					return failing
+				}
+				handled.add(event)
+				return nil
+			},
+		})
+		Expect(consumer.Drain(ctx)).To(MatchError(failing))
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice"}))
+
+		fail = false
+		Expect(consumer.Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice", "INSERT b ->bob", "INSERT c ->carol"}))
+	})
+
+	It("should process children of split shards after their parents", func() {
+		saveUser("a", "alice")
+		db.SplitStreamShards(UserTableName, 2)
+		saveUser("a", "alicia")
+		db.SplitStreamShards(UserTableName, 1)
+		saveUser("a", "ali")
+
+		Expect(newConsumer().WithBatchSize(1).Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice", "MODIFY a alice->alicia", "MODIFY a alicia->ali"}))
+	})
+
+	It("should skip records that do not match", func() {
+		saveUser("a", "alice")
+		saveUser("admin#1", "root")
+		consumer := stream.Handle(stream.NewConsumer(db, db.Streams(), UserTableName), stream.Handler[User]{
+			Match: func(item map[string]*dynamodb.AttributeValue) bool {
+				return aws.StringValue(item["UUID"].S) != "admin#1"
+			},
+			OnInsert: record,
+		})
+		Expect(consumer.Drain(ctx)).To(Succeed())
+		Expect(handled.list()).To(Equal([]string{"INSERT a ->alice"}))
+	})
+
+	It("should process shards in parallel until it is canceled", func() {
+		db.SplitStreamShards(UserTableName, 4)
+		runCtx, cancel := context.WithCancel(ctx)
+		stopped := make(chan error)
+		go func() {
+			stopped <- newConsumer().WithPollInterval(10 * time.Millisecond).Run(runCtx)
+		}()
+
+		for i := 0; i < 20; i++ {
+			saveUser(fmt.Sprint(i), "name")
+		}
+		Eventually(func() []string { return handled.list() }).Should(HaveLen(20))
+		cancel()
+		Eventually(stopped).Should(Receive(BeNil()))
+	})
+
+	It("should fail for tables without stream", func() {
+		db.WithTable("Plain", "UUID", "")
+		consumer := stream.Handle(stream.NewConsumer(db, db.Streams(), "Plain"), stream.Handler[User]{OnInsert: record})
+		Expect(consumer.Drain(ctx)).To(MatchError(stream.ErrStreamNotEnabled))
+		Expect(stream.NewConsumer(db, db.Streams(), UserTableName).Drain(ctx)).To(MatchError("stream: no handler registered"))
+	})
+})
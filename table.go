@@ -0,0 +1,661 @@
+package djoemo
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/guregu/dynamo"
+)
+
+// TableSchema describes a table with its keys, secondary indexes, billing mode, time to live and stream;
+// SchemaOf derives it from a model
+type TableSchema struct {
+	Name     string
+	HashKey  string
+	RangeKey string
+	// AttributeTypes maps the key attributes of the table and its indexes to their type: S, N or B
+	AttributeTypes map[string]string
+	GlobalIndexes  []IndexSchema
+	LocalIndexes   []IndexSchema
+	// BillingMode is PAY_PER_REQUEST unless read or write units are set
+	BillingMode string
+	ReadUnits   int64
+	WriteUnits  int64
+	// TTLAttribute is the attribute holding the expiry time of items; time to live is disabled if it is empty
+	TTLAttribute string
+	// StreamView is the view type of the stream of the table, like NEW_AND_OLD_IMAGES; the stream is disabled if it is empty
+	StreamView string
+}
+
+// IndexSchema describes a secondary index; local indexes share the hash key of their table
+type IndexSchema struct {
+	Name     string
+	HashKey  string
+	RangeKey string
+	// Projection is the projection type of the index; ALL by default
+	Projection string
+	// NonKeyAttributes are the attributes projected by an INCLUDE projection
+	NonKeyAttributes []string
+	// ReadUnits and WriteUnits of global indexes default to the units of the table
+	ReadUnits  int64
+	WriteUnits int64
+}
+
+// SchemaModel is implemented by models that declare their table; the declared schema is merged into the schema
+// derived from the struct tags of the model, so it has to name the table at least
+type SchemaModel interface {
+	TableSchema() TableSchema
+}
+
+// SchemaOf returns the schema of a table; model is a TableSchema or a struct with tags like guregu/dynamo uses them:
+//
+//	type User struct {
+//		UUID      string `dynamo:",hash"`
+//		Email     string `index:"email-index,hash"`
+//		CreatedAt int64  `localIndex:"created-index,range"`
+//		ExpiresAt int64  `djoemo:"ttl"`
+//	}
+//
+//	func (User) TableSchema() djoemo.TableSchema {
+//		return djoemo.TableSchema{Name: "users", StreamView: dynamodb.StreamViewTypeNewImage}
+//	}
+func SchemaOf(model any) (TableSchema, error) {
+	var schema TableSchema
+	switch model := model.(type) {
+	case TableSchema:
+		schema = model
+	case *TableSchema:
+		schema = *model
+	default:
+		t := reflect.TypeOf(model)
+		for t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			return TableSchema{}, fmt.Errorf("djoemo: model of table schema must be a struct, got %T", model)
+		}
+		var err error
+		if schema, err = schemaFromTags(t); err != nil {
+			return TableSchema{}, err
+		}
+		declaring, ok := model.(SchemaModel)
+		if !ok {
+			declaring, ok = reflect.New(t).Interface().(SchemaModel)
+		}
+		if ok {
+			schema.merge(declaring.TableSchema())
+		}
+	}
+	return schema.normalized()
+}
+
+// CreateTable creates the table of model and waits until it is active; model is passed to SchemaOf
+func (repository Repository) CreateTable(ctx context.Context, model any) error {
+	schema, err := SchemaOf(model)
+	if err != nil {
+		return err
+	}
+	return repository.createTable(ctx, schema)
+}
+
+func (repository Repository) createTable(ctx context.Context, schema TableSchema) error {
+	if _, err := repository.client.CreateTableWithContext(ctx, schema.createTableInput()); err != nil {
+		return err
+	}
+	describe := &dynamodb.DescribeTableInput{TableName: aws.String(schema.Name)}
+	if err := repository.client.WaitUntilTableExistsWithContext(ctx, describe); err != nil {
+		return err
+	}
+	if schema.TTLAttribute == "" {
+		return nil
+	}
+	_, err := repository.client.UpdateTimeToLiveWithContext(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(schema.Name),
+		TimeToLiveSpecification: &dynamodb.TimeToLiveSpecification{
+			AttributeName: aws.String(schema.TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+// DescribeTable returns the schema of an existing table
+func (repository Repository) DescribeTable(ctx context.Context, tableName string) (TableSchema, error) {
+	output, err := repository.client.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return TableSchema{}, err
+	}
+	ttl, err := repository.client.DescribeTimeToLiveWithContext(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(tableName)})
+	if err != nil {
+		return TableSchema{}, err
+	}
+	return schemaFromDescription(output.Table, ttl.TimeToLiveDescription), nil
+}
+
+// EnsureTable creates the table of model if it does not exist yet; an existing table is compared with the schema of
+// model and not changed, differences fail with ErrTableSchemaMismatch. Throughput is not compared.
+func (repository Repository) EnsureTable(ctx context.Context, model any) error {
+	schema, err := SchemaOf(model)
+	if err != nil {
+		return err
+	}
+	actual, err := repository.DescribeTable(ctx, schema.Name)
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) && awsErr.Code() == dynamodb.ErrCodeResourceNotFoundException {
+		return repository.createTable(ctx, schema)
+	}
+	if err != nil {
+		return err
+	}
+	if differences := schema.differences(actual); len(differences) > 0 {
+		return fmt.Errorf("%w: table %s: %s", ErrTableSchemaMismatch, schema.Name, strings.Join(differences, "; "))
+	}
+	return nil
+}
+
+// schemaFromTags derives keys, indexes and the time to live attribute from the tags of the fields of t
+func schemaFromTags(t reflect.Type) (TableSchema, error) {
+	schema := TableSchema{AttributeTypes: make(map[string]string)}
+	indexes := make(map[string]*IndexSchema)
+	var order []string
+
+	var walk func(t reflect.Type) error
+	walk = func(t reflect.Type) error {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			options := strings.Split(field.Tag.Get("dynamo"), ",")
+			name := options[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if field.Anonymous && indirect(field.Type).Kind() == reflect.Struct {
+				if err := walk(indirect(field.Type)); err != nil {
+					return err
+				}
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Tag.Get("djoemo") == "ttl" {
+				schema.TTLAttribute = name
+			}
+
+			keyType := keyTypeOf(options[1:])
+			indexKeys := append(tagValues(field.Tag, "index"), tagValues(field.Tag, "localIndex")...)
+			if keyType == "" && len(indexKeys) == 0 {
+				continue
+			}
+			attributeType, err := attributeTypeOf(field.Type)
+			if err != nil {
+				return fmt.Errorf("djoemo: key attribute %s: %w", name, err)
+			}
+			schema.AttributeTypes[name] = attributeType
+
+			switch keyType {
+			case dynamodb.KeyTypeHash:
+				schema.HashKey = name
+			case dynamodb.KeyTypeRange:
+				schema.RangeKey = name
+			}
+
+			local := len(tagValues(field.Tag, "index"))
+			for n, value := range indexKeys {
+				parts := strings.Split(value, ",")
+				indexName := parts[0]
+				index, ok := indexes[indexName]
+				if !ok {
+					index = &IndexSchema{Name: indexName}
+					indexes[indexName] = index
+					order = append(order, indexName)
+					if n >= local {
+						// local indexes are marked by the hash key of the table, which is set on normalization
+						index.HashKey = localIndexMarker
+					}
+				}
+				switch keyTypeOf(parts[1:]) {
+				case dynamodb.KeyTypeHash:
+					index.HashKey = name
+				case dynamodb.KeyTypeRange:
+					index.RangeKey = name
+				default:
+					return fmt.Errorf("djoemo: index %s of attribute %s is neither hash nor range key", indexName, name)
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(t); err != nil {
+		return TableSchema{}, err
+	}
+
+	for _, name := range order {
+		index := *indexes[name]
+		if index.HashKey == localIndexMarker {
+			index.HashKey = ""
+			schema.LocalIndexes = append(schema.LocalIndexes, index)
+			continue
+		}
+		schema.GlobalIndexes = append(schema.GlobalIndexes, index)
+	}
+	return schema, nil
+}
+
+// localIndexMarker marks indexes derived from localIndex tags until all fields are read
+const localIndexMarker = "\x00local"
+
+// merge sets the fields of declared that are not empty; declared indexes replace derived indexes with the same name
+func (schema *TableSchema) merge(declared TableSchema) {
+	for _, value := range []struct {
+		target *string
+		value  string
+	}{
+		{&schema.Name, declared.Name},
+		{&schema.HashKey, declared.HashKey},
+		{&schema.RangeKey, declared.RangeKey},
+		{&schema.BillingMode, declared.BillingMode},
+		{&schema.TTLAttribute, declared.TTLAttribute},
+		{&schema.StreamView, declared.StreamView},
+	} {
+		if value.value != "" {
+			*value.target = value.value
+		}
+	}
+	if declared.ReadUnits != 0 || declared.WriteUnits != 0 {
+		schema.ReadUnits, schema.WriteUnits = declared.ReadUnits, declared.WriteUnits
+	}
+	for name, attributeType := range declared.AttributeTypes {
+		schema.AttributeTypes[name] = attributeType
+	}
+	schema.GlobalIndexes = mergeIndexes(schema.GlobalIndexes, declared.GlobalIndexes)
+	schema.LocalIndexes = mergeIndexes(schema.LocalIndexes, declared.LocalIndexes)
+}
+
+func mergeIndexes(derived []IndexSchema, declared []IndexSchema) []IndexSchema {
+	merged := append([]IndexSchema(nil), derived...)
+	for _, index := range declared {
+		replaced := false
+		for i := range merged {
+			if merged[i].Name == index.Name {
+				merged[i] = index
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged = append(merged, index)
+		}
+	}
+	return merged
+}
+
+// normalized validates the schema and sets its defaults; indexes are sorted by name
+func (schema TableSchema) normalized() (TableSchema, error) {
+	if schema.Name == "" {
+		return schema, ErrInvalidTableName
+	}
+	if schema.HashKey == "" {
+		return schema, fmt.Errorf("%w of table %s", ErrInvalidHashKeyName, schema.Name)
+	}
+	if schema.BillingMode == "" {
+		schema.BillingMode = dynamodb.BillingModePayPerRequest
+		if schema.ReadUnits != 0 || schema.WriteUnits != 0 {
+			schema.BillingMode = dynamodb.BillingModeProvisioned
+		}
+	}
+
+	normalize := func(indexes []IndexSchema, local bool) ([]IndexSchema, error) {
+		normalized := make([]IndexSchema, len(indexes))
+		for i, index := range indexes {
+			if local {
+				index.HashKey = schema.HashKey
+				if index.RangeKey == "" || schema.RangeKey == "" {
+					return nil, fmt.Errorf("djoemo: local index %s of table %s needs a range key and a table with range key", index.Name, schema.Name)
+				}
+			}
+			if index.Name == "" || index.HashKey == "" {
+				return nil, fmt.Errorf("djoemo: index %q of table %s needs a name and a hash key", index.Name, schema.Name)
+			}
+			if index.Projection == "" {
+				index.Projection = dynamodb.ProjectionTypeAll
+			}
+			index.NonKeyAttributes = append([]string(nil), index.NonKeyAttributes...)
+			sort.Strings(index.NonKeyAttributes)
+			if !local && schema.BillingMode == dynamodb.BillingModeProvisioned && index.ReadUnits == 0 && index.WriteUnits == 0 {
+				index.ReadUnits, index.WriteUnits = schema.ReadUnits, schema.WriteUnits
+			}
+			normalized[i] = index
+		}
+		sort.Slice(normalized, func(i, j int) bool { return normalized[i].Name < normalized[j].Name })
+		return normalized, nil
+	}
+	var err error
+	if schema.GlobalIndexes, err = normalize(schema.GlobalIndexes, false); err != nil {
+		return schema, err
+	}
+	if schema.LocalIndexes, err = normalize(schema.LocalIndexes, true); err != nil {
+		return schema, err
+	}
+
+	types := make(map[string]string)
+	for _, name := range schema.keyAttributes() {
+		attributeType, ok := schema.AttributeTypes[name]
+		if !ok {
+			return schema, fmt.Errorf("djoemo: type of key attribute %s of table %s is unknown", name, schema.Name)
+		}
+		types[name] = attributeType
+	}
+	schema.AttributeTypes = types
+	return schema, nil
+}
+
+// keyAttributes returns the names of the key attributes of the table and its indexes
+func (schema TableSchema) keyAttributes() []string {
+	names := []string{schema.HashKey, schema.RangeKey}
+	for _, index := range append(append([]IndexSchema(nil), schema.GlobalIndexes...), schema.LocalIndexes...) {
+		names = append(names, index.HashKey, index.RangeKey)
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (schema TableSchema) createTableInput() *dynamodb.CreateTableInput {
+	input := &dynamodb.CreateTableInput{
+		TableName:   aws.String(schema.Name),
+		KeySchema:   keySchema(schema.HashKey, schema.RangeKey),
+		BillingMode: aws.String(schema.BillingMode),
+	}
+	for _, name := range schema.keyAttributes() {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, &dynamodb.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: aws.String(schema.AttributeTypes[name]),
+		})
+	}
+	provisioned := schema.BillingMode == dynamodb.BillingModeProvisioned
+	if provisioned {
+		input.ProvisionedThroughput = throughput(schema.ReadUnits, schema.WriteUnits)
+	}
+	for _, index := range schema.GlobalIndexes {
+		gsi := &dynamodb.GlobalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  keySchema(index.HashKey, index.RangeKey),
+			Projection: index.projection(),
+		}
+		if provisioned {
+			gsi.ProvisionedThroughput = throughput(index.ReadUnits, index.WriteUnits)
+		}
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, gsi)
+	}
+	for _, index := range schema.LocalIndexes {
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, &dynamodb.LocalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  keySchema(index.HashKey, index.RangeKey),
+			Projection: index.projection(),
+		})
+	}
+	if schema.StreamView != "" {
+		input.StreamSpecification = &dynamodb.StreamSpecification{
+			StreamEnabled:  aws.Bool(true),
+			StreamViewType: aws.String(schema.StreamView),
+		}
+	}
+	return input
+}
+
+func (index IndexSchema) projection() *dynamodb.Projection {
+	projection := &dynamodb.Projection{ProjectionType: aws.String(index.Projection)}
+	if len(index.NonKeyAttributes) > 0 {
+		projection.NonKeyAttributes = aws.StringSlice(index.NonKeyAttributes)
+	}
+	return projection
+}
+
+func keySchema(hashKey string, rangeKey string) []*dynamodb.KeySchemaElement {
+	elements := []*dynamodb.KeySchemaElement{{AttributeName: aws.String(hashKey), KeyType: aws.String(dynamodb.KeyTypeHash)}}
+	if rangeKey != "" {
+		elements = append(elements, &dynamodb.KeySchemaElement{AttributeName: aws.String(rangeKey), KeyType: aws.String(dynamodb.KeyTypeRange)})
+	}
+	return elements
+}
+
+func throughput(readUnits int64, writeUnits int64) *dynamodb.ProvisionedThroughput {
+	return &dynamodb.ProvisionedThroughput{ReadCapacityUnits: aws.Int64(readUnits), WriteCapacityUnits: aws.Int64(writeUnits)}
+}
+
+// schemaFromDescription converts the description of a table to its schema
+func schemaFromDescription(table *dynamodb.TableDescription, ttl *dynamodb.TimeToLiveDescription) TableSchema {
+	schema := TableSchema{
+		Name:           aws.StringValue(table.TableName),
+		AttributeTypes: make(map[string]string),
+		BillingMode:    dynamodb.BillingModeProvisioned,
+	}
+	schema.HashKey, schema.RangeKey = keysOf(table.KeySchema)
+	for _, definition := range table.AttributeDefinitions {
+		schema.AttributeTypes[aws.StringValue(definition.AttributeName)] = aws.StringValue(definition.AttributeType)
+	}
+	if table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode != nil {
+		schema.BillingMode = aws.StringValue(table.BillingModeSummary.BillingMode)
+	}
+	if table.ProvisionedThroughput != nil {
+		schema.ReadUnits = aws.Int64Value(table.ProvisionedThroughput.ReadCapacityUnits)
+		schema.WriteUnits = aws.Int64Value(table.ProvisionedThroughput.WriteCapacityUnits)
+	}
+	if table.StreamSpecification != nil && aws.BoolValue(table.StreamSpecification.StreamEnabled) {
+		schema.StreamView = aws.StringValue(table.StreamSpecification.StreamViewType)
+	}
+	if ttl != nil && aws.StringValue(ttl.TimeToLiveStatus) != dynamodb.TimeToLiveStatusDisabled {
+		schema.TTLAttribute = aws.StringValue(ttl.AttributeName)
+	}
+
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		index := IndexSchema{Name: aws.StringValue(gsi.IndexName)}
+		index.HashKey, index.RangeKey = keysOf(gsi.KeySchema)
+		index.Projection, index.NonKeyAttributes = projectionOf(gsi.Projection)
+		if gsi.ProvisionedThroughput != nil {
+			index.ReadUnits = aws.Int64Value(gsi.ProvisionedThroughput.ReadCapacityUnits)
+			index.WriteUnits = aws.Int64Value(gsi.ProvisionedThroughput.WriteCapacityUnits)
+		}
+		schema.GlobalIndexes = append(schema.GlobalIndexes, index)
+	}
+	for _, lsi := range table.LocalSecondaryIndexes {
+		index := IndexSchema{Name: aws.StringValue(lsi.IndexName)}
+		index.HashKey, index.RangeKey = keysOf(lsi.KeySchema)
+		index.Projection, index.NonKeyAttributes = projectionOf(lsi.Projection)
+		schema.LocalIndexes = append(schema.LocalIndexes, index)
+	}
+	sort.Slice(schema.GlobalIndexes, func(i, j int) bool { return schema.GlobalIndexes[i].Name < schema.GlobalIndexes[j].Name })
+	sort.Slice(schema.LocalIndexes, func(i, j int) bool { return schema.LocalIndexes[i].Name < schema.LocalIndexes[j].Name })
+	return schema
+}
+
+func keysOf(elements []*dynamodb.KeySchemaElement) (hashKey string, rangeKey string) {
+	for _, element := range elements {
+		switch aws.StringValue(element.KeyType) {
+		case dynamodb.KeyTypeHash:
+			hashKey = aws.StringValue(element.AttributeName)
+		case dynamodb.KeyTypeRange:
+			rangeKey = aws.StringValue(element.AttributeName)
+		}
+	}
+	return hashKey, rangeKey
+}
+
+func projectionOf(projection *dynamodb.Projection) (string, []string) {
+	if projection == nil {
+		return dynamodb.ProjectionTypeAll, nil
+	}
+	attributes := aws.StringValueSlice(projection.NonKeyAttributes)
+	sort.Strings(attributes)
+	if len(attributes) == 0 {
+		attributes = nil
+	}
+	return aws.StringValue(projection.ProjectionType), attributes
+}
+
+// differences lists how the actual table differs from the schema; throughput is ignored
+func (schema TableSchema) differences(actual TableSchema) []string {
+	var differences []string
+	differ := func(what string, expected string, actual string) {
+		if expected != actual {
+			differences = append(differences, fmt.Sprintf("%s is %s, expected %s", what, quoted(actual), quoted(expected)))
+		}
+	}
+
+	differ("hash key", schema.HashKey, actual.HashKey)
+	differ("range key", schema.RangeKey, actual.RangeKey)
+	for _, name := range schema.keyAttributes() {
+		differ("type of "+name, schema.AttributeTypes[name], actual.AttributeTypes[name])
+	}
+	differ("billing mode", schema.BillingMode, actual.BillingMode)
+	differ("time to live attribute", schema.TTLAttribute, actual.TTLAttribute)
+	differ("stream view", schema.StreamView, actual.StreamView)
+
+	compareIndexes := func(kind string, expected []IndexSchema, actual []IndexSchema) {
+		indexes := make(map[string]IndexSchema)
+		for _, index := range actual {
+			indexes[index.Name] = index
+		}
+		for _, index := range expected {
+			other, ok := indexes[index.Name]
+			if !ok {
+				differences = append(differences, fmt.Sprintf("%s %s is missing", kind, index.Name))
+				continue
+			}
+			delete(indexes, index.Name)
+			what := kind + " " + index.Name
+			differ(what+" hash key", index.HashKey, other.HashKey)
+			differ(what+" range key", index.RangeKey, other.RangeKey)
+			differ(what+" projection", index.Projection, other.Projection)
+			differ(what+" projected attributes", strings.Join(index.NonKeyAttributes, ", "), strings.Join(other.NonKeyAttributes, ", "))
+		}
+		for _, index := range actual {
+			if _, ok := indexes[index.Name]; ok {
+				differences = append(differences, fmt.Sprintf("%s %s is not in the model", kind, index.Name))
+			}
+		}
+	}
+	compareIndexes("global index", schema.GlobalIndexes, actual.GlobalIndexes)
+	compareIndexes("local index", schema.LocalIndexes, actual.LocalIndexes)
+	return differences
+}
+
+func quoted(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return strconv.Quote(s)
+}
+
+// keyTypeOf returns the key type of the options of a tag like guregu/dynamo reads them
+func keyTypeOf(options []string) string {
+	for _, option := range options {
+		switch option {
+		case "hash", "partition":
+			return dynamodb.KeyTypeHash
+		case "range", "sort":
+			return dynamodb.KeyTypeRange
+		}
+	}
+	return ""
+}
+
+var (
+	textMarshalerType     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	dynamoMarshalerType   = reflect.TypeOf((*dynamo.Marshaler)(nil)).Elem()
+	sdkMarshalerType      = reflect.TypeOf((*dynamodbattribute.Marshaler)(nil)).Elem()
+	errNoKeyAttributeType = errors.New("type is neither string, number nor binary")
+)
+
+// attributeTypeOf returns the type of the attribute a field of type t is marshalled to; like guregu/dynamo, methods
+// with pointer receivers only marshal pointer fields
+func attributeTypeOf(t reflect.Type) (string, error) {
+	if t.Implements(dynamoMarshalerType) || t.Implements(sdkMarshalerType) {
+		zero := reflect.New(t).Elem()
+		if t.Kind() == reflect.Ptr {
+			zero = reflect.New(t.Elem())
+		}
+		av, err := dynamo.Marshal(zero.Interface())
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case av != nil && av.S != nil:
+			return dynamodb.ScalarAttributeTypeS, nil
+		case av != nil && av.N != nil:
+			return dynamodb.ScalarAttributeTypeN, nil
+		case av != nil && av.B != nil:
+			return dynamodb.ScalarAttributeTypeB, nil
+		}
+		return "", errNoKeyAttributeType
+	}
+	if t.Implements(textMarshalerType) {
+		return dynamodb.ScalarAttributeTypeS, nil
+	}
+
+	switch t := indirect(t); t.Kind() {
+	case reflect.String:
+		return dynamodb.ScalarAttributeTypeS, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return dynamodb.ScalarAttributeTypeN, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return dynamodb.ScalarAttributeTypeB, nil
+		}
+	}
+	return "", errNoKeyAttributeType
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// tagValues returns the values of all occurrences of key in tag; guregu/dynamo allows to repeat index tags
+func tagValues(tag reflect.StructTag, key string) []string {
+	var values []string
+	for tag != "" {
+		tag = reflect.StructTag(strings.TrimLeft(string(tag), " "))
+		colon := strings.Index(string(tag), `:"`)
+		if colon <= 0 {
+			break
+		}
+		name := string(tag[:colon])
+		quoted, err := strconv.QuotedPrefix(string(tag[colon+1:]))
+		if err != nil {
+			break
+		}
+		tag = tag[colon+1+len(quoted):]
+		if name != key {
+			continue
+		}
+		if value, err := strconv.Unquote(quoted); err == nil {
+			values = append(values, value)
+		}
+	}
+	return values
+}
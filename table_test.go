@@ -0,0 +1,189 @@
+package djoemo_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Order model declaring its table by struct tags and TableSchema
+type Order struct {
+	djoemo.Model
+	CustomerID string             `dynamo:",hash"`
+	OrderID    string             `dynamo:",range" index:"order-index,hash"`
+	Status     string             `index:"status-index,hash"`
+	Total      float64            `localIndex:"total-index,range" index:"status-index,range"`
+	PlacedAt   *djoemo.DjoemoTime `localIndex:"placed-index,range"`
+	ExpiresAt  int64              `djoemo:"ttl"`
+	Notes      string             `dynamo:"-" index:"ignored-index,hash"`
+}
+
+func (Order) TableSchema() djoemo.TableSchema {
+	return djoemo.TableSchema{
+		Name:       "OrderTable",
+		StreamView: dynamodb.StreamViewTypeNewAndOldImages,
+		GlobalIndexes: []djoemo.IndexSchema{
+			{Name: "order-index", HashKey: "OrderID", Projection: dynamodb.ProjectionTypeKeysOnly},
+		},
+	}
+}
+
+var _ = Describe("Table", func() {
+	const OrderTableName = "OrderTable"
+
+	var (
+		db         *fake.DB
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+	)
+
+	BeforeEach(func() {
+		db = fake.New()
+		repository = djoemo.NewRepository(db)
+		ctx = context.Background()
+	})
+
+	Describe("SchemaOf", func() {
+		It("should derive the schema from struct tags and the declared schema", func() {
+			schema, err := djoemo.SchemaOf(&Order{})
+			Expect(err).To(BeNil())
+			Expect(schema).To(Equal(djoemo.TableSchema{
+				Name:     OrderTableName,
+				HashKey:  "CustomerID",
+				RangeKey: "OrderID",
+				AttributeTypes: map[string]string{
+					"CustomerID": "S", "OrderID": "S", "Status": "S", "Total": "N", "PlacedAt": "N",
+				},
+				GlobalIndexes: []djoemo.IndexSchema{
+					{Name: "order-index", HashKey: "OrderID", Projection: dynamodb.ProjectionTypeKeysOnly},
+					{Name: "status-index", HashKey: "Status", RangeKey: "Total", Projection: dynamodb.ProjectionTypeAll},
+				},
+				LocalIndexes: []djoemo.IndexSchema{
+					{Name: "placed-index", HashKey: "CustomerID", RangeKey: "PlacedAt", Projection: dynamodb.ProjectionTypeAll},
+					{Name: "total-index", HashKey: "CustomerID", RangeKey: "Total", Projection: dynamodb.ProjectionTypeAll},
+				},
+				BillingMode:  dynamodb.BillingModePayPerRequest,
+				TTLAttribute: "ExpiresAt",
+				StreamView:   dynamodb.StreamViewTypeNewAndOldImages,
+			}))
+		})
+
+		It("should fail without table name", func() {
+			_, err := djoemo.SchemaOf(User{})
+			Expect(err).To(Equal(djoemo.ErrInvalidTableName))
+		})
+
+		It("should fail without hash key", func() {
+			_, err := djoemo.SchemaOf(djoemo.TableSchema{Name: OrderTableName})
+			Expect(err).To(MatchError(djoemo.ErrInvalidHashKeyName))
+		})
+
+		It("should fail for keys of unknown type", func() {
+			_, err := djoemo.SchemaOf(djoemo.TableSchema{Name: OrderTableName, HashKey: "UUID"})
+			Expect(err).To(MatchError(ContainSubstring("type of key attribute UUID of table OrderTable is unknown")))
+		})
+	})
+
+	Describe("CreateTable", func() {
+		It("should create a table with indexes, time to live and stream", func() {
+			Expect(repository.CreateTable(ctx, Order{})).To(Succeed())
+
+			output, err := db.DescribeTable(&dynamodb.DescribeTableInput{TableName: aws.String(OrderTableName)})
+			Expect(err).To(BeNil())
+			Expect(output.Table.GlobalSecondaryIndexes).To(HaveLen(2))
+			Expect(output.Table.LocalSecondaryIndexes).To(HaveLen(2))
+			Expect(aws.StringValue(output.Table.StreamSpecification.StreamViewType)).To(Equal(dynamodb.StreamViewTypeNewAndOldImages))
+
+			ttl, err := db.DescribeTimeToLive(&dynamodb.DescribeTimeToLiveInput{TableName: aws.String(OrderTableName)})
+			Expect(err).To(BeNil())
+			Expect(aws.StringValue(ttl.TimeToLiveDescription.AttributeName)).To(Equal("ExpiresAt"))
+
+			expires := time.Now().Add(time.Hour).Unix()
+			key := djoemo.Key().WithTableName(OrderTableName).WithHashKeyName("CustomerID").WithHashKey("customer").
+				WithRangeKeyName("OrderID").WithRangeKey("order")
+			Expect(repository.SaveItemWithContext(ctx, key, &Order{CustomerID: "customer", OrderID: "order", Status: "open", ExpiresAt: expires})).To(Succeed())
+			Expect(db.Items(OrderTableName)).To(HaveLen(1))
+		})
+
+		It("should create provisioned tables", func() {
+			Expect(repository.CreateTable(ctx, djoemo.TableSchema{
+				Name:           OrderTableName,
+				HashKey:        "CustomerID",
+				AttributeTypes: map[string]string{"CustomerID": "S", "Status": "S"},
+				GlobalIndexes:  []djoemo.IndexSchema{{Name: "status-index", HashKey: "Status"}},
+				ReadUnits:      5,
+				WriteUnits:     2,
+			})).To(Succeed())
+
+			schema, err := repository.DescribeTable(ctx, OrderTableName)
+			Expect(err).To(BeNil())
+			Expect(schema.BillingMode).To(Equal(dynamodb.BillingModeProvisioned))
+			Expect(schema.ReadUnits).To(Equal(int64(5)))
+			Expect(schema.GlobalIndexes[0].WriteUnits).To(Equal(int64(2)))
+		})
+
+		It("should fail if the table exists", func() {
+			db.WithTable(OrderTableName, "CustomerID", "OrderID")
+			err := repository.CreateTable(ctx, Order{})
+			Expect(err).To(MatchError(ContainSubstring(dynamodb.ErrCodeResourceInUseException)))
+		})
+	})
+
+	Describe("DescribeTable", func() {
+		It("should describe the schema of a created table", func() {
+			Expect(repository.CreateTable(ctx, Order{})).To(Succeed())
+
+			expected, _ := djoemo.SchemaOf(Order{})
+			schema, err := repository.DescribeTable(ctx, OrderTableName)
+			Expect(err).To(BeNil())
+			Expect(schema.HashKey).To(Equal(expected.HashKey))
+			Expect(schema.RangeKey).To(Equal(expected.RangeKey))
+			Expect(schema.AttributeTypes).To(Equal(expected.AttributeTypes))
+			Expect(schema.GlobalIndexes).To(HaveLen(2))
+			Expect(schema.GlobalIndexes[0].Projection).To(Equal(dynamodb.ProjectionTypeKeysOnly))
+			Expect(schema.LocalIndexes[1].RangeKey).To(Equal("Total"))
+			Expect(schema.TTLAttribute).To(Equal("ExpiresAt"))
+			Expect(schema.StreamView).To(Equal(expected.StreamView))
+		})
+
+		It("should return the error of a missing table", func() {
+			_, err := repository.DescribeTable(ctx, OrderTableName)
+			Expect(err).To(MatchError(ContainSubstring(dynamodb.ErrCodeResourceNotFoundException)))
+		})
+	})
+
+	Describe("EnsureTable", func() {
+		It("should create a missing table once", func() {
+			Expect(repository.EnsureTable(ctx, Order{})).To(Succeed())
+			Expect(repository.EnsureTable(ctx, &Order{})).To(Succeed())
+
+			output, err := db.ListTables(&dynamodb.ListTablesInput{})
+			Expect(err).To(BeNil())
+			Expect(aws.StringValueSlice(output.TableNames)).To(Equal([]string{OrderTableName}))
+		})
+
+		It("should list the differences of an existing table", func() {
+			db.WithTable(OrderTableName, "CustomerID", "OrderID").
+				WithGlobalIndex(OrderTableName, "status-index", "Status", "").
+				WithGlobalIndex(OrderTableName, "legacy-index", "Legacy", "")
+
+			err := repository.EnsureTable(ctx, Order{})
+			Expect(err).To(MatchError(djoemo.ErrTableSchemaMismatch))
+			Expect(err).To(MatchError(ContainSubstring("table OrderTable: ")))
+			Expect(err).To(MatchError(ContainSubstring(`billing mode is "PROVISIONED", expected "PAY_PER_REQUEST"`)))
+			Expect(err).To(MatchError(ContainSubstring(`time to live attribute is none, expected "ExpiresAt"`)))
+			Expect(err).To(MatchError(ContainSubstring("global index order-index is missing")))
+			Expect(err).To(MatchError(ContainSubstring(`global index status-index range key is none, expected "Total"`)))
+			Expect(err).To(MatchError(ContainSubstring("global index legacy-index is not in the model")))
+			Expect(err).To(MatchError(ContainSubstring("local index total-index is missing")))
+		})
+	})
+})
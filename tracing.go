@@ -0,0 +1,156 @@
+package djoemo
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer creating the spans of djoemo
+const instrumentationName = "github.com/adjoeio/djoemo"
+
+const (
+	attrDBSystem      = attribute.Key("db.system")
+	attrDBOperation   = attribute.Key("db.operation")
+	attrTableNames    = attribute.Key("aws.dynamodb.table_names")
+	attrIndexName     = attribute.Key("aws.dynamodb.index_name")
+	attrItemCount     = attribute.Key("aws.dynamodb.item_count")
+	attrConsumedRead  = attribute.Key("aws.dynamodb.consumed_read_capacity")
+	attrConsumedWrite = attribute.Key("aws.dynamodb.consumed_write_capacity")
+	attrAttempt       = attribute.Key("djoemo.attempt")
+	attrErrorType     = attribute.Key("error.type")
+)
+
+const (
+	dbSystemDynamoDB   = "dynamodb"
+	spanNameRetry      = "retry"
+	spanNameCallPrefix = "DynamoDB."
+)
+
+func defaultTracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// operationSpan traces a repository or index operation and the capacity consumed by it
+type operationSpan struct {
+	trace.Span
+	ctx      context.Context
+	consumed *ConsumedCapacity
+	out      any
+	slow     *slowOperation
+}
+
+// startOperationSpan starts the span of an operation on the tables of keys; the returned context carries a recording span,
+// so retries and requests to DynamoDB become its children. The operation is measured for the slow log of client, if any
+func startOperationSpan(ctx context.Context, tracer trace.Tracer, client *apiClient, operation string, indexName string, keys ...KeyInterface) (context.Context, *operationSpan) {
+	var tableNames []string
+	for _, key := range keys {
+		if key != nil && !slices.Contains(tableNames, key.TableName()) {
+			tableNames = append(tableNames, key.TableName())
+		}
+	}
+
+	attributes := []attribute.KeyValue{
+		attrDBSystem.String(dbSystemDynamoDB),
+		attrDBOperation.String(operation),
+		attrTableNames.StringSlice(tableNames),
+	}
+	if indexName != "" {
+		attributes = append(attributes, attrIndexName.String(indexName))
+	}
+
+	name := operation
+	if len(tableNames) == 1 {
+		name += " " + tableNames[0]
+	}
+	if tracer == nil {
+		tracer = defaultTracer()
+	}
+
+	ctx, slow := withSlowOperation(ctx, client, operation, tableNames, indexName)
+	spanCtx, span := tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+	if !span.IsRecording() {
+		// keep the context of the caller if tracing is disabled
+		return ctx, &operationSpan{Span: span, ctx: ctx, slow: slow}
+	}
+
+	spanCtx, consumed := WithConsumedCapacity(spanCtx)
+	return spanCtx, &operationSpan{Span: span, ctx: ctx, consumed: consumed, slow: slow}
+}
+
+// setItemCount records the number of items read or written by the operation
+func (s *operationSpan) setItemCount(n int) {
+	s.SetAttributes(attrItemCount.Int(n))
+}
+
+// countItems records the length of the slice out points to as item count when the span ends
+func (s *operationSpan) countItems(out any) {
+	s.out = out
+}
+
+// end records the consumed capacity and the error of the operation, ends the span and keeps the operation in the
+// slow log if it was slow; errors that do not fail the operation, like a missing item, are not recorded
+func (s *operationSpan) end(err *error) {
+	if p, ok := s.out.(*Polymorphic); ok {
+		s.setItemCount(p.Len())
+	} else if s.out != nil && IsPointerOFSlice(s.out) {
+		s.setItemCount(sliceLen(s.out))
+	}
+	if s.consumed != nil {
+		total := s.consumed.Total()
+		s.SetAttributes(attrConsumedRead.Float64(total.ReadUnits), attrConsumedWrite.Float64(total.WriteUnits))
+	}
+	var opErr error
+	if !isOpSuccess(err) {
+		opErr = *err
+		recordSpanError(s.Span, opErr)
+	}
+	s.slow.end(s.ctx, opErr)
+	s.End()
+}
+
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.SetAttributes(attrErrorType.String(string(ClassifyError(err))))
+}
+
+// childTracer returns a tracer of the provider that created the span in ctx
+func childTracer(ctx context.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(instrumentationName)
+}
+
+// startCallSpan starts the span of a request to DynamoDB, e.g. a page of a query or a chunk of a batch
+func startCallSpan(ctx context.Context, name string, usage capacityUsage) (context.Context, trace.Span) {
+	var (
+		tableNames []string
+		indexName  string
+	)
+	for key := range usage {
+		if !slices.Contains(tableNames, key.table) {
+			tableNames = append(tableNames, key.table)
+		}
+		if key.index != "" {
+			indexName = key.index
+		}
+	}
+	slices.Sort(tableNames)
+
+	attributes := []attribute.KeyValue{
+		attrDBSystem.String(dbSystemDynamoDB),
+		attrDBOperation.String(name),
+		attrTableNames.StringSlice(tableNames),
+	}
+	if indexName != "" {
+		attributes = append(attributes, attrIndexName.String(indexName))
+	}
+
+	return childTracer(ctx).Start(ctx, spanNameCallPrefix+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+}
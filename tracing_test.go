@@ -0,0 +1,156 @@
+package djoemo_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing", func() {
+	const (
+		UserTableName = "UserTable"
+		IndexName     = "email-index"
+	)
+
+	var (
+		dAPIMock   *mock.MockDynamoDBAPI
+		repository djoemo.RepositoryInterface
+		recorder   *tracetest.SpanRecorder
+		key        djoemo.KeyInterface
+	)
+
+	spanNamed := func(name string) sdktrace.ReadOnlySpan {
+		for _, span := range recorder.Ended() {
+			if span.Name() == name {
+				return span
+			}
+		}
+		Fail("no span named " + name)
+		return nil
+	}
+
+	attributeOf := func(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+		for _, kv := range span.Attributes() {
+			if kv.Key == key {
+				return kv.Value
+			}
+		}
+		return attribute.Value{}
+	}
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+
+		recorder = tracetest.NewSpanRecorder()
+		repository.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		key = djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey("uuid")
+	})
+
+	It("should trace an operation and its request to DynamoDB", func() {
+		item, _ := dynamodbattribute.MarshalMap(map[string]interface{}{"UUID": "uuid"})
+		dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(&dynamodb.GetItemOutput{
+			Item: item,
+			ConsumedCapacity: &dynamodb.ConsumedCapacity{
+				TableName:     aws.String(UserTableName),
+				CapacityUnits: aws.Float64(0.5),
+			},
+		}, nil)
+
+		found, err := repository.GetItemWithContext(context.Background(), key, &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+
+		operation := spanNamed("GetItem " + UserTableName)
+		Expect(attributeOf(operation, "db.system").AsString()).To(Equal("dynamodb"))
+		Expect(attributeOf(operation, "db.operation").AsString()).To(Equal("GetItem"))
+		Expect(attributeOf(operation, "aws.dynamodb.table_names").AsStringSlice()).To(Equal([]string{UserTableName}))
+		Expect(attributeOf(operation, "aws.dynamodb.item_count").AsInt64()).To(Equal(int64(1)))
+		Expect(attributeOf(operation, "aws.dynamodb.consumed_read_capacity").AsFloat64()).To(Equal(0.5))
+		Expect(operation.Status().Code).To(Equal(codes.Unset))
+
+		call := spanNamed("DynamoDB.GetItem")
+		Expect(call.Parent().SpanID()).To(Equal(operation.SpanContext().SpanID()))
+	})
+
+	It("should trace retries as child spans", func() {
+		repository.WithRetryPolicy(djoemo.RetryPolicy{
+			MaxAttempts: 2,
+			Backoff:     djoemo.ConstantBackoff(time.Millisecond),
+			RetryOn:     []djoemo.ErrorClass{djoemo.ErrorClassThroughputExceeded},
+		})
+		throttled := awserr.NewRequestFailure(awserr.New(dynamodb.ErrCodeProvisionedThroughputExceededException, "throughput exceeded", nil), 400, "request-id")
+		gomock.InOrder(
+			dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, throttled),
+			dAPIMock.EXPECT().PutItemWithContext(gomock.Any(), gomock.Any(), gomock.Any()).Return(&dynamodb.PutItemOutput{}, nil),
+		)
+
+		Expect(repository.SaveItemWithContext(context.Background(), key, &User{UUID: "uuid"})).To(BeNil())
+
+		operation := spanNamed("SaveItem " + UserTableName)
+		retry := spanNamed("retry")
+		Expect(retry.Parent().SpanID()).To(Equal(operation.SpanContext().SpanID()))
+		Expect(attributeOf(retry, "djoemo.attempt").AsInt64()).To(Equal(int64(2)))
+	})
+
+	It("should record errors", func() {
+		dAPIMock.EXPECT().DeleteItemWithContext(gomock.Any(), gomock.Any()).Return(nil, awserr.New("ValidationException", "invalid", nil))
+
+		Expect(repository.DeleteItemWithContext(context.Background(), key)).ToNot(BeNil())
+
+		operation := spanNamed("DeleteItem " + UserTableName)
+		Expect(operation.Status().Code).To(Equal(codes.Error))
+		Expect(attributeOf(operation, "error.type").AsString()).To(Equal(string(djoemo.ErrorClassOther)))
+	})
+
+	It("should trace index operations with the index name", func() {
+		dAPIMock.EXPECT().QueryWithContext(gomock.Any(), gomock.Any()).Return(&dynamodb.QueryOutput{}, nil)
+
+		query := djoemo.Query().WithTableName(UserTableName).WithHashKeyName("Email").WithHashKey("email")
+		Expect(repository.GIndex(IndexName).QueryWithContext(context.Background(), query, &[]User{})).To(BeNil())
+
+		operation := spanNamed("Query " + UserTableName)
+		Expect(attributeOf(operation, "aws.dynamodb.index_name").AsString()).To(Equal(IndexName))
+		Expect(attributeOf(operation, "aws.dynamodb.item_count").AsInt64()).To(Equal(int64(0)))
+		Expect(attributeOf(spanNamed("DynamoDB.Query"), "aws.dynamodb.index_name").AsString()).To(Equal(IndexName))
+	})
+
+	It("should link prometheus samples to the active span", func() {
+		registry := prometheus.NewRegistry()
+		metrics, err := djoemo.NewPrometheusMetrics(registry)
+		Expect(err).To(BeNil())
+
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		ctx, span := provider.Tracer("test").Start(context.Background(), "test")
+		metrics.RecordRetry(ctx, djoemo.OpRead, key, djoemo.ErrorClassThrottling)
+		span.End()
+
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+		Expect(families).To(HaveLen(1))
+
+		exemplar := families[0].GetMetric()[0].GetCounter().GetExemplar()
+		Expect(exemplar).ToNot(BeNil())
+		Expect(exemplar.GetLabel()).To(ContainElement(HaveField("GetValue()", span.SpanContext().TraceID().String())))
+	})
+})
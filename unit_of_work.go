@@ -0,0 +1,678 @@
+package djoemo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/guregu/dynamo"
+
+	"github.com/adjoeio/djoemo/internal/expr"
+)
+
+// MaxTransactionItems is the maximum number of write operations DynamoDB accepts in a single transaction;
+// a unit of work with more pending writes is committed as chunked batches instead
+var MaxTransactionItems = 100
+
+type unitOfWorkContextKey int
+
+const unitOfWorkCtxKey unitOfWorkContextKey = iota
+
+type pendingOp int
+
+const (
+	pendingNone pendingOp = iota
+	pendingSave
+	pendingUpdate
+	pendingDelete
+)
+
+// unitOfWorkEntry is the tracked state of a single item, identified by table name, hash and range key
+type unitOfWorkEntry struct {
+	repository Repository
+	key        KeyInterface
+	// item is the loaded or saved instance handed out by the identity map
+	item any
+	// copies are the copies of a loaded item handed out to pointers of its type; their modifications are committed too
+	copies []any
+	// snapshot is the marshalled state of item when it was loaded; used to detect modifications
+	snapshot map[string]*dynamodb.AttributeValue
+	// loaded is set once the state of the item is known, either read from the table or written in this unit of work
+	loaded bool
+	found  bool
+
+	op pendingOp
+	// updateExpressions are the expressions of a pending update, or of updates applied to the item of a pending save
+	updateExpressions UpdateExpressions
+}
+
+// pendingWrite is a write flushed by Commit
+type pendingWrite struct {
+	entry *unitOfWorkEntry
+	op    pendingOp
+	// item is the item put by a save
+	item any
+}
+
+// UnitOfWork is a request-scoped identity map that collects writes and flushes them on Commit.
+// While a unit of work is bound to a context, GetItemWithContext returns the already loaded instance
+// for a key instead of reading it again, and SaveItemWithContext, SaveItemsWithContext, UpdateWithContext,
+// UpdateWithUpdateExpressions, DeleteItemWithContext and DeleteItemsWithContext are deferred until Commit.
+// Operations returning values or evaluating conditions are always executed immediately.
+// Updates of a pending save are applied to its item when it is committed; updates of a pending delete are rejected
+// with ErrUnitOfWorkConflict.
+// Getters passing a pointer to a pointer receive the tracked instance itself; getters passing a pointer of its type
+// receive a copy, whose modifications are committed like those of the instance. Commit fails with ErrUnitOfWorkConflict
+// if copies are modified differently.
+// Domain events of the contexts of deferred writes, see WithDomainEvents, are written to the outbox by Commit.
+type UnitOfWork struct {
+	sync.Mutex
+	entries map[string]*unitOfWorkEntry
+	order   []string
+	events  []outboxPut
+}
+
+// NewUnitOfWork creates a unit of work and binds it to the returned context
+func NewUnitOfWork(ctx context.Context) (context.Context, *UnitOfWork) {
+	uow := &UnitOfWork{
+		entries: make(map[string]*unitOfWorkEntry),
+	}
+	return context.WithValue(ctx, unitOfWorkCtxKey, uow), uow
+}
+
+// UnitOfWorkFromContext returns the unit of work bound to ctx, or nil if there is none
+func UnitOfWorkFromContext(ctx context.Context) *UnitOfWork {
+	uow, ok := ctx.Value(unitOfWorkCtxKey).(*UnitOfWork)
+	if !ok {
+		return nil
+	}
+	return uow
+}
+
+// withoutUnitOfWork detaches the unit of work from ctx, so operations run against it are executed immediately
+func withoutUnitOfWork(ctx context.Context) context.Context {
+	return context.WithValue(ctx, unitOfWorkCtxKey, (*UnitOfWork)(nil))
+}
+
+// Pending returns the number of writes Commit would currently flush, including modified loaded items
+func (uow *UnitOfWork) Pending() (int, error) {
+	uow.Lock()
+	defer uow.Unlock()
+
+	writes, err := uow.pendingWrites(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return len(writes), nil
+}
+
+// Discard drops all tracked items and pending writes
+func (uow *UnitOfWork) Discard() {
+	uow.Lock()
+	defer uow.Unlock()
+
+	uow.entries = make(map[string]*unitOfWorkEntry)
+	uow.order = nil
+	uow.events = nil
+}
+
+// Commit flushes all pending saves, updates and deletes, as well as loaded items that were modified since they were read.
+// Up to MaxTransactionItems writes are executed as a single transaction; larger units of work are written as chunked batches.
+// Units of work with domain events are always written as a single transaction, together with the events, and fail with
+// ErrOutboxTransactionTooLarge if they exceed MaxTransactionItems. All tracked state is reset after a successful commit.
+func (uow *UnitOfWork) Commit(ctx context.Context) error {
+	uow.Lock()
+	defer uow.Unlock()
+
+	writes, err := uow.pendingWrites(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(writes) > 0 {
+		keys := make([]KeyInterface, len(writes))
+		for i, write := range writes {
+			keys[i] = write.entry.key
+		}
+		var span *operationSpan
+		ctx, span = writes[0].entry.repository.startSpan(ctx, "Commit", keys...)
+		defer span.end(&err)
+		span.setItemCount(len(writes))
+
+		switch {
+		case len(uow.events) > 0 && len(writes)+len(uow.events) > MaxTransactionItems:
+			err = fmt.Errorf("%w: %d writes and %d events", ErrOutboxTransactionTooLarge, len(writes), len(uow.events))
+		case len(writes)+len(uow.events) <= MaxTransactionItems:
+			err = commitTransaction(ctx, writes, uow.events)
+		default:
+			err = commitBatches(ctx, writes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	uow.entries = make(map[string]*unitOfWorkEntry)
+	uow.order = nil
+	uow.events = nil
+	return nil
+}
+
+// pendingWrites returns the writes of the entries in registration order without changing the entries; loaded items
+// modified in memory are saved and updates of pending saves are applied to the saved items
+func (uow *UnitOfWork) pendingWrites(ctx context.Context) ([]pendingWrite, error) {
+	var writes []pendingWrite
+	for _, id := range uow.order {
+		entry := uow.entries[id]
+		write := pendingWrite{entry: entry, op: entry.op, item: entry.item}
+		switch entry.op {
+		case pendingNone:
+			item, _, err := entry.modified()
+			if err != nil {
+				return nil, err
+			}
+			if item == nil {
+				continue
+			}
+			write.op = pendingSave
+			write.item = item
+		case pendingSave:
+			if len(entry.updateExpressions) > 0 {
+				item, err := entry.applyUpdate(ctx)
+				if err != nil {
+					return nil, err
+				}
+				write.item = item
+			}
+		}
+		writes = append(writes, write)
+	}
+	return writes, nil
+}
+
+// applyUpdate returns the marshalled item of a pending save with its pending update applied
+func (entry *unitOfWorkEntry) applyUpdate(ctx context.Context) (map[string]*dynamodb.AttributeValue, error) {
+	state, err := dynamo.MarshalItem(entry.item)
+	if err != nil {
+		return nil, err
+	}
+
+	// the update is built like any other and captured instead of being sent, so it is applied as DynamoDB would
+	capture := &updateCapture{}
+	repository := entry.repository
+	repository.dynamoClient = dynamo.NewFromIface(capture)
+	update, err := repository.prepareUpdateWithUpdateExpressions(ctx, entry.key, entry.updateExpressions)
+	if err != nil {
+		return nil, err
+	}
+	if err := update.RunWithContext(ctx); err != nil {
+		return nil, err
+	}
+	if capture.input == nil || capture.input.UpdateExpression == nil {
+		return state, nil
+	}
+
+	scope := expr.NewScope(capture.input.ExpressionAttributeNames, capture.input.ExpressionAttributeValues).AllowReserved()
+	parsed, err := scope.Update(*capture.input.UpdateExpression)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Apply(state)
+}
+
+// updateCapture keeps the request of an update instead of sending it
+type updateCapture struct {
+	dynamodbiface.DynamoDBAPI
+	input *dynamodb.UpdateItemInput
+}
+
+func (c *updateCapture) UpdateItemWithContext(_ aws.Context, input *dynamodb.UpdateItemInput, _ ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+	c.input = input
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+// modified returns the loaded instance or copy that was modified since it was read and its marshalled state, or nil
+// if there is none; it fails with ErrUnitOfWorkConflict if several are modified differently
+func (entry *unitOfWorkEntry) modified() (any, map[string]*dynamodb.AttributeValue, error) {
+	if !entry.loaded || !entry.found || entry.snapshot == nil {
+		return nil, nil, nil
+	}
+	var (
+		item  any
+		state map[string]*dynamodb.AttributeValue
+	)
+	for _, instance := range append([]any{entry.item}, entry.copies...) {
+		current, err := dynamo.MarshalItem(instance)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reflect.DeepEqual(current, entry.snapshot) {
+			continue
+		}
+		if state != nil && !reflect.DeepEqual(current, state) {
+			return nil, nil, ErrUnitOfWorkConflict
+		}
+		item, state = instance, current
+	}
+	return item, state, nil
+}
+
+// prepareUpdate prepares the pending update of entry; attributes of a loaded item modified in memory are set or
+// removed by the update too, unless the update expressions change them
+func (entry *unitOfWorkEntry) prepareUpdate(ctx context.Context) (*dynamo.Update, error) {
+	update, err := entry.repository.prepareUpdateWithUpdateExpressions(ctx, entry.key, entry.updateExpressions)
+	if err != nil {
+		return nil, err
+	}
+	_, state, err := entry.modified()
+	if err != nil || state == nil {
+		return update, err
+	}
+
+	skip := map[string]bool{*entry.key.HashKeyName(): true}
+	if entry.key.RangeKeyName() != nil {
+		skip[*entry.key.RangeKeyName()] = true
+	}
+	for _, values := range entry.updateExpressions {
+		for field := range values {
+			skip[field] = true
+		}
+	}
+	for name, value := range state {
+		if !skip[name] && !reflect.DeepEqual(value, entry.snapshot[name]) {
+			update.Set(name, value)
+		}
+	}
+	for name := range entry.snapshot {
+		if _, ok := state[name]; !ok && !skip[name] {
+			update.Remove(name)
+		}
+	}
+	return update, nil
+}
+
+func (uow *UnitOfWork) entry(repository Repository, key KeyInterface) (*unitOfWorkEntry, error) {
+	id, err := identityOf(key)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := uow.entries[id]
+	if !ok {
+		entry = &unitOfWorkEntry{repository: repository, key: key}
+		uow.entries[id] = entry
+		uow.order = append(uow.order, id)
+	}
+	return entry, nil
+}
+
+// getItem serves item from the identity map; it loads it through load on the first call for a key. The lock is not
+// held while loading; an item loaded or written by another call in the meantime is served instead of the loaded one.
+func (uow *UnitOfWork) getItem(repository Repository, key KeyInterface, item any, load func() (bool, error)) (bool, error) {
+	if found, ok, err := uow.tracked(key, item); ok {
+		return found, err
+	}
+
+	found, err := load()
+	if err != nil {
+		return false, err
+	}
+
+	uow.Lock()
+	defer uow.Unlock()
+
+	entry, err := uow.entry(repository, key)
+	if err != nil {
+		return false, err
+	}
+	if entry.loaded {
+		return entry.found, entry.assign(item)
+	}
+
+	entry.loaded = true
+	entry.found = found
+	if !found {
+		return false, nil
+	}
+
+	entry.item = item
+	if out := reflect.ValueOf(item); out.Kind() == reflect.Ptr && out.Elem().Kind() == reflect.Ptr {
+		entry.item = out.Elem().Interface()
+	}
+	entry.copies = nil
+	entry.snapshot, err = dynamo.MarshalItem(entry.item)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// tracked serves item from the identity map; ok is false if the item of key is not loaded yet
+func (uow *UnitOfWork) tracked(key KeyInterface, item any) (found bool, ok bool, err error) {
+	uow.Lock()
+	defer uow.Unlock()
+
+	id, err := identityOf(key)
+	if err != nil {
+		return false, true, err
+	}
+	entry, ok := uow.entries[id]
+	if !ok || !entry.loaded {
+		return false, false, nil
+	}
+	return entry.found, true, entry.assign(item)
+}
+
+// assign hands out the tracked item to out and keeps track of copies of it
+func (entry *unitOfWorkEntry) assign(out any) error {
+	if !entry.found {
+		return nil
+	}
+	if err := assignTracked(entry.item, out); err != nil {
+		return err
+	}
+	if entry.snapshot != nil && out != entry.item && reflect.TypeOf(out) == reflect.TypeOf(entry.item) {
+		entry.copies = append(entry.copies, out)
+	}
+	return nil
+}
+
+// register applies a write to the entries of keys and adds the domain events of ctx under one lock; neither is
+// registered if a key or an event is invalid or apply fails
+func (uow *UnitOfWork) register(ctx context.Context, repository Repository, keys []KeyInterface, apply func(entries []*unitOfWorkEntry) error) error {
+	for _, key := range keys {
+		if _, err := identityOf(key); err != nil {
+			return err
+		}
+	}
+	puts, err := repository.outboxPuts(ctx)
+	if err != nil {
+		return err
+	}
+
+	uow.Lock()
+	defer uow.Unlock()
+
+	entries := make([]*unitOfWorkEntry, len(keys))
+	for i, key := range keys {
+		if entries[i], err = uow.entry(repository, key); err != nil {
+			return err
+		}
+	}
+	if err := apply(entries); err != nil {
+		return err
+	}
+	uow.events = append(uow.events, puts...)
+	return nil
+}
+
+func (uow *UnitOfWork) registerSave(ctx context.Context, repository Repository, key KeyInterface, item any) error {
+	if err := isValidKey(key); err != nil {
+		return err
+	}
+	return uow.register(ctx, repository, []KeyInterface{key}, func(entries []*unitOfWorkEntry) error {
+		entries[0].save(item)
+		return nil
+	})
+}
+
+func (uow *UnitOfWork) registerSaves(ctx context.Context, repository Repository, key KeyInterface, items any) error {
+	if err := isValidTemplateKey(key); err != nil {
+		return err
+	}
+
+	itemSlice, err := InterfaceToArrayOfInterface(items)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]KeyInterface, len(itemSlice))
+	for i, item := range itemSlice {
+		if keys[i], err = keyFromItem(key, item); err != nil {
+			return err
+		}
+		if err := isValidKey(keys[i]); err != nil {
+			return err
+		}
+	}
+	return uow.register(ctx, repository, keys, func(entries []*unitOfWorkEntry) error {
+		for i, entry := range entries {
+			entry.save(itemSlice[i])
+		}
+		return nil
+	})
+}
+
+func (uow *UnitOfWork) registerUpdate(ctx context.Context, repository Repository, key KeyInterface, updateExpressions UpdateExpressions) error {
+	if err := isValidKey(key); err != nil {
+		return err
+	}
+	return uow.register(ctx, repository, []KeyInterface{key}, func(entries []*unitOfWorkEntry) error {
+		entry := entries[0]
+		switch entry.op {
+		case pendingDelete:
+			return ErrUnitOfWorkConflict
+		case pendingNone:
+			entry.op = pendingUpdate
+			entry.updateExpressions = make(UpdateExpressions)
+		case pendingSave:
+			if entry.updateExpressions == nil {
+				entry.updateExpressions = make(UpdateExpressions)
+			}
+		}
+
+		for expression, values := range updateExpressions {
+			if entry.updateExpressions[expression] == nil {
+				entry.updateExpressions[expression] = make(map[string]any)
+			}
+			for field, value := range values {
+				entry.updateExpressions[expression][field] = value
+			}
+		}
+		return nil
+	})
+}
+
+func (uow *UnitOfWork) registerDelete(ctx context.Context, repository Repository, key KeyInterface) error {
+	return uow.registerDeletes(ctx, repository, []KeyInterface{key})
+}
+
+func (uow *UnitOfWork) registerDeletes(ctx context.Context, repository Repository, keys []KeyInterface) error {
+	for _, key := range keys {
+		if err := isValidKey(key); err != nil {
+			return err
+		}
+	}
+	return uow.register(ctx, repository, keys, func(entries []*unitOfWorkEntry) error {
+		for _, entry := range entries {
+			entry.delete()
+		}
+		return nil
+	})
+}
+
+// save marks the entry to be saved as item
+func (entry *unitOfWorkEntry) save(item any) {
+	entry.op = pendingSave
+	entry.updateExpressions = nil
+	entry.item = item
+	entry.copies = nil
+	entry.snapshot = nil
+	entry.loaded = true
+	entry.found = true
+}
+
+// delete marks the entry to be deleted
+func (entry *unitOfWorkEntry) delete() {
+	entry.op = pendingDelete
+	entry.updateExpressions = nil
+	entry.item = nil
+	entry.copies = nil
+	entry.snapshot = nil
+	entry.loaded = true
+	entry.found = false
+}
+
+func commitTransaction(ctx context.Context, writes []pendingWrite, events []outboxPut) (err error) {
+	repository := writes[0].entry.repository
+	keys := make([]KeyInterface, len(writes))
+	for i, write := range writes {
+		keys[i] = write.entry.key
+	}
+	defer repository.recordMultipleMetrics(ctx, OpCommit, keys, &err)()
+
+	// every attempt sends the same client request token, so a retry of a transaction that succeeded is not applied again
+	tx := repository.dynamoClient.WriteTx().Idempotent(true)
+	for _, write := range writes {
+		table := write.entry.repository.table(write.entry.key.TableName())
+		switch write.op {
+		case pendingSave:
+			tx.Put(table.Put(write.item))
+		case pendingDelete:
+			tx.Delete(deleteByKey(table, write.entry.key))
+		case pendingUpdate:
+			update, err := write.entry.prepareUpdate(ctx)
+			if err != nil {
+				return err
+			}
+			tx.Update(update)
+		}
+	}
+	addOutboxPuts(repository, tx, events)
+
+	return repository.retry(ctx, OpCommit, writes[0].entry.key, tx.RunWithContext)
+}
+
+func commitBatches(ctx context.Context, writes []pendingWrite) error {
+	ctx = withoutUnitOfWork(ctx)
+
+	var (
+		order   []string
+		batches = make(map[string][]pendingWrite)
+	)
+	for _, write := range writes {
+		if write.op == pendingUpdate {
+			if err := commitUpdate(ctx, write.entry); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tableName := write.entry.key.TableName()
+		if _, ok := batches[tableName]; !ok {
+			order = append(order, tableName)
+		}
+		batches[tableName] = append(batches[tableName], write)
+	}
+
+	for _, tableName := range order {
+		var (
+			items []any
+			keys  []KeyInterface
+		)
+		for _, write := range batches[tableName] {
+			if write.op == pendingSave {
+				items = append(items, write.item)
+			} else {
+				keys = append(keys, write.entry.key)
+			}
+		}
+
+		repository := batches[tableName][0].entry.repository
+		if len(items) > 0 {
+			if err := repository.SaveItemsWithContext(ctx, batches[tableName][0].entry.key, items); err != nil {
+				return err
+			}
+		}
+		if err := repository.DeleteItemsWithContext(ctx, keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func commitUpdate(ctx context.Context, entry *unitOfWorkEntry) (err error) {
+	defer entry.repository.recordMetrics(ctx, OpUpdate, entry.key, &err)()
+
+	update, err := entry.prepareUpdate(ctx)
+	if err != nil {
+		return err
+	}
+	return entry.repository.retry(ctx, OpUpdate, entry.key, update.RunWithContext)
+}
+
+// keyFromItem builds the key of item from the key names of key and the attribute values of the marshalled item
+func keyFromItem(key KeyInterface, item any) (KeyInterface, error) {
+	av, err := dynamo.MarshalItem(item)
+	if err != nil {
+		return nil, err
+	}
+
+	hashKey, ok := av[*key.HashKeyName()]
+	if !ok {
+		return nil, ErrInvalidHashKeyValue
+	}
+	itemKey := Key().WithTableName(key.TableName()).WithHashKeyName(*key.HashKeyName())
+	if err := dynamodbattribute.Unmarshal(hashKey, &itemKey.hashKey); err != nil {
+		return nil, err
+	}
+
+	if key.RangeKeyName() != nil {
+		itemKey.WithRangeKeyName(*key.RangeKeyName())
+		if rangeKey, ok := av[*key.RangeKeyName()]; ok {
+			if err := dynamodbattribute.Unmarshal(rangeKey, &itemKey.rangeKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return itemKey, nil
+}
+
+// identityOf returns the identity map id of the item identified by key; the key values are marshalled and formatted
+// with their type, so only values stored as the same attribute value share an id
+func identityOf(key KeyInterface) (string, error) {
+	hashKey, err := dynamo.Marshal(key.HashKey())
+	if err != nil {
+		return "", err
+	}
+	id := strconv.Quote(key.TableName()) + "|" + expr.Format(hashKey)
+	if key.RangeKeyName() != nil && key.RangeKey() != nil {
+		rangeKey, err := dynamo.Marshal(key.RangeKey())
+		if err != nil {
+			return "", err
+		}
+		id += "|" + expr.Format(rangeKey)
+	}
+	return id, nil
+}
+
+// assignTracked hands out the tracked instance to out; a pointer to a pointer receives the instance itself,
+// a pointer of the same type receives a copy and any other type is decoded from the tracked state
+func assignTracked(tracked any, out any) error {
+	outValue := reflect.ValueOf(out)
+	trackedValue := reflect.ValueOf(tracked)
+	if outValue.Kind() != reflect.Ptr || outValue.IsNil() {
+		return ErrInvalidPointerType
+	}
+
+	if outValue.Elem().Type() == trackedValue.Type() {
+		outValue.Elem().Set(trackedValue)
+		return nil
+	}
+	if trackedValue.Kind() == reflect.Ptr && outValue.Type() == trackedValue.Type() {
+		outValue.Elem().Set(trackedValue.Elem())
+		return nil
+	}
+
+	av, err := dynamo.MarshalItem(tracked)
+	if err != nil {
+		return err
+	}
+	return dynamo.UnmarshalItem(av, out)
+}
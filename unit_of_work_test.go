@@ -0,0 +1,323 @@
+package djoemo_test
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"go.uber.org/mock/gomock"
+
+	"github.com/adjoeio/djoemo"
+	"github.com/adjoeio/djoemo/fake"
+	"github.com/adjoeio/djoemo/mock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UnitOfWork", func() {
+	const (
+		UserTableName = "UserTable"
+	)
+
+	var (
+		dAPIMock    *mock.MockDynamoDBAPI
+		repository  djoemo.RepositoryInterface
+		metricsMock *mock.MockMetricsInterface
+	)
+
+	BeforeEach(func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		dAPIMock = mock.NewMockDynamoDBAPI(mockCtrl)
+		metricsMock = mock.NewMockMetricsInterface(mockCtrl)
+		repository = djoemo.NewRepository(dAPIMock)
+		repository.WithMetrics(metricsMock)
+		repository.WithLog(mock.NewMockLogInterface(mockCtrl))
+	})
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).
+			WithHashKeyName("UUID").
+			WithHashKey(uuid)
+	}
+
+	expectGet := func(uuid string, userName string) {
+		item, _ := dynamodbattribute.MarshalMap(map[string]interface{}{"UUID": uuid, "UserName": userName})
+		dAPIMock.EXPECT().GetItemWithContext(gomock.Any(), gomock.Any()).
+			Return(&dynamodb.GetItemOutput{Item: item}, nil).Times(1)
+		metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpRead, gomock.Any(), gomock.Any(), true).Times(1)
+	}
+
+	Describe("GetItem", func() {
+		It("should read an item only once", func() {
+			ctx, _ := djoemo.NewUnitOfWork(context.Background())
+			expectGet("uuid", "name")
+
+			first := &User{}
+			found, err := repository.GetItemWithContext(ctx, userKey("uuid"), first)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+
+			second := &User{}
+			found, err = repository.GetItemWithContext(ctx, userKey("uuid"), second)
+			Expect(err).To(BeNil())
+			Expect(found).To(BeTrue())
+			Expect(second.UserName).To(Equal("name"))
+		})
+
+		It("should hand out the loaded instance to a pointer of pointer", func() {
+			ctx, _ := djoemo.NewUnitOfWork(context.Background())
+			expectGet("uuid", "name")
+
+			var first, second *User
+			_, err := repository.GetItemWithContext(ctx, userKey("uuid"), &first)
+			Expect(err).To(BeNil())
+			_, err = repository.GetItemWithContext(ctx, userKey("uuid"), &second)
+			Expect(err).To(BeNil())
+
+			Expect(second).To(BeIdenticalTo(first))
+		})
+
+		It("should not find an item deleted in the unit of work", func() {
+			ctx, _ := djoemo.NewUnitOfWork(context.Background())
+			expectGet("uuid", "name")
+
+			_, err := repository.GetItemWithContext(ctx, userKey("uuid"), &User{})
+			Expect(err).To(BeNil())
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid"))).To(BeNil())
+
+			found, err := repository.GetItemWithContext(ctx, userKey("uuid"), &User{})
+			Expect(err).To(BeNil())
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Commit", func() {
+		It("should flush pending writes in a single transaction", func() {
+			ctx, uow := djoemo.NewUnitOfWork(context.Background())
+
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid1"), &User{UUID: "uuid1"})).To(BeNil())
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid1"), &User{UUID: "uuid1", UserName: "name"})).To(BeNil())
+			Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid2"), map[string]interface{}{"UserName": "name"})).To(BeNil())
+			Expect(repository.DeleteItemWithContext(ctx, userKey("uuid3"))).To(BeNil())
+
+			dAPIMock.EXPECT().TransactWriteItemsWithContext(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...interface{}) (*dynamodb.TransactWriteItemsOutput, error) {
+					Expect(input.TransactItems).To(HaveLen(3))
+					Expect(*input.TransactItems[0].Put.Item["UserName"].S).To(Equal("name"))
+					Expect(input.TransactItems[1].Update).NotTo(BeNil())
+					Expect(*input.TransactItems[2].Delete.Key["UUID"].S).To(Equal("uuid3"))
+					return &dynamodb.TransactWriteItemsOutput{}, nil
+				}).Times(1)
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpCommit, gomock.Any(), gomock.Any(), true).Times(3)
+
+			Expect(uow.Commit(ctx)).To(BeNil())
+
+			pending, err := uow.Pending()
+			Expect(err).To(BeNil())
+			Expect(pending).To(Equal(0))
+		})
+
+		It("should save loaded items that were modified", func() {
+			ctx, uow := djoemo.NewUnitOfWork(context.Background())
+			expectGet("uuid", "name")
+
+			user := &User{}
+			_, err := repository.GetItemWithContext(ctx, userKey("uuid"), user)
+			Expect(err).To(BeNil())
+
+			pending, err := uow.Pending()
+			Expect(err).To(BeNil())
+			Expect(pending).To(Equal(0))
+
+			user.UserName = "changed"
+			pending, err = uow.Pending()
+			Expect(err).To(BeNil())
+			Expect(pending).To(Equal(1))
+
+			dAPIMock.EXPECT().TransactWriteItemsWithContext(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...interface{}) (*dynamodb.TransactWriteItemsOutput, error) {
+					Expect(input.TransactItems).To(HaveLen(1))
+					Expect(*input.TransactItems[0].Put.Item["UserName"].S).To(Equal("changed"))
+					return &dynamodb.TransactWriteItemsOutput{}, nil
+				}).Times(1)
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpCommit, gomock.Any(), gomock.Any(), true).Times(1)
+
+			Expect(uow.Commit(ctx)).To(BeNil())
+		})
+
+		It("should retry a failed transaction with the same client request token", func() {
+			ctx, uow := djoemo.NewUnitOfWork(context.Background())
+			Expect(repository.SaveItemWithContext(ctx, userKey("uuid"), &User{UUID: "uuid"})).To(BeNil())
+
+			var tokens []string
+			serverError := awserr.NewRequestFailure(awserr.New("InternalServerError", "internal error", nil), 500, "request-id")
+			gomock.InOrder(
+				dAPIMock.EXPECT().TransactWriteItemsWithContext(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...interface{}) (*dynamodb.TransactWriteItemsOutput, error) {
+						tokens = append(tokens, aws.StringValue(input.ClientRequestToken))
+						return nil, serverError
+					}),
+				dAPIMock.EXPECT().TransactWriteItemsWithContext(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ aws.Context, input *dynamodb.TransactWriteItemsInput, _ ...interface{}) (*dynamodb.TransactWriteItemsOutput, error) {
+						tokens = append(tokens, aws.StringValue(input.ClientRequestToken))
+						return &dynamodb.TransactWriteItemsOutput{}, nil
+					}),
+			)
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpCommit, gomock.Any(), gomock.Any(), true).Times(1)
+
+			Expect(uow.Commit(ctx)).To(BeNil())
+			Expect(tokens).To(HaveLen(2))
+			Expect(tokens[0]).NotTo(BeEmpty())
+			Expect(tokens[1]).To(Equal(tokens[0]))
+		})
+
+		It("should write chunked batches when exceeding the transaction limit", func() {
+			defaultMaxTransactionItems := djoemo.MaxTransactionItems
+			djoemo.MaxTransactionItems = 1
+			DeferCleanup(func() { djoemo.MaxTransactionItems = defaultMaxTransactionItems })
+
+			ctx, uow := djoemo.NewUnitOfWork(context.Background())
+			users := []User{{UUID: "uuid1"}, {UUID: "uuid2"}}
+			Expect(repository.SaveItemsWithContext(ctx, userKey("uuid1"), users)).To(BeNil())
+
+			dAPIMock.EXPECT().BatchWriteItemWithContext(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ aws.Context, input *dynamodb.BatchWriteItemInput, _ ...interface{}) (*dynamodb.BatchWriteItemOutput, error) {
+					Expect(input.RequestItems[UserTableName]).To(HaveLen(2))
+					return &dynamodb.BatchWriteItemOutput{}, nil
+				}).Times(1)
+			metricsMock.EXPECT().Record(gomock.Any(), djoemo.OpCommit, gomock.Any(), gomock.Any(), true).Times(1)
+
+			Expect(uow.Commit(ctx)).To(BeNil())
+		})
+	})
+})
+
+var _ = Describe("UnitOfWork with tracked items", func() {
+	const UserTableName = "UserTable"
+
+	var (
+		repository djoemo.RepositoryInterface
+		ctx        context.Context
+		uow        *djoemo.UnitOfWork
+	)
+
+	userKey := func(uuid string) djoemo.KeyInterface {
+		return djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(uuid)
+	}
+
+	stored := func(uuid string) User {
+		user := User{}
+		found, err := repository.GetItemWithContext(context.Background(), userKey(uuid), &user)
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+		return user
+	}
+
+	BeforeEach(func() {
+		repository = djoemo.NewRepository(fake.New().WithTable(UserTableName, "UUID", ""))
+		Expect(repository.SaveItemWithContext(context.Background(), userKey("uuid"),
+			User{UUID: "uuid", UserName: "name", TraceID: "trace"})).To(Succeed())
+		ctx, uow = djoemo.NewUnitOfWork(context.Background())
+	})
+
+	It("should reject an update of a pending delete", func() {
+		Expect(repository.DeleteItemWithContext(ctx, userKey("uuid"))).To(Succeed())
+		err := repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]any{"UserName": "other"})
+
+		Expect(err).To(Equal(djoemo.ErrUnitOfWorkConflict))
+		Expect(uow.Commit(ctx)).To(Succeed())
+		found, err := repository.GetItemWithContext(context.Background(), userKey("uuid"), &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+	})
+
+	It("should apply an update of a pending save to its item", func() {
+		Expect(repository.SaveItemWithContext(ctx, userKey("other"), &User{UUID: "other", UserName: "name", TraceID: "trace"})).To(Succeed())
+		Expect(repository.UpdateWithUpdateExpressions(ctx, userKey("other"), djoemo.UpdateExpressions{
+			djoemo.Set:            {"UserName": "changed"},
+			djoemo.SetIfNotExists: {"TraceID": "other"},
+		})).To(Succeed())
+
+		pending, err := uow.Pending()
+		Expect(err).To(BeNil())
+		Expect(pending).To(Equal(1))
+		Expect(uow.Commit(ctx)).To(Succeed())
+		Expect(stored("other")).To(Equal(User{UUID: "other", UserName: "changed", TraceID: "trace"}))
+	})
+
+	It("should not save a loaded item once its modification is reverted", func() {
+		user := &User{}
+		_, err := repository.GetItemWithContext(ctx, userKey("uuid"), user)
+		Expect(err).To(BeNil())
+		user.UserName = "changed"
+
+		pending, err := uow.Pending()
+		Expect(err).To(BeNil())
+		Expect(pending).To(Equal(1))
+
+		user.UserName = "name"
+		pending, err = uow.Pending()
+		Expect(err).To(BeNil())
+		Expect(pending).To(Equal(0))
+		Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]any{"TraceID": "other"})).To(Succeed())
+		Expect(uow.Commit(ctx)).To(Succeed())
+		Expect(stored("uuid")).To(Equal(User{UUID: "uuid", UserName: "name", TraceID: "other"}))
+	})
+
+	It("should track keys with values of different types or separators apart", func() {
+		repository := djoemo.NewRepository(fake.New().WithTable(UserTableName, "UUID", "").WithTable(UserTableName+"|a", "UUID", ""))
+		Expect(repository.SaveItemWithContext(context.Background(), djoemo.Key().WithTableName(UserTableName+"|a").WithHashKeyName("UUID").WithHashKey("b"),
+			User{UUID: "b"})).To(Succeed())
+		Expect(repository.SaveItemWithContext(ctx, userKey("1"), &User{UUID: "1"})).To(Succeed())
+		Expect(repository.DeleteItemWithContext(ctx, userKey("a|b"))).To(Succeed())
+
+		found, err := repository.GetItemWithContext(ctx, djoemo.Key().WithTableName(UserTableName).WithHashKeyName("UUID").WithHashKey(1), &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeFalse())
+		found, err = repository.GetItemWithContext(ctx, djoemo.Key().WithTableName(UserTableName+"|a").WithHashKeyName("UUID").WithHashKey("b"), &User{})
+		Expect(err).To(BeNil())
+		Expect(found).To(BeTrue())
+	})
+
+	It("should fold modifications of a loaded item into its update", func() {
+		user := &User{}
+		_, err := repository.GetItemWithContext(ctx, userKey("uuid"), user)
+		Expect(err).To(BeNil())
+		user.UserName = "changed"
+		user.TraceID = ""
+		Expect(repository.UpdateWithContext(ctx, djoemo.Set, userKey("uuid"), map[string]any{"Meta": map[string]string{"a": "b"}})).To(Succeed())
+
+		Expect(uow.Commit(ctx)).To(Succeed())
+		Expect(stored("uuid")).To(Equal(User{UUID: "uuid", UserName: "changed", Meta: map[string]string{"a": "b"}}))
+	})
+
+	It("should commit modifications of copies handed out to pointers", func() {
+		first, second := &User{}, &User{}
+		_, err := repository.GetItemWithContext(ctx, userKey("uuid"), first)
+		Expect(err).To(BeNil())
+		_, err = repository.GetItemWithContext(ctx, userKey("uuid"), second)
+		Expect(err).To(BeNil())
+		second.UserName = "changed"
+
+		pending, err := uow.Pending()
+		Expect(err).To(BeNil())
+		Expect(pending).To(Equal(1))
+		Expect(uow.Commit(ctx)).To(Succeed())
+		Expect(stored("uuid").UserName).To(Equal("changed"))
+	})
+
+	It("should reject copies modified differently", func() {
+		first, second := &User{}, &User{}
+		_, err := repository.GetItemWithContext(ctx, userKey("uuid"), first)
+		Expect(err).To(BeNil())
+		_, err = repository.GetItemWithContext(ctx, userKey("uuid"), second)
+		Expect(err).To(BeNil())
+		first.UserName = "first"
+		second.UserName = "second"
+
+		Expect(uow.Commit(ctx)).To(Equal(djoemo.ErrUnitOfWorkConflict))
+	})
+})